@@ -4,19 +4,58 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/common/fx"
+	"github.com/denizdoganinsider/kpi_project/common/hold"
+	"github.com/denizdoganinsider/kpi_project/common/idempotency"
 	"github.com/denizdoganinsider/kpi_project/common/mysql"
+	"github.com/denizdoganinsider/kpi_project/common/risk"
+	"github.com/denizdoganinsider/kpi_project/common/scheduledtransfer"
+	"github.com/denizdoganinsider/kpi_project/common/scheduler"
+	"github.com/denizdoganinsider/kpi_project/common/standingorder"
+	"github.com/denizdoganinsider/kpi_project/common/transferbatch"
+	"github.com/denizdoganinsider/kpi_project/domain"
 	"github.com/joho/godotenv"
 )
 
 type ConfigurationManager struct {
-	MySqlConfig mysql.Config
+	MySqlConfig             mysql.Config
+	FxConfig                fx.Config
+	SchedulerConfig         scheduler.Config
+	StandingOrderConfig     standingorder.Config
+	HoldConfig              hold.Config
+	ApprovalConfig          approval.Config
+	RiskConfig              risk.Config
+	IdempotencyConfig       idempotency.Config
+	TransferBatchConfig     transferbatch.Config
+	ScheduledTransferConfig scheduledtransfer.Config
 }
 
 func NewConfigurationManager() *ConfigurationManager {
 	MySqlConfig := getMySqlConfig()
+	FxConfig := getFxConfig()
+	SchedulerConfig := getSchedulerConfig()
+	StandingOrderConfig := getStandingOrderConfig()
+	HoldConfig := getHoldConfig()
+	ApprovalConfig := getApprovalConfig()
+	RiskConfig := getRiskConfig()
+	IdempotencyConfig := getIdempotencyConfig()
+	TransferBatchConfig := getTransferBatchConfig()
+	ScheduledTransferConfig := getScheduledTransferConfig()
 	return &ConfigurationManager{
-		MySqlConfig: MySqlConfig,
+		MySqlConfig:             MySqlConfig,
+		FxConfig:                FxConfig,
+		SchedulerConfig:         SchedulerConfig,
+		StandingOrderConfig:     StandingOrderConfig,
+		HoldConfig:              HoldConfig,
+		ApprovalConfig:          ApprovalConfig,
+		RiskConfig:              RiskConfig,
+		IdempotencyConfig:       IdempotencyConfig,
+		TransferBatchConfig:     TransferBatchConfig,
+		ScheduledTransferConfig: ScheduledTransferConfig,
 	}
 }
 
@@ -55,3 +94,132 @@ func getMySqlConfig() mysql.Config {
 		MaxConnectionIdleTime: dbMaxConnectionIdleTime,
 	}
 }
+
+func getFxConfig() fx.Config {
+	quoteTTLSeconds, err := strconv.Atoi(os.Getenv("FX_QUOTE_TTL_SECONDS"))
+	if err != nil {
+		quoteTTLSeconds = 30 // Default value
+	}
+
+	return fx.Config{
+		RatesFile: os.Getenv("FX_RATES_FILE"),
+		QuoteTTL:  time.Duration(quoteTTLSeconds) * time.Second,
+	}
+}
+
+func getSchedulerConfig() scheduler.Config {
+	pollIntervalSeconds, err := strconv.Atoi(os.Getenv("SCHEDULER_POLL_INTERVAL_SECONDS"))
+	if err != nil {
+		pollIntervalSeconds = 10 // Default value
+	}
+
+	return scheduler.Config{
+		PollInterval: time.Duration(pollIntervalSeconds) * time.Second,
+	}
+}
+
+func getStandingOrderConfig() standingorder.Config {
+	policy := domain.InsufficientFundsPolicy(os.Getenv("STANDING_ORDER_INSUFFICIENT_FUNDS_POLICY"))
+	if policy != domain.RetryOccurrence {
+		policy = domain.SkipOccurrence // Default value
+	}
+
+	maxRetries, err := strconv.Atoi(os.Getenv("STANDING_ORDER_MAX_RETRIES"))
+	if err != nil {
+		maxRetries = 3 // Default value
+	}
+
+	retryIntervalMinutes, err := strconv.Atoi(os.Getenv("STANDING_ORDER_RETRY_INTERVAL_MINUTES"))
+	if err != nil {
+		retryIntervalMinutes = 60 // Default value
+	}
+
+	return standingorder.Config{
+		InsufficientFundsPolicy: policy,
+		MaxRetries:              maxRetries,
+		RetryInterval:           time.Duration(retryIntervalMinutes) * time.Minute,
+	}
+}
+
+func getHoldConfig() hold.Config {
+	ttlMinutes, err := strconv.Atoi(os.Getenv("HOLD_TTL_MINUTES"))
+	if err != nil {
+		ttlMinutes = 7 * 24 * 60 // Default value
+	}
+
+	return hold.Config{
+		TTL: time.Duration(ttlMinutes) * time.Minute,
+	}
+}
+
+/* getApprovalConfig reads thresholds like "TRY:100000,EUR:5000" */
+func getApprovalConfig() approval.Config {
+	thresholds := map[domain.Currency]domain.Money{}
+	for _, entry := range strings.Split(os.Getenv("APPROVAL_THRESHOLDS"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		currency, amount, found := strings.Cut(strings.TrimSpace(entry), ":")
+		threshold, err := domain.ParseMoney(amount, domain.Currency(strings.ToUpper(currency)))
+		if !found || err != nil {
+			log.Fatalf("Invalid approval threshold %q: %v", entry, err)
+		}
+		thresholds[threshold.Currency()] = threshold
+	}
+
+	ttlHours, err := strconv.Atoi(os.Getenv("APPROVAL_TTL_HOURS"))
+	if err != nil {
+		ttlHours = 24 // Default value
+	}
+
+	return approval.Config{
+		Thresholds: thresholds,
+		TTL:        time.Duration(ttlHours) * time.Hour,
+	}
+}
+
+func getRiskConfig() risk.Config {
+	return risk.Config{
+		RulesFile: os.Getenv("RISK_RULES_FILE"),
+	}
+}
+
+func getIdempotencyConfig() idempotency.Config {
+	inFlightTimeoutMinutes, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_IN_FLIGHT_TIMEOUT_MINUTES"))
+	if err != nil {
+		inFlightTimeoutMinutes = 10 // Default value
+	}
+
+	retentionHours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_RETENTION_HOURS"))
+	if err != nil {
+		retentionHours = 24 // Default value
+	}
+
+	return idempotency.Config{
+		InFlightTimeout: time.Duration(inFlightTimeoutMinutes) * time.Minute,
+		Retention:       time.Duration(retentionHours) * time.Hour,
+	}
+}
+
+func getTransferBatchConfig() transferbatch.Config {
+	processingTimeoutMinutes, err := strconv.Atoi(os.Getenv("TRANSFER_BATCH_PROCESSING_TIMEOUT_MINUTES"))
+	if err != nil {
+		processingTimeoutMinutes = 10 // Default value
+	}
+
+	return transferbatch.Config{
+		ProcessingTimeout: time.Duration(processingTimeoutMinutes) * time.Minute,
+	}
+}
+
+func getScheduledTransferConfig() scheduledtransfer.Config {
+	executionTimeoutMinutes, err := strconv.Atoi(os.Getenv("SCHEDULED_TRANSFER_EXECUTION_TIMEOUT_MINUTES"))
+	if err != nil {
+		executionTimeoutMinutes = 10 // Default value
+	}
+
+	return scheduledtransfer.Config{
+		ExecutionTimeout: time.Duration(executionTimeoutMinutes) * time.Minute,
+	}
+}
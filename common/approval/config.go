@@ -0,0 +1,16 @@
+package approval
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+/*
+Config holds the maker-checker settings, a transfer over the threshold of its
+currency waits for an approver. Currencies without a threshold never do.
+*/
+type Config struct {
+	Thresholds map[domain.Currency]domain.Money
+	TTL        time.Duration
+}
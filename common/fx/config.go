@@ -0,0 +1,8 @@
+package fx
+
+import "time"
+
+type Config struct {
+	RatesFile string
+	QuoteTTL  time.Duration
+}
@@ -0,0 +1,7 @@
+package hold
+
+import "time"
+
+type Config struct {
+	TTL time.Duration
+}
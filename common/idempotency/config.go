@@ -0,0 +1,13 @@
+package idempotency
+
+import "time"
+
+/*
+Config bounds how long a key is kept: a request still in flight after
+InFlightTimeout is taken to have died and its key can be claimed again, a
+completed response is replayed for Retention and purged afterwards.
+*/
+type Config struct {
+	InFlightTimeout time.Duration
+	Retention       time.Duration
+}
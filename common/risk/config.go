@@ -0,0 +1,5 @@
+package risk
+
+type Config struct {
+	RulesFile string
+}
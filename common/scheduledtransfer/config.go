@@ -0,0 +1,12 @@
+package scheduledtransfer
+
+import "time"
+
+/*
+Config bounds how long a transfer may stay claimed: one still executing after
+ExecutionTimeout is taken to have been interrupted and is settled from what
+its transfer left behind.
+*/
+type Config struct {
+	ExecutionTimeout time.Duration
+}
@@ -0,0 +1,7 @@
+package scheduler
+
+import "time"
+
+type Config struct {
+	PollInterval time.Duration
+}
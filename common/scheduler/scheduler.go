@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+/* Job is one run of a background task, now is the tick that triggered it */
+type Job func(ctx context.Context, now time.Time) error
+
+type registeredJob struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+/*
+Scheduler runs every registered job on its own ticker in the background. A
+job never overlaps with itself, a slow run simply delays the next tick.
+*/
+type Scheduler struct {
+	jobs   []registeredJob
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+func (scheduler *Scheduler) Register(name string, interval time.Duration, run Job) {
+	scheduler.jobs = append(scheduler.jobs, registeredJob{name: name, interval: interval, run: run})
+}
+
+func (scheduler *Scheduler) Start(ctx context.Context) {
+	ctx, scheduler.cancel = context.WithCancel(ctx)
+
+	for _, job := range scheduler.jobs {
+		scheduler.wg.Add(1)
+		go scheduler.loop(ctx, job)
+	}
+}
+
+/* Stop waits for running jobs to finish their current run */
+func (scheduler *Scheduler) Stop() {
+	if scheduler.cancel != nil {
+		scheduler.cancel()
+	}
+	scheduler.wg.Wait()
+}
+
+func (scheduler *Scheduler) loop(ctx context.Context, job registeredJob) {
+	defer scheduler.wg.Done()
+
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := job.run(ctx, now); err != nil {
+				log.Printf("Scheduled job %s failed: %v", job.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package standingorder
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+/* Config holds the defaults for standing orders that don't set their own policy */
+type Config struct {
+	InsufficientFundsPolicy domain.InsufficientFundsPolicy
+	MaxRetries              int
+	RetryInterval           time.Duration
+}
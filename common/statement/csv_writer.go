@@ -0,0 +1,87 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+/* csvFlushEvery is how many lines are buffered before they are sent on */
+const csvFlushEvery = 100
+
+/*
+CSVWriter writes a statement as one table. The section column tells the
+opening balance, the transactions, the totals by type and the closing balance
+apart, so the file can still be sorted and filtered in a spreadsheet.
+*/
+type CSVWriter struct {
+	writer    *csv.Writer
+	flusher   http.Flusher
+	statement *domain.Statement
+	lines     int
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	csvWriter := &CSVWriter{writer: csv.NewWriter(w)}
+	csvWriter.flusher, _ = w.(http.Flusher)
+	return csvWriter
+}
+
+func (csvWriter *CSVWriter) WriteHeader(statement *domain.Statement) error {
+	csvWriter.statement = statement
+	csvWriter.writer.Write([]string{"section", "date", "transaction_id", "type", "status", "counterparty", "amount", "balance", "count"})
+	csvWriter.writer.Write([]string{"opening", formatTime(statement.From), "", "", "", "", "", statement.OpeningBalance.String(), ""})
+	return csvWriter.flush()
+}
+
+func (csvWriter *CSVWriter) WriteLine(line domain.StatementLine) error {
+	counterparty := ""
+	if id := line.Counterparty(csvWriter.statement.UserID); id != nil {
+		counterparty = strconv.FormatInt(*id, 10)
+	}
+
+	csvWriter.writer.Write([]string{
+		"transaction",
+		formatTime(line.Transaction.CreatedAt),
+		strconv.FormatInt(line.Transaction.ID, 10),
+		string(line.Transaction.Type),
+		string(line.Transaction.Status),
+		counterparty,
+		line.Amount.String(),
+		line.Balance.String(),
+		"",
+	})
+
+	csvWriter.lines++
+	if csvWriter.lines%csvFlushEvery == 0 {
+		return csvWriter.flush()
+	}
+	return csvWriter.writer.Error()
+}
+
+func (csvWriter *CSVWriter) WriteSummary(summary domain.StatementSummary) error {
+	for _, total := range summary.Totals {
+		csvWriter.writer.Write([]string{"total", "", "", string(total.Type), "", "", total.Amount.String(), "", strconv.Itoa(total.Count)})
+	}
+	csvWriter.writer.Write([]string{"closing", formatTime(csvWriter.statement.To), "", "", "", "", "", summary.ClosingBalance.String(), ""})
+	return csvWriter.flush()
+}
+
+func (csvWriter *CSVWriter) flush() error {
+	csvWriter.writer.Flush()
+	if err := csvWriter.writer.Error(); err != nil {
+		return err
+	}
+	if csvWriter.flusher != nil {
+		csvWriter.flusher.Flush()
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
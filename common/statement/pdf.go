@@ -0,0 +1,141 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+/* A4 in points, with the text set in 9pt Courier so columns line up */
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	pageMargin   = 40
+	fontSize     = 9
+	lineHeight   = 12
+	linesPerPage = (pageHeight - 2*pageMargin) / lineHeight
+)
+
+const (
+	catalogObject = 1
+	pagesObject   = 2
+	fontObject    = 3
+)
+
+/*
+pdfDocument writes a plain text PDF page by page. Every page is sent as soon
+as it is full and only the byte offsets of the objects are kept, the page tree
+and the cross-reference table follow at the end once all pages are known.
+*/
+type pdfDocument struct {
+	w       io.Writer
+	flusher http.Flusher
+	written int64
+	offsets []int64
+	pages   []int
+	lines   []string
+	err     error
+}
+
+func newPDFDocument(w io.Writer) *pdfDocument {
+	document := &pdfDocument{w: w, offsets: make([]int64, fontObject+1)}
+	document.flusher, _ = w.(http.Flusher)
+
+	document.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	document.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	document.object(fontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	return document
+}
+
+/* lineCount is how many lines the current page already has */
+func (document *pdfDocument) lineCount() int {
+	return len(document.lines)
+}
+
+func (document *pdfDocument) addLine(line string) error {
+	if len(document.lines) == linesPerPage {
+		document.flushPage()
+	}
+	document.lines = append(document.lines, line)
+	return document.err
+}
+
+func (document *pdfDocument) flushPage() {
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, lineHeight, pageMargin, pageHeight-pageMargin)
+	for _, line := range document.lines {
+		fmt.Fprintf(&content, "(%s) '\n", escapePDFText(line))
+	}
+	content.WriteString("ET")
+
+	contentObject := document.newObject()
+	document.object(contentObject, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+
+	pageObject := document.newObject()
+	document.object(pageObject, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, pageWidth, pageHeight, fontObject, contentObject))
+
+	document.pages = append(document.pages, pageObject)
+	document.lines = document.lines[:0]
+	if document.flusher != nil && document.err == nil {
+		document.flusher.Flush()
+	}
+}
+
+func (document *pdfDocument) close() error {
+	if len(document.lines) > 0 || len(document.pages) == 0 {
+		document.flushPage()
+	}
+
+	kids := make([]string, 0, len(document.pages))
+	for _, page := range document.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	document.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(document.pages)))
+
+	xref := document.written
+	document.write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(document.offsets)))
+	for _, offset := range document.offsets[1:] {
+		document.write(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	document.write(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(document.offsets), catalogObject, xref))
+	return document.err
+}
+
+func (document *pdfDocument) newObject() int {
+	document.offsets = append(document.offsets, 0)
+	return len(document.offsets) - 1
+}
+
+func (document *pdfDocument) object(number int, body string) {
+	document.offsets[number] = document.written
+	document.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", number, body))
+}
+
+func (document *pdfDocument) write(s string) {
+	if document.err != nil {
+		return
+	}
+	n, err := io.WriteString(document.w, s)
+	document.written += int64(n)
+	document.err = err
+}
+
+/* escapePDFText keeps a line inside its string literal, anything outside ASCII is replaced */
+func escapePDFText(line string) string {
+	var escaped strings.Builder
+	for _, r := range line {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 126:
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
@@ -0,0 +1,88 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+const (
+	pdfColumns    = "%-19s %8s %-12s %-9s %12s %13s %13s"
+	pdfDateLayout = "2006-01-02 15:04:05"
+)
+
+/* PDFWriter lays a statement out as text pages, the column titles are repeated on every page */
+type PDFWriter struct {
+	document  *pdfDocument
+	statement *domain.Statement
+}
+
+func NewPDFWriter(w io.Writer) *PDFWriter {
+	return &PDFWriter{document: newPDFDocument(w)}
+}
+
+func (pdfWriter *PDFWriter) WriteHeader(statement *domain.Statement) error {
+	pdfWriter.statement = statement
+	for _, line := range []string{
+		"Account statement",
+		"",
+		fmt.Sprintf("User:            %d", statement.UserID),
+		fmt.Sprintf("Currency:        %s", statement.Currency),
+		fmt.Sprintf("Period:          %s - %s (UTC)", statement.From.UTC().Format(pdfDateLayout), statement.To.UTC().Format(pdfDateLayout)),
+		fmt.Sprintf("Opening balance: %s", statement.OpeningBalance.String()),
+		"",
+	} {
+		if err := pdfWriter.document.addLine(line); err != nil {
+			return err
+		}
+	}
+	return pdfWriter.writeColumnTitles()
+}
+
+func (pdfWriter *PDFWriter) WriteLine(line domain.StatementLine) error {
+	if pdfWriter.document.lineCount() == linesPerPage {
+		pdfWriter.document.flushPage()
+		if err := pdfWriter.writeColumnTitles(); err != nil {
+			return err
+		}
+	}
+
+	counterparty := ""
+	if id := line.Counterparty(pdfWriter.statement.UserID); id != nil {
+		counterparty = strconv.FormatInt(*id, 10)
+	}
+
+	return pdfWriter.document.addLine(fmt.Sprintf(pdfColumns,
+		line.Transaction.CreatedAt.UTC().Format(pdfDateLayout),
+		strconv.FormatInt(line.Transaction.ID, 10),
+		line.Transaction.Type,
+		line.Transaction.Status,
+		counterparty,
+		line.Amount.String(),
+		line.Balance.String(),
+	))
+}
+
+func (pdfWriter *PDFWriter) WriteSummary(summary domain.StatementSummary) error {
+	lines := []string{"", "Totals by type"}
+	for _, total := range summary.Totals {
+		lines = append(lines, fmt.Sprintf("  %-12s %6d transactions %15s", total.Type, total.Count, total.Amount.String()))
+	}
+	lines = append(lines, "", fmt.Sprintf("Closing balance: %s", summary.ClosingBalance.String()))
+
+	for _, line := range lines {
+		if err := pdfWriter.document.addLine(line); err != nil {
+			return err
+		}
+	}
+	return pdfWriter.document.close()
+}
+
+func (pdfWriter *PDFWriter) writeColumnTitles() error {
+	if err := pdfWriter.document.addLine(fmt.Sprintf(pdfColumns, "Date", "ID", "Type", "Status", "Counterparty", "Amount", "Balance")); err != nil {
+		return err
+	}
+	return pdfWriter.document.addLine("")
+}
@@ -0,0 +1,11 @@
+package transferbatch
+
+import "time"
+
+/*
+Config bounds how long an item may stay claimed: one still processing after
+ProcessingTimeout is taken to have been interrupted and is failed.
+*/
+type Config struct {
+	ProcessingTimeout time.Duration
+}
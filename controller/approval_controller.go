@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type ApprovalController struct {
+	approvalService       service.IApprovalService
+	idempotencyMiddleware *IdempotencyMiddleware
+}
+
+func NewApprovalController(approvalService service.IApprovalService, idempotencyMiddleware *IdempotencyMiddleware) *ApprovalController {
+	return &ApprovalController{
+		approvalService:       approvalService,
+		idempotencyMiddleware: idempotencyMiddleware,
+	}
+}
+
+func (approvalController *ApprovalController) RegisterRoutes(e *echo.Echo) {
+	// Transfer approval routes, :id is the transfer awaiting approval
+	e.GET("/api/v1/approvals", approvalController.GetPendingApprovals)
+	e.GET("/api/v1/approvals/:id", approvalController.GetApproval)
+	e.POST("/api/v1/approvals/:id/approve", approvalController.Approve, approvalController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/approvals/:id/reject", approvalController.Reject, approvalController.idempotencyMiddleware.Handle)
+}
+
+func (approvalController *ApprovalController) GetPendingApprovals(c echo.Context) error {
+	approvals, err := approvalController.approvalService.GetPendingApprovals()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToTransferApprovalResponseList(approvals))
+}
+
+func (approvalController *ApprovalController) GetApproval(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	approval, err := approvalController.approvalService.GetApproval(int64(transactionID))
+	if err != nil {
+		return approvalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToTransferApprovalResponse(approval))
+}
+
+func (approvalController *ApprovalController) Approve(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	var request request.ApprovalDecisionRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	transaction, err := approvalController.approvalService.Approve(int64(transactionID), request.ApproverID, request.Note)
+	if err != nil {
+		return approvalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, transaction)
+}
+
+func (approvalController *ApprovalController) Reject(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	var request request.ApprovalDecisionRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	approval, err := approvalController.approvalService.Reject(int64(transactionID), request.ApproverID, request.Note)
+	if err != nil {
+		return approvalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToTransferApprovalResponse(approval))
+}
+
+/* approvalErrorResponse keeps the structured limit error of transactionErrorResponse for failed approvals */
+func approvalErrorResponse(c echo.Context, err error) error {
+	status := 0
+	switch {
+	case errors.Is(err, persistence.ErrApprovalNotFound), errors.Is(err, service.ErrTransactionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrNotApprover), errors.Is(err, service.ErrSelfApproval):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrApprovalNotPending), errors.Is(err, service.ErrApprovalExpired), errors.Is(err, persistence.ErrTransactionStatusConflict):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrApprovalNoteTooLong):
+		status = http.StatusBadRequest
+	default:
+		return transactionErrorResponse(c, err)
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
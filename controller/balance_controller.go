@@ -1,30 +1,51 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/denizdoganinsider/kpi_project/controller/request"
 	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
 	"github.com/denizdoganinsider/kpi_project/service"
 	"github.com/labstack/echo/v4"
 )
 
 type BalanceController struct {
-	balanceService service.IBalanceService
+	balanceService         service.IBalanceService
+	balanceSnapshotService service.IBalanceSnapshotService
+	idempotencyMiddleware  *IdempotencyMiddleware
 }
 
-func NewBalanceController(balanceService service.IBalanceService) *BalanceController {
+func NewBalanceController(balanceService service.IBalanceService, balanceSnapshotService service.IBalanceSnapshotService, idempotencyMiddleware *IdempotencyMiddleware) *BalanceController {
 	return &BalanceController{
-		balanceService: balanceService,
+		balanceService:         balanceService,
+		balanceSnapshotService: balanceSnapshotService,
+		idempotencyMiddleware:  idempotencyMiddleware,
 	}
 }
 
 func (balanceController *BalanceController) RegisterRoutes(e *echo.Echo) {
 	// Balance routes
 	e.GET("/api/v1/balance/:userID", balanceController.GetBalanceByUserID)
-	e.POST("/api/v1/balance/credit", balanceController.CreditBalance)
-	e.POST("/api/v1/balance/debit", balanceController.DebitBalance)
+	e.GET("/api/v1/balance/:userID/wallets", balanceController.GetWallets)
+	e.POST("/api/v1/balance/credit", balanceController.CreditBalance, balanceController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/balance/debit", balanceController.DebitBalance, balanceController.idempotencyMiddleware.Handle)
+
+	// Pocket routes, pockets set part of a balance aside
+	e.GET("/api/v1/balance/:userID/pockets", balanceController.GetPockets)
+	e.POST("/api/v1/balance/:userID/pockets", balanceController.CreatePocket)
+	e.GET("/api/v1/pockets/:id", balanceController.GetPocket)
+	e.PUT("/api/v1/pockets/:id", balanceController.RenamePocket)
+	e.DELETE("/api/v1/pockets/:id", balanceController.ClosePocket)
+	e.POST("/api/v1/pockets/:id/deposit", balanceController.MoveToPocket, balanceController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/pockets/:id/withdraw", balanceController.MoveFromPocket, balanceController.idempotencyMiddleware.Handle)
+
+	// Admin routes
+	e.POST("/api/v1/admin/balance-snapshots/backfill", balanceController.BackfillSnapshots)
 }
 
 func (balanceController *BalanceController) GetBalanceByUserID(c echo.Context) error {
@@ -37,7 +58,19 @@ func (balanceController *BalanceController) GetBalanceByUserID(c echo.Context) e
 		})
 	}
 
-	balance, err := balanceController.balanceService.GetBalanceByUserID(int64(userId))
+	currency, err := currencyQueryParam(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	/* with as_of the balance is replayed from the nearest snapshot before it */
+	if asOf := c.QueryParam("as_of"); asOf != "" {
+		return balanceController.getBalanceAsOf(c, int64(userId), currency, asOf)
+	}
+
+	breakdown, err := balanceController.balanceService.GetBalanceBreakdown(int64(userId), currency)
 	if err != nil {
 		/* if user doesn't have any balance */
 		return c.JSON(http.StatusNotFound, response.ErrorResponse{
@@ -45,10 +78,51 @@ func (balanceController *BalanceController) GetBalanceByUserID(c echo.Context) e
 		})
 	}
 
-	/* user have a balance so we are returning the balance amount */
-	return c.JSON(http.StatusOK, response.GetBalanceResponse{
-		Balance: balance.Amount,
-	})
+	/* user have a balance so we are returning the balance amount with its pockets */
+	return c.JSON(http.StatusOK, response.ToBalanceResponse(breakdown))
+}
+
+func (balanceController *BalanceController) getBalanceAsOf(c echo.Context, userID int64, currency domain.Currency, value string) error {
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "as_of must be an RFC 3339 timestamp",
+		})
+	}
+
+	historicalBalance, err := balanceController.balanceSnapshotService.GetBalanceAsOf(userID, currency, asOf)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrAsOfInFuture):
+			status = http.StatusBadRequest
+		case errors.Is(err, persistence.ErrBalanceNotFound):
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToHistoricalBalanceResponse(historicalBalance))
+}
+
+func (balanceController *BalanceController) GetWallets(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	wallets, err := balanceController.balanceService.GetWallets(int64(userId))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToWalletResponseList(wallets))
 }
 
 func (balanceController *BalanceController) CreditBalance(c echo.Context) error {
@@ -61,7 +135,6 @@ func (balanceController *BalanceController) DebitBalance(c echo.Context) error {
 
 func (balanceController *BalanceController) UpdateBalance(c echo.Context, isCredit bool) error {
 	var request request.UpdateBalanceRequest
-	var amount float64
 
 	bindError := c.Bind(&request)
 
@@ -72,13 +145,18 @@ func (balanceController *BalanceController) UpdateBalance(c echo.Context, isCred
 		})
 	}
 
-	if isCredit {
-		amount = request.Amount
-	} else {
-		amount = -request.Amount
+	amount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	if !isCredit {
+		amount = amount.Neg()
 	}
 	/* adding the given amount to user's previous balance */
-	err := balanceController.balanceService.UpdateBalance(request.UserID, amount)
+	err = balanceController.balanceService.UpdateBalance(request.UserID, amount)
 	if err != nil {
 		errDescription := err.Error()
 
@@ -93,9 +171,203 @@ func (balanceController *BalanceController) UpdateBalance(c echo.Context, isCred
 	}
 
 	/* fetching updated balance and sending to user */
-	updatedBalance, _ := balanceController.balanceService.GetBalanceByUserID(request.UserID)
+	updatedBalance, err := balanceController.balanceService.GetBalanceBreakdown(request.UserID, amount.Currency())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToBalanceResponse(updatedBalance))
+}
+
+func (balanceController *BalanceController) BackfillSnapshots(c echo.Context) error {
+	var request request.BackfillSnapshotsRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	from, to, err := request.Dates()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	written, err := balanceController.balanceSnapshotService.Backfill(from, to)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidBackfillRange) {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.BackfillSnapshotsResponse{Snapshots: written})
+}
+
+/* GetPockets lists the open pockets in ?currency=, the default currency when left out */
+func (balanceController *BalanceController) GetPockets(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	currency, err := currencyQueryParam(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	pockets, err := balanceController.balanceService.GetPockets(int64(userID), currency)
+	if err != nil {
+		return pocketErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToPocketResponseList(pockets))
+}
+
+func (balanceController *BalanceController) CreatePocket(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	var request request.PocketRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	pocket, err := balanceController.balanceService.CreatePocket(int64(userID), request.PocketCurrency(), request.Name)
+	if err != nil {
+		return pocketErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToPocketResponse(pocket))
+}
+
+func (balanceController *BalanceController) GetPocket(c echo.Context) error {
+	pocketID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid pocket ID",
+		})
+	}
+
+	pocket, err := balanceController.balanceService.GetPocket(int64(pocketID))
+	if err != nil {
+		return pocketErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToPocketResponse(pocket))
+}
+
+func (balanceController *BalanceController) RenamePocket(c echo.Context) error {
+	pocketID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid pocket ID",
+		})
+	}
+
+	var request request.PocketRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	pocket, err := balanceController.balanceService.RenamePocket(int64(pocketID), request.Name)
+	if err != nil {
+		return pocketErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToPocketResponse(pocket))
+}
+
+/* ClosePocket moves what is left in the pocket back to the main balance */
+func (balanceController *BalanceController) ClosePocket(c echo.Context) error {
+	pocketID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid pocket ID",
+		})
+	}
+
+	pocket, err := balanceController.balanceService.ClosePocket(int64(pocketID))
+	if err != nil {
+		return pocketErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToPocketResponse(pocket))
+}
+
+func (balanceController *BalanceController) MoveToPocket(c echo.Context) error {
+	return balanceController.movePocketFunds(c, true)
+}
+
+func (balanceController *BalanceController) MoveFromPocket(c echo.Context) error {
+	return balanceController.movePocketFunds(c, false)
+}
+
+func (balanceController *BalanceController) movePocketFunds(c echo.Context, intoPocket bool) error {
+	pocketID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid pocket ID",
+		})
+	}
+
+	var request request.PocketFundsRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	amount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	var pocket *domain.Pocket
+	if intoPocket {
+		pocket, err = balanceController.balanceService.MoveToPocket(int64(pocketID), amount)
+	} else {
+		pocket, err = balanceController.balanceService.MoveFromPocket(int64(pocketID), amount)
+	}
+	if err != nil {
+		return pocketErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToPocketResponse(pocket))
+}
+
+func pocketErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrPocketNotFound), errors.Is(err, persistence.ErrUserNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrPocketNameTaken), errors.Is(err, service.ErrPocketClosed):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrInsufficientBalance), errors.Is(err, service.ErrInsufficientPocketFunds):
+		status = http.StatusUnprocessableEntity
+	}
 
-	return c.JSON(http.StatusOK, response.GetBalanceResponse{
-		Balance: updatedBalance.Amount,
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
 	})
 }
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type DisputeController struct {
+	disputeService        service.IDisputeService
+	idempotencyMiddleware *IdempotencyMiddleware
+}
+
+func NewDisputeController(disputeService service.IDisputeService, idempotencyMiddleware *IdempotencyMiddleware) *DisputeController {
+	return &DisputeController{
+		disputeService:        disputeService,
+		idempotencyMiddleware: idempotencyMiddleware,
+	}
+}
+
+func (disputeController *DisputeController) RegisterRoutes(e *echo.Echo) {
+	// Dispute routes of the claimant, :id is the disputed transaction
+	e.POST("/api/v1/transactions/:id/disputes", disputeController.OpenDispute, disputeController.idempotencyMiddleware.Handle)
+	e.GET("/api/v1/transactions/:id/disputes", disputeController.GetTransactionDisputes)
+
+	// Admin dispute routes, :id is the dispute
+	e.GET("/api/v1/admin/disputes", disputeController.GetDisputes)
+	e.GET("/api/v1/admin/disputes/:id", disputeController.GetDisputeByID)
+	e.GET("/api/v1/admin/disputes/:id/events", disputeController.GetDisputeEvents)
+	e.POST("/api/v1/admin/disputes/:id/review", disputeController.StartReview)
+	e.POST("/api/v1/admin/disputes/:id/provisional-credit", disputeController.GrantProvisionalCredit, disputeController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/admin/disputes/:id/resolve", disputeController.ResolveDispute, disputeController.idempotencyMiddleware.Handle)
+}
+
+func (disputeController *DisputeController) OpenDispute(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	var request request.OpenDisputeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	dispute, err := disputeController.disputeService.Open(int64(transactionID), request.ClaimantID, request.Reason, request.Evidence)
+	if err != nil {
+		return disputeErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToDisputeResponse(dispute))
+}
+
+func (disputeController *DisputeController) GetTransactionDisputes(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	disputes, err := disputeController.disputeService.GetTransactionDisputes(int64(transactionID))
+	if err != nil {
+		return disputeErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToDisputeResponseList(disputes))
+}
+
+func (disputeController *DisputeController) GetDisputes(c echo.Context) error {
+	disputes, err := disputeController.disputeService.GetDisputes(domain.DisputeStatus(c.QueryParam("status")))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToDisputeResponseList(disputes))
+}
+
+func (disputeController *DisputeController) GetDisputeByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid dispute ID",
+		})
+	}
+
+	dispute, err := disputeController.disputeService.GetDispute(int64(id))
+	if err != nil {
+		return disputeErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToDisputeResponse(dispute))
+}
+
+func (disputeController *DisputeController) GetDisputeEvents(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid dispute ID",
+		})
+	}
+
+	events, err := disputeController.disputeService.GetEvents(int64(id))
+	if err != nil {
+		return disputeErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToDisputeEventResponseList(events))
+}
+
+func (disputeController *DisputeController) StartReview(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid dispute ID",
+		})
+	}
+
+	var request request.DisputeReviewRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	dispute, err := disputeController.disputeService.StartReview(int64(id), request.ReviewerID, request.Note)
+	if err != nil {
+		return disputeErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToDisputeResponse(dispute))
+}
+
+func (disputeController *DisputeController) GrantProvisionalCredit(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid dispute ID",
+		})
+	}
+
+	var request request.DisputeReviewRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	dispute, err := disputeController.disputeService.GrantProvisionalCredit(int64(id), request.ReviewerID, request.Note)
+	if err != nil {
+		return disputeErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToDisputeResponse(dispute))
+}
+
+func (disputeController *DisputeController) ResolveDispute(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid dispute ID",
+		})
+	}
+
+	var request request.ResolveDisputeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	dispute, err := disputeController.disputeService.Resolve(int64(id), request.Outcome, request.ReviewerID, request.Note)
+	if err != nil {
+		return disputeErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToDisputeResponse(dispute))
+}
+
+func disputeErrorResponse(c echo.Context, err error) error {
+	status := http.StatusUnprocessableEntity
+	switch {
+	case errors.Is(err, persistence.ErrDisputeNotFound), errors.Is(err, service.ErrTransactionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrDisputeAlreadyOpen), errors.Is(err, service.ErrProvisionalCreditNotAllowed),
+		errors.Is(err, service.ErrTransactionNotReversible), errors.Is(err, domain.ErrInvalidDisputeTransition),
+		errors.Is(err, persistence.ErrTransactionStatusConflict):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
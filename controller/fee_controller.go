@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type FeeController struct {
+	feeService service.IFeeService
+}
+
+func NewFeeController(feeService service.IFeeService) *FeeController {
+	return &FeeController{
+		feeService: feeService,
+	}
+}
+
+func (feeController *FeeController) RegisterRoutes(e *echo.Echo) {
+	// Admin fee schedule routes
+	e.POST("/api/v1/admin/fee-schedules", feeController.CreateFeeSchedule)
+	e.GET("/api/v1/admin/fee-schedules", feeController.GetFeeSchedules)
+	e.GET("/api/v1/admin/fee-schedules/:id", feeController.GetFeeScheduleByID)
+	e.PUT("/api/v1/admin/fee-schedules/:id", feeController.UpdateFeeSchedule)
+	e.DELETE("/api/v1/admin/fee-schedules/:id", feeController.DeleteFeeSchedule)
+
+	// Fee preview route
+	e.GET("/api/v1/fees/preview", feeController.PreviewFee)
+}
+
+func (feeController *FeeController) CreateFeeSchedule(c echo.Context) error {
+	var request request.FeeScheduleRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	feeSchedule, err := request.ToDomain()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	if err := feeController.feeService.CreateSchedule(feeSchedule); err != nil {
+		return feeScheduleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToFeeScheduleResponse(feeSchedule))
+}
+
+func (feeController *FeeController) GetFeeSchedules(c echo.Context) error {
+	feeSchedules, err := feeController.feeService.GetSchedules()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToFeeScheduleResponseList(feeSchedules))
+}
+
+func (feeController *FeeController) GetFeeScheduleByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid fee schedule ID",
+		})
+	}
+
+	feeSchedule, err := feeController.feeService.GetSchedule(int64(id))
+	if err != nil {
+		return feeScheduleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToFeeScheduleResponse(feeSchedule))
+}
+
+func (feeController *FeeController) UpdateFeeSchedule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid fee schedule ID",
+		})
+	}
+
+	var request request.FeeScheduleRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	feeSchedule, err := request.ToDomain()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	if err := feeController.feeService.UpdateSchedule(int64(id), feeSchedule); err != nil {
+		return feeScheduleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToFeeScheduleResponse(feeSchedule))
+}
+
+func (feeController *FeeController) DeleteFeeSchedule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid fee schedule ID",
+		})
+	}
+
+	if err := feeController.feeService.DeleteSchedule(int64(id)); err != nil {
+		return feeScheduleErrorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (feeController *FeeController) PreviewFee(c echo.Context) error {
+	userID, err := strconv.Atoi(c.QueryParam("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	currency, err := currencyQueryParam(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	amount, err := domain.ParseMoney(c.QueryParam("amount"), currency)
+	if err != nil || !amount.IsPositive() {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid amount",
+		})
+	}
+
+	transactionType := domain.TransactionType(c.QueryParam("transaction_type"))
+	fee, err := feeController.feeService.Calculate(int64(userID), transactionType, amount)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	total, err := amount.Add(fee)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.FeePreviewResponse{
+		TransactionType: transactionType,
+		Amount:          amount,
+		Fee:             fee,
+		Total:           total,
+		Currency:        currency,
+	})
+}
+
+func feeScheduleErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrFeeScheduleNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, persistence.ErrFeeScheduleExists):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type FxController struct {
+	fxService             service.IFxService
+	idempotencyMiddleware *IdempotencyMiddleware
+}
+
+func NewFxController(fxService service.IFxService, idempotencyMiddleware *IdempotencyMiddleware) *FxController {
+	return &FxController{
+		fxService:             fxService,
+		idempotencyMiddleware: idempotencyMiddleware,
+	}
+}
+
+func (fxController *FxController) RegisterRoutes(e *echo.Echo) {
+	// FX routes
+	e.GET("/api/v1/fx/rates", fxController.GetRates)
+	e.POST("/api/v1/fx/quotes", fxController.CreateQuote)
+	e.POST("/api/v1/transactions/convert", fxController.Convert, fxController.idempotencyMiddleware.Handle)
+}
+
+func (fxController *FxController) GetRates(c echo.Context) error {
+	rates, err := fxController.fxService.GetRates()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToFxRateResponseList(rates))
+}
+
+func (fxController *FxController) CreateQuote(c echo.Context) error {
+	var request request.FxQuoteRequest
+
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	sellAmount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	quote, err := fxController.fxService.CreateQuote(request.UserID, sellAmount, request.ToCurrency)
+	if err != nil {
+		if errors.Is(err, persistence.ErrFxRateNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				ErrorDescription: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response.ToFxQuoteResponse(quote))
+}
+
+func (fxController *FxController) Convert(c echo.Context) error {
+	var request request.ConvertRequest
+
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	conversion, err := fxController.fxService.ExecuteQuote(request.QuoteID, request.UserID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrFxQuoteNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				ErrorDescription: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response.ToConversionResponse(conversion))
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type HoldController struct {
+	holdService           service.IHoldService
+	idempotencyMiddleware *IdempotencyMiddleware
+}
+
+func NewHoldController(holdService service.IHoldService, idempotencyMiddleware *IdempotencyMiddleware) *HoldController {
+	return &HoldController{
+		holdService:           holdService,
+		idempotencyMiddleware: idempotencyMiddleware,
+	}
+}
+
+func (holdController *HoldController) RegisterRoutes(e *echo.Echo) {
+	// Authorization hold routes, :id is the authorization transaction
+	e.POST("/api/v1/transactions/authorize", holdController.Authorize, holdController.idempotencyMiddleware.Handle)
+	e.GET("/api/v1/transactions/:id/hold", holdController.GetHold)
+	e.POST("/api/v1/transactions/:id/capture", holdController.Capture, holdController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/transactions/:id/void", holdController.Void, holdController.idempotencyMiddleware.Handle)
+}
+
+func (holdController *HoldController) Authorize(c echo.Context) error {
+	var request request.AuthorizeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	amount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	hold, err := holdController.holdService.Authorize(request.UserID, request.ToUserID, amount)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response.ToHoldResponse(hold))
+}
+
+func (holdController *HoldController) GetHold(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	hold, err := holdController.holdService.GetHold(int64(transactionID))
+	if err != nil {
+		return holdErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToHoldResponse(hold))
+}
+
+func (holdController *HoldController) Capture(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	var request request.CaptureRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	amount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	transaction, err := holdController.holdService.Capture(int64(transactionID), amount)
+	if err != nil {
+		return holdErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, transaction)
+}
+
+func (holdController *HoldController) Void(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	hold, err := holdController.holdService.Void(int64(transactionID))
+	if err != nil {
+		return holdErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToHoldResponse(hold))
+}
+
+func holdErrorResponse(c echo.Context, err error) error {
+	status := http.StatusUnprocessableEntity
+	switch {
+	case errors.Is(err, persistence.ErrHoldNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrHoldNotAuthorized), errors.Is(err, service.ErrHoldExpired), errors.Is(err, persistence.ErrTransactionStatusConflict):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type IdempotencyMiddleware struct {
+	idempotencyService service.IIdempotencyService
+}
+
+func NewIdempotencyMiddleware(idempotencyService service.IIdempotencyService) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		idempotencyService: idempotencyService,
+	}
+}
+
+/*
+Handle replays the stored response when a request carries an Idempotency-Key
+that was already processed. Requests without the header pass through.
+*/
+func (idempotencyMiddleware *IdempotencyMiddleware) Handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return next(c)
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				ErrorDescription: "Idempotency-Key is too long",
+			})
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				ErrorDescription: "Invalid request data",
+			})
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := idempotencyMiddleware.idempotencyService.Begin(key, c.Request().Method, c.Request().URL.Path, body)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{ErrorDescription: err.Error()})
+			case errors.Is(err, service.ErrIdempotencyKeyInFlight):
+				return c.JSON(http.StatusConflict, response.ErrorResponse{ErrorDescription: err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{ErrorDescription: err.Error()})
+		}
+
+		/* same key and same request, answer with what the first call produced */
+		if record != nil {
+			c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+			return c.JSONBlob(record.StatusCode, record.ResponseBody)
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+
+		handlerErr := next(c)
+		if handlerErr != nil {
+			c.Error(handlerErr)
+		}
+
+		/* server errors are not remembered so that the client can retry */
+		statusCode := c.Response().Status
+		if statusCode >= http.StatusInternalServerError {
+			if err := idempotencyMiddleware.idempotencyService.Release(key); err != nil {
+				log.Printf("Idempotency key %s couldn't be released: %v", key, err)
+			}
+			return nil
+		}
+
+		if err := idempotencyMiddleware.idempotencyService.Complete(key, statusCode, recorder.body.Bytes()); err != nil {
+			log.Printf("Idempotency key %s couldn't be completed: %v", key, err)
+		}
+		return nil
+	}
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	recorder.body.Write(b)
+	return recorder.ResponseWriter.Write(b)
+}
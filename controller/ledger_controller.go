@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type LedgerController struct {
+	ledgerService service.ILedgerService
+}
+
+func NewLedgerController(ledgerService service.ILedgerService) *LedgerController {
+	return &LedgerController{
+		ledgerService: ledgerService,
+	}
+}
+
+func (ledgerController *LedgerController) RegisterRoutes(e *echo.Echo) {
+	// Ledger routes
+	e.GET("/api/v1/ledger/users/:userID", ledgerController.GetUserLedger)
+}
+
+func (ledgerController *LedgerController) GetUserLedger(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	currency, err := currencyQueryParam(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	userLedger, err := ledgerController.ledgerService.GetUserLedger(int64(userID), currency)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToLedgerResponse(userLedger))
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type LimitController struct {
+	limitService service.ILimitService
+}
+
+func NewLimitController(limitService service.ILimitService) *LimitController {
+	return &LimitController{
+		limitService: limitService,
+	}
+}
+
+func (limitController *LimitController) RegisterRoutes(e *echo.Echo) {
+	// Admin limit profile routes
+	e.POST("/api/v1/admin/limit-profiles", limitController.CreateLimitProfile)
+	e.GET("/api/v1/admin/limit-profiles", limitController.GetLimitProfiles)
+	e.GET("/api/v1/admin/limit-profiles/:id", limitController.GetLimitProfileByID)
+	e.PUT("/api/v1/admin/limit-profiles/:id", limitController.UpdateLimitProfile)
+	e.DELETE("/api/v1/admin/limit-profiles/:id", limitController.DeleteLimitProfile)
+}
+
+func (limitController *LimitController) CreateLimitProfile(c echo.Context) error {
+	var request request.LimitProfileRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	limitProfile, err := request.ToDomain()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	if err := limitController.limitService.CreateProfile(limitProfile); err != nil {
+		return limitProfileErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToLimitProfileResponse(limitProfile))
+}
+
+func (limitController *LimitController) GetLimitProfiles(c echo.Context) error {
+	limitProfiles, err := limitController.limitService.GetProfiles()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToLimitProfileResponseList(limitProfiles))
+}
+
+func (limitController *LimitController) GetLimitProfileByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid limit profile ID",
+		})
+	}
+
+	limitProfile, err := limitController.limitService.GetProfile(int64(id))
+	if err != nil {
+		return limitProfileErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToLimitProfileResponse(limitProfile))
+}
+
+func (limitController *LimitController) UpdateLimitProfile(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid limit profile ID",
+		})
+	}
+
+	var request request.LimitProfileRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	limitProfile, err := request.ToDomain()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	if err := limitController.limitService.UpdateProfile(int64(id), limitProfile); err != nil {
+		return limitProfileErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToLimitProfileResponse(limitProfile))
+}
+
+func (limitController *LimitController) DeleteLimitProfile(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid limit profile ID",
+		})
+	}
+
+	if err := limitController.limitService.DeleteProfile(int64(id)); err != nil {
+		return limitProfileErrorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func limitProfileErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrLimitProfileNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, persistence.ErrLimitProfileExists):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
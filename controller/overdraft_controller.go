@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type OverdraftController struct {
+	overdraftService service.IOverdraftService
+}
+
+func NewOverdraftController(overdraftService service.IOverdraftService) *OverdraftController {
+	return &OverdraftController{
+		overdraftService: overdraftService,
+	}
+}
+
+func (overdraftController *OverdraftController) RegisterRoutes(e *echo.Echo) {
+	// Admin overdraft routes
+	e.GET("/api/v1/admin/overdrafts", overdraftController.GetOverdraftFacilities)
+	e.GET("/api/v1/admin/overdrafts/:userID", overdraftController.GetOverdraftFacility)
+	e.PUT("/api/v1/admin/overdrafts/:userID", overdraftController.SetOverdraftFacility)
+	e.DELETE("/api/v1/admin/overdrafts/:userID", overdraftController.RemoveOverdraftFacility)
+}
+
+func (overdraftController *OverdraftController) GetOverdraftFacilities(c echo.Context) error {
+	usages, err := overdraftController.overdraftService.GetFacilities()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToOverdraftFacilityResponseList(usages))
+}
+
+/* GetOverdraftFacility reads the facility in ?currency=, the default currency when left out */
+func (overdraftController *OverdraftController) GetOverdraftFacility(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	currency, err := currencyQueryParam(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	usage, err := overdraftController.overdraftService.GetFacility(int64(userID), currency)
+	if err != nil {
+		return overdraftErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToOverdraftFacilityResponse(usage))
+}
+
+func (overdraftController *OverdraftController) SetOverdraftFacility(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	var request request.OverdraftFacilityRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	facility, err := request.ToDomain(int64(userID))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	usage, err := overdraftController.overdraftService.SetFacility(facility)
+	if err != nil {
+		return overdraftErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToOverdraftFacilityResponse(usage))
+}
+
+func (overdraftController *OverdraftController) RemoveOverdraftFacility(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	currency, err := currencyQueryParam(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	if err := overdraftController.overdraftService.RemoveFacility(int64(userID), currency); err != nil {
+		return overdraftErrorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func overdraftErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrOverdraftNotFound), errors.Is(err, persistence.ErrBalanceNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrOverdraftInUse):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
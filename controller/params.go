@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/labstack/echo/v4"
+)
+
+/* currencyQueryParam reads ?currency=, falling back to the default currency */
+func currencyQueryParam(c echo.Context) (domain.Currency, error) {
+	currency := domain.Currency(strings.ToUpper(c.QueryParam("currency")))
+	if currency == "" {
+		return domain.DefaultCurrency, nil
+	}
+
+	if !currency.IsSupported() {
+		return "", fmt.Errorf("unsupported currency %q", currency)
+	}
+	return currency, nil
+}
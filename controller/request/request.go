@@ -1,6 +1,17 @@
 package request
 
-import "github.com/denizdoganinsider/kpi_project/service/model"
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service/model"
+)
 
 type AddUserRequest struct {
 	Username string `json:"username"`
@@ -24,12 +35,705 @@ type LoginRequest struct {
 }
 
 type UpdateBalanceRequest struct {
-	UserID int64   `json:"user_id"`
-	Amount float64 `json:"amount"`
+	UserID   int64           `json:"user_id"`
+	Amount   domain.Money    `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (updateBalanceRequest UpdateBalanceRequest) Money() (domain.Money, error) {
+	return toMoney(updateBalanceRequest.Amount, updateBalanceRequest.Currency)
+}
+
+/* PocketRequest names a pocket, Currency is only read when the pocket is created */
+type PocketRequest struct {
+	Name     string          `json:"name"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (pocketRequest PocketRequest) PocketCurrency() domain.Currency {
+	return currencyOrDefault(pocketRequest.Currency)
+}
+
+/* PocketFundsRequest moves Amount between the main balance and a pocket, in the pocket's currency */
+type PocketFundsRequest struct {
+	Amount   domain.Money    `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (pocketFundsRequest PocketFundsRequest) Money() (domain.Money, error) {
+	return toMoney(pocketFundsRequest.Amount, pocketFundsRequest.Currency)
 }
 
 type TransactionRequest struct {
-	FromUserID int64   `json:"from_user_id"`
-	ToUserID   int64   `json:"to_user_id"`
-	Amount     float64 `json:"amount"`
+	FromUserID int64           `json:"from_user_id"`
+	ToUserID   int64           `json:"to_user_id"`
+	Amount     domain.Money    `json:"amount"`
+	Currency   domain.Currency `json:"currency"`
+	ToCurrency domain.Currency `json:"to_currency"`
+	ExecuteAt  *time.Time      `json:"execute_at"`
+}
+
+func (transactionRequest TransactionRequest) Money() (domain.Money, error) {
+	return toMoney(transactionRequest.Amount, transactionRequest.Currency)
+}
+
+/* IsCrossCurrency tells whether the receiver should be paid in another currency */
+func (transactionRequest TransactionRequest) IsCrossCurrency() bool {
+	return transactionRequest.ToCurrency != "" && transactionRequest.ToCurrency != currencyOrDefault(transactionRequest.Currency)
+}
+
+/* IsFutureDated tells whether the transfer should be scheduled instead of run now */
+func (transactionRequest TransactionRequest) IsFutureDated(now time.Time) bool {
+	return transactionRequest.ExecuteAt != nil && transactionRequest.ExecuteAt.After(now)
+}
+
+/* RefundRequest has to name the currency of the refunded transaction unless it is the default one */
+type RefundRequest struct {
+	Amount   domain.Money    `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (refundRequest RefundRequest) Money() (domain.Money, error) {
+	return toMoney(refundRequest.Amount, refundRequest.Currency)
+}
+
+/* AuthorizeRequest pays ToUserID on capture, without it the captured amount leaves the system */
+type AuthorizeRequest struct {
+	UserID   int64           `json:"user_id"`
+	ToUserID *int64          `json:"to_user_id"`
+	Amount   domain.Money    `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (authorizeRequest AuthorizeRequest) Money() (domain.Money, error) {
+	return toMoney(authorizeRequest.Amount, authorizeRequest.Currency)
+}
+
+/* CaptureRequest captures the whole hold when Amount is left out */
+type CaptureRequest struct {
+	Amount   *domain.Money   `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (captureRequest CaptureRequest) Money() (*domain.Money, error) {
+	return optionalMoney(captureRequest.Amount, currencyOrDefault(captureRequest.Currency))
+}
+
+/* OpenDisputeRequest is sent by the sender of a transfer they didn't authorize */
+type OpenDisputeRequest struct {
+	ClaimantID int64  `json:"claimant_id"`
+	Reason     string `json:"reason"`
+	Evidence   string `json:"evidence"`
+}
+
+/* DisputeReviewRequest is sent by the reviewer moving a dispute along */
+type DisputeReviewRequest struct {
+	ReviewerID int64  `json:"reviewer_id"`
+	Note       string `json:"note"`
+}
+
+type ResolveDisputeRequest struct {
+	Outcome    domain.DisputeStatus `json:"outcome"`
+	ReviewerID int64                `json:"reviewer_id"`
+	Note       string               `json:"note"`
+}
+
+/* ResolveRiskReviewRequest closes a review as cleared or confirmed fraud */
+type ResolveRiskReviewRequest struct {
+	Status     domain.RiskReviewStatus `json:"status"`
+	ResolvedBy int64                   `json:"resolved_by"`
+	Note       string                  `json:"note"`
+}
+
+/* ApprovalDecisionRequest is sent by the approver approving or rejecting a transfer */
+type ApprovalDecisionRequest struct {
+	ApproverID int64  `json:"approver_id"`
+	Note       string `json:"note"`
+}
+
+func toMoney(amount domain.Money, currency domain.Currency) (domain.Money, error) {
+	return amount.WithCurrency(currencyOrDefault(currency))
+}
+
+/* requests without a currency keep working in the default currency */
+func currencyOrDefault(currency domain.Currency) domain.Currency {
+	if currency == "" {
+		return domain.DefaultCurrency
+	}
+	return currency
+}
+
+type FxQuoteRequest struct {
+	UserID       int64           `json:"user_id"`
+	FromCurrency domain.Currency `json:"from_currency"`
+	ToCurrency   domain.Currency `json:"to_currency"`
+	Amount       domain.Money    `json:"amount"`
+}
+
+/* Money is the amount the user sells, in FromCurrency */
+func (fxQuoteRequest FxQuoteRequest) Money() (domain.Money, error) {
+	return toMoney(fxQuoteRequest.Amount, fxQuoteRequest.FromCurrency)
+}
+
+type ConvertRequest struct {
+	UserID  int64  `json:"user_id"`
+	QuoteID string `json:"quote_id"`
+}
+
+type RecurrenceRequest struct {
+	Frequency      domain.RecurrenceFrequency `json:"frequency"`
+	Day            int                        `json:"day"`
+	EndDate        *time.Time                 `json:"end_date"`
+	MaxOccurrences *int                       `json:"max_occurrences"`
+}
+
+func (recurrenceRequest RecurrenceRequest) ToDomain() domain.Recurrence {
+	return domain.Recurrence{
+		Frequency:      recurrenceRequest.Frequency,
+		Day:            recurrenceRequest.Day,
+		EndDate:        recurrenceRequest.EndDate,
+		MaxOccurrences: recurrenceRequest.MaxOccurrences,
+	}
+}
+
+type StandingOrderRequest struct {
+	FromUserID              int64                           `json:"from_user_id"`
+	ToUserID                int64                           `json:"to_user_id"`
+	Amount                  domain.Money                    `json:"amount"`
+	Currency                domain.Currency                 `json:"currency"`
+	Recurrence              RecurrenceRequest               `json:"recurrence"`
+	StartAt                 *time.Time                      `json:"start_at"`
+	InsufficientFundsPolicy *domain.InsufficientFundsPolicy `json:"insufficient_funds_policy"`
+	MaxRetries              *int                            `json:"max_retries"`
+}
+
+func (standingOrderRequest StandingOrderRequest) ToModel() (model.StandingOrderCreate, error) {
+	amount, err := toMoney(standingOrderRequest.Amount, standingOrderRequest.Currency)
+	if err != nil {
+		return model.StandingOrderCreate{}, err
+	}
+
+	standingOrderCreate := model.StandingOrderCreate{
+		FromUser:                standingOrderRequest.FromUserID,
+		ToUser:                  standingOrderRequest.ToUserID,
+		Amount:                  amount,
+		Recurrence:              standingOrderRequest.Recurrence.ToDomain(),
+		InsufficientFundsPolicy: standingOrderRequest.InsufficientFundsPolicy,
+		MaxRetries:              standingOrderRequest.MaxRetries,
+	}
+	if standingOrderRequest.StartAt != nil {
+		standingOrderCreate.StartAt = *standingOrderRequest.StartAt
+	}
+	return standingOrderCreate, nil
+}
+
+type UpdateStandingOrderRequest struct {
+	Amount                  domain.Money                    `json:"amount"`
+	Currency                domain.Currency                 `json:"currency"`
+	Recurrence              RecurrenceRequest               `json:"recurrence"`
+	InsufficientFundsPolicy *domain.InsufficientFundsPolicy `json:"insufficient_funds_policy"`
+	MaxRetries              *int                            `json:"max_retries"`
+}
+
+func (updateStandingOrderRequest UpdateStandingOrderRequest) ToModel() (model.StandingOrderUpdate, error) {
+	amount, err := toMoney(updateStandingOrderRequest.Amount, updateStandingOrderRequest.Currency)
+	if err != nil {
+		return model.StandingOrderUpdate{}, err
+	}
+
+	return model.StandingOrderUpdate{
+		Amount:                  amount,
+		Recurrence:              updateStandingOrderRequest.Recurrence.ToDomain(),
+		InsufficientFundsPolicy: updateStandingOrderRequest.InsufficientFundsPolicy,
+		MaxRetries:              updateStandingOrderRequest.MaxRetries,
+	}, nil
+}
+
+/* LimitProfileRequest sets either UserID or Role, amounts are in Currency and left out limits aren't enforced */
+type LimitProfileRequest struct {
+	UserID          *int64                 `json:"user_id"`
+	Role            string                 `json:"role"`
+	TransactionType domain.TransactionType `json:"transaction_type"`
+	Currency        domain.Currency        `json:"currency"`
+	MaxSingleAmount *domain.Money          `json:"max_single_amount"`
+	DailyAmount     *domain.Money          `json:"daily_amount"`
+	MonthlyAmount   *domain.Money          `json:"monthly_amount"`
+	DailyCount      *int                   `json:"daily_count"`
+}
+
+func (limitProfileRequest LimitProfileRequest) ToDomain() (*domain.LimitProfile, error) {
+	currency := currencyOrDefault(limitProfileRequest.Currency)
+	limitProfile := &domain.LimitProfile{
+		UserID:          limitProfileRequest.UserID,
+		Role:            limitProfileRequest.Role,
+		TransactionType: limitProfileRequest.TransactionType,
+		Currency:        currency,
+		DailyCount:      limitProfileRequest.DailyCount,
+	}
+
+	var err error
+	if limitProfile.MaxSingleAmount, err = optionalMoney(limitProfileRequest.MaxSingleAmount, currency); err != nil {
+		return nil, err
+	}
+	if limitProfile.DailyAmount, err = optionalMoney(limitProfileRequest.DailyAmount, currency); err != nil {
+		return nil, err
+	}
+	if limitProfile.MonthlyAmount, err = optionalMoney(limitProfileRequest.MonthlyAmount, currency); err != nil {
+		return nil, err
+	}
+	return limitProfile, nil
+}
+
+func optionalMoney(amount *domain.Money, currency domain.Currency) (*domain.Money, error) {
+	if amount == nil {
+		return nil, nil
+	}
+	converted, err := toMoney(*amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &converted, nil
+}
+
+/* OverdraftFacilityRequest grants a user a credit limit in Currency, DailyRateBps of zero charges nothing */
+type OverdraftFacilityRequest struct {
+	Currency     domain.Currency `json:"currency"`
+	CreditLimit  domain.Money    `json:"credit_limit"`
+	DailyRateBps int64           `json:"daily_rate_bps"`
+}
+
+func (overdraftFacilityRequest OverdraftFacilityRequest) ToDomain(userID int64) (*domain.OverdraftFacility, error) {
+	currency := currencyOrDefault(overdraftFacilityRequest.Currency)
+	creditLimit, err := toMoney(overdraftFacilityRequest.CreditLimit, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.OverdraftFacility{
+		UserID:       userID,
+		Currency:     currency,
+		CreditLimit:  creditLimit,
+		DailyRateBps: overdraftFacilityRequest.DailyRateBps,
+	}, nil
+}
+
+type SavingsGoalRequest struct {
+	UserID       int64           `json:"user_id"`
+	Name         string          `json:"name"`
+	Currency     domain.Currency `json:"currency"`
+	TargetAmount domain.Money    `json:"target_amount"`
+	TargetDate   time.Time       `json:"target_date"`
+}
+
+func (savingsGoalRequest SavingsGoalRequest) ToDomain() (*domain.SavingsGoal, error) {
+	currency := currencyOrDefault(savingsGoalRequest.Currency)
+	targetAmount, err := toMoney(savingsGoalRequest.TargetAmount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SavingsGoal{
+		UserID:       savingsGoalRequest.UserID,
+		Name:         savingsGoalRequest.Name,
+		Currency:     currency,
+		TargetAmount: targetAmount,
+		TargetDate:   savingsGoalRequest.TargetDate,
+	}, nil
+}
+
+/* SweepRuleRequest sets Amount and Recurrence for fixed_amount rules only, Amount is in the goal's currency */
+type SweepRuleRequest struct {
+	Type          domain.SweepRuleType `json:"type"`
+	Amount        *domain.Money        `json:"amount"`
+	Currency      domain.Currency      `json:"currency"`
+	PercentageBps int64                `json:"percentage_bps"`
+	Recurrence    *RecurrenceRequest   `json:"recurrence"`
+	StartAt       *time.Time           `json:"start_at"`
+}
+
+func (sweepRuleRequest SweepRuleRequest) ToDomain() (*domain.SweepRule, error) {
+	rule := &domain.SweepRule{
+		Type:          sweepRuleRequest.Type,
+		PercentageBps: sweepRuleRequest.PercentageBps,
+	}
+	if sweepRuleRequest.Amount != nil {
+		amount, err := toMoney(*sweepRuleRequest.Amount, sweepRuleRequest.Currency)
+		if err != nil {
+			return nil, err
+		}
+		rule.Amount = &amount
+	}
+	if sweepRuleRequest.Recurrence != nil {
+		recurrence := sweepRuleRequest.Recurrence.ToDomain()
+		rule.Recurrence = &recurrence
+	}
+	return rule, nil
+}
+
+/* SavingsContributionRequest moves Amount into a goal by hand, in the goal's currency */
+type SavingsContributionRequest struct {
+	Amount   domain.Money    `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (savingsContributionRequest SavingsContributionRequest) Money() (domain.Money, error) {
+	return toMoney(savingsContributionRequest.Amount, savingsContributionRequest.Currency)
+}
+
+type FeeTierRequest struct {
+	UpTo          *domain.Money `json:"up_to"`
+	FlatAmount    domain.Money  `json:"flat_amount"`
+	PercentageBps int64         `json:"percentage_bps"`
+}
+
+/* FeeScheduleRequest leaves Role empty for the schedule every user falls back to */
+type FeeScheduleRequest struct {
+	TransactionType domain.TransactionType `json:"transaction_type"`
+	Currency        domain.Currency        `json:"currency"`
+	Role            string                 `json:"role"`
+	Type            domain.FeeType         `json:"type"`
+	FlatAmount      *domain.Money          `json:"flat_amount"`
+	PercentageBps   int64                  `json:"percentage_bps"`
+	Tiers           []FeeTierRequest       `json:"tiers"`
+	MinFee          *domain.Money          `json:"min_fee"`
+	MaxFee          *domain.Money          `json:"max_fee"`
+}
+
+func (feeScheduleRequest FeeScheduleRequest) ToDomain() (*domain.FeeSchedule, error) {
+	currency := currencyOrDefault(feeScheduleRequest.Currency)
+	feeSchedule := &domain.FeeSchedule{
+		TransactionType: feeScheduleRequest.TransactionType,
+		Currency:        currency,
+		Role:            feeScheduleRequest.Role,
+		Type:            feeScheduleRequest.Type,
+		PercentageBps:   feeScheduleRequest.PercentageBps,
+	}
+
+	var err error
+	if feeSchedule.FlatAmount, err = optionalMoney(feeScheduleRequest.FlatAmount, currency); err != nil {
+		return nil, err
+	}
+	if feeSchedule.MinFee, err = optionalMoney(feeScheduleRequest.MinFee, currency); err != nil {
+		return nil, err
+	}
+	if feeSchedule.MaxFee, err = optionalMoney(feeScheduleRequest.MaxFee, currency); err != nil {
+		return nil, err
+	}
+
+	for _, tierRequest := range feeScheduleRequest.Tiers {
+		tier := domain.FeeTier{PercentageBps: tierRequest.PercentageBps}
+		if tier.UpTo, err = optionalMoney(tierRequest.UpTo, currency); err != nil {
+			return nil, err
+		}
+		if tier.FlatAmount, err = toMoney(tierRequest.FlatAmount, currency); err != nil {
+			return nil, err
+		}
+		feeSchedule.Tiers = append(feeSchedule.Tiers, tier)
+	}
+	return feeSchedule, nil
+}
+
+type TransferBatchItemRequest struct {
+	ToUserID  int64        `json:"to_user"`
+	Amount    domain.Money `json:"amount"`
+	Reference string       `json:"reference"`
+}
+
+/* TransferBatchRequest is the JSON form of a batch, every item is paid in Currency */
+type TransferBatchRequest struct {
+	FromUserID int64                      `json:"from_user_id"`
+	Currency   domain.Currency            `json:"currency"`
+	Items      []TransferBatchItemRequest `json:"items"`
+}
+
+func (transferBatchRequest TransferBatchRequest) ToDomain() ([]domain.TransferBatchItem, error) {
+	currency := currencyOrDefault(transferBatchRequest.Currency)
+	items := make([]domain.TransferBatchItem, 0, len(transferBatchRequest.Items))
+	for i, itemRequest := range transferBatchRequest.Items {
+		amount, err := toMoney(itemRequest.Amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, domain.TransferBatchItem{
+			Row:       i + 1,
+			ToUser:    itemRequest.ToUserID,
+			Amount:    amount,
+			Reference: itemRequest.Reference,
+		})
+	}
+	return items, nil
+}
+
+/* SplitRecipientRequest gives either an amount or a percentage in basis points */
+type SplitRecipientRequest struct {
+	ToUserID      int64         `json:"to_user_id"`
+	Amount        *domain.Money `json:"amount"`
+	PercentageBps int64         `json:"percentage_bps"`
+}
+
+/* SplitPaymentRequest needs Amount only when recipients are given percentages */
+type SplitPaymentRequest struct {
+	FromUserID int64                   `json:"from_user_id"`
+	Amount     *domain.Money           `json:"amount"`
+	Currency   domain.Currency         `json:"currency"`
+	Recipients []SplitRecipientRequest `json:"recipients"`
+}
+
+func (splitPaymentRequest SplitPaymentRequest) ToDomain() (domain.SplitInstruction, error) {
+	currency := currencyOrDefault(splitPaymentRequest.Currency)
+	total, err := optionalMoney(splitPaymentRequest.Amount, currency)
+	if err != nil {
+		return domain.SplitInstruction{}, err
+	}
+
+	instruction := domain.SplitInstruction{
+		Payer:    splitPaymentRequest.FromUserID,
+		Currency: currency,
+		Total:    total,
+		Shares:   make([]domain.SplitShare, 0, len(splitPaymentRequest.Recipients)),
+	}
+	for _, recipientRequest := range splitPaymentRequest.Recipients {
+		amount, err := optionalMoney(recipientRequest.Amount, currency)
+		if err != nil {
+			return domain.SplitInstruction{}, err
+		}
+		instruction.Shares = append(instruction.Shares, domain.SplitShare{
+			ToUser:        recipientRequest.ToUserID,
+			Amount:        amount,
+			PercentageBps: recipientRequest.PercentageBps,
+		})
+	}
+	return instruction, nil
+}
+
+/*
+ParseTransferBatchCSV reads the uploaded form of a batch. The first line is
+the header to_user,amount,reference; reference may be left out. Rows are
+numbered like in the JSON form, the header doesn't count.
+*/
+func ParseTransferBatchCSV(reader io.Reader, currency domain.Currency) ([]domain.TransferBatchItem, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("csv file is empty")
+	}
+
+	header := records[0]
+	if len(header) < 2 || strings.TrimSpace(header[0]) != "to_user" || strings.TrimSpace(header[1]) != "amount" {
+		return nil, errors.New("csv header must be to_user,amount,reference")
+	}
+
+	validationError := &domain.TransferBatchValidationError{}
+	items := make([]domain.TransferBatchItem, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := i + 1
+		if len(record) < 2 || len(record) > 3 {
+			validationError.Add(row, "expected to_user,amount,reference")
+			continue
+		}
+
+		toUserID, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		if err != nil {
+			validationError.Add(row, "invalid to_user")
+			continue
+		}
+
+		amount, err := domain.ParseMoney(strings.TrimSpace(record[1]), currencyOrDefault(currency))
+		if err != nil {
+			validationError.Add(row, "invalid amount")
+			continue
+		}
+
+		item := domain.TransferBatchItem{Row: row, ToUser: toUserID, Amount: amount}
+		if len(record) == 3 {
+			item.Reference = strings.TrimSpace(record[2])
+		}
+		items = append(items, item)
+	}
+
+	if len(validationError.Rows) > 0 {
+		return nil, validationError
+	}
+	return items, nil
+}
+
+/*
+TransactionHistoryRequest is read from the query string. Type and status take
+comma separated lists, dates are RFC 3339 or plain 2006-01-02 and sort is
+created_at or -created_at, the newest first being the default.
+*/
+type TransactionHistoryRequest struct {
+	Type           string          `query:"type"`
+	Status         string          `query:"status"`
+	CounterpartyID string          `query:"counterparty_id"`
+	MinAmount      string          `query:"min_amount"`
+	MaxAmount      string          `query:"max_amount"`
+	Currency       domain.Currency `query:"currency"`
+	From           string          `query:"from"`
+	To             string          `query:"to"`
+	Sort           string          `query:"sort"`
+	Cursor         string          `query:"cursor"`
+	Limit          int             `query:"limit"`
+}
+
+func (transactionHistoryRequest TransactionHistoryRequest) ToDomain() (domain.TransactionFilter, error) {
+	filter := domain.TransactionFilter{
+		Currency: domain.Currency(strings.ToUpper(string(transactionHistoryRequest.Currency))),
+		Limit:    transactionHistoryRequest.Limit,
+	}
+
+	for _, transactionType := range splitList(transactionHistoryRequest.Type) {
+		filter.Types = append(filter.Types, domain.TransactionType(transactionType))
+	}
+	for _, status := range splitList(transactionHistoryRequest.Status) {
+		filter.Statuses = append(filter.Statuses, domain.TransactionStatus(status))
+	}
+
+	if transactionHistoryRequest.CounterpartyID != "" {
+		counterpartyID, err := strconv.ParseInt(transactionHistoryRequest.CounterpartyID, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid counterparty_id")
+		}
+		filter.Counterparty = &counterpartyID
+	}
+
+	var err error
+	if filter.MinAmount, err = parseOptionalMoney(transactionHistoryRequest.MinAmount, filter.Currency, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseOptionalMoney(transactionHistoryRequest.MaxAmount, filter.Currency, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.From, err = parseOptionalDate(transactionHistoryRequest.From, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseOptionalDate(transactionHistoryRequest.To, "to"); err != nil {
+		return filter, err
+	}
+
+	switch transactionHistoryRequest.Sort {
+	case "", "-created_at":
+	case "created_at":
+		filter.Ascending = true
+	default:
+		return filter, errors.New("sort must be created_at or -created_at")
+	}
+
+	if transactionHistoryRequest.Cursor != "" {
+		if filter.After, err = domain.ParseTransactionCursor(transactionHistoryRequest.Cursor); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func parseOptionalMoney(value string, currency domain.Currency, name string) (*domain.Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := domain.ParseMoney(value, currencyOrDefault(currency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &amount, nil
+}
+
+func parseOptionalDate(value string, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s", name)
+}
+
+/* BackfillSnapshotsRequest takes the first and last day to snapshot as YYYY-MM-DD */
+type BackfillSnapshotsRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (backfillSnapshotsRequest BackfillSnapshotsRequest) Dates() (time.Time, time.Time, error) {
+	from, err := time.Parse(time.DateOnly, backfillSnapshotsRequest.From)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid from")
+	}
+	to, err := time.Parse(time.DateOnly, backfillSnapshotsRequest.To)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid to")
+	}
+	return from, to, nil
+}
+
+type StatementFormat string
+
+const (
+	CSVStatement StatementFormat = "csv"
+	PDFStatement StatementFormat = "pdf"
+)
+
+/*
+StatementRequest is read from the query string. A plain date in To includes
+that whole day, an RFC 3339 time is the exclusive end of the period.
+*/
+type StatementRequest struct {
+	From     string          `query:"from"`
+	To       string          `query:"to"`
+	Format   StatementFormat `query:"format"`
+	Currency domain.Currency `query:"currency"`
+}
+
+func (statementRequest StatementRequest) ToDomain(userID int64) (*domain.Statement, error) {
+	if statementRequest.From == "" || statementRequest.To == "" {
+		return nil, errors.New("from and to are required")
+	}
+
+	from, err := parseOptionalDate(statementRequest.From, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseOptionalDate(statementRequest.To, "to")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(time.DateOnly, statementRequest.To); err == nil {
+		*to = to.AddDate(0, 0, 1)
+	}
+
+	return &domain.Statement{
+		UserID:   userID,
+		Currency: currencyOrDefault(domain.Currency(strings.ToUpper(string(statementRequest.Currency)))),
+		From:     *from,
+		To:       *to,
+	}, nil
+}
+
+/* StatementFormat defaults to csv */
+func (statementRequest StatementRequest) StatementFormat() (StatementFormat, error) {
+	switch statementRequest.Format {
+	case "", CSVStatement:
+		return CSVStatement, nil
+	case PDFStatement:
+		return PDFStatement, nil
+	}
+	return "", errors.New("format must be csv or pdf")
 }
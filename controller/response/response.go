@@ -1,13 +1,141 @@
 package response
 
-import "github.com/denizdoganinsider/kpi_project/domain"
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service/model"
+)
 
 type ErrorResponse struct {
 	ErrorDescription string `json:"error_description"`
 }
 
+/* LimitExceededResponse tells which limit was hit and how much headroom is left under it */
+type LimitExceededResponse struct {
+	ErrorDescription string                 `json:"error_description"`
+	Limit            domain.LimitName       `json:"limit"`
+	TransactionType  domain.TransactionType `json:"transaction_type"`
+	Currency         domain.Currency        `json:"currency"`
+	LimitAmount      *domain.Money          `json:"limit_amount,omitempty"`
+	RemainingAmount  *domain.Money          `json:"remaining_amount,omitempty"`
+	LimitCount       *int                   `json:"limit_count,omitempty"`
+	RemainingCount   *int                   `json:"remaining_count,omitempty"`
+}
+
+type LimitProfileResponse struct {
+	ID              int64                  `json:"id"`
+	UserID          *int64                 `json:"user_id,omitempty"`
+	Role            string                 `json:"role,omitempty"`
+	TransactionType domain.TransactionType `json:"transaction_type"`
+	Currency        domain.Currency        `json:"currency"`
+	MaxSingleAmount *domain.Money          `json:"max_single_amount,omitempty"`
+	DailyAmount     *domain.Money          `json:"daily_amount,omitempty"`
+	MonthlyAmount   *domain.Money          `json:"monthly_amount,omitempty"`
+	DailyCount      *int                   `json:"daily_count,omitempty"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
+
+type OverdraftFacilityResponse struct {
+	UserID           int64           `json:"user_id"`
+	Currency         domain.Currency `json:"currency"`
+	CreditLimit      domain.Money    `json:"credit_limit"`
+	DailyRateBps     int64           `json:"daily_rate_bps"`
+	Balance          domain.Money    `json:"balance"`
+	OverdraftUsed    domain.Money    `json:"overdraft_used"`
+	AvailableBalance domain.Money    `json:"available_balance"`
+	LastChargedOn    *string         `json:"last_charged_on,omitempty"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+type FeeTierResponse struct {
+	UpTo          *domain.Money `json:"up_to,omitempty"`
+	FlatAmount    domain.Money  `json:"flat_amount"`
+	PercentageBps int64         `json:"percentage_bps"`
+}
+
+type FeeScheduleResponse struct {
+	ID              int64                  `json:"id"`
+	TransactionType domain.TransactionType `json:"transaction_type"`
+	Currency        domain.Currency        `json:"currency"`
+	Role            string                 `json:"role,omitempty"`
+	Type            domain.FeeType         `json:"type"`
+	FlatAmount      *domain.Money          `json:"flat_amount,omitempty"`
+	PercentageBps   int64                  `json:"percentage_bps,omitempty"`
+	Tiers           []FeeTierResponse      `json:"tiers,omitempty"`
+	MinFee          *domain.Money          `json:"min_fee,omitempty"`
+	MaxFee          *domain.Money          `json:"max_fee,omitempty"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
+
+/* FeePreviewResponse shows what a transaction would cost before it is made, Total is Amount plus Fee */
+type FeePreviewResponse struct {
+	TransactionType domain.TransactionType `json:"transaction_type"`
+	Amount          domain.Money           `json:"amount"`
+	Fee             domain.Money           `json:"fee"`
+	Total           domain.Money           `json:"total"`
+	Currency        domain.Currency        `json:"currency"`
+}
+
+/*
+Balance is kept for older clients, it is the same as LedgerBalance: the total
+of the main balance and every pocket.
+*/
 type GetBalanceResponse struct {
-	Balance float64 `json:"balance"`
+	Balance          domain.Money     `json:"balance"`
+	AvailableBalance domain.Money     `json:"available_balance"`
+	LedgerBalance    domain.Money     `json:"ledger_balance"`
+	MainBalance      domain.Money     `json:"main_balance"`
+	PocketedBalance  domain.Money     `json:"pocketed_balance"`
+	CreditLimit      domain.Money     `json:"credit_limit"`
+	OverdraftUsed    domain.Money     `json:"overdraft_used"`
+	Currency         domain.Currency  `json:"currency"`
+	Pockets          []PocketResponse `json:"pockets"`
+}
+
+type PocketResponse struct {
+	ID        int64               `json:"id"`
+	UserID    int64               `json:"user_id"`
+	Currency  domain.Currency     `json:"currency"`
+	Name      string              `json:"name"`
+	Amount    domain.Money        `json:"amount"`
+	Status    domain.PocketStatus `json:"status"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+	ClosedAt  *time.Time          `json:"closed_at,omitempty"`
+}
+
+/* HistoricalBalanceResponse has no snapshot_date when every posting was replayed */
+type HistoricalBalanceResponse struct {
+	Balance      domain.Money    `json:"balance"`
+	Currency     domain.Currency `json:"currency"`
+	AsOf         time.Time       `json:"as_of"`
+	SnapshotDate *string         `json:"snapshot_date,omitempty"`
+}
+
+type BackfillSnapshotsResponse struct {
+	Snapshots int `json:"snapshots"`
+}
+
+type WalletResponse struct {
+	Currency         domain.Currency `json:"currency"`
+	Balance          domain.Money    `json:"balance"`
+	AvailableBalance domain.Money    `json:"available_balance"`
+	CreditLimit      domain.Money    `json:"credit_limit"`
+	OverdraftUsed    domain.Money    `json:"overdraft_used"`
+	LastUpdatedAt    time.Time       `json:"last_updated_at"`
+}
+
+type HoldResponse struct {
+	TransactionID  int64                    `json:"transaction_id"`
+	UserID         int64                    `json:"user_id"`
+	ToUserID       *int64                   `json:"to_user_id,omitempty"`
+	Amount         domain.Money             `json:"amount"`
+	CapturedAmount domain.Money             `json:"captured_amount"`
+	Currency       domain.Currency          `json:"currency"`
+	Status         domain.TransactionStatus `json:"status"`
+	ExpiresAt      time.Time                `json:"expires_at"`
+	CreatedAt      time.Time                `json:"created_at"`
 }
 
 type UserResponse struct {
@@ -24,6 +152,264 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
+type PostingResponse struct {
+	ID             int64        `json:"id"`
+	JournalEntryID int64        `json:"journal_entry_id"`
+	Amount         domain.Money `json:"amount"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+type LedgerResponse struct {
+	AccountCode   string            `json:"account_code"`
+	Currency      domain.Currency   `json:"currency"`
+	LedgerBalance domain.Money      `json:"ledger_balance"`
+	Balance       domain.Money      `json:"balance"`
+	InSync        bool              `json:"in_sync"`
+	Postings      []PostingResponse `json:"postings"`
+}
+
+type FxRateResponse struct {
+	Base      domain.Currency `json:"base"`
+	Quote     domain.Currency `json:"quote"`
+	Rate      string          `json:"rate"`
+	SpreadBps int64           `json:"spread_bps"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type FxQuoteResponse struct {
+	ID           string               `json:"id"`
+	UserID       int64                `json:"user_id"`
+	FromCurrency domain.Currency      `json:"from_currency"`
+	ToCurrency   domain.Currency      `json:"to_currency"`
+	SellAmount   domain.Money         `json:"sell_amount"`
+	BuyAmount    domain.Money         `json:"buy_amount"`
+	Rate         string               `json:"rate"`
+	MidRate      string               `json:"mid_rate"`
+	SpreadBps    int64                `json:"spread_bps"`
+	Status       domain.FxQuoteStatus `json:"status"`
+	ExpiresAt    time.Time            `json:"expires_at"`
+}
+
+type ConversionResponse struct {
+	Quote           FxQuoteResponse    `json:"quote"`
+	SellTransaction domain.Transaction `json:"sell_transaction"`
+	BuyTransaction  domain.Transaction `json:"buy_transaction"`
+}
+
+type ScheduledTransferResponse struct {
+	ID            int64                          `json:"id"`
+	FromUserID    int64                          `json:"from_user_id"`
+	ToUserID      int64                          `json:"to_user_id"`
+	Amount        domain.Money                   `json:"amount"`
+	Currency      domain.Currency                `json:"currency"`
+	ExecuteAt     time.Time                      `json:"execute_at"`
+	Status        domain.ScheduledTransferStatus `json:"status"`
+	TransactionID *int64                         `json:"transaction_id,omitempty"`
+	FailureReason string                         `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time                      `json:"created_at"`
+}
+
+type TransferBatchProgressResponse struct {
+	Total            int `json:"total"`
+	Pending          int `json:"pending"`
+	Completed        int `json:"completed"`
+	Failed           int `json:"failed"`
+	Cancelled        int `json:"cancelled"`
+	AwaitingApproval int `json:"awaiting_approval"`
+}
+
+type TransferBatchResponse struct {
+	ID         int64                         `json:"id"`
+	FromUserID int64                         `json:"from_user_id"`
+	Currency   domain.Currency               `json:"currency"`
+	Status     domain.TransferBatchStatus    `json:"status"`
+	Progress   TransferBatchProgressResponse `json:"progress"`
+	CreatedAt  time.Time                     `json:"created_at"`
+	UpdatedAt  time.Time                     `json:"updated_at"`
+}
+
+type TransferBatchItemResponse struct {
+	ID            int64                          `json:"id"`
+	Row           int                            `json:"row"`
+	ToUserID      int64                          `json:"to_user"`
+	Amount        domain.Money                   `json:"amount"`
+	Reference     string                         `json:"reference,omitempty"`
+	Status        domain.TransferBatchItemStatus `json:"status"`
+	TransactionID *int64                         `json:"transaction_id,omitempty"`
+	FailureReason string                         `json:"failure_reason,omitempty"`
+}
+
+type TransferBatchRowErrorResponse struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+/* TransferBatchValidationResponse lists every rejected row of an upload, row 0 is the batch itself */
+type TransferBatchValidationResponse struct {
+	ErrorDescription string                          `json:"error_description"`
+	Rows             []TransferBatchRowErrorResponse `json:"rows"`
+}
+
+/* TransactionPageResponse has no next_cursor on the last page */
+type TransactionPageResponse struct {
+	Data       []domain.Transaction `json:"data"`
+	NextCursor *string              `json:"next_cursor"`
+}
+
+/* SplitPaymentResponse is the payment debiting the payer with the legs crediting each recipient */
+type SplitPaymentResponse struct {
+	Payment domain.Transaction   `json:"payment"`
+	Legs    []domain.Transaction `json:"legs"`
+}
+
+/* TransactionEventResponse has no from for the status the transaction was created with */
+type TransactionEventResponse struct {
+	From      *domain.TransactionStatus `json:"from,omitempty"`
+	To        domain.TransactionStatus  `json:"to"`
+	Reason    string                    `json:"reason"`
+	ChangedAt time.Time                 `json:"changed_at"`
+}
+
+type TransferApprovalResponse struct {
+	TransactionID int64                 `json:"transaction_id"`
+	MakerID       int64                 `json:"maker_id"`
+	CheckerID     *int64                `json:"checker_id,omitempty"`
+	Status        domain.ApprovalStatus `json:"status"`
+	Note          string                `json:"note,omitempty"`
+	ExpiresAt     time.Time             `json:"expires_at"`
+	DecidedAt     *time.Time            `json:"decided_at,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+type RiskReviewResponse struct {
+	ID            int64                   `json:"id"`
+	TransactionID int64                   `json:"transaction_id"`
+	UserID        int64                   `json:"user_id"`
+	Reasons       string                  `json:"reasons"`
+	Status        domain.RiskReviewStatus `json:"status"`
+	ResolvedBy    *int64                  `json:"resolved_by,omitempty"`
+	Note          string                  `json:"note,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+	ResolvedAt    *time.Time              `json:"resolved_at,omitempty"`
+}
+
+type DisputeResponse struct {
+	ID                  int64                `json:"id"`
+	TransactionID       int64                `json:"transaction_id"`
+	ClaimantID          int64                `json:"claimant_id"`
+	Reason              string               `json:"reason"`
+	Evidence            string               `json:"evidence"`
+	Status              domain.DisputeStatus `json:"status"`
+	ProvisionalCreditID *int64               `json:"provisional_credit_id,omitempty"`
+	ReversalID          *int64               `json:"reversal_id,omitempty"`
+	CreatedAt           time.Time            `json:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at"`
+}
+
+type DisputeEventResponse struct {
+	Action        domain.DisputeAction `json:"action"`
+	Status        domain.DisputeStatus `json:"status"`
+	ActorID       int64                `json:"actor_id"`
+	Note          string               `json:"note,omitempty"`
+	TransactionID *int64               `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+type RecurrenceResponse struct {
+	Frequency      domain.RecurrenceFrequency `json:"frequency"`
+	Day            int                        `json:"day,omitempty"`
+	EndDate        *time.Time                 `json:"end_date,omitempty"`
+	MaxOccurrences *int                       `json:"max_occurrences,omitempty"`
+}
+
+type StandingOrderResponse struct {
+	ID                      int64                          `json:"id"`
+	FromUserID              int64                          `json:"from_user_id"`
+	ToUserID                int64                          `json:"to_user_id"`
+	Amount                  domain.Money                   `json:"amount"`
+	Currency                domain.Currency                `json:"currency"`
+	Recurrence              RecurrenceResponse             `json:"recurrence"`
+	StartAt                 time.Time                      `json:"start_at"`
+	NextRunAt               *time.Time                     `json:"next_run_at,omitempty"`
+	OccurrencesCount        int                            `json:"occurrences_count"`
+	InsufficientFundsPolicy domain.InsufficientFundsPolicy `json:"insufficient_funds_policy"`
+	MaxRetries              int                            `json:"max_retries"`
+	Status                  domain.StandingOrderStatus     `json:"status"`
+	CreatedAt               time.Time                      `json:"created_at"`
+}
+
+type StandingOrderAttemptResponse struct {
+	ID            int64                             `json:"id"`
+	OccurrenceAt  time.Time                         `json:"occurrence_at"`
+	AttemptedAt   time.Time                         `json:"attempted_at"`
+	Status        domain.StandingOrderAttemptStatus `json:"status"`
+	TransactionID *int64                            `json:"transaction_id,omitempty"`
+	FailureReason string                            `json:"failure_reason,omitempty"`
+}
+
+type SavingsGoalResponse struct {
+	ID           int64                    `json:"id"`
+	UserID       int64                    `json:"user_id"`
+	Name         string                   `json:"name"`
+	Currency     domain.Currency          `json:"currency"`
+	TargetAmount domain.Money             `json:"target_amount"`
+	TargetDate   time.Time                `json:"target_date"`
+	SavedAmount  domain.Money             `json:"saved_amount"`
+	Remaining    domain.Money             `json:"remaining"`
+	ProgressBps  int64                    `json:"progress_bps"`
+	Reached      bool                     `json:"reached"`
+	Status       domain.SavingsGoalStatus `json:"status"`
+	CreatedAt    time.Time                `json:"created_at"`
+	UpdatedAt    time.Time                `json:"updated_at"`
+	ClosedAt     *time.Time               `json:"closed_at,omitempty"`
+}
+
+type SweepRuleResponse struct {
+	ID               int64                `json:"id"`
+	GoalID           int64                `json:"goal_id"`
+	Type             domain.SweepRuleType `json:"type"`
+	Amount           *domain.Money        `json:"amount,omitempty"`
+	Currency         domain.Currency      `json:"currency"`
+	PercentageBps    int64                `json:"percentage_bps,omitempty"`
+	Recurrence       *RecurrenceResponse  `json:"recurrence,omitempty"`
+	NextRunAt        *time.Time           `json:"next_run_at,omitempty"`
+	OccurrencesCount int                  `json:"occurrences_count"`
+	Active           bool                 `json:"active"`
+	CreatedAt        time.Time            `json:"created_at"`
+}
+
+/* SavingsSweepResponse has no rule_id for contributions made by hand */
+type SavingsSweepResponse struct {
+	ID            int64        `json:"id"`
+	GoalID        int64        `json:"goal_id"`
+	RuleID        *int64       `json:"rule_id,omitempty"`
+	TransactionID int64        `json:"transaction_id"`
+	Amount        domain.Money `json:"amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type SavingsProgressResponse struct {
+	GoalID       int64           `json:"goal_id"`
+	Currency     domain.Currency `json:"currency"`
+	TargetAmount domain.Money    `json:"target_amount"`
+	SavedAmount  domain.Money    `json:"saved_amount"`
+	Remaining    domain.Money    `json:"remaining"`
+	ProgressBps  int64           `json:"progress_bps"`
+	Reached      bool            `json:"reached"`
+	TargetDate   time.Time       `json:"target_date"`
+	DaysLeft     int             `json:"days_left"`
+}
+
+/* SavingsProjectionResponse has no projected_completion when the goal won't be reached within ten years */
+type SavingsProjectionResponse struct {
+	GoalID              int64        `json:"goal_id"`
+	Remaining           domain.Money `json:"remaining"`
+	DailyRate           domain.Money `json:"daily_rate"`
+	RequiredDaily       domain.Money `json:"required_daily"`
+	ProjectedCompletion *time.Time   `json:"projected_completion,omitempty"`
+	OnTrack             bool         `json:"on_track"`
+}
+
 func ToResponse(user domain.User) UserResponse {
 	return UserResponse{
 		Username:  user.Username,
@@ -40,3 +426,605 @@ func ToResponseList(allUsers []domain.User) []UserResponse {
 
 	return userResponseList
 }
+
+func ToLedgerResponse(userLedger *model.UserLedger) LedgerResponse {
+	var postings = []PostingResponse{}
+	for _, posting := range userLedger.Postings {
+		postings = append(postings, PostingResponse{
+			ID:             posting.ID,
+			JournalEntryID: posting.JournalEntryID,
+			Amount:         posting.Amount,
+			CreatedAt:      posting.CreatedAt,
+		})
+	}
+
+	return LedgerResponse{
+		AccountCode:   userLedger.Account.Code,
+		Currency:      userLedger.Account.Currency,
+		LedgerBalance: userLedger.LedgerBalance,
+		Balance:       userLedger.Balance,
+		InSync:        userLedger.InSync,
+		Postings:      postings,
+	}
+}
+
+func ToBalanceResponse(breakdown *domain.BalanceBreakdown) GetBalanceResponse {
+	balance := breakdown.Balance
+	return GetBalanceResponse{
+		Balance:          balance.Amount,
+		AvailableBalance: balance.Available(),
+		LedgerBalance:    balance.Amount,
+		MainBalance:      balance.Main(),
+		PocketedBalance:  balance.PocketedAmount,
+		CreditLimit:      balance.CreditLimit,
+		OverdraftUsed:    balance.OverdraftUsed(),
+		Currency:         balance.Currency,
+		Pockets:          ToPocketResponseList(breakdown.Pockets),
+	}
+}
+
+func ToPocketResponse(pocket *domain.Pocket) PocketResponse {
+	return PocketResponse{
+		ID:        pocket.ID,
+		UserID:    pocket.UserID,
+		Currency:  pocket.Currency,
+		Name:      pocket.Name,
+		Amount:    pocket.Amount,
+		Status:    pocket.Status,
+		CreatedAt: pocket.CreatedAt,
+		UpdatedAt: pocket.UpdatedAt,
+		ClosedAt:  pocket.ClosedAt,
+	}
+}
+
+func ToPocketResponseList(pockets []domain.Pocket) []PocketResponse {
+	var pocketResponseList = []PocketResponse{}
+	for _, pocket := range pockets {
+		pocketResponseList = append(pocketResponseList, ToPocketResponse(&pocket))
+	}
+
+	return pocketResponseList
+}
+
+func ToHistoricalBalanceResponse(historicalBalance *domain.HistoricalBalance) HistoricalBalanceResponse {
+	historicalBalanceResponse := HistoricalBalanceResponse{
+		Balance:  historicalBalance.Amount,
+		Currency: historicalBalance.Currency,
+		AsOf:     historicalBalance.AsOf,
+	}
+	if historicalBalance.SnapshotDate != nil {
+		snapshotDate := historicalBalance.SnapshotDate.Format(time.DateOnly)
+		historicalBalanceResponse.SnapshotDate = &snapshotDate
+	}
+
+	return historicalBalanceResponse
+}
+
+func ToWalletResponseList(balances []domain.Balance) []WalletResponse {
+	var walletResponseList = []WalletResponse{}
+	for _, balance := range balances {
+		walletResponseList = append(walletResponseList, WalletResponse{
+			Currency:         balance.Currency,
+			Balance:          balance.Amount,
+			AvailableBalance: balance.Available(),
+			CreditLimit:      balance.CreditLimit,
+			OverdraftUsed:    balance.OverdraftUsed(),
+			LastUpdatedAt:    balance.LastUpdatedAt,
+		})
+	}
+
+	return walletResponseList
+}
+
+func ToFxRateResponseList(rates []domain.FxRate) []FxRateResponse {
+	var fxRateResponseList = []FxRateResponse{}
+	for _, rate := range rates {
+		fxRateResponseList = append(fxRateResponseList, FxRateResponse{
+			Base:      rate.Base,
+			Quote:     rate.Quote,
+			Rate:      domain.FormatRate(rate.Rate),
+			SpreadBps: rate.SpreadBps,
+			UpdatedAt: rate.UpdatedAt,
+		})
+	}
+
+	return fxRateResponseList
+}
+
+func ToFxQuoteResponse(quote *domain.FxQuote) FxQuoteResponse {
+	return FxQuoteResponse{
+		ID:           quote.ID,
+		UserID:       quote.UserID,
+		FromCurrency: quote.SellAmount.Currency(),
+		ToCurrency:   quote.BuyAmount.Currency(),
+		SellAmount:   quote.SellAmount,
+		BuyAmount:    quote.BuyAmount,
+		Rate:         domain.FormatRate(quote.Rate),
+		MidRate:      domain.FormatRate(quote.MidRate),
+		SpreadBps:    quote.SpreadBps,
+		Status:       quote.Status,
+		ExpiresAt:    quote.ExpiresAt,
+	}
+}
+
+func ToConversionResponse(conversion *model.Conversion) ConversionResponse {
+	return ConversionResponse{
+		Quote:           ToFxQuoteResponse(&conversion.Quote),
+		SellTransaction: conversion.SellTransaction,
+		BuyTransaction:  conversion.BuyTransaction,
+	}
+}
+
+func ToScheduledTransferResponse(scheduledTransfer *domain.ScheduledTransfer) ScheduledTransferResponse {
+	return ScheduledTransferResponse{
+		ID:            scheduledTransfer.ID,
+		FromUserID:    scheduledTransfer.FromUser,
+		ToUserID:      scheduledTransfer.ToUser,
+		Amount:        scheduledTransfer.Amount,
+		Currency:      scheduledTransfer.Amount.Currency(),
+		ExecuteAt:     scheduledTransfer.ExecuteAt,
+		Status:        scheduledTransfer.Status,
+		TransactionID: scheduledTransfer.TransactionID,
+		FailureReason: scheduledTransfer.FailureReason,
+		CreatedAt:     scheduledTransfer.CreatedAt,
+	}
+}
+
+func ToScheduledTransferResponseList(scheduledTransfers []domain.ScheduledTransfer) []ScheduledTransferResponse {
+	var scheduledTransferResponseList = []ScheduledTransferResponse{}
+	for _, scheduledTransfer := range scheduledTransfers {
+		scheduledTransferResponseList = append(scheduledTransferResponseList, ToScheduledTransferResponse(&scheduledTransfer))
+	}
+
+	return scheduledTransferResponseList
+}
+
+func ToStandingOrderResponse(standingOrder *domain.StandingOrder) StandingOrderResponse {
+	standingOrderResponse := StandingOrderResponse{
+		ID:         standingOrder.ID,
+		FromUserID: standingOrder.FromUser,
+		ToUserID:   standingOrder.ToUser,
+		Amount:     standingOrder.Amount,
+		Currency:   standingOrder.Amount.Currency(),
+		Recurrence: RecurrenceResponse{
+			Frequency:      standingOrder.Recurrence.Frequency,
+			Day:            standingOrder.Recurrence.Day,
+			EndDate:        standingOrder.Recurrence.EndDate,
+			MaxOccurrences: standingOrder.Recurrence.MaxOccurrences,
+		},
+		StartAt:                 standingOrder.StartAt,
+		OccurrencesCount:        standingOrder.OccurrencesCount,
+		InsufficientFundsPolicy: standingOrder.InsufficientFundsPolicy,
+		MaxRetries:              standingOrder.MaxRetries,
+		Status:                  standingOrder.Status,
+		CreatedAt:               standingOrder.CreatedAt,
+	}
+	// Only an active order has a next run
+	if standingOrder.Status == domain.StandingOrderActive {
+		standingOrderResponse.NextRunAt = &standingOrder.NextRunAt
+	}
+	return standingOrderResponse
+}
+
+func ToStandingOrderResponseList(standingOrders []domain.StandingOrder) []StandingOrderResponse {
+	var standingOrderResponseList = []StandingOrderResponse{}
+	for _, standingOrder := range standingOrders {
+		standingOrderResponseList = append(standingOrderResponseList, ToStandingOrderResponse(&standingOrder))
+	}
+
+	return standingOrderResponseList
+}
+
+func ToStandingOrderAttemptResponseList(attempts []domain.StandingOrderAttempt) []StandingOrderAttemptResponse {
+	var attemptResponseList = []StandingOrderAttemptResponse{}
+	for _, attempt := range attempts {
+		attemptResponseList = append(attemptResponseList, StandingOrderAttemptResponse{
+			ID:            attempt.ID,
+			OccurrenceAt:  attempt.OccurrenceAt,
+			AttemptedAt:   attempt.AttemptedAt,
+			Status:        attempt.Status,
+			TransactionID: attempt.TransactionID,
+			FailureReason: attempt.FailureReason,
+		})
+	}
+
+	return attemptResponseList
+}
+
+func ToHoldResponse(hold *domain.Hold) HoldResponse {
+	return HoldResponse{
+		TransactionID:  hold.TransactionID,
+		UserID:         hold.UserID,
+		ToUserID:       hold.ToUser,
+		Amount:         hold.Amount,
+		CapturedAmount: hold.CapturedAmount,
+		Currency:       hold.Amount.Currency(),
+		Status:         hold.Status,
+		ExpiresAt:      hold.ExpiresAt,
+		CreatedAt:      hold.CreatedAt,
+	}
+}
+
+func ToLimitExceededResponse(limitExceededError *domain.LimitExceededError) LimitExceededResponse {
+	return LimitExceededResponse{
+		ErrorDescription: limitExceededError.Error(),
+		Limit:            limitExceededError.Limit,
+		TransactionType:  limitExceededError.TransactionType,
+		Currency:         limitExceededError.Currency,
+		LimitAmount:      limitExceededError.LimitAmount,
+		RemainingAmount:  limitExceededError.RemainingAmount,
+		LimitCount:       limitExceededError.LimitCount,
+		RemainingCount:   limitExceededError.RemainingCount,
+	}
+}
+
+func ToLimitProfileResponse(limitProfile *domain.LimitProfile) LimitProfileResponse {
+	return LimitProfileResponse{
+		ID:              limitProfile.ID,
+		UserID:          limitProfile.UserID,
+		Role:            limitProfile.Role,
+		TransactionType: limitProfile.TransactionType,
+		Currency:        limitProfile.Currency,
+		MaxSingleAmount: limitProfile.MaxSingleAmount,
+		DailyAmount:     limitProfile.DailyAmount,
+		MonthlyAmount:   limitProfile.MonthlyAmount,
+		DailyCount:      limitProfile.DailyCount,
+		UpdatedAt:       limitProfile.UpdatedAt,
+	}
+}
+
+func ToLimitProfileResponseList(limitProfiles []domain.LimitProfile) []LimitProfileResponse {
+	var limitProfileResponseList = []LimitProfileResponse{}
+	for _, limitProfile := range limitProfiles {
+		limitProfileResponseList = append(limitProfileResponseList, ToLimitProfileResponse(&limitProfile))
+	}
+
+	return limitProfileResponseList
+}
+
+func ToOverdraftFacilityResponse(usage *model.OverdraftUsage) OverdraftFacilityResponse {
+	overdraftFacilityResponse := OverdraftFacilityResponse{
+		UserID:           usage.Facility.UserID,
+		Currency:         usage.Facility.Currency,
+		CreditLimit:      usage.Facility.CreditLimit,
+		DailyRateBps:     usage.Facility.DailyRateBps,
+		Balance:          usage.Balance,
+		OverdraftUsed:    usage.Used,
+		AvailableBalance: usage.Available,
+		UpdatedAt:        usage.Facility.UpdatedAt,
+	}
+	if usage.Facility.LastChargedOn != nil {
+		lastChargedOn := usage.Facility.LastChargedOn.Format(time.DateOnly)
+		overdraftFacilityResponse.LastChargedOn = &lastChargedOn
+	}
+	return overdraftFacilityResponse
+}
+
+func ToOverdraftFacilityResponseList(usages []model.OverdraftUsage) []OverdraftFacilityResponse {
+	var overdraftFacilityResponseList = []OverdraftFacilityResponse{}
+	for _, usage := range usages {
+		overdraftFacilityResponseList = append(overdraftFacilityResponseList, ToOverdraftFacilityResponse(&usage))
+	}
+
+	return overdraftFacilityResponseList
+}
+
+func ToFeeScheduleResponse(feeSchedule *domain.FeeSchedule) FeeScheduleResponse {
+	var tiers []FeeTierResponse
+	for _, tier := range feeSchedule.Tiers {
+		tiers = append(tiers, FeeTierResponse{
+			UpTo:          tier.UpTo,
+			FlatAmount:    tier.FlatAmount,
+			PercentageBps: tier.PercentageBps,
+		})
+	}
+
+	return FeeScheduleResponse{
+		ID:              feeSchedule.ID,
+		TransactionType: feeSchedule.TransactionType,
+		Currency:        feeSchedule.Currency,
+		Role:            feeSchedule.Role,
+		Type:            feeSchedule.Type,
+		FlatAmount:      feeSchedule.FlatAmount,
+		PercentageBps:   feeSchedule.PercentageBps,
+		Tiers:           tiers,
+		MinFee:          feeSchedule.MinFee,
+		MaxFee:          feeSchedule.MaxFee,
+		UpdatedAt:       feeSchedule.UpdatedAt,
+	}
+}
+
+func ToFeeScheduleResponseList(feeSchedules []domain.FeeSchedule) []FeeScheduleResponse {
+	var feeScheduleResponseList = []FeeScheduleResponse{}
+	for _, feeSchedule := range feeSchedules {
+		feeScheduleResponseList = append(feeScheduleResponseList, ToFeeScheduleResponse(&feeSchedule))
+	}
+
+	return feeScheduleResponseList
+}
+
+func ToTransferBatchResponse(transferBatch *domain.TransferBatch) TransferBatchResponse {
+	return TransferBatchResponse{
+		ID:         transferBatch.ID,
+		FromUserID: transferBatch.FromUser,
+		Currency:   transferBatch.Currency,
+		Status:     transferBatch.Status,
+		Progress: TransferBatchProgressResponse{
+			Total:            transferBatch.Progress.Total,
+			Pending:          transferBatch.Progress.Pending,
+			Completed:        transferBatch.Progress.Completed,
+			Failed:           transferBatch.Progress.Failed,
+			Cancelled:        transferBatch.Progress.Cancelled,
+			AwaitingApproval: transferBatch.Progress.AwaitingApproval,
+		},
+		CreatedAt: transferBatch.CreatedAt,
+		UpdatedAt: transferBatch.UpdatedAt,
+	}
+}
+
+func ToTransferBatchItemResponseList(items []domain.TransferBatchItem) []TransferBatchItemResponse {
+	var transferBatchItemResponseList = []TransferBatchItemResponse{}
+	for _, item := range items {
+		transferBatchItemResponseList = append(transferBatchItemResponseList, TransferBatchItemResponse{
+			ID:            item.ID,
+			Row:           item.Row,
+			ToUserID:      item.ToUser,
+			Amount:        item.Amount,
+			Reference:     item.Reference,
+			Status:        item.Status,
+			TransactionID: item.TransactionID,
+			FailureReason: item.FailureReason,
+		})
+	}
+
+	return transferBatchItemResponseList
+}
+
+func ToTransferBatchValidationResponse(validationError *domain.TransferBatchValidationError) TransferBatchValidationResponse {
+	transferBatchValidationResponse := TransferBatchValidationResponse{
+		ErrorDescription: "invalid batch",
+		Rows:             []TransferBatchRowErrorResponse{},
+	}
+	for _, row := range validationError.Rows {
+		transferBatchValidationResponse.Rows = append(transferBatchValidationResponse.Rows, TransferBatchRowErrorResponse{
+			Row:   row.Row,
+			Error: row.Message,
+		})
+	}
+
+	return transferBatchValidationResponse
+}
+
+func ToTransactionPageResponse(page *domain.TransactionPage) TransactionPageResponse {
+	transactionPageResponse := TransactionPageResponse{
+		Data: page.Transactions,
+	}
+	if transactionPageResponse.Data == nil {
+		transactionPageResponse.Data = []domain.Transaction{}
+	}
+	if page.NextCursor != nil {
+		nextCursor := page.NextCursor.Encode()
+		transactionPageResponse.NextCursor = &nextCursor
+	}
+
+	return transactionPageResponse
+}
+
+func ToSplitPaymentResponse(splitPayment *domain.SplitPayment) SplitPaymentResponse {
+	splitPaymentResponse := SplitPaymentResponse{
+		Payment: splitPayment.Payment,
+		Legs:    splitPayment.Legs,
+	}
+	if splitPaymentResponse.Legs == nil {
+		splitPaymentResponse.Legs = []domain.Transaction{}
+	}
+
+	return splitPaymentResponse
+}
+
+func ToTransactionEventResponseList(events []domain.TransactionStatusChange) []TransactionEventResponse {
+	var transactionEventResponseList = []TransactionEventResponse{}
+	for _, event := range events {
+		transactionEventResponseList = append(transactionEventResponseList, TransactionEventResponse{
+			From:      event.From,
+			To:        event.To,
+			Reason:    event.Reason,
+			ChangedAt: event.ChangedAt,
+		})
+	}
+
+	return transactionEventResponseList
+}
+
+func ToTransferApprovalResponse(approval *domain.TransferApproval) TransferApprovalResponse {
+	return TransferApprovalResponse{
+		TransactionID: approval.TransactionID,
+		MakerID:       approval.MakerID,
+		CheckerID:     approval.CheckerID,
+		Status:        approval.Status,
+		Note:          approval.Note,
+		ExpiresAt:     approval.ExpiresAt,
+		DecidedAt:     approval.DecidedAt,
+		CreatedAt:     approval.CreatedAt,
+	}
+}
+
+func ToTransferApprovalResponseList(approvals []domain.TransferApproval) []TransferApprovalResponse {
+	var transferApprovalResponseList = []TransferApprovalResponse{}
+	for _, approval := range approvals {
+		transferApprovalResponseList = append(transferApprovalResponseList, ToTransferApprovalResponse(&approval))
+	}
+
+	return transferApprovalResponseList
+}
+
+func ToRiskReviewResponse(review *domain.RiskReview) RiskReviewResponse {
+	return RiskReviewResponse{
+		ID:            review.ID,
+		TransactionID: review.TransactionID,
+		UserID:        review.UserID,
+		Reasons:       review.Reasons,
+		Status:        review.Status,
+		ResolvedBy:    review.ResolvedBy,
+		Note:          review.Note,
+		CreatedAt:     review.CreatedAt,
+		ResolvedAt:    review.ResolvedAt,
+	}
+}
+
+func ToRiskReviewResponseList(reviews []domain.RiskReview) []RiskReviewResponse {
+	var riskReviewResponseList = []RiskReviewResponse{}
+	for _, review := range reviews {
+		riskReviewResponseList = append(riskReviewResponseList, ToRiskReviewResponse(&review))
+	}
+
+	return riskReviewResponseList
+}
+
+func ToDisputeResponse(dispute *domain.Dispute) DisputeResponse {
+	return DisputeResponse{
+		ID:                  dispute.ID,
+		TransactionID:       dispute.TransactionID,
+		ClaimantID:          dispute.ClaimantID,
+		Reason:              dispute.Reason,
+		Evidence:            dispute.Evidence,
+		Status:              dispute.Status,
+		ProvisionalCreditID: dispute.ProvisionalCreditID,
+		ReversalID:          dispute.ReversalID,
+		CreatedAt:           dispute.CreatedAt,
+		UpdatedAt:           dispute.UpdatedAt,
+	}
+}
+
+func ToDisputeResponseList(disputes []domain.Dispute) []DisputeResponse {
+	var disputeResponseList = []DisputeResponse{}
+	for _, dispute := range disputes {
+		disputeResponseList = append(disputeResponseList, ToDisputeResponse(&dispute))
+	}
+
+	return disputeResponseList
+}
+
+func ToDisputeEventResponseList(events []domain.DisputeEvent) []DisputeEventResponse {
+	var disputeEventResponseList = []DisputeEventResponse{}
+	for _, event := range events {
+		disputeEventResponseList = append(disputeEventResponseList, DisputeEventResponse{
+			Action:        event.Action,
+			Status:        event.Status,
+			ActorID:       event.ActorID,
+			Note:          event.Note,
+			TransactionID: event.TransactionID,
+			CreatedAt:     event.CreatedAt,
+		})
+	}
+
+	return disputeEventResponseList
+}
+
+func ToSavingsGoalResponse(goal *domain.SavingsGoal) SavingsGoalResponse {
+	return SavingsGoalResponse{
+		ID:           goal.ID,
+		UserID:       goal.UserID,
+		Name:         goal.Name,
+		Currency:     goal.Currency,
+		TargetAmount: goal.TargetAmount,
+		TargetDate:   goal.TargetDate,
+		SavedAmount:  goal.SavedAmount,
+		Remaining:    goal.Remaining(),
+		ProgressBps:  goal.ProgressBps(),
+		Reached:      goal.IsReached(),
+		Status:       goal.Status,
+		CreatedAt:    goal.CreatedAt,
+		UpdatedAt:    goal.UpdatedAt,
+		ClosedAt:     goal.ClosedAt,
+	}
+}
+
+func ToSavingsGoalResponseList(goals []domain.SavingsGoal) []SavingsGoalResponse {
+	var savingsGoalResponseList = []SavingsGoalResponse{}
+	for _, goal := range goals {
+		savingsGoalResponseList = append(savingsGoalResponseList, ToSavingsGoalResponse(&goal))
+	}
+
+	return savingsGoalResponseList
+}
+
+func ToSweepRuleResponse(rule *domain.SweepRule) SweepRuleResponse {
+	sweepRuleResponse := SweepRuleResponse{
+		ID:               rule.ID,
+		GoalID:           rule.GoalID,
+		Type:             rule.Type,
+		Amount:           rule.Amount,
+		Currency:         rule.Currency,
+		PercentageBps:    rule.PercentageBps,
+		NextRunAt:        rule.NextRunAt,
+		OccurrencesCount: rule.OccurrencesCount,
+		Active:           rule.Active,
+		CreatedAt:        rule.CreatedAt,
+	}
+	if rule.Recurrence != nil {
+		sweepRuleResponse.Recurrence = &RecurrenceResponse{
+			Frequency:      rule.Recurrence.Frequency,
+			Day:            rule.Recurrence.Day,
+			EndDate:        rule.Recurrence.EndDate,
+			MaxOccurrences: rule.Recurrence.MaxOccurrences,
+		}
+	}
+
+	return sweepRuleResponse
+}
+
+func ToSweepRuleResponseList(rules []domain.SweepRule) []SweepRuleResponse {
+	var sweepRuleResponseList = []SweepRuleResponse{}
+	for _, rule := range rules {
+		sweepRuleResponseList = append(sweepRuleResponseList, ToSweepRuleResponse(&rule))
+	}
+
+	return sweepRuleResponseList
+}
+
+func ToSavingsSweepResponse(sweep *domain.SavingsSweep) SavingsSweepResponse {
+	return SavingsSweepResponse{
+		ID:            sweep.ID,
+		GoalID:        sweep.GoalID,
+		RuleID:        sweep.RuleID,
+		TransactionID: sweep.TransactionID,
+		Amount:        sweep.Amount,
+		CreatedAt:     sweep.CreatedAt,
+	}
+}
+
+func ToSavingsSweepResponseList(sweeps []domain.SavingsSweep) []SavingsSweepResponse {
+	var savingsSweepResponseList = []SavingsSweepResponse{}
+	for _, sweep := range sweeps {
+		savingsSweepResponseList = append(savingsSweepResponseList, ToSavingsSweepResponse(&sweep))
+	}
+
+	return savingsSweepResponseList
+}
+
+/* ToSavingsProgressResponse counts days_left from now, zero once the target date passed */
+func ToSavingsProgressResponse(goal *domain.SavingsGoal, now time.Time) SavingsProgressResponse {
+	return SavingsProgressResponse{
+		GoalID:       goal.ID,
+		Currency:     goal.Currency,
+		TargetAmount: goal.TargetAmount,
+		SavedAmount:  goal.SavedAmount,
+		Remaining:    goal.Remaining(),
+		ProgressBps:  goal.ProgressBps(),
+		Reached:      goal.IsReached(),
+		TargetDate:   goal.TargetDate,
+		DaysLeft:     max(int(goal.TargetDate.Sub(now).Hours()/24), 0),
+	}
+}
+
+func ToSavingsProjectionResponse(projection *domain.SavingsProjection) SavingsProjectionResponse {
+	return SavingsProjectionResponse{
+		GoalID:              projection.GoalID,
+		Remaining:           projection.Remaining,
+		DailyRate:           projection.DailyRate,
+		RequiredDaily:       projection.RequiredDaily,
+		ProjectedCompletion: projection.ProjectedCompletion,
+		OnTrack:             projection.OnTrack,
+	}
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type RiskController struct {
+	riskService service.IRiskService
+}
+
+func NewRiskController(riskService service.IRiskService) *RiskController {
+	return &RiskController{
+		riskService: riskService,
+	}
+}
+
+func (riskController *RiskController) RegisterRoutes(e *echo.Echo) {
+	// Admin risk review queue routes
+	e.GET("/api/v1/admin/risk-reviews", riskController.GetRiskReviews)
+	e.GET("/api/v1/admin/risk-reviews/:id", riskController.GetRiskReviewByID)
+	e.POST("/api/v1/admin/risk-reviews/:id/resolve", riskController.ResolveRiskReview)
+}
+
+func (riskController *RiskController) GetRiskReviews(c echo.Context) error {
+	reviews, err := riskController.riskService.GetReviews(domain.RiskReviewStatus(c.QueryParam("status")))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToRiskReviewResponseList(reviews))
+}
+
+func (riskController *RiskController) GetRiskReviewByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid risk review ID",
+		})
+	}
+
+	review, err := riskController.riskService.GetReview(int64(id))
+	if err != nil {
+		return riskReviewErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToRiskReviewResponse(review))
+}
+
+func (riskController *RiskController) ResolveRiskReview(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid risk review ID",
+		})
+	}
+
+	var request request.ResolveRiskReviewRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	review, err := riskController.riskService.ResolveReview(int64(id), request.Status, request.ResolvedBy, request.Note)
+	if err != nil {
+		return riskReviewErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToRiskReviewResponse(review))
+}
+
+func riskReviewErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrRiskReviewNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrRiskReviewResolved):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
@@ -0,0 +1,285 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type SavingsGoalController struct {
+	savingsGoalService    service.ISavingsGoalService
+	idempotencyMiddleware *IdempotencyMiddleware
+}
+
+func NewSavingsGoalController(savingsGoalService service.ISavingsGoalService, idempotencyMiddleware *IdempotencyMiddleware) *SavingsGoalController {
+	return &SavingsGoalController{
+		savingsGoalService:    savingsGoalService,
+		idempotencyMiddleware: idempotencyMiddleware,
+	}
+}
+
+func (savingsGoalController *SavingsGoalController) RegisterRoutes(e *echo.Echo) {
+	// Savings goal routes, contributions move money and are idempotent
+	e.POST("/api/v1/savings-goals", savingsGoalController.CreateSavingsGoal)
+	e.GET("/api/v1/savings-goals", savingsGoalController.GetSavingsGoals)
+	e.GET("/api/v1/savings-goals/:id", savingsGoalController.GetSavingsGoal)
+	e.DELETE("/api/v1/savings-goals/:id", savingsGoalController.CloseSavingsGoal)
+	e.POST("/api/v1/savings-goals/:id/contributions", savingsGoalController.Contribute, savingsGoalController.idempotencyMiddleware.Handle)
+	e.GET("/api/v1/savings-goals/:id/progress", savingsGoalController.GetProgress)
+	e.GET("/api/v1/savings-goals/:id/projection", savingsGoalController.GetProjection)
+	e.GET("/api/v1/savings-goals/:id/sweeps", savingsGoalController.GetSweeps)
+
+	// Sweep rule routes
+	e.POST("/api/v1/savings-goals/:id/rules", savingsGoalController.AddSweepRule)
+	e.GET("/api/v1/savings-goals/:id/rules", savingsGoalController.GetSweepRules)
+	e.DELETE("/api/v1/savings-goals/:id/rules/:ruleID", savingsGoalController.RemoveSweepRule)
+}
+
+func (savingsGoalController *SavingsGoalController) CreateSavingsGoal(c echo.Context) error {
+	var request request.SavingsGoalRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	goal, err := request.ToDomain()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	goal, err = savingsGoalController.savingsGoalService.CreateGoal(goal)
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToSavingsGoalResponse(goal))
+}
+
+/* GetSavingsGoals lists the goals of ?user_id=, closed ones included */
+func (savingsGoalController *SavingsGoalController) GetSavingsGoals(c echo.Context) error {
+	userID, err := strconv.Atoi(c.QueryParam("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	goals, err := savingsGoalController.savingsGoalService.GetGoals(int64(userID))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToSavingsGoalResponseList(goals))
+}
+
+func (savingsGoalController *SavingsGoalController) GetSavingsGoal(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	goal, err := savingsGoalController.savingsGoalService.GetGoal(int64(goalID))
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToSavingsGoalResponse(goal))
+}
+
+/* CloseSavingsGoal pays what the goal saved back into the wallet */
+func (savingsGoalController *SavingsGoalController) CloseSavingsGoal(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	goal, err := savingsGoalController.savingsGoalService.CloseGoal(int64(goalID))
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToSavingsGoalResponse(goal))
+}
+
+func (savingsGoalController *SavingsGoalController) Contribute(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	var request request.SavingsContributionRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	amount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	sweep, err := savingsGoalController.savingsGoalService.Contribute(int64(goalID), amount)
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToSavingsSweepResponse(sweep))
+}
+
+func (savingsGoalController *SavingsGoalController) GetProgress(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	goal, err := savingsGoalController.savingsGoalService.GetGoal(int64(goalID))
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToSavingsProgressResponse(goal, time.Now()))
+}
+
+func (savingsGoalController *SavingsGoalController) GetProjection(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	projection, err := savingsGoalController.savingsGoalService.GetProjection(int64(goalID))
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToSavingsProjectionResponse(projection))
+}
+
+func (savingsGoalController *SavingsGoalController) GetSweeps(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	sweeps, err := savingsGoalController.savingsGoalService.GetSweeps(int64(goalID))
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToSavingsSweepResponseList(sweeps))
+}
+
+func (savingsGoalController *SavingsGoalController) AddSweepRule(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	var request request.SweepRuleRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	rule, err := request.ToDomain()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	var startAt time.Time
+	if request.StartAt != nil {
+		startAt = *request.StartAt
+	}
+
+	rule, err = savingsGoalController.savingsGoalService.AddRule(int64(goalID), rule, startAt)
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToSweepRuleResponse(rule))
+}
+
+func (savingsGoalController *SavingsGoalController) GetSweepRules(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	rules, err := savingsGoalController.savingsGoalService.GetRules(int64(goalID))
+	if err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToSweepRuleResponseList(rules))
+}
+
+func (savingsGoalController *SavingsGoalController) RemoveSweepRule(c echo.Context) error {
+	goalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid savings goal ID",
+		})
+	}
+
+	ruleID, err := strconv.Atoi(c.Param("ruleID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid sweep rule ID",
+		})
+	}
+
+	if err := savingsGoalController.savingsGoalService.RemoveRule(int64(goalID), int64(ruleID)); err != nil {
+		return savingsGoalErrorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func savingsGoalErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrSavingsGoalNotFound), errors.Is(err, persistence.ErrSweepRuleNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrSavingsGoalClosed), errors.Is(err, service.ErrSavingsGoalReached):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrInsufficientBalance):
+		status = http.StatusUnprocessableEntity
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type ScheduledTransferController struct {
+	scheduledTransferService service.IScheduledTransferService
+}
+
+func NewScheduledTransferController(scheduledTransferService service.IScheduledTransferService) *ScheduledTransferController {
+	return &ScheduledTransferController{
+		scheduledTransferService: scheduledTransferService,
+	}
+}
+
+func (scheduledTransferController *ScheduledTransferController) RegisterRoutes(e *echo.Echo) {
+	// Scheduled transfer routes
+	e.GET("/api/v1/transactions/scheduled", scheduledTransferController.GetPendingScheduledTransfers)
+	e.GET("/api/v1/transactions/scheduled/:id", scheduledTransferController.GetScheduledTransferByID)
+	e.POST("/api/v1/transactions/scheduled/:id/cancel", scheduledTransferController.CancelScheduledTransfer)
+}
+
+func (scheduledTransferController *ScheduledTransferController) GetPendingScheduledTransfers(c echo.Context) error {
+	userID, err := strconv.Atoi(c.QueryParam("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	scheduledTransfers, err := scheduledTransferController.scheduledTransferService.GetPendingByUserID(int64(userID))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToScheduledTransferResponseList(scheduledTransfers))
+}
+
+func (scheduledTransferController *ScheduledTransferController) GetScheduledTransferByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid scheduled transfer ID",
+		})
+	}
+
+	scheduledTransfer, err := scheduledTransferController.scheduledTransferService.GetByID(int64(id))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToScheduledTransferResponse(scheduledTransfer))
+}
+
+func (scheduledTransferController *ScheduledTransferController) CancelScheduledTransfer(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid scheduled transfer ID",
+		})
+	}
+
+	scheduledTransfer, err := scheduledTransferController.scheduledTransferService.Cancel(int64(id))
+	if err != nil {
+		if errors.Is(err, persistence.ErrScheduledTransferNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				ErrorDescription: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToScheduledTransferResponse(scheduledTransfer))
+}
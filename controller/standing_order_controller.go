@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type StandingOrderController struct {
+	standingOrderService  service.IStandingOrderService
+	idempotencyMiddleware *IdempotencyMiddleware
+}
+
+func NewStandingOrderController(standingOrderService service.IStandingOrderService, idempotencyMiddleware *IdempotencyMiddleware) *StandingOrderController {
+	return &StandingOrderController{
+		standingOrderService:  standingOrderService,
+		idempotencyMiddleware: idempotencyMiddleware,
+	}
+}
+
+func (standingOrderController *StandingOrderController) RegisterRoutes(e *echo.Echo) {
+	// Standing order routes
+	e.POST("/api/v1/standing-orders", standingOrderController.CreateStandingOrder, standingOrderController.idempotencyMiddleware.Handle)
+	e.GET("/api/v1/standing-orders", standingOrderController.GetStandingOrders)
+	e.GET("/api/v1/standing-orders/:id", standingOrderController.GetStandingOrderByID)
+	e.PUT("/api/v1/standing-orders/:id", standingOrderController.UpdateStandingOrder)
+	e.DELETE("/api/v1/standing-orders/:id", standingOrderController.CancelStandingOrder)
+	e.GET("/api/v1/standing-orders/:id/attempts", standingOrderController.GetStandingOrderAttempts)
+}
+
+func (standingOrderController *StandingOrderController) CreateStandingOrder(c echo.Context) error {
+	var standingOrderRequest request.StandingOrderRequest
+	if err := c.Bind(&standingOrderRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request body",
+		})
+	}
+
+	standingOrderCreate, err := standingOrderRequest.ToModel()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	standingOrder, err := standingOrderController.standingOrderService.Create(standingOrderCreate)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response.ToStandingOrderResponse(standingOrder))
+}
+
+func (standingOrderController *StandingOrderController) GetStandingOrders(c echo.Context) error {
+	userID, err := strconv.Atoi(c.QueryParam("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	standingOrders, err := standingOrderController.standingOrderService.GetByUserID(int64(userID))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.ToStandingOrderResponseList(standingOrders))
+}
+
+func (standingOrderController *StandingOrderController) GetStandingOrderByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid standing order ID",
+		})
+	}
+
+	standingOrder, err := standingOrderController.standingOrderService.GetByID(int64(id))
+	if err != nil {
+		return standingOrderErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToStandingOrderResponse(standingOrder))
+}
+
+func (standingOrderController *StandingOrderController) UpdateStandingOrder(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid standing order ID",
+		})
+	}
+
+	var updateStandingOrderRequest request.UpdateStandingOrderRequest
+	if err := c.Bind(&updateStandingOrderRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request body",
+		})
+	}
+
+	standingOrderUpdate, err := updateStandingOrderRequest.ToModel()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	standingOrder, err := standingOrderController.standingOrderService.Update(int64(id), standingOrderUpdate)
+	if err != nil {
+		return standingOrderErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToStandingOrderResponse(standingOrder))
+}
+
+func (standingOrderController *StandingOrderController) CancelStandingOrder(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid standing order ID",
+		})
+	}
+
+	standingOrder, err := standingOrderController.standingOrderService.Cancel(int64(id))
+	if err != nil {
+		return standingOrderErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToStandingOrderResponse(standingOrder))
+}
+
+func (standingOrderController *StandingOrderController) GetStandingOrderAttempts(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid standing order ID",
+		})
+	}
+
+	attempts, err := standingOrderController.standingOrderService.GetAttempts(int64(id))
+	if err != nil {
+		return standingOrderErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToStandingOrderAttemptResponseList(attempts))
+}
+
+func standingOrderErrorResponse(c echo.Context, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrStandingOrderNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrStandingOrderNotActive), errors.Is(err, service.ErrStandingOrderConcurrentEdit):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/denizdoganinsider/kpi_project/common/statement"
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type StatementController struct {
+	statementService service.IStatementService
+}
+
+func NewStatementController(statementService service.IStatementService) *StatementController {
+	return &StatementController{
+		statementService: statementService,
+	}
+}
+
+func (statementController *StatementController) RegisterRoutes(e *echo.Echo) {
+	// Account statement routes
+	e.GET("/api/v1/users/:id/statements", statementController.ExportStatement)
+}
+
+/*
+ExportStatement streams the statement as it is read. Once the first bytes are
+out the status can't change anymore, so a later failure only cuts the file
+short and is logged.
+*/
+func (statementController *StatementController) ExportStatement(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid user ID",
+		})
+	}
+
+	var statementRequest request.StatementRequest
+	if err := c.Bind(&statementRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid query parameters",
+		})
+	}
+
+	format, err := statementRequest.StatementFormat()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	accountStatement, err := statementRequest.ToDomain(int64(id))
+	if err == nil {
+		err = accountStatement.Validate()
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	var writer service.IStatementWriter
+	contentType := "text/csv"
+	if format == request.PDFStatement {
+		contentType = "application/pdf"
+		writer = statement.NewPDFWriter(c.Response())
+	} else {
+		writer = statement.NewCSVWriter(c.Response())
+	}
+
+	fileName := fmt.Sprintf("statement-%d-%s-%s.%s", id, accountStatement.Currency, accountStatement.From.Format("20060102"), format)
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+
+	if err := statementController.statementService.Export(accountStatement, writer); err != nil {
+		if c.Response().Committed {
+			log.Printf("Statement of user %d was cut short: %v", id, err)
+			return nil
+		}
+
+		c.Response().Header().Del(echo.HeaderContentDisposition)
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	return nil
+}
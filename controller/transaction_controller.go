@@ -1,32 +1,45 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/denizdoganinsider/kpi_project/controller/request"
 	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
 	"github.com/denizdoganinsider/kpi_project/service"
 	"github.com/labstack/echo/v4"
 )
 
 type TransactionController struct {
-	transactionService service.ITransactionService
+	transactionService       service.ITransactionService
+	scheduledTransferService service.IScheduledTransferService
+	idempotencyMiddleware    *IdempotencyMiddleware
 }
 
-func NewTransactionController(transactionService service.ITransactionService) *TransactionController {
+func NewTransactionController(transactionService service.ITransactionService, scheduledTransferService service.IScheduledTransferService, idempotencyMiddleware *IdempotencyMiddleware) *TransactionController {
 	return &TransactionController{
-		transactionService: transactionService,
+		transactionService:       transactionService,
+		scheduledTransferService: scheduledTransferService,
+		idempotencyMiddleware:    idempotencyMiddleware,
 	}
 }
 
 func (transactionController *TransactionController) RegisterRoutes(e *echo.Echo) {
 	// Transaction routes
 	e.GET("/api/v1/transactions/:id", transactionController.GetTransactionByID)
+	e.GET("/api/v1/transactions/split/:id", transactionController.GetSplitPayment)
+	e.GET("/api/v1/transactions/:id/events", transactionController.GetTransactionEvents)
 	e.GET("/api/v1/transactions/history/:userID", transactionController.GetTransactionHistory)
-	e.POST("/api/v1/transactions/credit", transactionController.Credit)
-	e.POST("/api/v1/transactions/debit", transactionController.Debit)
-	e.POST("/api/v1/transactions/transfer", transactionController.Transfer)
+	e.POST("/api/v1/transactions/credit", transactionController.Credit, transactionController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/transactions/debit", transactionController.Debit, transactionController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/transactions/transfer", transactionController.Transfer, transactionController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/transactions/split", transactionController.Split, transactionController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/transactions/:id/reverse", transactionController.Reverse, transactionController.idempotencyMiddleware.Handle)
+	e.POST("/api/v1/transactions/:id/refund", transactionController.Refund, transactionController.idempotencyMiddleware.Handle)
 }
 
 func (transactionController *TransactionController) GetTransactionByID(c echo.Context) error {
@@ -48,25 +61,52 @@ func (transactionController *TransactionController) GetTransactionByID(c echo.Co
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (transactionController *TransactionController) GetTransactionEvents(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	events, err := transactionController.transactionService.GetTransactionEvents(int64(transactionID))
+	if err != nil {
+		return reversalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToTransactionEventResponseList(events))
+}
+
 func (transactionController *TransactionController) GetTransactionHistory(c echo.Context) error {
 	userID := c.Param("userID")
 	userId, _ := strconv.Atoi(userID)
 
-	transactions, err := transactionController.transactionService.GetTransactionHistory(int64(userId))
+	var request request.TransactionHistoryRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid query parameters",
+		})
+	}
+
+	filter, err := request.ToDomain()
 	if err != nil {
-		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	page, err := transactionController.transactionService.GetTransactionHistory(int64(userId), filter)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			ErrorDescription: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, transactions)
+	return c.JSON(http.StatusOK, response.ToTransactionPageResponse(page))
 }
 
 func (transactionController *TransactionController) Credit(c echo.Context) error {
-	var request struct {
-		UserID int64   `json:"user_id"`
-		Amount float64 `json:"amount"`
-	}
+	var request request.UpdateBalanceRequest
 
 	if err := c.Bind(&request); err != nil {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
@@ -74,7 +114,14 @@ func (transactionController *TransactionController) Credit(c echo.Context) error
 		})
 	}
 
-	transaction, err := transactionController.transactionService.Credit(request.UserID, request.Amount)
+	amount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	transaction, err := transactionController.transactionService.Credit(request.UserID, amount)
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
 			ErrorDescription: err.Error(),
@@ -85,10 +132,7 @@ func (transactionController *TransactionController) Credit(c echo.Context) error
 }
 
 func (transactionController *TransactionController) Debit(c echo.Context) error {
-	var request struct {
-		UserID int64   `json:"user_id"`
-		Amount float64 `json:"amount"`
-	}
+	var request request.UpdateBalanceRequest
 
 	if err := c.Bind(&request); err != nil {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
@@ -96,13 +140,18 @@ func (transactionController *TransactionController) Debit(c echo.Context) error
 		})
 	}
 
-	transaction, err := transactionController.transactionService.Debit(request.UserID, request.Amount)
+	amount, err := request.Money()
 	if err != nil {
-		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			ErrorDescription: err.Error(),
 		})
 	}
 
+	transaction, err := transactionController.transactionService.Debit(request.UserID, amount)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
 	return c.JSON(http.StatusCreated, transaction)
 }
 
@@ -117,12 +166,152 @@ func (transactionController *TransactionController) Transfer(c echo.Context) err
 		})
 	}
 
-	transaction, err := transactionController.transactionService.Transfer(request.FromUserID, request.ToUserID, request.Amount)
+	amount, err := request.Money()
 	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	/* converting between wallets is never implicit */
+	if request.IsCrossCurrency() {
 		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			ErrorDescription: service.ErrConversionRequired.Error(),
+		})
+	}
+
+	/* future-dated transfers are only stored, the scheduler runs them later */
+	if request.IsFutureDated(time.Now()) {
+		scheduledTransfer, err := transactionController.scheduledTransferService.Schedule(request.FromUserID, request.ToUserID, amount, *request.ExecuteAt)
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+				ErrorDescription: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusAccepted, response.ToScheduledTransferResponse(scheduledTransfer))
+	}
+
+	transaction, err := transactionController.transactionService.Transfer(request.FromUserID, request.ToUserID, amount)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
+	/* large transfers only run once an approver approves them */
+	if transaction.AwaitsApproval() {
+		return c.JSON(http.StatusAccepted, transaction)
+	}
+
+	return c.JSON(http.StatusCreated, transaction)
+}
+
+func (transactionController *TransactionController) Split(c echo.Context) error {
+	var request request.SplitPaymentRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	instruction, err := request.ToDomain()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: err.Error(),
+		})
+	}
+
+	splitPayment, err := transactionController.transactionService.Split(instruction)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, response.ToSplitPaymentResponse(splitPayment))
+}
+
+func (transactionController *TransactionController) GetSplitPayment(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	splitPayment, err := transactionController.transactionService.GetSplitPayment(int64(transactionID))
+	if err != nil {
+		return reversalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToSplitPaymentResponse(splitPayment))
+}
+
+func (transactionController *TransactionController) Reverse(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	transaction, err := transactionController.transactionService.Reverse(int64(transactionID))
+	if err != nil {
+		return reversalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, transaction)
+}
+
+func (transactionController *TransactionController) Refund(c echo.Context) error {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transaction ID",
+		})
+	}
+
+	var request request.RefundRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid request data",
+		})
+	}
+
+	amount, err := request.Money()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			ErrorDescription: err.Error(),
 		})
 	}
 
+	transaction, err := transactionController.transactionService.Refund(int64(transactionID), amount)
+	if err != nil {
+		return reversalErrorResponse(c, err)
+	}
+
 	return c.JSON(http.StatusCreated, transaction)
 }
+
+func reversalErrorResponse(c echo.Context, err error) error {
+	status := http.StatusUnprocessableEntity
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound), errors.Is(err, service.ErrSplitPaymentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrTransactionNotReversible), errors.Is(err, persistence.ErrTransactionStatusConflict):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
+
+/* transactionErrorResponse answers a limit violation with the structured limit error */
+func transactionErrorResponse(c echo.Context, err error) error {
+	var limitExceededError *domain.LimitExceededError
+	if errors.As(err, &limitExceededError) {
+		return c.JSON(http.StatusUnprocessableEntity, response.ToLimitExceededResponse(limitExceededError))
+	}
+
+	return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
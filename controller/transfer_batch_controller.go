@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/denizdoganinsider/kpi_project/controller/request"
+	"github.com/denizdoganinsider/kpi_project/controller/response"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/labstack/echo/v4"
+)
+
+type TransferBatchController struct {
+	transferBatchService  service.ITransferBatchService
+	idempotencyMiddleware *IdempotencyMiddleware
+}
+
+func NewTransferBatchController(transferBatchService service.ITransferBatchService, idempotencyMiddleware *IdempotencyMiddleware) *TransferBatchController {
+	return &TransferBatchController{
+		transferBatchService:  transferBatchService,
+		idempotencyMiddleware: idempotencyMiddleware,
+	}
+}
+
+func (transferBatchController *TransferBatchController) RegisterRoutes(e *echo.Echo) {
+	// Bulk transfer routes
+	e.POST("/api/v1/transactions/batches", transferBatchController.CreateTransferBatch, transferBatchController.idempotencyMiddleware.Handle)
+	e.GET("/api/v1/transactions/batches/:id", transferBatchController.GetTransferBatchByID)
+	e.GET("/api/v1/transactions/batches/:id/items", transferBatchController.GetTransferBatchItems)
+	e.POST("/api/v1/transactions/batches/:id/cancel", transferBatchController.CancelTransferBatch)
+}
+
+/*
+CreateTransferBatch takes either a JSON body or a multipart upload with the
+CSV in "file" and from_user_id and currency as form fields.
+*/
+func (transferBatchController *TransferBatchController) CreateTransferBatch(c echo.Context) error {
+	var fromUserID int64
+	var currency domain.Currency
+	var items []domain.TransferBatchItem
+	var err error
+
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fromUserID, currency, items, err = transferBatchFromCSV(c)
+	} else {
+		fromUserID, currency, items, err = transferBatchFromJSON(c)
+	}
+	if err != nil {
+		return transferBatchErrorResponse(c, err)
+	}
+
+	transferBatch, err := transferBatchController.transferBatchService.Create(fromUserID, currency, items)
+	if err != nil {
+		return transferBatchErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, response.ToTransferBatchResponse(transferBatch))
+}
+
+func transferBatchFromJSON(c echo.Context) (int64, domain.Currency, []domain.TransferBatchItem, error) {
+	var request request.TransferBatchRequest
+	if err := c.Bind(&request); err != nil {
+		return 0, "", nil, errors.New("invalid request data")
+	}
+
+	items, err := request.ToDomain()
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return request.FromUserID, batchCurrency(request.Currency), items, nil
+}
+
+func transferBatchFromCSV(c echo.Context) (int64, domain.Currency, []domain.TransferBatchItem, error) {
+	fromUserID, err := strconv.Atoi(c.FormValue("from_user_id"))
+	if err != nil {
+		return 0, "", nil, errors.New("invalid user ID")
+	}
+	currency := batchCurrency(domain.Currency(strings.ToUpper(c.FormValue("currency"))))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return 0, "", nil, errors.New("csv file is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return 0, "", nil, err
+	}
+	defer file.Close()
+
+	items, err := request.ParseTransferBatchCSV(file, currency)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return int64(fromUserID), currency, items, nil
+}
+
+func batchCurrency(currency domain.Currency) domain.Currency {
+	if currency == "" {
+		return domain.DefaultCurrency
+	}
+	return currency
+}
+
+func (transferBatchController *TransferBatchController) GetTransferBatchByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transfer batch ID",
+		})
+	}
+
+	transferBatch, err := transferBatchController.transferBatchService.GetByID(int64(id))
+	if err != nil {
+		return transferBatchErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToTransferBatchResponse(transferBatch))
+}
+
+func (transferBatchController *TransferBatchController) GetTransferBatchItems(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transfer batch ID",
+		})
+	}
+
+	items, err := transferBatchController.transferBatchService.GetItems(int64(id))
+	if err != nil {
+		return transferBatchErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToTransferBatchItemResponseList(items))
+}
+
+func (transferBatchController *TransferBatchController) CancelTransferBatch(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			ErrorDescription: "Invalid transfer batch ID",
+		})
+	}
+
+	transferBatch, err := transferBatchController.transferBatchService.Cancel(int64(id))
+	if err != nil {
+		return transferBatchErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response.ToTransferBatchResponse(transferBatch))
+}
+
+func transferBatchErrorResponse(c echo.Context, err error) error {
+	var validationError *domain.TransferBatchValidationError
+	if errors.As(err, &validationError) {
+		return c.JSON(http.StatusUnprocessableEntity, response.ToTransferBatchValidationResponse(validationError))
+	}
+
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, persistence.ErrTransferBatchNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrTransferBatchNotCancellable):
+		status = http.StatusConflict
+	}
+
+	return c.JSON(status, response.ErrorResponse{
+		ErrorDescription: err.Error(),
+	})
+}
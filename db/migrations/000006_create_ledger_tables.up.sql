@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(100) NOT NULL UNIQUE,
+    user_id INT NULL,
+    type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    transaction_id INT NULL,
+    description VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    journal_entry_id INT NOT NULL,
+    account_id INT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (journal_entry_id) REFERENCES journal_entries(id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES ledger_accounts(id) ON DELETE CASCADE,
+    INDEX idx_ledger_postings_account_created_at (account_id, created_at)
+);
+
+INSERT INTO ledger_accounts (code, user_id, type) VALUES ('system:external', NULL, 'system');
+
+/* wallets opened before the ledger start from one opening entry against the external account */
+INSERT INTO ledger_accounts (code, user_id, type, created_at)
+SELECT CONCAT('user:', b.user_id), b.user_id, 'user', COALESCE(b.last_updated_at, CURRENT_TIMESTAMP)
+FROM balances b;
+
+INSERT INTO journal_entries (transaction_id, description, created_at)
+SELECT NULL, CONCAT('opening balance of user:', b.user_id), COALESCE(b.last_updated_at, CURRENT_TIMESTAMP)
+FROM balances b
+WHERE b.amount <> 0;
+
+INSERT INTO ledger_postings (journal_entry_id, account_id, amount, created_at)
+SELECT je.id, ua.id, b.amount, je.created_at
+FROM balances b
+JOIN ledger_accounts ua ON ua.code = CONCAT('user:', b.user_id)
+JOIN journal_entries je ON je.transaction_id IS NULL AND je.description = CONCAT('opening balance of ', ua.code);
+
+INSERT INTO ledger_postings (journal_entry_id, account_id, amount, created_at)
+SELECT je.id, ea.id, -b.amount, je.created_at
+FROM balances b
+JOIN journal_entries je ON je.transaction_id IS NULL AND je.description = CONCAT('opening balance of user:', b.user_id)
+JOIN ledger_accounts ea ON ea.code = 'system:external';
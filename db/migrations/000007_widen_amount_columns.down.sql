@@ -0,0 +1,10 @@
+ALTER TABLE ledger_postings MODIFY amount DECIMAL(10, 2) NOT NULL;
+
+ALTER TABLE balances MODIFY amount DECIMAL(10, 2) NOT NULL;
+
+-- the old schema had no way to store a missing counterparty, credits and debits point back at their own user
+UPDATE transactions SET to_user_id = from_user_id WHERE to_user_id IS NULL;
+
+ALTER TABLE transactions
+    MODIFY amount DECIMAL(10, 2) NOT NULL,
+    MODIFY to_user_id INT NOT NULL;
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE transactions
+    MODIFY amount DECIMAL(19, 2) NOT NULL,
+    -- credits and debits have no counterparty
+    MODIFY to_user_id INT NULL;
+
+ALTER TABLE balances MODIFY amount DECIMAL(19, 2) NOT NULL;
+
+ALTER TABLE ledger_postings MODIFY amount DECIMAL(19, 2) NOT NULL;
@@ -0,0 +1,16 @@
+DELETE FROM ledger_accounts WHERE currency <> 'TRY';
+
+UPDATE ledger_accounts SET code = SUBSTRING(code, 1, CHAR_LENGTH(code) - 4);
+
+ALTER TABLE ledger_accounts DROP COLUMN currency;
+
+DELETE FROM transactions WHERE currency <> 'TRY';
+
+ALTER TABLE transactions DROP COLUMN currency;
+
+DELETE FROM balances WHERE currency <> 'TRY';
+
+ALTER TABLE balances
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (user_id),
+    DROP COLUMN currency;
\ No newline at end of file
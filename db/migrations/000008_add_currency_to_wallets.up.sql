@@ -0,0 +1,14 @@
+ALTER TABLE balances
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'TRY' AFTER user_id,
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (user_id, currency);
+
+ALTER TABLE transactions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'TRY' AFTER amount;
+
+ALTER TABLE ledger_accounts ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'TRY' AFTER user_id;
+
+UPDATE ledger_accounts SET code = CONCAT(code, ':', currency);
+
+INSERT INTO ledger_accounts (code, user_id, currency, type) VALUES
+    ('system:external:EUR', NULL, 'EUR', 'system'),
+    ('system:external:USD', NULL, 'USD', 'system');
@@ -0,0 +1,5 @@
+DELETE FROM ledger_accounts WHERE code LIKE 'system:fx:%';
+
+DROP TABLE IF EXISTS fx_quotes;
+
+DROP TABLE IF EXISTS fx_rates;
\ No newline at end of file
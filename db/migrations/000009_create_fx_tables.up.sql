@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate DECIMAL(24, 10) NOT NULL,
+    spread_bps INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency)
+);
+
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id CHAR(36) PRIMARY KEY,
+    user_id INT NOT NULL,
+    from_currency CHAR(3) NOT NULL,
+    to_currency CHAR(3) NOT NULL,
+    sell_amount DECIMAL(19, 2) NOT NULL,
+    buy_amount DECIMAL(19, 2) NOT NULL,
+    mid_rate DECIMAL(24, 10) NOT NULL,
+    rate DECIMAL(24, 10) NOT NULL,
+    spread_bps INT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    executed_at TIMESTAMP NULL,
+    sell_transaction_id INT NULL,
+    buy_transaction_id INT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (sell_transaction_id) REFERENCES transactions(id),
+    FOREIGN KEY (buy_transaction_id) REFERENCES transactions(id)
+);
+
+INSERT INTO ledger_accounts (code, user_id, currency, type) VALUES
+    ('system:fx:TRY', NULL, 'TRY', 'system'),
+    ('system:fx:EUR', NULL, 'EUR', 'system'),
+    ('system:fx:USD', NULL, 'USD', 'system');
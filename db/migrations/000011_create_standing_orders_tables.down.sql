@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS standing_order_attempts;
+
+DROP TABLE IF EXISTS standing_orders;
\ No newline at end of file
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    from_user_id INT NOT NULL,
+    to_user_id INT NOT NULL,
+    amount DECIMAL(19, 2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    frequency VARCHAR(20) NOT NULL,
+    day INT NOT NULL DEFAULT 0,
+    start_at TIMESTAMP NOT NULL,
+    end_date TIMESTAMP NULL,
+    max_occurrences INT NULL,
+    occurrence_at TIMESTAMP NOT NULL,
+    next_run_at TIMESTAMP NOT NULL,
+    occurrences_count INT NOT NULL DEFAULT 0,
+    retry_count INT NOT NULL DEFAULT 0,
+    insufficient_funds_policy VARCHAR(20) NOT NULL,
+    max_retries INT NOT NULL DEFAULT 0,
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_standing_orders_status_next_run_at (status, next_run_at)
+);
+
+CREATE TABLE IF NOT EXISTS standing_order_attempts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    standing_order_id INT NOT NULL,
+    occurrence_at TIMESTAMP NOT NULL,
+    attempted_at TIMESTAMP NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    transaction_id INT NULL,
+    failure_reason VARCHAR(255),
+    FOREIGN KEY (standing_order_id) REFERENCES standing_orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+);
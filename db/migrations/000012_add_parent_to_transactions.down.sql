@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    DROP FOREIGN KEY fk_transactions_parent,
+    DROP INDEX idx_transactions_parent_transaction_id,
+    DROP COLUMN parent_transaction_id;
\ No newline at end of file
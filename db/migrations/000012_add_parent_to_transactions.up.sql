@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    ADD COLUMN parent_transaction_id INT NULL AFTER id,
+    ADD CONSTRAINT fk_transactions_parent FOREIGN KEY (parent_transaction_id) REFERENCES transactions(id),
+    ADD INDEX idx_transactions_parent_transaction_id (parent_transaction_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS holds;
+
+ALTER TABLE balances DROP COLUMN held_amount;
\ No newline at end of file
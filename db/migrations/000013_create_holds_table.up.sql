@@ -0,0 +1,18 @@
+ALTER TABLE balances ADD COLUMN held_amount DECIMAL(19, 2) NOT NULL DEFAULT 0 AFTER amount;
+
+CREATE TABLE IF NOT EXISTS holds (
+    transaction_id INT PRIMARY KEY,
+    user_id INT NOT NULL,
+    to_user_id INT NULL,
+    amount DECIMAL(19, 2) NOT NULL,
+    captured_amount DECIMAL(19, 2) NOT NULL DEFAULT 0,
+    currency CHAR(3) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_holds_status_expires_at (status, expires_at)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP INDEX idx_transactions_usage;
+
+DROP TABLE IF EXISTS limit_profiles;
\ No newline at end of file
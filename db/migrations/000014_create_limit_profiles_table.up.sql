@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS limit_profiles (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NULL,
+    role VARCHAR(50) NULL,
+    transaction_type VARCHAR(20) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    max_single_amount DECIMAL(19, 2) NULL,
+    daily_amount DECIMAL(19, 2) NULL,
+    monthly_amount DECIMAL(19, 2) NULL,
+    daily_count INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_limit_profiles_user (user_id, transaction_type, currency),
+    UNIQUE KEY uq_limit_profiles_role (role, transaction_type, currency)
+);
+
+ALTER TABLE transactions ADD INDEX idx_transactions_usage (from_user_id, type, currency, created_at);
@@ -0,0 +1,3 @@
+DELETE FROM ledger_accounts WHERE code LIKE 'system:fees:%';
+
+DROP TABLE IF EXISTS fee_schedules;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    transaction_type VARCHAR(20) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT '',
+    type VARCHAR(20) NOT NULL,
+    flat_amount DECIMAL(19, 2) NULL,
+    percentage_bps INT NOT NULL DEFAULT 0,
+    tiers JSON NULL,
+    min_fee DECIMAL(19, 2) NULL,
+    max_fee DECIMAL(19, 2) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_fee_schedules_key (transaction_type, currency, role)
+);
+
+INSERT INTO ledger_accounts (code, user_id, currency, type) VALUES
+    ('system:fees:TRY', NULL, 'TRY', 'system'),
+    ('system:fees:EUR', NULL, 'EUR', 'system'),
+    ('system:fees:USD', NULL, 'USD', 'system');
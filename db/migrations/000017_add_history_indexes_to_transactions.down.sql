@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    ADD INDEX to_user_id (to_user_id),
+    DROP INDEX idx_transactions_to_user_created_at,
+    DROP INDEX idx_transactions_from_user_created_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE transactions
+    ADD INDEX idx_transactions_from_user_created_at (from_user_id, created_at, id),
+    ADD INDEX idx_transactions_to_user_created_at (to_user_id, created_at, id);
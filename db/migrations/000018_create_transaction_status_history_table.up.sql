@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS transaction_status_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    transaction_id INT NOT NULL,
+    from_status VARCHAR(50) NULL,
+    to_status VARCHAR(50) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    changed_at TIMESTAMP(6) NOT NULL,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    INDEX idx_transaction_status_history_transaction_id (transaction_id, id)
+);
+
+INSERT INTO transaction_status_history (transaction_id, from_status, to_status, reason, changed_at)
+    SELECT id, NULL, status, 'recorded before status history', created_at FROM transactions;
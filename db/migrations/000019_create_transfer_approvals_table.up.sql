@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS transfer_approvals (
+    transaction_id INT PRIMARY KEY,
+    maker_id INT NOT NULL,
+    checker_id INT NULL,
+    status VARCHAR(20) NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    FOREIGN KEY (maker_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (checker_id) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_transfer_approvals_status_expires_at (status, expires_at)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS dispute_events;
+
+DROP TABLE IF EXISTS disputes;
\ No newline at end of file
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS disputes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    transaction_id INT NOT NULL,
+    claimant_id INT NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    evidence TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    provisional_credit_id INT NULL,
+    reversal_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    FOREIGN KEY (claimant_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (provisional_credit_id) REFERENCES transactions(id),
+    FOREIGN KEY (reversal_id) REFERENCES transactions(id),
+    INDEX idx_disputes_status (status, id)
+);
+
+CREATE TABLE IF NOT EXISTS dispute_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    dispute_id INT NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    actor_id INT NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    transaction_id INT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    FOREIGN KEY (dispute_id) REFERENCES disputes(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    INDEX idx_dispute_events_dispute_id (dispute_id, id)
+);
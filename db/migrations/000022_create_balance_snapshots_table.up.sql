@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    user_id INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    snapshot_date DATE NOT NULL,
+    amount DECIMAL(19, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency, snapshot_date),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_balance_snapshots_date (snapshot_date)
+);
@@ -0,0 +1 @@
+ALTER TABLE balances DROP COLUMN version;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE balances ADD COLUMN version BIGINT NOT NULL DEFAULT 0 AFTER held_amount;
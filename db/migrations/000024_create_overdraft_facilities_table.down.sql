@@ -0,0 +1 @@
+DROP TABLE IF EXISTS overdraft_facilities;
\ No newline at end of file
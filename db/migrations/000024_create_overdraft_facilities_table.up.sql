@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS overdraft_facilities (
+    user_id INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    credit_limit DECIMAL(19, 2) NOT NULL,
+    daily_rate_bps INT NOT NULL DEFAULT 0,
+    last_charged_on DATE NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS pockets;
+
+ALTER TABLE balances DROP COLUMN pocketed_amount;
\ No newline at end of file
@@ -0,0 +1,15 @@
+ALTER TABLE balances ADD COLUMN pocketed_amount DECIMAL(19, 2) NOT NULL DEFAULT 0 AFTER held_amount;
+
+CREATE TABLE IF NOT EXISTS pockets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    amount DECIMAL(19, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_pockets_user_currency_status (user_id, currency, status)
+);
@@ -0,0 +1,7 @@
+DELETE FROM ledger_accounts WHERE code LIKE 'system:savings:%';
+
+DROP TABLE IF EXISTS savings_sweeps;
+
+DROP TABLE IF EXISTS savings_sweep_rules;
+
+DROP TABLE IF EXISTS savings_goals;
\ No newline at end of file
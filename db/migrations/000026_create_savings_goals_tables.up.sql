@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS savings_goals (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    target_amount DECIMAL(19, 2) NOT NULL,
+    target_date TIMESTAMP NOT NULL,
+    saved_amount DECIMAL(19, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_savings_goals_user_id (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS savings_sweep_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    goal_id INT NOT NULL,
+    user_id INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    amount DECIMAL(19, 2) NULL,
+    percentage_bps INT NOT NULL DEFAULT 0,
+    frequency VARCHAR(20) NULL,
+    day INT NOT NULL DEFAULT 0,
+    end_date TIMESTAMP NULL,
+    max_occurrences INT NULL,
+    next_run_at TIMESTAMP NULL,
+    occurrences_count INT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (goal_id) REFERENCES savings_goals(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_savings_sweep_rules_trigger (user_id, currency, type, active),
+    INDEX idx_savings_sweep_rules_active_next_run_at (active, next_run_at)
+);
+
+CREATE TABLE IF NOT EXISTS savings_sweeps (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    goal_id INT NOT NULL,
+    rule_id INT NULL,
+    transaction_id INT NOT NULL,
+    amount DECIMAL(19, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (goal_id) REFERENCES savings_goals(id) ON DELETE CASCADE,
+    FOREIGN KEY (rule_id) REFERENCES savings_sweep_rules(id) ON DELETE SET NULL,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    INDEX idx_savings_sweeps_goal_id_created_at (goal_id, created_at)
+);
+
+INSERT INTO ledger_accounts (code, user_id, currency, type) VALUES
+    ('system:savings:TRY', NULL, 'TRY', 'system'),
+    ('system:savings:EUR', NULL, 'EUR', 'system'),
+    ('system:savings:USD', NULL, 'USD', 'system');
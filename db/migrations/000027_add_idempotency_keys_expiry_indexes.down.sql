@@ -0,0 +1,3 @@
+ALTER TABLE idempotency_keys
+    DROP INDEX idx_idempotency_keys_created_at,
+    DROP INDEX idx_idempotency_keys_completed_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE idempotency_keys
+    ADD INDEX idx_idempotency_keys_completed_at (completed_at),
+    ADD INDEX idx_idempotency_keys_created_at (created_at);
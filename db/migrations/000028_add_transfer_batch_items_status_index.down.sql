@@ -0,0 +1,2 @@
+ALTER TABLE transfer_batch_items
+    DROP INDEX idx_transfer_batch_items_status_updated_at;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE transfer_batch_items
+    ADD INDEX idx_transfer_batch_items_status_updated_at (status, updated_at);
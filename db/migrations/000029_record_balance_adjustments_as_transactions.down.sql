@@ -0,0 +1,6 @@
+UPDATE journal_entries je
+JOIN transactions t ON t.id = je.transaction_id
+SET je.transaction_id = NULL
+WHERE t.type = 'adjustment';
+
+DELETE FROM transactions WHERE type = 'adjustment';
\ No newline at end of file
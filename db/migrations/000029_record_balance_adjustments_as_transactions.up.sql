@@ -0,0 +1,21 @@
+/* adjustments posted before they were recorded as transactions get one, so statements list them */
+ALTER TABLE transactions ADD COLUMN adjusted_journal_entry_id INT NULL;
+
+/* each transaction remembers the entry it was made from, two adjustments alike in every other way stay apart */
+INSERT INTO transactions (from_user_id, to_user_id, amount, currency, type, status, created_at, adjusted_journal_entry_id)
+SELECT ua.user_id, NULL, p.amount, ua.currency, 'adjustment', 'completed', je.created_at, je.id
+FROM journal_entries je
+JOIN ledger_postings p ON p.journal_entry_id = je.id
+JOIN ledger_accounts ua ON ua.id = p.account_id AND ua.type = 'user'
+WHERE je.transaction_id IS NULL AND je.description = 'balance adjustment';
+
+UPDATE journal_entries je
+JOIN transactions t ON t.adjusted_journal_entry_id = je.id
+SET je.transaction_id = t.id;
+
+INSERT INTO transaction_status_history (transaction_id, from_status, to_status, reason, changed_at)
+SELECT t.id, NULL, t.status, 'recorded from the ledger', t.created_at
+FROM transactions t
+WHERE t.adjusted_journal_entry_id IS NOT NULL;
+
+ALTER TABLE transactions DROP COLUMN adjusted_journal_entry_id;
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+/* ApproverRole is the user role allowed to decide on transfer approvals */
+const ApproverRole = "approver"
+
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+	ApprovalExpired  ApprovalStatus = "expired"
+)
+
+/*
+TransferApproval is the maker-checker request of the transfer with
+TransactionID. MakerID asked for the transfer, CheckerID is the approver who
+decided on it and stays nil until then. The transfer doesn't touch any
+balance while the approval is pending.
+*/
+type TransferApproval struct {
+	TransactionID int64
+	MakerID       int64
+	CheckerID     *int64
+	Status        ApprovalStatus
+	Note          string
+	ExpiresAt     time.Time
+	DecidedAt     *time.Time
+	CreatedAt     time.Time
+}
+
+func (a *TransferApproval) IsExpired(now time.Time) bool {
+	return !now.Before(a.ExpiresAt)
+}
+
+/* Decide records the checker's decision, the caller checks the approval is still pending */
+func (a *TransferApproval) Decide(checkerID int64, status ApprovalStatus, note string, now time.Time) {
+	a.CheckerID = &checkerID
+	a.Status = status
+	a.Note = note
+	a.DecidedAt = &now
+}
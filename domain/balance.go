@@ -2,8 +2,37 @@ package domain
 
 import "time"
 
+/*
+Amount is the ledger balance, HeldAmount the part of it authorized holds
+reserve and PocketedAmount the part set aside in pockets. CreditLimit comes
+from the user's overdraft facility, zero without one. Version goes up with
+every write, a write based on an older version is refused.
+*/
 type Balance struct {
-	UserID        int64
-	Amount        float64
-	LastUpdatedAt time.Time
+	UserID         int64
+	Currency       Currency
+	Amount         Money
+	HeldAmount     Money
+	PocketedAmount Money
+	CreditLimit    Money
+	Version        int64
+	LastUpdatedAt  time.Time
+}
+
+/* Main is the balance outside of pockets, the only part a debit draws from */
+func (b *Balance) Main() Money {
+	return NewMoney(b.Amount.MinorUnits()-b.PocketedAmount.MinorUnits(), b.Currency)
+}
+
+/* Available is what can still be spent: the main balance not reserved by holds plus the credit limit */
+func (b *Balance) Available() Money {
+	return NewMoney(b.Main().MinorUnits()-b.HeldAmount.MinorUnits()+b.CreditLimit.MinorUnits(), b.Currency)
+}
+
+/* OverdraftUsed is how far the main balance is below zero, pockets can't cover it */
+func (b *Balance) OverdraftUsed() Money {
+	if main := b.Main(); main.IsNegative() {
+		return main.Abs()
+	}
+	return Zero(b.Currency)
 }
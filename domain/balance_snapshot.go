@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+/*
+BalanceSnapshot is the closing balance of a wallet at the end of Date, a day
+in UTC, so it holds everything posted before the following midnight.
+*/
+type BalanceSnapshot struct {
+	UserID    int64
+	Currency  Currency
+	Date      time.Time
+	Amount    Money
+	CreatedAt time.Time
+}
+
+/* ClosesAt is the instant the snapshot stands for, the end of its day */
+func (s *BalanceSnapshot) ClosesAt() time.Time {
+	return s.Date.AddDate(0, 0, 1)
+}
+
+/* SnapshotDate is the UTC day t falls on */
+func SnapshotDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+/* LastClosedDate is the latest day that ended at or before t */
+func LastClosedDate(t time.Time) time.Time {
+	return SnapshotDate(t).AddDate(0, 0, -1)
+}
+
+/*
+HistoricalBalance is the balance of a wallet at AsOf, before anything posted
+at that instant. SnapshotDate is the snapshot it was replayed from, nil when
+the wallet had none yet and every posting was replayed.
+*/
+type HistoricalBalance struct {
+	UserID       int64
+	Currency     Currency
+	Amount       Money
+	AsOf         time.Time
+	SnapshotDate *time.Time
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+type DisputeStatus string
+
+const (
+	DisputeOpen        DisputeStatus = "open"
+	DisputeUnderReview DisputeStatus = "under_review"
+	DisputeWon         DisputeStatus = "won"
+	DisputeLost        DisputeStatus = "lost"
+)
+
+var ErrInvalidDisputeTransition = errors.New("dispute status transition isn't allowed")
+
+var disputeTransitions = map[DisputeStatus][]DisputeStatus{
+	DisputeOpen:        {DisputeUnderReview},
+	DisputeUnderReview: {DisputeWon, DisputeLost},
+}
+
+func (s DisputeStatus) CanTransitionTo(to DisputeStatus) bool {
+	return slices.Contains(disputeTransitions[s], to)
+}
+
+/* IsActive tells whether the dispute is still waiting for an outcome */
+func (s DisputeStatus) IsActive() bool {
+	return s == DisputeOpen || s == DisputeUnderReview
+}
+
+const (
+	DisputeReasonMaxLength   = 255
+	DisputeEvidenceMaxLength = 10000
+)
+
+/*
+Dispute is the claim of ClaimantID that the transfer with TransactionID
+wasn't authorized. ProvisionalCreditID is the credit given to the claimant
+while the dispute runs, it is taken back on either outcome. ReversalID is
+the reversal of the disputed transfer once the dispute is won.
+*/
+type Dispute struct {
+	ID                  int64
+	TransactionID       int64
+	ClaimantID          int64
+	Reason              string
+	Evidence            string
+	Status              DisputeStatus
+	ProvisionalCreditID *int64
+	ReversalID          *int64
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+func (d *Dispute) Validate() error {
+	if d.Reason == "" {
+		return errors.New("dispute needs a reason")
+	}
+	if len(d.Reason) > DisputeReasonMaxLength {
+		return errors.New("reason cannot be longer than 255 characters")
+	}
+	if len(d.Evidence) > DisputeEvidenceMaxLength {
+		return errors.New("evidence cannot be longer than 10000 characters")
+	}
+	return nil
+}
+
+/* MoveTo changes the status along the dispute workflow only */
+func (d *Dispute) MoveTo(status DisputeStatus, now time.Time) error {
+	if !d.Status.CanTransitionTo(status) {
+		return ErrInvalidDisputeTransition
+	}
+	d.Status = status
+	d.UpdatedAt = now
+	return nil
+}
+
+type DisputeAction string
+
+const (
+	DisputeOpened            DisputeAction = "opened"
+	DisputeReviewStarted     DisputeAction = "review_started"
+	DisputeProvisionalCredit DisputeAction = "provisional_credit"
+	DisputeResolved          DisputeAction = "resolved"
+)
+
+/*
+DisputeEvent is one entry of the audit trail of a dispute. Status is the
+dispute status after the action and TransactionID links the money the action
+moved, if any.
+*/
+type DisputeEvent struct {
+	ID            int64
+	DisputeID     int64
+	Action        DisputeAction
+	Status        DisputeStatus
+	ActorID       int64
+	Note          string
+	TransactionID *int64
+	CreatedAt     time.Time
+}
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+type FeeType string
+
+const (
+	FlatFee       FeeType = "flat"
+	PercentageFee FeeType = "percentage"
+	TieredFee     FeeType = "tiered"
+)
+
+/*
+FeeTier applies to amounts up to UpTo, the last tier has no upper bound. The
+tier an amount falls into prices the whole amount.
+*/
+type FeeTier struct {
+	UpTo          *Money
+	FlatAmount    Money
+	PercentageBps int64
+}
+
+/*
+FeeSchedule prices one transaction type in one currency, for the users with
+Role or for everyone when Role is empty. Percentages are in basis points and
+round down to minor units, MinFee and MaxFee cap the result.
+*/
+type FeeSchedule struct {
+	ID              int64
+	TransactionType TransactionType
+	Currency        Currency
+	Role            string
+	Type            FeeType
+	FlatAmount      *Money
+	PercentageBps   int64
+	Tiers           []FeeTier
+	MinFee          *Money
+	MaxFee          *Money
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (s *FeeSchedule) Validate() error {
+	if s.TransactionType != DebitTransaction && s.TransactionType != TransferTransaction {
+		return errors.New("fees can only be charged on debit and transfer")
+	}
+	if !s.Currency.IsSupported() {
+		return fmt.Errorf("unsupported currency %q", s.Currency)
+	}
+
+	switch s.Type {
+	case FlatFee:
+		if s.FlatAmount == nil {
+			return errors.New("flat fee needs an amount")
+		}
+	case PercentageFee:
+		if s.PercentageBps <= 0 || s.PercentageBps >= basisPointsPerUnit {
+			return errors.New("percentage must be between 1 and 9999 basis points")
+		}
+	case TieredFee:
+		if err := s.validateTiers(); err != nil {
+			return err
+		}
+	default:
+		return errors.New("fee type must be flat, percentage or tiered")
+	}
+
+	for _, amount := range []*Money{s.FlatAmount, s.MinFee, s.MaxFee} {
+		if amount == nil {
+			continue
+		}
+		if amount.Currency() != s.Currency {
+			return ErrCurrencyMismatch
+		}
+		if amount.IsNegative() {
+			return errors.New("fee amounts cannot be negative")
+		}
+	}
+	if s.MinFee != nil && s.MaxFee != nil {
+		if cmp, _ := s.MinFee.Cmp(*s.MaxFee); cmp > 0 {
+			return errors.New("minimum fee cannot be above the maximum fee")
+		}
+	}
+	return nil
+}
+
+func (s *FeeSchedule) validateTiers() error {
+	if len(s.Tiers) == 0 {
+		return errors.New("tiered fee needs at least one tier")
+	}
+
+	var previous *Money
+	for i, tier := range s.Tiers {
+		last := i == len(s.Tiers)-1
+		if (tier.UpTo == nil) != last {
+			return errors.New("only the last tier may be open ended")
+		}
+		if tier.FlatAmount.Currency() != s.Currency || (tier.UpTo != nil && tier.UpTo.Currency() != s.Currency) {
+			return ErrCurrencyMismatch
+		}
+		if tier.FlatAmount.IsNegative() || tier.PercentageBps < 0 || tier.PercentageBps >= basisPointsPerUnit {
+			return errors.New("tier fees must be positive and below 10000 basis points")
+		}
+		if previous != nil && tier.UpTo != nil {
+			if cmp, _ := tier.UpTo.Cmp(*previous); cmp <= 0 {
+				return errors.New("tiers must be in ascending order")
+			}
+		}
+		previous = tier.UpTo
+	}
+	return nil
+}
+
+/* Calculate returns the fee charged on top of amount */
+func (s *FeeSchedule) Calculate(amount Money) (Money, error) {
+	var fee Money
+	var err error
+
+	switch s.Type {
+	case FlatFee:
+		fee = *s.FlatAmount
+	case PercentageFee:
+		fee, err = percentageOf(amount, s.PercentageBps)
+	case TieredFee:
+		fee, err = s.tieredFee(amount)
+	}
+	if err != nil {
+		return Money{}, err
+	}
+
+	if s.MinFee != nil {
+		if cmp, err := fee.Cmp(*s.MinFee); err != nil {
+			return Money{}, err
+		} else if cmp < 0 {
+			fee = *s.MinFee
+		}
+	}
+	if s.MaxFee != nil {
+		if cmp, err := fee.Cmp(*s.MaxFee); err != nil {
+			return Money{}, err
+		} else if cmp > 0 {
+			fee = *s.MaxFee
+		}
+	}
+	return fee, nil
+}
+
+func (s *FeeSchedule) tieredFee(amount Money) (Money, error) {
+	for _, tier := range s.Tiers {
+		if tier.UpTo != nil {
+			cmp, err := amount.Cmp(*tier.UpTo)
+			if err != nil {
+				return Money{}, err
+			}
+			if cmp > 0 {
+				continue
+			}
+		}
+
+		percentage, err := percentageOf(amount, tier.PercentageBps)
+		if err != nil {
+			return Money{}, err
+		}
+		return tier.FlatAmount.Add(percentage)
+	}
+	return Zero(amount.Currency()), nil
+}
+
+func percentageOf(amount Money, bps int64) (Money, error) {
+	return amount.Convert(big.NewRat(bps, basisPointsPerUnit), amount.Currency())
+}
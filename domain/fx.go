@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+/* RateScale is the number of decimal places rates are stored and quoted with */
+const RateScale = 10
+
+const basisPointsPerUnit = 10000
+
+type FxRate struct {
+	Base      Currency
+	Quote     Currency
+	Rate      *big.Rat
+	SpreadBps int64
+	UpdatedAt time.Time
+}
+
+/* ParseRate reads a decimal rate like "35.1234" exactly */
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rate %q", value)
+	}
+	return RoundRate(rate), nil
+}
+
+/* RoundRate rounds to RateScale places so the quoted rate is the stored rate */
+func RoundRate(rate *big.Rat) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(rate.FloatString(RateScale))
+	return rounded
+}
+
+func FormatRate(rate *big.Rat) string {
+	return rate.FloatString(RateScale)
+}
+
+func (r FxRate) Validate() error {
+	if !r.Base.IsSupported() || !r.Quote.IsSupported() {
+		return fmt.Errorf("unsupported currency pair %s/%s", r.Base, r.Quote)
+	}
+	if r.Base == r.Quote {
+		return errors.New("currency pair must have two different currencies")
+	}
+	if r.Rate == nil || r.Rate.Sign() <= 0 {
+		return errors.New("rate must be greater than zero")
+	}
+	if r.SpreadBps < 0 || r.SpreadBps >= basisPointsPerUnit {
+		return errors.New("spread must be between 0 and 9999 basis points")
+	}
+	return nil
+}
+
+/* CustomerRate is the mid rate reduced by the pair's spread */
+func (r FxRate) CustomerRate() *big.Rat {
+	spread := big.NewRat(basisPointsPerUnit-r.SpreadBps, basisPointsPerUnit)
+	return RoundRate(new(big.Rat).Mul(r.Rate, spread))
+}
+
+/* Inverse quotes the pair the other way round with the same spread */
+func (r FxRate) Inverse() FxRate {
+	return FxRate{
+		Base:      r.Quote,
+		Quote:     r.Base,
+		Rate:      RoundRate(new(big.Rat).Inv(r.Rate)),
+		SpreadBps: r.SpreadBps,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+type FxQuoteStatus string
+
+const (
+	FxQuoteOpen     FxQuoteStatus = "open"
+	FxQuoteExecuted FxQuoteStatus = "executed"
+)
+
+/*
+FxQuote fixes the rate and both amounts of a conversion until ExpiresAt, so
+the conversion is executed at the rate the user has seen.
+*/
+type FxQuote struct {
+	ID                string
+	UserID            int64
+	SellAmount        Money
+	BuyAmount         Money
+	MidRate           *big.Rat
+	Rate              *big.Rat
+	SpreadBps         int64
+	Status            FxQuoteStatus
+	ExpiresAt         time.Time
+	CreatedAt         time.Time
+	ExecutedAt        *time.Time
+	SellTransactionID *int64
+	BuyTransactionID  *int64
+}
+
+func (q *FxQuote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
+
+/* FxHouseAccountCode is the treasury account taking the other side of conversions */
+func FxHouseAccountCode(currency Currency) string {
+	return fmt.Sprintf("system:fx:%s", currency)
+}
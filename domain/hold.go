@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+/*
+Hold reserves Amount of the user's balance for the authorization transaction
+with TransactionID. Its status follows the authorization: authorized until it
+is captured, voided or expired. ToUser receives the captured amount, without
+it the amount leaves the system like a debit.
+*/
+type Hold struct {
+	TransactionID  int64
+	UserID         int64
+	ToUser         *int64
+	Amount         Money
+	CapturedAmount Money
+	Status         TransactionStatus
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (h *Hold) IsExpired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
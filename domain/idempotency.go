@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+/*
+IdempotencyRecord remembers the response produced for an Idempotency-Key.
+StatusCode stays zero and CompletedAt nil while the first request is still
+being processed.
+*/
+type IdempotencyRecord struct {
+	Key                string
+	RequestFingerprint string
+	StatusCode         int
+	ResponseBody       []byte
+	CreatedAt          time.Time
+	CompletedAt        *time.Time
+}
+
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
+
+/* IsStale tells whether the request holding the key has been in flight for longer than timeout */
+func (r *IdempotencyRecord) IsStale(now time.Time, timeout time.Duration) bool {
+	return !r.IsCompleted() && !r.CreatedAt.Add(timeout).After(now)
+}
+
+/* IsExpired tells whether the stored response has been kept for longer than retention */
+func (r *IdempotencyRecord) IsExpired(now time.Time, retention time.Duration) bool {
+	return r.IsCompleted() && r.CompletedAt != nil && !r.CompletedAt.Add(retention).After(now)
+}
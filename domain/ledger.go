@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type LedgerAccountType string
+
+const (
+	UserLedgerAccount   LedgerAccountType = "user"
+	SystemLedgerAccount LedgerAccountType = "system"
+)
+
+type LedgerAccount struct {
+	ID        int64
+	Code      string
+	UserID    *int64
+	Currency  Currency
+	Type      LedgerAccountType
+	CreatedAt time.Time
+}
+
+type Posting struct {
+	ID             int64
+	JournalEntryID int64
+	AccountID      int64
+	Amount         Money
+	CreatedAt      time.Time
+}
+
+type JournalEntry struct {
+	ID            int64
+	TransactionID *int64
+	Description   string
+	Postings      []Posting
+	CreatedAt     time.Time
+}
+
+func UserAccountCode(userID int64, currency Currency) string {
+	return fmt.Sprintf("user:%d:%s", userID, currency)
+}
+
+/* FeeHouseAccountCode collects the fees charged in currency */
+func FeeHouseAccountCode(currency Currency) string {
+	return fmt.Sprintf("system:fees:%s", currency)
+}
+
+/* SavingsAccountCode holds what is swept into the savings goals of currency */
+func SavingsAccountCode(currency Currency) string {
+	return fmt.Sprintf("system:savings:%s", currency)
+}
+
+/* ExternalAccountCode is the counterpart of money entering or leaving the system */
+func ExternalAccountCode(currency Currency) string {
+	return fmt.Sprintf("system:external:%s", currency)
+}
+
+/* Validate makes sure the entry is balanced, its postings must sum to zero */
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return errors.New("journal entry needs at least two postings")
+	}
+
+	sum := Zero(e.Postings[0].Amount.Currency())
+	for _, posting := range e.Postings {
+		if posting.Amount.IsZero() {
+			return errors.New("journal entry cannot contain zero postings")
+		}
+
+		var err error
+		if sum, err = sum.Add(posting.Amount); err != nil {
+			return err
+		}
+	}
+
+	if !sum.IsZero() {
+		return fmt.Errorf("journal entry is unbalanced by %s", sum)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type LimitName string
+
+const (
+	MaxSingleAmountLimit LimitName = "max_single_amount"
+	DailyAmountLimit     LimitName = "daily_amount"
+	MonthlyAmountLimit   LimitName = "monthly_amount"
+	DailyCountLimit      LimitName = "daily_count"
+)
+
+/*
+LimitProfile caps what a user may move per transaction type and currency. It
+applies either to a single user or to every user with Role, a user's own
+profile takes precedence over the one of their role. Nil limits are not
+enforced.
+*/
+type LimitProfile struct {
+	ID              int64
+	UserID          *int64
+	Role            string
+	TransactionType TransactionType
+	Currency        Currency
+	MaxSingleAmount *Money
+	DailyAmount     *Money
+	MonthlyAmount   *Money
+	DailyCount      *int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (p *LimitProfile) Validate() error {
+	if (p.UserID == nil) == (p.Role == "") {
+		return errors.New("limit profile needs either a user or a role")
+	}
+	if p.TransactionType != DebitTransaction && p.TransactionType != TransferTransaction && p.TransactionType != SplitPaymentTransaction {
+		return errors.New("limits can only be set for debit, transfer and split_payment")
+	}
+	if !p.Currency.IsSupported() {
+		return fmt.Errorf("unsupported currency %q", p.Currency)
+	}
+
+	for _, limit := range []*Money{p.MaxSingleAmount, p.DailyAmount, p.MonthlyAmount} {
+		if limit == nil {
+			continue
+		}
+		if limit.Currency() != p.Currency {
+			return ErrCurrencyMismatch
+		}
+		if limit.IsNegative() {
+			return errors.New("limits cannot be negative")
+		}
+	}
+	if p.DailyCount != nil && *p.DailyCount < 0 {
+		return errors.New("limits cannot be negative")
+	}
+	return nil
+}
+
+/* LimitUsage is what the user already moved, completed transactions only */
+type LimitUsage struct {
+	DailyAmount   Money
+	MonthlyAmount Money
+	DailyCount    int
+}
+
+/* Check tells whether amount still fits into the profile on top of usage */
+func (p *LimitProfile) Check(amount Money, usage LimitUsage) error {
+	if p.MaxSingleAmount != nil {
+		if err := checkAmountLimit(p, MaxSingleAmountLimit, *p.MaxSingleAmount, Zero(p.Currency), amount); err != nil {
+			return err
+		}
+	}
+
+	if p.DailyCount != nil && usage.DailyCount+1 > *p.DailyCount {
+		return &LimitExceededError{
+			Limit:           DailyCountLimit,
+			TransactionType: p.TransactionType,
+			Currency:        p.Currency,
+			LimitCount:      p.DailyCount,
+			RemainingCount:  new(int),
+		}
+	}
+
+	if p.DailyAmount != nil {
+		if err := checkAmountLimit(p, DailyAmountLimit, *p.DailyAmount, usage.DailyAmount, amount); err != nil {
+			return err
+		}
+	}
+	if p.MonthlyAmount != nil {
+		if err := checkAmountLimit(p, MonthlyAmountLimit, *p.MonthlyAmount, usage.MonthlyAmount, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAmountLimit(p *LimitProfile, name LimitName, limit Money, used Money, amount Money) error {
+	remaining, err := limit.Sub(used)
+	if err != nil {
+		return err
+	}
+	if remaining.IsNegative() {
+		remaining = Zero(limit.Currency())
+	}
+
+	cmp, err := amount.Cmp(remaining)
+	if err != nil {
+		return err
+	}
+	if cmp <= 0 {
+		return nil
+	}
+
+	return &LimitExceededError{
+		Limit:           name,
+		TransactionType: p.TransactionType,
+		Currency:        p.Currency,
+		LimitAmount:     &limit,
+		RemainingAmount: &remaining,
+	}
+}
+
+/*
+LimitExceededError names the exceeded limit and the headroom left under it,
+amounts for the amount limits and counts for the count limit.
+*/
+type LimitExceededError struct {
+	Limit           LimitName
+	TransactionType TransactionType
+	Currency        Currency
+	LimitAmount     *Money
+	RemainingAmount *Money
+	LimitCount      *int
+	RemainingCount  *int
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.RemainingAmount != nil {
+		return fmt.Sprintf("%s limit of %s %s exceeded for %s, %s %s remaining", e.Limit, e.LimitAmount, e.Currency, e.TransactionType, e.RemainingAmount, e.Currency)
+	}
+	return fmt.Sprintf("%s limit of %d exceeded for %s, %d remaining", e.Limit, *e.LimitCount, e.TransactionType, *e.RemainingCount)
+}
@@ -0,0 +1,312 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type Currency string
+
+const (
+	TRY Currency = "TRY"
+	EUR Currency = "EUR"
+	USD Currency = "USD"
+
+	DefaultCurrency = TRY
+)
+
+/* currencyExponents holds the number of minor unit digits of each currency */
+var currencyExponents = map[Currency]int{
+	TRY: 2,
+	EUR: 2,
+	USD: 2,
+}
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrAmountOverflow   = errors.New("amount is out of range")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+func (c Currency) IsSupported() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+func (c Currency) exponent() int {
+	if exponent, ok := currencyExponents[c]; ok {
+		return exponent
+	}
+	return currencyExponents[DefaultCurrency]
+}
+
+/*
+Money is an exact amount kept as integer minor units (kuruş, cents) of its
+currency. It is serialized as a decimal string, e.g. "1250.75", so that it
+never goes through float64.
+*/
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+func NewMoney(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+/* ParseMoney reads a decimal string like "-12.5" without losing precision */
+func ParseMoney(value string, currency Currency) (Money, error) {
+	if !currency.IsSupported() {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+	exponent := currency.exponent()
+
+	s := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || hasFraction && fraction == "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, value, exponent)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrAmountOverflow, value)
+	}
+
+	if negative {
+		minor = -minor
+	}
+	return Money{minor: minor, currency: currency}, nil
+}
+
+/*
+WithCurrency re-expresses the amount in another currency's minor units. It's
+for amounts parsed before their currency was known, no conversion happens.
+*/
+func (m Money) WithCurrency(currency Currency) (Money, error) {
+	if !currency.IsSupported() {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+
+	minor := m.minor
+	for shift := currency.exponent() - m.currency.exponent(); shift != 0; {
+		if shift > 0 {
+			if minor > math.MaxInt64/10 || minor < math.MinInt64/10 {
+				return Money{}, ErrAmountOverflow
+			}
+			minor *= 10
+			shift--
+		} else {
+			if minor%10 != 0 {
+				return Money{}, fmt.Errorf("%w: %s has too many decimal places for %s", ErrInvalidAmount, m, currency)
+			}
+			minor /= 10
+			shift++
+		}
+	}
+
+	return Money{minor: minor, currency: currency}, nil
+}
+
+/*
+Convert multiplies the amount by rate into the target currency. Fractions of
+the smallest unit are truncated, the quoted party never pays out more than
+the rate allows.
+*/
+func (m Money) Convert(rate *big.Rat, currency Currency) (Money, error) {
+	if !currency.IsSupported() {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+
+	value := new(big.Rat).SetInt64(m.minor)
+	value.Mul(value, rate)
+
+	shift := currency.exponent() - m.currency.exponent()
+	if shift != 0 {
+		scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(shift, -shift))), nil))
+		if shift > 0 {
+			value.Mul(value, scale)
+		} else {
+			value.Quo(value, scale)
+		}
+	}
+
+	minor := new(big.Int).Quo(value.Num(), value.Denom())
+	if !minor.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{minor: minor.Int64(), currency: currency}, nil
+}
+
+func (m Money) MinorUnits() int64 {
+	return m.minor
+}
+
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+func (m Money) Abs() Money {
+	if m.minor < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	sum := m.minor + other.minor
+	if (other.minor > 0 && sum < m.minor) || (other.minor < 0 && sum > m.minor) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{minor: sum, currency: m.currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	difference := m.minor - other.minor
+	if (other.minor > 0 && difference > m.minor) || (other.minor < 0 && difference < m.minor) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{minor: difference, currency: m.currency}, nil
+}
+
+/* Cmp returns -1, 0 or +1 like strings.Compare, for the same currency only */
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.minor < other.minor:
+		return -1, nil
+	case m.minor > other.minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) String() string {
+	exponent := m.currency.exponent()
+
+	sign := ""
+	abs := uint64(m.minor)
+	if m.minor < 0 {
+		sign = "-"
+		abs = uint64(-(m.minor + 1)) + 1
+	}
+
+	if exponent == 0 {
+		return sign + strconv.FormatUint(abs, 10)
+	}
+
+	scale := uint64(1)
+	for i := 0; i < exponent; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/scale, exponent, abs%scale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+/*
+UnmarshalJSON accepts "12.34" as well as the bare number 12.34; the number is
+parsed from its literal text, so it's still exact. The currency is kept if
+already set, otherwise DefaultCurrency is used.
+*/
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(raw, `"`) {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+
+	currency := m.currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	parsed, err := ParseMoney(raw, currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+/* Value stores Money as its decimal string in DECIMAL columns */
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
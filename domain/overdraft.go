@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+OverdraftFacility lets the wallet of a user go below zero, down to minus
+CreditLimit. DailyRateBps of the overdrawn amount is charged once a day,
+nothing is charged when it is zero. LastChargedOn is the last day charged.
+*/
+type OverdraftFacility struct {
+	UserID        int64
+	Currency      Currency
+	CreditLimit   Money
+	DailyRateBps  int64
+	LastChargedOn *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (f *OverdraftFacility) Validate() error {
+	if f.UserID <= 0 {
+		return errors.New("overdraft facility needs a user")
+	}
+	if !f.Currency.IsSupported() {
+		return fmt.Errorf("unsupported currency %q", f.Currency)
+	}
+	if f.CreditLimit.Currency() != f.Currency {
+		return ErrCurrencyMismatch
+	}
+	if !f.CreditLimit.IsPositive() {
+		return errors.New("credit limit must be greater than zero")
+	}
+	if f.DailyRateBps < 0 || f.DailyRateBps >= basisPointsPerUnit {
+		return errors.New("daily rate must be between 0 and 9999 basis points")
+	}
+	return nil
+}
+
+/* DailyCharge is what a day overdrawn by balance costs, rounded down to minor units */
+func (f *OverdraftFacility) DailyCharge(balance Money) (Money, error) {
+	if !balance.IsNegative() || f.DailyRateBps == 0 {
+		return Zero(f.Currency), nil
+	}
+	return percentageOf(balance.Abs(), f.DailyRateBps)
+}
+
+/* IsChargedOn tells whether the charge of day was already posted */
+func (f *OverdraftFacility) IsChargedOn(day time.Time) bool {
+	return f.LastChargedOn != nil && !f.LastChargedOn.Before(day)
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PocketStatus string
+
+const (
+	PocketOpen   PocketStatus = "open"
+	PocketClosed PocketStatus = "closed"
+)
+
+/* MaxPocketNameLength matches the name column of the pockets table */
+const MaxPocketNameLength = 50
+
+/*
+Pocket sets Amount of the user's balance in Currency aside under a name. The
+money stays in the balance, counted in its PocketedAmount, but only the main
+balance can be spent. A closed pocket is kept empty for its history.
+*/
+type Pocket struct {
+	ID        int64
+	UserID    int64
+	Currency  Currency
+	Name      string
+	Amount    Money
+	Status    PocketStatus
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ClosedAt  *time.Time
+}
+
+/*
+BalanceBreakdown is a balance with the open pockets it contains, the main
+balance is what is left of the total outside of them.
+*/
+type BalanceBreakdown struct {
+	Balance Balance
+	Pockets []Pocket
+}
+
+/* PocketName trims name and checks it fits the pockets table */
+func PocketName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("pocket needs a name")
+	}
+	if len([]rune(name)) > MaxPocketNameLength {
+		return "", fmt.Errorf("pocket name cannot be longer than %d characters", MaxPocketNameLength)
+	}
+	return name, nil
+}
+
+func (p *Pocket) IsOpen() bool {
+	return p.Status == PocketOpen
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+/* RiskAction is what a matching risk rule does with a transaction */
+type RiskAction string
+
+const (
+	RiskAllow RiskAction = "allow"
+	RiskFlag  RiskAction = "flag"
+	RiskBlock RiskAction = "block"
+)
+
+func (a RiskAction) IsValid() bool {
+	return a == RiskAllow || a == RiskFlag || a == RiskBlock
+}
+
+type RiskRuleType string
+
+const (
+	VelocityRiskRule        RiskRuleType = "velocity"
+	NewCounterpartyRiskRule RiskRuleType = "new_counterparty"
+	AboveAverageRiskRule    RiskRuleType = "above_average"
+)
+
+/* screenedTransactionTypes are the types the risk engine sees */
+var screenedTransactionTypes = []TransactionType{CreditTransaction, DebitTransaction, TransferTransaction, SplitPaymentTransaction}
+
+/*
+RiskRuleConfig configures one risk rule, only the fields of its Type are used.
+A velocity rule matches more than MaxCount transactions within Window, a new
+counterparty rule a transfer of at least MinAmounts to someone the sender
+never paid and an above average rule an amount over Multiplier times the
+sender's average, once MinHistory transactions make the average meaningful.
+Rules without TransactionTypes apply to every screened type.
+*/
+type RiskRuleConfig struct {
+	Name             string
+	Type             RiskRuleType
+	Action           RiskAction
+	TransactionTypes []TransactionType
+	MaxCount         int
+	Window           time.Duration
+	MinAmounts       map[Currency]Money
+	Multiplier       float64
+	MinHistory       int
+}
+
+func (c *RiskRuleConfig) Validate() error {
+	if c.Name == "" {
+		return errors.New("risk rule needs a name")
+	}
+	if !c.Action.IsValid() {
+		return fmt.Errorf("risk rule %s: action must be allow, flag or block", c.Name)
+	}
+	for _, transactionType := range c.TransactionTypes {
+		if !slices.Contains(screenedTransactionTypes, transactionType) {
+			return fmt.Errorf("risk rule %s: only credit, debit, transfer and split_payment are screened", c.Name)
+		}
+	}
+
+	switch c.Type {
+	case VelocityRiskRule:
+		if c.MaxCount <= 0 || c.Window <= 0 {
+			return fmt.Errorf("risk rule %s: velocity needs a positive max count and window", c.Name)
+		}
+	case NewCounterpartyRiskRule:
+		if len(c.MinAmounts) == 0 {
+			return fmt.Errorf("risk rule %s: new counterparty needs a minimum amount", c.Name)
+		}
+		for currency, amount := range c.MinAmounts {
+			if amount.Currency() != currency {
+				return ErrCurrencyMismatch
+			}
+			if amount.IsNegative() {
+				return fmt.Errorf("risk rule %s: minimum amount cannot be negative", c.Name)
+			}
+		}
+	case AboveAverageRiskRule:
+		if c.Multiplier <= 1 || c.MinHistory <= 0 {
+			return fmt.Errorf("risk rule %s: above average needs a multiplier over 1 and a minimum history", c.Name)
+		}
+	default:
+		return fmt.Errorf("risk rule %s: type must be velocity, new_counterparty or above_average", c.Name)
+	}
+	return nil
+}
+
+/* AppliesTo tells whether transactions of transactionType are screened by the rule */
+func (c *RiskRuleConfig) AppliesTo(transactionType TransactionType) bool {
+	if len(c.TransactionTypes) == 0 {
+		return slices.Contains(screenedTransactionTypes, transactionType)
+	}
+	return slices.Contains(c.TransactionTypes, transactionType)
+}
+
+type RiskReviewStatus string
+
+const (
+	RiskReviewOpen      RiskReviewStatus = "open"
+	RiskReviewCleared   RiskReviewStatus = "cleared"
+	RiskReviewConfirmed RiskReviewStatus = "confirmed"
+)
+
+/*
+RiskReview queues a flagged transaction for a person to look at, Reasons
+lists every rule it matched. The transaction itself went through, resolving
+the review records whether it was legitimate or confirmed as fraud.
+*/
+type RiskReview struct {
+	ID            int64
+	TransactionID int64
+	UserID        int64
+	Reasons       string
+	Status        RiskReviewStatus
+	ResolvedBy    *int64
+	Note          string
+	CreatedAt     time.Time
+	ResolvedAt    *time.Time
+}
+
+/* Resolve records the outcome, the caller checks the review is still open */
+func (r *RiskReview) Resolve(status RiskReviewStatus, resolvedBy int64, note string, now time.Time) error {
+	if status != RiskReviewCleared && status != RiskReviewConfirmed {
+		return errors.New("risk review can only be resolved as cleared or confirmed")
+	}
+
+	r.Status = status
+	r.ResolvedBy = &resolvedBy
+	r.Note = note
+	r.ResolvedAt = &now
+	return nil
+}
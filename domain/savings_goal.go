@@ -0,0 +1,261 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type SavingsGoalStatus string
+
+const (
+	SavingsGoalActive SavingsGoalStatus = "active"
+	SavingsGoalClosed SavingsGoalStatus = "closed"
+)
+
+/* MaxSavingsGoalNameLength matches the name column of the savings_goals table */
+const MaxSavingsGoalNameLength = 100
+
+/* maxProjectionDays is how far ahead Project looks for the day a goal is reached */
+const maxProjectionDays = 3650
+
+/*
+SavingsGoal is money a user saves towards TargetAmount by TargetDate. What is
+swept into the goal leaves the wallet for the savings account of its
+currency and is counted in SavedAmount, closing the goal pays it back.
+*/
+type SavingsGoal struct {
+	ID           int64
+	UserID       int64
+	Currency     Currency
+	Name         string
+	TargetAmount Money
+	TargetDate   time.Time
+	SavedAmount  Money
+	Status       SavingsGoalStatus
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ClosedAt     *time.Time
+}
+
+func (g *SavingsGoal) Validate(now time.Time) error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return errors.New("savings goal needs a name")
+	}
+	if len([]rune(g.Name)) > MaxSavingsGoalNameLength {
+		return fmt.Errorf("savings goal name cannot be longer than %d characters", MaxSavingsGoalNameLength)
+	}
+	if !g.Currency.IsSupported() {
+		return fmt.Errorf("unsupported currency %q", g.Currency)
+	}
+	if g.TargetAmount.Currency() != g.Currency {
+		return ErrCurrencyMismatch
+	}
+	if !g.TargetAmount.IsPositive() {
+		return errors.New("target amount must be greater than zero")
+	}
+	if !g.TargetDate.After(now) {
+		return errors.New("target date must be in the future")
+	}
+	return nil
+}
+
+func (g *SavingsGoal) IsActive() bool {
+	return g.Status == SavingsGoalActive
+}
+
+/* Remaining is what is still missing to the target, zero once it is reached */
+func (g *SavingsGoal) Remaining() Money {
+	remaining := g.TargetAmount.MinorUnits() - g.SavedAmount.MinorUnits()
+	return NewMoney(max(remaining, 0), g.Currency)
+}
+
+func (g *SavingsGoal) IsReached() bool {
+	return g.Remaining().IsZero()
+}
+
+/* ProgressBps is how much of the target is saved in basis points, capped at 10000 */
+func (g *SavingsGoal) ProgressBps() int64 {
+	progress := g.SavedAmount.MinorUnits() * basisPointsPerUnit / g.TargetAmount.MinorUnits()
+	return min(progress, basisPointsPerUnit)
+}
+
+/*
+SavingsProjection tells when a goal is expected to be reached. DailyRate is
+what sweeps triggered by credits and debits saved per day lately, fixed
+amount rules are projected from their schedule. ProjectedCompletion is nil
+when the goal won't be reached within ten years at that pace. RequiredDaily
+is what would have to be saved every day to reach the target on time.
+*/
+type SavingsProjection struct {
+	GoalID              int64
+	Remaining           Money
+	DailyRate           Money
+	ProjectedCompletion *time.Time
+	OnTrack             bool
+	RequiredDaily       Money
+}
+
+/* Project plays the active fixed amount rules and dailyRate forward from now until the goal is reached */
+func (g *SavingsGoal) Project(rules []SweepRule, dailyRate Money, now time.Time) SavingsProjection {
+	projection := SavingsProjection{
+		GoalID:        g.ID,
+		Remaining:     g.Remaining(),
+		DailyRate:     dailyRate,
+		RequiredDaily: g.requiredDaily(now),
+	}
+	if projection.Remaining.IsZero() {
+		projection.ProjectedCompletion = &now
+		projection.OnTrack = true
+		return projection
+	}
+
+	var scheduled []SweepRule
+	for _, rule := range rules {
+		if rule.Active && rule.Type == FixedAmountSweep {
+			scheduled = append(scheduled, rule)
+		}
+	}
+
+	missing := projection.Remaining.MinorUnits()
+	for day := 1; day <= maxProjectionDays && projection.ProjectedCompletion == nil; day++ {
+		endOfDay := now.AddDate(0, 0, day)
+		for i := range scheduled {
+			for scheduled[i].Active && !scheduled[i].NextRunAt.After(endOfDay) {
+				occurrence := *scheduled[i].NextRunAt
+				missing -= scheduled[i].Amount.MinorUnits()
+				scheduled[i].Advance()
+				if missing <= 0 && projection.ProjectedCompletion == nil {
+					projection.ProjectedCompletion = &occurrence
+				}
+			}
+		}
+
+		missing -= dailyRate.MinorUnits()
+		if missing <= 0 && projection.ProjectedCompletion == nil {
+			projection.ProjectedCompletion = &endOfDay
+		}
+	}
+
+	projection.OnTrack = projection.ProjectedCompletion != nil && !projection.ProjectedCompletion.After(g.TargetDate)
+	return projection
+}
+
+/* requiredDaily spreads what is missing over the days left, rounded up, all of it when none are left */
+func (g *SavingsGoal) requiredDaily(now time.Time) Money {
+	remaining := g.Remaining().MinorUnits()
+	days := int64(g.TargetDate.Sub(now).Hours() / 24)
+	if days < 1 {
+		return NewMoney(remaining, g.Currency)
+	}
+	return NewMoney((remaining+days-1)/days, g.Currency)
+}
+
+type SweepRuleType string
+
+const (
+	FixedAmountSweep        SweepRuleType = "fixed_amount"
+	PercentageOfCreditSweep SweepRuleType = "percentage_of_credit"
+	RoundUpSweep            SweepRuleType = "round_up"
+)
+
+/*
+SweepRule moves money into a savings goal automatically: Amount on every
+occurrence of Recurrence for fixed_amount rules, PercentageBps of every
+amount paid into the balance for percentage_of_credit rules and the
+round-up to the next whole unit of every amount taken from it for round_up
+rules. NextRunAt is only set for fixed_amount rules, a rule whose
+recurrence ended is no longer Active.
+*/
+type SweepRule struct {
+	ID               int64
+	GoalID           int64
+	UserID           int64
+	Currency         Currency
+	Type             SweepRuleType
+	Amount           *Money
+	PercentageBps    int64
+	Recurrence       *Recurrence
+	NextRunAt        *time.Time
+	OccurrencesCount int
+	Active           bool
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (r *SweepRule) Validate() error {
+	switch r.Type {
+	case FixedAmountSweep:
+		if r.Amount == nil || r.Recurrence == nil {
+			return errors.New("fixed amount sweep needs an amount and a recurrence")
+		}
+		if r.Amount.Currency() != r.Currency {
+			return ErrCurrencyMismatch
+		}
+		if !r.Amount.IsPositive() {
+			return errors.New("amount must be greater than zero")
+		}
+		return r.Recurrence.Validate()
+	case PercentageOfCreditSweep:
+		if r.PercentageBps <= 0 || r.PercentageBps > basisPointsPerUnit {
+			return fmt.Errorf("percentage must be between 1 and %d basis points", basisPointsPerUnit)
+		}
+	case RoundUpSweep:
+	default:
+		return errors.New("sweep type must be fixed_amount, percentage_of_credit or round_up")
+	}
+
+	if r.Amount != nil || r.Recurrence != nil {
+		return errors.New("only fixed amount sweeps take an amount and a recurrence")
+	}
+	return nil
+}
+
+/* SweepAmount is what the rule moves for trigger, the amount paid in or debited that set it off */
+func (r *SweepRule) SweepAmount(trigger Money) (Money, error) {
+	switch r.Type {
+	case FixedAmountSweep:
+		return *r.Amount, nil
+	case PercentageOfCreditSweep:
+		return percentageOf(trigger.Abs(), r.PercentageBps)
+	}
+	return RoundUp(trigger.Abs()), nil
+}
+
+/* Advance moves a fixed amount rule to its next occurrence, deactivating it once the recurrence ends */
+func (r *SweepRule) Advance() {
+	r.OccurrencesCount++
+
+	next := r.Recurrence.NextOccurrence(*r.NextRunAt)
+	r.NextRunAt = &next
+
+	if r.Recurrence.IsFinished(next, r.OccurrencesCount) {
+		r.Active = false
+	}
+}
+
+/* RoundUp is what takes amount up to the next whole unit of its currency, zero when it already is one */
+func RoundUp(amount Money) Money {
+	unit := int64(1)
+	for i := 0; i < amount.Currency().exponent(); i++ {
+		unit *= 10
+	}
+
+	remainder := amount.MinorUnits() % unit
+	if remainder <= 0 {
+		return Zero(amount.Currency())
+	}
+	return NewMoney(unit-remainder, amount.Currency())
+}
+
+/* SavingsSweep records one sweep into a goal, RuleID is nil for money moved in by hand */
+type SavingsSweep struct {
+	ID            int64
+	GoalID        int64
+	RuleID        *int64
+	TransactionID int64
+	Amount        Money
+	CreatedAt     time.Time
+}
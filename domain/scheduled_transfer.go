@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type ScheduledTransferStatus string
+
+const (
+	Scheduled          ScheduledTransferStatus = "scheduled"
+	ScheduledExecuting ScheduledTransferStatus = "executing"
+	ScheduledCompleted ScheduledTransferStatus = "completed"
+	ScheduledFailed    ScheduledTransferStatus = "failed"
+	ScheduledCancelled ScheduledTransferStatus = "cancelled"
+
+	ScheduledAwaitingApproval ScheduledTransferStatus = "awaiting_approval"
+)
+
+/*
+ScheduledTransfer is a future-dated transfer. It doesn't touch balances until
+the scheduler runs it, then TransactionID or FailureReason tells the outcome.
+*/
+type ScheduledTransfer struct {
+	ID            int64
+	FromUser      int64
+	ToUser        int64
+	Amount        Money
+	ExecuteAt     time.Time
+	Status        ScheduledTransferStatus
+	TransactionID *int64
+	FailureReason string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+/* MaxSplitRecipients bounds one split payment, it is settled in a single unit of work */
+const MaxSplitRecipients = 50
+
+/*
+SplitShare is what one recipient gets of a split payment, either a fixed
+Amount or PercentageBps basis points of the total.
+*/
+type SplitShare struct {
+	ToUser        int64
+	Amount        *Money
+	PercentageBps int64
+}
+
+/*
+SplitInstruction is a payment from Payer shared among several recipients.
+Total is only needed for percentage shares, amount shares add up to it.
+*/
+type SplitInstruction struct {
+	Payer    int64
+	Currency Currency
+	Total    *Money
+	Shares   []SplitShare
+}
+
+/*
+SplitPayment groups a split: Payment debits the payer once and every leg
+credits one recipient, linked to the payment by its ParentID.
+*/
+type SplitPayment struct {
+	Payment Transaction
+	Legs    []Transaction
+}
+
+/*
+Allocate validates the instruction and returns the total with the amount of
+every share in order. Percentages round down to minor units and the minor
+units left over go one by one to the first shares, so the legs always add up
+to the total.
+*/
+func (i *SplitInstruction) Allocate() (Money, []Money, error) {
+	if err := i.validateShares(); err != nil {
+		return Money{}, nil, err
+	}
+
+	if i.Shares[0].Amount != nil {
+		return i.allocateAmounts()
+	}
+	return i.allocatePercentages()
+}
+
+func (i *SplitInstruction) validateShares() error {
+	if !i.Currency.IsSupported() {
+		return fmt.Errorf("unsupported currency %q", i.Currency)
+	}
+	if len(i.Shares) < 2 {
+		return errors.New("split payment needs at least two recipients")
+	}
+	if len(i.Shares) > MaxSplitRecipients {
+		return fmt.Errorf("split payment cannot have more than %d recipients", MaxSplitRecipients)
+	}
+
+	byAmount := i.Shares[0].Amount != nil
+	recipients := make(map[int64]bool, len(i.Shares))
+	for _, share := range i.Shares {
+		switch {
+		case share.ToUser <= 0:
+			return errors.New("every recipient needs a to_user_id")
+		case share.ToUser == i.Payer:
+			return errors.New("split payment cannot be to the payer")
+		case recipients[share.ToUser]:
+			return fmt.Errorf("recipient %d appears more than once", share.ToUser)
+		case (share.Amount != nil) != byAmount || (share.Amount != nil && share.PercentageBps != 0):
+			return errors.New("shares must either all have an amount or all have a percentage")
+		}
+		recipients[share.ToUser] = true
+	}
+	return nil
+}
+
+func (i *SplitInstruction) allocateAmounts() (Money, []Money, error) {
+	total := Zero(i.Currency)
+	amounts := make([]Money, 0, len(i.Shares))
+	for _, share := range i.Shares {
+		if share.Amount.Currency() != i.Currency {
+			return Money{}, nil, ErrCurrencyMismatch
+		}
+		if !share.Amount.IsPositive() {
+			return Money{}, nil, errors.New("amount must be greater than zero")
+		}
+
+		var err error
+		if total, err = total.Add(*share.Amount); err != nil {
+			return Money{}, nil, err
+		}
+		amounts = append(amounts, *share.Amount)
+	}
+
+	if i.Total != nil {
+		if cmp, err := i.Total.Cmp(total); err != nil || cmp != 0 {
+			return Money{}, nil, errors.New("shares must add up to the total amount")
+		}
+	}
+	return total, amounts, nil
+}
+
+func (i *SplitInstruction) allocatePercentages() (Money, []Money, error) {
+	if i.Total == nil {
+		return Money{}, nil, errors.New("percentage shares need a total amount")
+	}
+	if i.Total.Currency() != i.Currency {
+		return Money{}, nil, ErrCurrencyMismatch
+	}
+	if !i.Total.IsPositive() {
+		return Money{}, nil, errors.New("amount must be greater than zero")
+	}
+
+	/* bounding every share keeps the sum from overflowing and wrapping around to a whole */
+	var totalBps int64
+	for _, share := range i.Shares {
+		if share.PercentageBps <= 0 || share.PercentageBps > basisPointsPerUnit {
+			return Money{}, nil, fmt.Errorf("percentage must be between 1 and %d basis points", basisPointsPerUnit)
+		}
+		totalBps += share.PercentageBps
+	}
+	if totalBps != basisPointsPerUnit {
+		return Money{}, nil, fmt.Errorf("percentages must add up to %d basis points", basisPointsPerUnit)
+	}
+
+	amounts := make([]Money, 0, len(i.Shares))
+	allocated := int64(0)
+	for _, share := range i.Shares {
+		amount, err := i.Total.Convert(big.NewRat(share.PercentageBps, basisPointsPerUnit), i.Currency)
+		if err != nil {
+			return Money{}, nil, err
+		}
+		amounts = append(amounts, amount)
+		allocated += amount.MinorUnits()
+	}
+
+	for index := 0; allocated < i.Total.MinorUnits(); index++ {
+		amounts[index] = NewMoney(amounts[index].MinorUnits()+1, i.Currency)
+		allocated++
+	}
+
+	for _, amount := range amounts {
+		if !amount.IsPositive() {
+			return Money{}, nil, errors.New("total is too small to split between every recipient")
+		}
+	}
+	return *i.Total, amounts, nil
+}
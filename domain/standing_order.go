@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type RecurrenceFrequency string
+
+const (
+	Daily   RecurrenceFrequency = "daily"
+	Weekly  RecurrenceFrequency = "weekly"
+	Monthly RecurrenceFrequency = "monthly"
+)
+
+/*
+Recurrence describes when a standing order runs. Day is the ISO weekday
+(1 = Monday .. 7 = Sunday) for weekly orders and the day of month (1..31) for
+monthly ones, months shorter than Day run on their last day. It ends at
+EndDate or after MaxOccurrences, whichever comes first.
+*/
+type Recurrence struct {
+	Frequency      RecurrenceFrequency
+	Day            int
+	EndDate        *time.Time
+	MaxOccurrences *int
+}
+
+func (r Recurrence) Validate() error {
+	switch r.Frequency {
+	case Daily:
+	case Weekly:
+		if r.Day < 1 || r.Day > 7 {
+			return errors.New("weekly recurrence needs a day between 1 (Monday) and 7 (Sunday)")
+		}
+	case Monthly:
+		if r.Day < 1 || r.Day > 31 {
+			return errors.New("monthly recurrence needs a day between 1 and 31")
+		}
+	default:
+		return errors.New("frequency must be daily, weekly or monthly")
+	}
+
+	if r.MaxOccurrences != nil && *r.MaxOccurrences < 1 {
+		return errors.New("occurrence count must be at least one")
+	}
+	return nil
+}
+
+/* FirstOccurrence is the first matching day on or after from, at from's time of day */
+func (r Recurrence) FirstOccurrence(from time.Time) time.Time {
+	switch r.Frequency {
+	case Weekly:
+		shift := (r.Day - isoWeekday(from) + 7) % 7
+		return from.AddDate(0, 0, shift)
+	case Monthly:
+		candidate := monthDay(from.Year(), from.Month(), r.Day, from)
+		if candidate.Before(from) {
+			candidate = monthDay(from.Year(), from.Month()+1, r.Day, from)
+		}
+		return candidate
+	}
+	return from
+}
+
+/* NextOccurrence is the occurrence following previous */
+func (r Recurrence) NextOccurrence(previous time.Time) time.Time {
+	switch r.Frequency {
+	case Weekly:
+		return previous.AddDate(0, 0, 7)
+	case Monthly:
+		return monthDay(previous.Year(), previous.Month()+1, r.Day, previous)
+	}
+	return previous.AddDate(0, 0, 1)
+}
+
+/* IsFinished tells whether occurrence would be past the end of the recurrence */
+func (r Recurrence) IsFinished(occurrence time.Time, occurrencesSoFar int) bool {
+	if r.MaxOccurrences != nil && occurrencesSoFar >= *r.MaxOccurrences {
+		return true
+	}
+	return r.EndDate != nil && occurrence.After(*r.EndDate)
+}
+
+type InsufficientFundsPolicy string
+
+const (
+	SkipOccurrence  InsufficientFundsPolicy = "skip"
+	RetryOccurrence InsufficientFundsPolicy = "retry"
+)
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "active"
+	StandingOrderCompleted StandingOrderStatus = "completed"
+	StandingOrderCancelled StandingOrderStatus = "cancelled"
+)
+
+/*
+StandingOrder is a recurring transfer. OccurrenceAt is the occurrence being
+worked on and NextRunAt when it's attempted next, they only differ while an
+occurrence is retried.
+*/
+type StandingOrder struct {
+	ID                      int64
+	FromUser                int64
+	ToUser                  int64
+	Amount                  Money
+	Recurrence              Recurrence
+	StartAt                 time.Time
+	OccurrenceAt            time.Time
+	NextRunAt               time.Time
+	OccurrencesCount        int
+	RetryCount              int
+	InsufficientFundsPolicy InsufficientFundsPolicy
+	MaxRetries              int
+	Status                  StandingOrderStatus
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+func (o *StandingOrder) Validate() error {
+	if !o.Amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+	if o.FromUser == o.ToUser {
+		return errors.New("transfer cannot be to the same user")
+	}
+	if o.InsufficientFundsPolicy != SkipOccurrence && o.InsufficientFundsPolicy != RetryOccurrence {
+		return errors.New("insufficient funds policy must be skip or retry")
+	}
+	if o.MaxRetries < 0 {
+		return errors.New("max retries cannot be negative")
+	}
+	return o.Recurrence.Validate()
+}
+
+/*
+Advance moves the order past its current occurrence, completing it once the
+recurrence has no further occurrence.
+*/
+func (o *StandingOrder) Advance() {
+	o.OccurrencesCount++
+	o.RetryCount = 0
+
+	next := o.Recurrence.NextOccurrence(o.OccurrenceAt)
+	o.OccurrenceAt = next
+	o.NextRunAt = next
+
+	if o.Recurrence.IsFinished(next, o.OccurrencesCount) {
+		o.Status = StandingOrderCompleted
+	}
+}
+
+type StandingOrderAttemptStatus string
+
+const (
+	AttemptSucceeded StandingOrderAttemptStatus = "succeeded"
+	AttemptFailed    StandingOrderAttemptStatus = "failed"
+	AttemptSkipped   StandingOrderAttemptStatus = "skipped"
+
+	AttemptAwaitingApproval StandingOrderAttemptStatus = "awaiting_approval"
+)
+
+/* StandingOrderAttempt records one try of one occurrence */
+type StandingOrderAttempt struct {
+	ID              int64
+	StandingOrderID int64
+	OccurrenceAt    time.Time
+	AttemptedAt     time.Time
+	Status          StandingOrderAttemptStatus
+	TransactionID   *int64
+	FailureReason   string
+}
+
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+/* monthDay returns the given day of the month, clamped to the month's last day */
+func monthDay(year int, month time.Month, day int, clock time.Time) time.Time {
+	firstOfMonth := time.Date(year, month, 1, clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(day, lastDay)-1)
+}
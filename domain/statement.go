@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+/* MaxStatementPeriod keeps one export within what a customer would ask for at once */
+const MaxStatementPeriod = 366 * 24 * time.Hour
+
+/*
+Statement is the head of an account statement for [From, To), the lines and
+the summary follow it while the transactions are read.
+*/
+type Statement struct {
+	UserID         int64
+	Currency       Currency
+	From           time.Time
+	To             time.Time
+	OpeningBalance Money
+}
+
+func (s *Statement) Validate() error {
+	if !s.Currency.IsSupported() {
+		return errors.New("unsupported currency")
+	}
+	if !s.From.Before(s.To) {
+		return errors.New("from must be before to")
+	}
+	if s.To.Sub(s.From) > MaxStatementPeriod {
+		return errors.New("statement period cannot be longer than a year")
+	}
+	return nil
+}
+
+/* StatementLine is one transaction as the user saw it, Amount is signed and Balance runs after it */
+type StatementLine struct {
+	Transaction Transaction
+	Amount      Money
+	Balance     Money
+}
+
+/* Counterparty is the other user of a transfer, nil when money came from or left the system */
+func (l StatementLine) Counterparty(userID int64) *int64 {
+	if l.Transaction.ToUser == nil {
+		return nil
+	}
+	if l.Transaction.FromUser == userID {
+		return l.Transaction.ToUser
+	}
+	return &l.Transaction.FromUser
+}
+
+type StatementTotal struct {
+	Type   TransactionType
+	Count  int
+	Amount Money
+}
+
+type StatementSummary struct {
+	Totals         []StatementTotal
+	ClosingBalance Money
+}
+
+/* MovedBalances tells whether t changed balances, reversed transactions did before being undone */
+func (t *Transaction) MovedBalances() bool {
+	return t.Status == Completed || t.Status == Reversed
+}
+
+/* BalanceChange is what t did to the balance of userID */
+func (t *Transaction) BalanceChange(userID int64) Money {
+	if change, ok := t.BalanceDeltas()[userID]; ok {
+		return change
+	}
+	return Zero(t.Currency)
+}
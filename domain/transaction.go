@@ -8,31 +8,61 @@ import (
 type TransactionType string
 
 const (
-	CreditTransaction   TransactionType = "credit"
-	DebitTransaction    TransactionType = "debit"
-	TransferTransaction TransactionType = "transfer"
+	CreditTransaction        TransactionType = "credit"
+	DebitTransaction         TransactionType = "debit"
+	TransferTransaction      TransactionType = "transfer"
+	ConversionTransaction    TransactionType = "conversion"
+	ReversalTransaction      TransactionType = "reversal"
+	RefundTransaction        TransactionType = "refund"
+	AuthorizationTransaction TransactionType = "authorization"
+	CaptureTransaction       TransactionType = "capture"
+	FeeTransaction           TransactionType = "fee"
+
+	ProvisionalCreditTransaction TransactionType = "provisional_credit"
+	SplitPaymentTransaction      TransactionType = "split_payment"
+	SplitLegTransaction          TransactionType = "split_leg"
+	OverdraftChargeTransaction   TransactionType = "overdraft_charge"
+	SavingsSweepTransaction      TransactionType = "savings_sweep"
+	SavingsWithdrawalTransaction TransactionType = "savings_withdrawal"
+	AdjustmentTransaction        TransactionType = "adjustment"
 )
 
 type TransactionStatus string
 
 const (
-	Pending   TransactionStatus = "pending"
-	Completed TransactionStatus = "completed"
-	Failed    TransactionStatus = "failed"
+	Pending    TransactionStatus = "pending"
+	Completed  TransactionStatus = "completed"
+	Failed     TransactionStatus = "failed"
+	Reversed   TransactionStatus = "reversed"
+	Authorized TransactionStatus = "authorized"
+	Captured   TransactionStatus = "captured"
+	Voided     TransactionStatus = "voided"
+	Expired    TransactionStatus = "expired"
+
+	AwaitingApproval TransactionStatus = "awaiting_approval"
+	Rejected         TransactionStatus = "rejected"
 )
 
+/*
+ParentID links a reversal, refund, fee, provisional credit or split leg to
+the transaction it belongs to. Fee is what was charged on top of Amount by
+the linked fee transaction, it isn't stored with the transaction itself.
+*/
 type Transaction struct {
 	ID        int64
+	ParentID  *int64
 	FromUser  int64
 	ToUser    *int64
-	Amount    float64
+	Amount    Money
+	Currency  Currency
 	Type      TransactionType
 	Status    TransactionStatus
 	CreatedAt time.Time
+	Fee       *Money
 }
 
 func (t *Transaction) Validate() error {
-	if t.Amount <= 0 {
+	if !t.Amount.IsPositive() {
 		return errors.New("amount must be greater than zero")
 	}
 	if t.Type == TransferTransaction && (t.ToUser == nil || *t.ToUser == t.FromUser) {
@@ -40,3 +70,71 @@ func (t *Transaction) Validate() error {
 	}
 	return nil
 }
+
+/* AwaitsApproval tells whether the transaction was stored without moving money until an approver settles it */
+func (t *Transaction) AwaitsApproval() bool {
+	return t.Status == AwaitingApproval
+}
+
+/* IsReversible tells whether the transaction type can be reversed or refunded */
+func (t *Transaction) IsReversible() bool {
+	switch t.Type {
+	case CreditTransaction, DebitTransaction, TransferTransaction, CaptureTransaction:
+		return true
+	}
+	return false
+}
+
+/*
+LimitType is the type whose limits and fees apply to the transaction: a
+capture counts as the transfer it pays out, or as a debit when the hold had
+no receiver.
+*/
+func (t *Transaction) LimitType() TransactionType {
+	if t.Type != CaptureTransaction {
+		return t.Type
+	}
+	if t.ToUser != nil {
+		return TransferTransaction
+	}
+	return DebitTransaction
+}
+
+/*
+BalanceDeltas is how much the transaction moved in or out of each user's
+balance, debits are stored with a negative amount.
+*/
+func (t *Transaction) BalanceDeltas() map[int64]Money {
+	if t.ToUser == nil {
+		return map[int64]Money{t.FromUser: t.Amount}
+	}
+	return map[int64]Money{
+		t.FromUser: t.Amount.Neg(),
+		*t.ToUser:  t.Amount,
+	}
+}
+
+/*
+NewReversal builds the transaction giving amount of t back, amount is always
+positive and the direction follows from t.
+*/
+func (t *Transaction) NewReversal(transactionType TransactionType, amount Money, createdAt time.Time) *Transaction {
+	reversal := &Transaction{
+		ParentID:  &t.ID,
+		FromUser:  t.FromUser,
+		Amount:    amount,
+		Currency:  t.Currency,
+		Type:      transactionType,
+		Status:    Pending,
+		CreatedAt: createdAt,
+	}
+
+	if t.ToUser != nil {
+		toUser := t.FromUser
+		reversal.FromUser = *t.ToUser
+		reversal.ToUser = &toUser
+	} else if t.Amount.IsPositive() {
+		reversal.Amount = amount.Neg()
+	}
+	return reversal
+}
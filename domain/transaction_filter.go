@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTransactionPageSize = 50
+	MaxTransactionPageSize     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+/* TransactionCursor points at the last transaction of a page, the next page starts right after it */
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+func (c TransactionCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)))
+}
+
+func ParseTransactionCursor(value string) (*TransactionCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	transactionID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &TransactionCursor{CreatedAt: time.Unix(0, nanos), ID: transactionID}, nil
+}
+
+/*
+TransactionFilter narrows the history of a user. Counterparty is the other
+side of a transfer, amounts compare against the absolute amount, From is
+inclusive and To exclusive. Transactions come newest first unless Ascending.
+*/
+type TransactionFilter struct {
+	Types        []TransactionType
+	Statuses     []TransactionStatus
+	Counterparty *int64
+	Currency     Currency
+	MinAmount    *Money
+	MaxAmount    *Money
+	From         *time.Time
+	To           *time.Time
+	Ascending    bool
+	After        *TransactionCursor
+	Limit        int
+}
+
+/* Validate also fills in the defaults, the page size and the currency the amount range implies */
+func (f *TransactionFilter) Validate() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultTransactionPageSize
+	}
+	if f.Limit < 0 || f.Limit > MaxTransactionPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", MaxTransactionPageSize)
+	}
+
+	for _, amount := range []*Money{f.MinAmount, f.MaxAmount} {
+		if amount == nil {
+			continue
+		}
+		if f.Currency == "" {
+			f.Currency = amount.Currency()
+		}
+		if amount.Currency() != f.Currency {
+			return ErrCurrencyMismatch
+		}
+	}
+	if f.Currency != "" && !f.Currency.IsSupported() {
+		return fmt.Errorf("unsupported currency %q", f.Currency)
+	}
+
+	if f.MinAmount != nil && f.MaxAmount != nil {
+		if cmp, _ := f.MinAmount.Cmp(*f.MaxAmount); cmp > 0 {
+			return errors.New("min_amount cannot be above max_amount")
+		}
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
+type TransactionPage struct {
+	Transactions []Transaction
+	NextCursor   *TransactionCursor
+}
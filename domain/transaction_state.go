@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var ErrInvalidStatusTransition = errors.New("transaction status transition isn't allowed")
+
+/*
+transactionTransitions is the transaction state machine, a status only moves
+to the ones listed for it and the statuses missing here are final.
+*/
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	Pending:    {Completed, Failed},
+	Completed:  {Reversed},
+	Authorized: {Captured, Voided, Expired},
+
+	AwaitingApproval: {Completed, Rejected, Expired},
+}
+
+func (s TransactionStatus) CanTransitionTo(to TransactionStatus) bool {
+	return slices.Contains(transactionTransitions[s], to)
+}
+
+func (s TransactionStatus) IsFinal() bool {
+	return len(transactionTransitions[s]) == 0
+}
+
+/* TransactionStatusChange is one entry of the status history, From is nil for the status a transaction was created with */
+type TransactionStatusChange struct {
+	ID            int64
+	TransactionID int64
+	From          *TransactionStatus
+	To            TransactionStatus
+	Reason        string
+	ChangedAt     time.Time
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/* MaxTransferBatchItems bounds one upload, larger payouts are split into several batches */
+const MaxTransferBatchItems = 1000
+
+type TransferBatchStatus string
+
+const (
+	BatchProcessing TransferBatchStatus = "processing"
+	BatchCompleted  TransferBatchStatus = "completed"
+	BatchCancelled  TransferBatchStatus = "cancelled"
+)
+
+type TransferBatchItemStatus string
+
+const (
+	BatchItemPending    TransferBatchItemStatus = "pending"
+	BatchItemProcessing TransferBatchItemStatus = "processing"
+	BatchItemCompleted  TransferBatchItemStatus = "completed"
+	BatchItemFailed     TransferBatchItemStatus = "failed"
+	BatchItemCancelled  TransferBatchItemStatus = "cancelled"
+
+	BatchItemAwaitingApproval TransferBatchItemStatus = "awaiting_approval"
+)
+
+/*
+TransferBatch is a set of transfers from one user, uploaded at once and run
+item by item in the background. Progress is counted from the items.
+*/
+type TransferBatch struct {
+	ID        int64
+	FromUser  int64
+	Currency  Currency
+	Status    TransferBatchStatus
+	Items     []TransferBatchItem
+	Progress  TransferBatchProgress
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+/* Row is the 1-based position of the item in the upload, so errors can point at it */
+type TransferBatchItem struct {
+	ID            int64
+	BatchID       int64
+	Row           int
+	ToUser        int64
+	Amount        Money
+	Reference     string
+	Status        TransferBatchItemStatus
+	TransactionID *int64
+	FailureReason string
+	UpdatedAt     time.Time
+}
+
+/* AwaitingApproval counts the items whose transfer was handed to an approver, the batch is done with them */
+type TransferBatchProgress struct {
+	Total            int
+	Pending          int
+	Completed        int
+	Failed           int
+	Cancelled        int
+	AwaitingApproval int
+}
+
+/* Done tells whether no item is waiting or running anymore */
+func (p TransferBatchProgress) Done() bool {
+	return p.Pending == 0 && p.Completed+p.Failed+p.Cancelled+p.AwaitingApproval == p.Total
+}
+
+type TransferBatchRowError struct {
+	Row     int
+	Message string
+}
+
+/* TransferBatchValidationError lists every invalid row, nothing of the batch is stored */
+type TransferBatchValidationError struct {
+	Rows []TransferBatchRowError
+}
+
+func (e *TransferBatchValidationError) Error() string {
+	messages := make([]string, 0, len(e.Rows))
+	for _, row := range e.Rows {
+		messages = append(messages, fmt.Sprintf("row %d: %s", row.Row, row.Message))
+	}
+	return "invalid batch: " + strings.Join(messages, "; ")
+}
+
+func (e *TransferBatchValidationError) Add(row int, message string) {
+	e.Rows = append(e.Rows, TransferBatchRowError{Row: row, Message: message})
+}
+
+/* Validate checks every item, so one upload reports all its bad rows at once */
+func (b *TransferBatch) Validate() error {
+	validationError := &TransferBatchValidationError{}
+	if !b.Currency.IsSupported() {
+		validationError.Add(0, fmt.Sprintf("unsupported currency %q", b.Currency))
+	}
+	if len(b.Items) == 0 {
+		validationError.Add(0, "batch has no items")
+	}
+	if len(b.Items) > MaxTransferBatchItems {
+		validationError.Add(0, fmt.Sprintf("batch cannot have more than %d items", MaxTransferBatchItems))
+	}
+
+	for _, item := range b.Items {
+		switch {
+		case item.ToUser <= 0:
+			validationError.Add(item.Row, "to_user is required")
+		case item.ToUser == b.FromUser:
+			validationError.Add(item.Row, "transfer cannot be to the same user")
+		case item.Amount.Currency() != b.Currency:
+			validationError.Add(item.Row, ErrCurrencyMismatch.Error())
+		case !item.Amount.IsPositive():
+			validationError.Add(item.Row, "amount must be greater than zero")
+		case len(item.Reference) > 255:
+			validationError.Add(item.Row, "reference cannot be longer than 255 characters")
+		}
+	}
+
+	if len(validationError.Rows) > 0 {
+		return validationError
+	}
+	return nil
+}
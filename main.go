@@ -10,6 +10,7 @@ import (
 
 	"github.com/denizdoganinsider/kpi_project/common/app"
 	"github.com/denizdoganinsider/kpi_project/common/mysql"
+	"github.com/denizdoganinsider/kpi_project/common/scheduler"
 	"github.com/denizdoganinsider/kpi_project/controller"
 	"github.com/denizdoganinsider/kpi_project/controller/response"
 	"github.com/denizdoganinsider/kpi_project/persistence"
@@ -30,7 +31,7 @@ func main() {
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"http://127.0.0.1:8080"},
 		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, controller.HeaderIdempotencyKey},
 	}))
 
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -84,21 +85,144 @@ func main() {
 	userController := controller.NewUserController(userService)
 	authController := controller.NewAuthController(userService)
 
-	// Balance repository and service setup
+	// Idempotency repository and middleware setup for money-moving endpoints
+	idempotencyRepository := persistence.NewIdempotencyRepository(db)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepository, configurationManager.IdempotencyConfig)
+	idempotencyMiddleware := controller.NewIdempotencyMiddleware(idempotencyService)
+
+	// Unit of work shared by every service that moves money
+	unitOfWork := persistence.NewUnitOfWork(db)
+
+	// Balance repository and service setup, past balances are replayed from daily snapshots and the ledger
 	balanceRepository := persistence.NewBalanceRepository(db)
-	balanceService := service.NewBalanceService(balanceRepository)
-	balanceController := controller.NewBalanceController(balanceService)
+	pocketRepository := persistence.NewPocketRepository(db)
+	balanceService := service.NewBalanceService(balanceRepository, pocketRepository, unitOfWork)
+	ledgerRepository := persistence.NewLedgerRepository(db)
+	balanceSnapshotRepository := persistence.NewBalanceSnapshotRepository(db)
+	balanceSnapshotService := service.NewBalanceSnapshotService(balanceSnapshotRepository, ledgerRepository)
+	balanceController := controller.NewBalanceController(balanceService, balanceSnapshotService, idempotencyMiddleware)
+
+	// Overdraft repository and service setup, balances may go below zero down to the user's credit limit
+	overdraftRepository := persistence.NewOverdraftRepository(db)
+	overdraftService := service.NewOverdraftService(overdraftRepository, balanceRepository, unitOfWork)
+	overdraftController := controller.NewOverdraftController(overdraftService)
+
+	// Limit repository and service setup, limits are checked by every debit and transfer
+	limitRepository := persistence.NewLimitRepository(db)
+	limitService := service.NewLimitService(limitRepository, userRepository)
+	limitController := controller.NewLimitController(limitService)
+
+	// Fee repository and service setup, fees are charged on every debit and transfer
+	feeRepository := persistence.NewFeeRepository(db)
+	feeService := service.NewFeeService(feeRepository, userRepository)
+	feeController := controller.NewFeeController(feeService)
+
+	// Risk repository and service setup, every credit, debit and transfer is screened by the configured rules
+	riskRepository := persistence.NewRiskRepository(db)
+	riskService := service.NewRiskService(riskRepository, nil)
+	if rulesFile := configurationManager.RiskConfig.RulesFile; rulesFile != "" {
+		loadedRules, err := riskService.LoadRulesFromFile(rulesFile)
+		if err != nil {
+			log.Fatalf("Error loading risk rules: %v", err)
+		}
+		log.Printf("%d risk rules loaded from %s", loadedRules, rulesFile)
+	}
+	riskController := controller.NewRiskController(riskService)
 
 	// Transaction repository and service setup
 	transactionRepository := persistence.NewTransactionRepository(db)
-	transactionService := service.NewTransactionService(transactionRepository, balanceRepository)
-	transactionController := controller.NewTransactionController(transactionService)
-
-	// Register routes for user, auth, balance, and transaction
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, riskService, configurationManager.ApprovalConfig)
+
+	// Statement service setup, statements are read from transactions and balances
+	statementService := service.NewStatementService(transactionRepository, ledgerRepository)
+	statementController := controller.NewStatementController(statementService)
+
+	// Scheduled transfer repository and service setup
+	scheduledTransferRepository := persistence.NewScheduledTransferRepository(db)
+	scheduledTransferService := service.NewScheduledTransferService(scheduledTransferRepository, transactionService, configurationManager.ScheduledTransferConfig)
+	scheduledTransferController := controller.NewScheduledTransferController(scheduledTransferService)
+	transactionController := controller.NewTransactionController(transactionService, scheduledTransferService, idempotencyMiddleware)
+
+	// Savings goal repository and service setup, triggered sweeps run within the transaction's unit of work
+	savingsGoalRepository := persistence.NewSavingsGoalRepository(db)
+	savingsGoalService := service.NewSavingsGoalService(savingsGoalRepository, unitOfWork)
+	savingsGoalController := controller.NewSavingsGoalController(savingsGoalService, idempotencyMiddleware)
+
+	// Transfer batch repository and service setup, items run through the transaction service
+	transferBatchRepository := persistence.NewTransferBatchRepository(db)
+	transferBatchService := service.NewTransferBatchService(transferBatchRepository, userRepository, transactionService, configurationManager.TransferBatchConfig)
+	transferBatchController := controller.NewTransferBatchController(transferBatchService, idempotencyMiddleware)
+
+	// Dispute repository and service setup, won disputes are reversed within the dispute's unit of work
+	disputeRepository := persistence.NewDisputeRepository(db)
+	disputeService := service.NewDisputeService(disputeRepository, unitOfWork)
+	disputeController := controller.NewDisputeController(disputeService, idempotencyMiddleware)
+
+	// Transfer approval repository and service setup, approved transfers are settled by the transaction service
+	approvalRepository := persistence.NewApprovalRepository(db)
+	approvalService := service.NewApprovalService(approvalRepository, userRepository, unitOfWork, transactionService)
+	approvalController := controller.NewApprovalController(approvalService, idempotencyMiddleware)
+
+	// Authorization hold repository and service setup, captures are settled by the transaction service
+	holdRepository := persistence.NewHoldRepository(db)
+	holdService := service.NewHoldService(holdRepository, unitOfWork, transactionService, configurationManager.HoldConfig.TTL)
+	holdController := controller.NewHoldController(holdService, idempotencyMiddleware)
+
+	// Standing order repository and service setup
+	standingOrderRepository := persistence.NewStandingOrderRepository(db)
+	standingOrderService := service.NewStandingOrderService(standingOrderRepository, transactionService, configurationManager.StandingOrderConfig)
+	standingOrderController := controller.NewStandingOrderController(standingOrderService, idempotencyMiddleware)
+
+	// Ledger service setup
+	ledgerService := service.NewLedgerService(ledgerRepository, balanceRepository)
+	ledgerController := controller.NewLedgerController(ledgerService)
+
+	// FX repository and service setup, rates are seeded from the configured file
+	fxRepository := persistence.NewFxRepository(db)
+	fxService := service.NewFxService(fxRepository, unitOfWork, configurationManager.FxConfig.QuoteTTL)
+	if ratesFile := configurationManager.FxConfig.RatesFile; ratesFile != "" {
+		loadedRates, err := fxService.LoadRatesFromFile(ratesFile)
+		if err != nil {
+			log.Fatalf("Error loading fx rates: %v", err)
+		}
+		log.Printf("%d fx rates loaded from %s", loadedRates, ratesFile)
+	}
+	fxController := controller.NewFxController(fxService, idempotencyMiddleware)
+
+	// Background jobs
+	backgroundScheduler := scheduler.NewScheduler()
+	backgroundScheduler.Register("scheduled-transfers", configurationManager.SchedulerConfig.PollInterval, scheduledTransferService.ExecuteDue)
+	backgroundScheduler.Register("standing-orders", configurationManager.SchedulerConfig.PollInterval, standingOrderService.ExecuteDue)
+	backgroundScheduler.Register("transfer-batches", configurationManager.SchedulerConfig.PollInterval, transferBatchService.ProcessPending)
+	backgroundScheduler.Register("expired-holds", configurationManager.SchedulerConfig.PollInterval, holdService.ExpireHolds)
+	backgroundScheduler.Register("expired-approvals", configurationManager.SchedulerConfig.PollInterval, approvalService.ExpireApprovals)
+	backgroundScheduler.Register("balance-snapshots", configurationManager.SchedulerConfig.PollInterval, balanceSnapshotService.SnapshotBalances)
+	backgroundScheduler.Register("overdraft-charges", configurationManager.SchedulerConfig.PollInterval, overdraftService.ChargeOverdrafts)
+	backgroundScheduler.Register("expired-idempotency-keys", configurationManager.SchedulerConfig.PollInterval, idempotencyService.PurgeExpired)
+	backgroundScheduler.Register("savings-sweeps", configurationManager.SchedulerConfig.PollInterval, savingsGoalService.ExecuteDueSweeps)
+
+	// Register routes of every controller
 	userController.RegisterRoutes(e)
 	authController.RegisterRoutes(e)
 	balanceController.RegisterRoutes(e)
 	transactionController.RegisterRoutes(e)
+	statementController.RegisterRoutes(e)
+	scheduledTransferController.RegisterRoutes(e)
+	transferBatchController.RegisterRoutes(e)
+	approvalController.RegisterRoutes(e)
+	disputeController.RegisterRoutes(e)
+	holdController.RegisterRoutes(e)
+	overdraftController.RegisterRoutes(e)
+	savingsGoalController.RegisterRoutes(e)
+	limitController.RegisterRoutes(e)
+	feeController.RegisterRoutes(e)
+	riskController.RegisterRoutes(e)
+	standingOrderController.RegisterRoutes(e)
+	ledgerController.RegisterRoutes(e)
+	fxController.RegisterRoutes(e)
+
+	// Start background jobs
+	backgroundScheduler.Start(ctx)
 
 	// Graceful shutdown handling
 	sigs := make(chan os.Signal, 1)
@@ -120,6 +244,9 @@ func main() {
 		log.Fatalf("Server shutdown failed: %v", err)
 	}
 
+	// Let running jobs finish before the database goes away
+	backgroundScheduler.Stop()
+
 	// Close database connection gracefully on exit
 	if err := db.Close(); err != nil {
 		log.Fatalf("Error closing database: %v", err)
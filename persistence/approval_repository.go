@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrApprovalNotFound = errors.New("approval not found")
+
+const approvalColumns = `transaction_id, maker_id, checker_id, status, note, expires_at, decided_at, created_at`
+
+type IApprovalRepository interface {
+	CreateApproval(approval *domain.TransferApproval) error
+	GetApprovalByTransactionID(transactionID int64) (*domain.TransferApproval, error)
+	GetApprovalByTransactionIDForUpdate(transactionID int64) (*domain.TransferApproval, error)
+	GetApprovalsByStatus(status domain.ApprovalStatus) ([]domain.TransferApproval, error)
+	GetExpiredApprovals(now time.Time, limit int) ([]domain.TransferApproval, error)
+	UpdateApproval(approval *domain.TransferApproval) error
+}
+
+type ApprovalRepository struct {
+	db DBTX
+}
+
+func NewApprovalRepository(db *sql.DB) IApprovalRepository {
+	return &ApprovalRepository{db: db}
+}
+
+func (repo *ApprovalRepository) CreateApproval(approval *domain.TransferApproval) error {
+	query := `INSERT INTO transfer_approvals (transaction_id, maker_id, status, note, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := repo.db.Exec(query, approval.TransactionID, approval.MakerID, approval.Status, approval.Note, approval.ExpiresAt, approval.CreatedAt)
+	return err
+}
+
+func (repo *ApprovalRepository) GetApprovalByTransactionID(transactionID int64) (*domain.TransferApproval, error) {
+	return repo.getApproval(`SELECT `+approvalColumns+` FROM transfer_approvals WHERE transaction_id = ?`, transactionID)
+}
+
+/* GetApprovalByTransactionIDForUpdate locks the approval until the surrounding unit of work ends */
+func (repo *ApprovalRepository) GetApprovalByTransactionIDForUpdate(transactionID int64) (*domain.TransferApproval, error) {
+	return repo.getApproval(`SELECT `+approvalColumns+` FROM transfer_approvals WHERE transaction_id = ? FOR UPDATE`, transactionID)
+}
+
+/* GetApprovalsByStatus returns the approvals with status, oldest first */
+func (repo *ApprovalRepository) GetApprovalsByStatus(status domain.ApprovalStatus) ([]domain.TransferApproval, error) {
+	query := `SELECT ` + approvalColumns + ` FROM transfer_approvals WHERE status = ? ORDER BY created_at, transaction_id`
+	return repo.getApprovals(query, status)
+}
+
+/* GetExpiredApprovals returns pending approvals whose TTL has run out */
+func (repo *ApprovalRepository) GetExpiredApprovals(now time.Time, limit int) ([]domain.TransferApproval, error) {
+	query := `SELECT ` + approvalColumns + ` FROM transfer_approvals WHERE status = ? AND expires_at <= ? ORDER BY expires_at LIMIT ?`
+	return repo.getApprovals(query, domain.ApprovalPending, now, limit)
+}
+
+func (repo *ApprovalRepository) UpdateApproval(approval *domain.TransferApproval) error {
+	var checkerID sql.NullInt64
+	if approval.CheckerID != nil {
+		checkerID.Int64 = *approval.CheckerID
+		checkerID.Valid = true
+	}
+
+	query := `UPDATE transfer_approvals SET checker_id = ?, status = ?, note = ?, decided_at = ? WHERE transaction_id = ?`
+	_, err := repo.db.Exec(query, checkerID, approval.Status, approval.Note, approval.DecidedAt, approval.TransactionID)
+	return err
+}
+
+func (repo *ApprovalRepository) getApproval(query string, transactionID int64) (*domain.TransferApproval, error) {
+	approval, err := scanApproval(repo.db.QueryRow(query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrApprovalNotFound
+		}
+		return nil, err
+	}
+	return approval, nil
+}
+
+func (repo *ApprovalRepository) getApprovals(query string, args ...any) ([]domain.TransferApproval, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var approvals []domain.TransferApproval
+
+	for rows.Next() {
+		approval, err := scanApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, *approval)
+	}
+
+	return approvals, rows.Err()
+}
+
+func scanApproval(scanner rowScanner) (*domain.TransferApproval, error) {
+	var approval domain.TransferApproval
+	var checkerID sql.NullInt64
+	var decidedAt sql.NullTime
+
+	err := scanner.Scan(&approval.TransactionID, &approval.MakerID, &checkerID, &approval.Status, &approval.Note, &approval.ExpiresAt, &decidedAt, &approval.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkerID.Valid {
+		approval.CheckerID = &checkerID.Int64
+	}
+	if decidedAt.Valid {
+		approval.DecidedAt = &decidedAt.Time
+	}
+
+	return &approval, nil
+}
@@ -2,58 +2,199 @@ package persistence
 
 import (
 	"database/sql"
-	"fmt"
+	"errors"
 
 	"github.com/denizdoganinsider/kpi_project/domain"
 )
 
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrBalanceNotFound = errors.New("user doesn't have balance")
+	ErrBalanceConflict = errors.New("balance was changed concurrently")
+)
+
+/* balanceSelect reads a balance with the credit limit of its overdraft facility, zero without one */
+const balanceSelect = `SELECT b.user_id, b.currency, b.amount, b.held_amount, b.pocketed_amount, COALESCE(o.credit_limit, 0), b.version, b.last_updated_at
+	FROM balances b LEFT JOIN overdraft_facilities o ON o.user_id = b.user_id AND o.currency = b.currency`
+
 type IBalanceRepository interface {
-	GetBalanceByUserID(userID int64) (*domain.Balance, error)
-	UpdateBalance(userID int64, amount float64) error
-	CreateBalance(userID int64, amount float64) error
+	GetBalanceByUserID(userID int64, currency domain.Currency) (*domain.Balance, error)
+	GetBalanceByUserIDForUpdate(userID int64, currency domain.Currency) (*domain.Balance, error)
+	GetBalancesByUserID(userID int64) ([]domain.Balance, error)
+	UpdateBalance(balance *domain.Balance, amount domain.Money) error
+	CreateBalance(userID int64, amount domain.Money) error
+	UpdateHeldAmount(balance *domain.Balance, heldAmount domain.Money) error
+	UpdatePocketedAmount(balance *domain.Balance, pocketedAmount domain.Money) error
 }
 
 type BalanceRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewBalanceRepository(db *sql.DB) IBalanceRepository {
 	return &BalanceRepository{db: db}
 }
 
-func (balanceRepository *BalanceRepository) GetBalanceByUserID(userID int64) (*domain.Balance, error) {
-	var count int
-	doesUserExistsQuery := `SELECT COUNT(*) FROM users WHERE id = ?`
-	errForUserExistence := balanceRepository.db.QueryRow(doesUserExistsQuery, userID).Scan(&count)
-	if errForUserExistence != nil {
-		return nil, errForUserExistence
-	} else if count == 0 {
-		return nil, fmt.Errorf("user not found")
+func (balanceRepository *BalanceRepository) GetBalanceByUserID(userID int64, currency domain.Currency) (*domain.Balance, error) {
+	return balanceRepository.getBalance(userID, currency, balanceSelect+` WHERE b.user_id = ? AND b.currency = ?`)
+}
+
+/*
+GetBalanceByUserIDForUpdate locks the balance row, and its overdraft facility
+if there is one, until the surrounding unit of work finishes, it only makes
+sense inside IUnitOfWork.Execute
+*/
+func (balanceRepository *BalanceRepository) GetBalanceByUserIDForUpdate(userID int64, currency domain.Currency) (*domain.Balance, error) {
+	return balanceRepository.getBalance(userID, currency, balanceSelect+` WHERE b.user_id = ? AND b.currency = ? FOR UPDATE`)
+}
+
+/* GetBalancesByUserID returns every wallet of the user, one per currency */
+func (balanceRepository *BalanceRepository) GetBalancesByUserID(userID int64) ([]domain.Balance, error) {
+	if err := balanceRepository.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	query := balanceSelect + ` WHERE b.user_id = ? ORDER BY b.currency`
+	rows, err := balanceRepository.db.Query(query, userID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	query := `SELECT user_id, amount, last_updated_at FROM balances WHERE user_id = ?`
-	row := balanceRepository.db.QueryRow(query, userID)
+	var balances []domain.Balance
 
-	var balance domain.Balance
-	err := row.Scan(&balance.UserID, &balance.Amount, &balance.LastUpdatedAt)
+	for rows.Next() {
+		balance, err := scanBalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, *balance)
+	}
+
+	return balances, rows.Err()
+}
+
+/*
+UpdateBalance writes amount only if the balance is still at the version it
+was read with, otherwise ErrBalanceConflict is returned and nothing changes.
+On success balance carries the new amount and version.
+*/
+func (balanceRepository *BalanceRepository) UpdateBalance(balance *domain.Balance, amount domain.Money) error {
+	query := `UPDATE balances SET amount = ?, version = version + 1, last_updated_at = NOW() WHERE user_id = ? AND currency = ? AND version = ?`
+	if err := balanceRepository.compareAndSwap(query, amount, balance.UserID, amount.Currency(), balance.Version); err != nil {
+		return err
+	}
+
+	balance.Amount = amount
+	balance.Version++
+	return nil
+}
+
+/* CreateBalance returns ErrBalanceConflict when the wallet was opened concurrently */
+func (balanceRepository *BalanceRepository) CreateBalance(userID int64, amount domain.Money) error {
+	query := `INSERT INTO balances (user_id, currency, amount, last_updated_at) VALUES (?, ?, ?, NOW())`
+	_, err := balanceRepository.db.Exec(query, userID, amount.Currency(), amount)
+	if isDuplicateEntry(err) {
+		return ErrBalanceConflict
+	}
+	return err
+}
+
+/* UpdateHeldAmount sets how much of the balance authorization holds reserve, in the same way as UpdateBalance */
+func (balanceRepository *BalanceRepository) UpdateHeldAmount(balance *domain.Balance, heldAmount domain.Money) error {
+	query := `UPDATE balances SET held_amount = ?, version = version + 1, last_updated_at = NOW() WHERE user_id = ? AND currency = ? AND version = ?`
+	if err := balanceRepository.compareAndSwap(query, heldAmount, balance.UserID, heldAmount.Currency(), balance.Version); err != nil {
+		return err
+	}
+
+	balance.HeldAmount = heldAmount
+	balance.Version++
+	return nil
+}
+
+/* UpdatePocketedAmount sets how much of the balance is set aside in pockets, in the same way as UpdateBalance */
+func (balanceRepository *BalanceRepository) UpdatePocketedAmount(balance *domain.Balance, pocketedAmount domain.Money) error {
+	query := `UPDATE balances SET pocketed_amount = ?, version = version + 1, last_updated_at = NOW() WHERE user_id = ? AND currency = ? AND version = ?`
+	if err := balanceRepository.compareAndSwap(query, pocketedAmount, balance.UserID, pocketedAmount.Currency(), balance.Version); err != nil {
+		return err
+	}
+
+	balance.PocketedAmount = pocketedAmount
+	balance.Version++
+	return nil
+}
+
+func (balanceRepository *BalanceRepository) compareAndSwap(query string, args ...any) error {
+	result, err := balanceRepository.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrBalanceConflict
+	}
+	return nil
+}
+
+func (balanceRepository *BalanceRepository) getBalance(userID int64, currency domain.Currency, query string) (*domain.Balance, error) {
+	if err := balanceRepository.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	row := balanceRepository.db.QueryRow(query, userID, currency)
+
+	balance, err := scanBalance(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user doesn't have balance")
+			return nil, ErrBalanceNotFound
 		}
 		return nil, err
 	}
 
-	return &balance, nil
+	return balance, nil
 }
 
-func (balanceRepository *BalanceRepository) UpdateBalance(userID int64, amount float64) error {
-	query := `UPDATE balances SET amount = ?, last_updated_at = NOW() WHERE user_id = ?`
-	_, err := balanceRepository.db.Exec(query, amount, userID)
-	return err
+func (balanceRepository *BalanceRepository) checkUserExists(userID int64) error {
+	var count int
+	doesUserExistsQuery := `SELECT COUNT(*) FROM users WHERE id = ?`
+	errForUserExistence := balanceRepository.db.QueryRow(doesUserExistsQuery, userID).Scan(&count)
+	if errForUserExistence != nil {
+		return errForUserExistence
+	} else if count == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
 
-func (balanceRepository *BalanceRepository) CreateBalance(userID int64, amount float64) error {
-	query := `INSERT INTO balances (user_id, amount, last_updated_at) VALUES (?, ?, NOW())`
-	_, err := balanceRepository.db.Exec(query, userID, amount)
-	return err
+func scanBalance(scanner rowScanner) (*domain.Balance, error) {
+	var balance domain.Balance
+	var amount, heldAmount, pocketedAmount, creditLimit string
+
+	err := scanner.Scan(&balance.UserID, &balance.Currency, &amount, &heldAmount, &pocketedAmount, &creditLimit, &balance.Version, &balance.LastUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	balance.Amount, err = domain.ParseMoney(amount, balance.Currency)
+	if err != nil {
+		return nil, err
+	}
+	balance.HeldAmount, err = domain.ParseMoney(heldAmount, balance.Currency)
+	if err != nil {
+		return nil, err
+	}
+	balance.PocketedAmount, err = domain.ParseMoney(pocketedAmount, balance.Currency)
+	if err != nil {
+		return nil, err
+	}
+	balance.CreditLimit, err = domain.ParseMoney(creditLimit, balance.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
 }
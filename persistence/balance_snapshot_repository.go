@@ -0,0 +1,85 @@
+package persistence
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+const balanceSnapshotColumns = `user_id, currency, snapshot_date, amount, created_at`
+
+type IBalanceSnapshotRepository interface {
+	SaveSnapshot(snapshot *domain.BalanceSnapshot) error
+	GetLatestSnapshot(userID int64, currency domain.Currency, onOrBefore time.Time) (*domain.BalanceSnapshot, error)
+	GetSnapshotsByDate(date time.Time) ([]domain.BalanceSnapshot, error)
+}
+
+type BalanceSnapshotRepository struct {
+	db DBTX
+}
+
+func NewBalanceSnapshotRepository(db *sql.DB) IBalanceSnapshotRepository {
+	return &BalanceSnapshotRepository{db: db}
+}
+
+/* SaveSnapshot overwrites an earlier snapshot of the same day, so backfills can be rerun */
+func (repo *BalanceSnapshotRepository) SaveSnapshot(snapshot *domain.BalanceSnapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+
+	query := `INSERT INTO balance_snapshots (` + balanceSnapshotColumns + `) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE amount = VALUES(amount), created_at = VALUES(created_at)`
+	_, err := repo.db.Exec(query, snapshot.UserID, snapshot.Currency, snapshot.Date.Format(time.DateOnly), snapshot.Amount, snapshot.CreatedAt)
+	return err
+}
+
+/* GetLatestSnapshot returns the newest snapshot of the wallet up to the given day, nil when there is none */
+func (repo *BalanceSnapshotRepository) GetLatestSnapshot(userID int64, currency domain.Currency, onOrBefore time.Time) (*domain.BalanceSnapshot, error) {
+	query := `SELECT ` + balanceSnapshotColumns + ` FROM balance_snapshots
+		WHERE user_id = ? AND currency = ? AND snapshot_date <= ? ORDER BY snapshot_date DESC LIMIT 1`
+
+	snapshot, err := scanBalanceSnapshot(repo.db.QueryRow(query, userID, currency, onOrBefore.Format(time.DateOnly)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return snapshot, err
+}
+
+func (repo *BalanceSnapshotRepository) GetSnapshotsByDate(date time.Time) ([]domain.BalanceSnapshot, error) {
+	query := `SELECT ` + balanceSnapshotColumns + ` FROM balance_snapshots WHERE snapshot_date = ? ORDER BY user_id, currency`
+	rows, err := repo.db.Query(query, date.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []domain.BalanceSnapshot
+
+	for rows.Next() {
+		snapshot, err := scanBalanceSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func scanBalanceSnapshot(scanner rowScanner) (*domain.BalanceSnapshot, error) {
+	var snapshot domain.BalanceSnapshot
+	var amount string
+	if err := scanner.Scan(&snapshot.UserID, &snapshot.Currency, &snapshot.Date, &amount, &snapshot.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if snapshot.Amount, err = domain.ParseMoney(amount, snapshot.Currency); err != nil {
+		return nil, err
+	}
+	/* DATE comes back as midnight in the connection's location, the day is what counts */
+	snapshot.Date = time.Date(snapshot.Date.Year(), snapshot.Date.Month(), snapshot.Date.Day(), 0, 0, 0, 0, time.UTC)
+	return &snapshot, nil
+}
@@ -0,0 +1,164 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrDisputeNotFound = errors.New("dispute not found")
+
+const disputeColumns = `id, transaction_id, claimant_id, reason, evidence, status, provisional_credit_id, reversal_id, created_at, updated_at`
+
+type IDisputeRepository interface {
+	CreateDispute(dispute *domain.Dispute) error
+	GetDisputeByID(id int64) (*domain.Dispute, error)
+	GetDisputeByIDForUpdate(id int64) (*domain.Dispute, error)
+	GetDisputesByTransactionID(transactionID int64) ([]domain.Dispute, error)
+	GetDisputesByStatus(status domain.DisputeStatus) ([]domain.Dispute, error)
+	UpdateDispute(dispute *domain.Dispute) error
+	CreateDisputeEvent(event *domain.DisputeEvent) error
+	GetDisputeEvents(disputeID int64) ([]domain.DisputeEvent, error)
+}
+
+type DisputeRepository struct {
+	db DBTX
+}
+
+func NewDisputeRepository(db *sql.DB) IDisputeRepository {
+	return &DisputeRepository{db: db}
+}
+
+func (repo *DisputeRepository) CreateDispute(dispute *domain.Dispute) error {
+	query := `INSERT INTO disputes (transaction_id, claimant_id, reason, evidence, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, dispute.TransactionID, dispute.ClaimantID, dispute.Reason, dispute.Evidence, dispute.Status, dispute.CreatedAt, dispute.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	dispute.ID = id
+	return nil
+}
+
+func (repo *DisputeRepository) GetDisputeByID(id int64) (*domain.Dispute, error) {
+	return repo.getDispute(`SELECT `+disputeColumns+` FROM disputes WHERE id = ?`, id)
+}
+
+/* GetDisputeByIDForUpdate locks the dispute until the surrounding unit of work ends */
+func (repo *DisputeRepository) GetDisputeByIDForUpdate(id int64) (*domain.Dispute, error) {
+	return repo.getDispute(`SELECT `+disputeColumns+` FROM disputes WHERE id = ? FOR UPDATE`, id)
+}
+
+func (repo *DisputeRepository) GetDisputesByTransactionID(transactionID int64) ([]domain.Dispute, error) {
+	return repo.getDisputes(`SELECT `+disputeColumns+` FROM disputes WHERE transaction_id = ? ORDER BY id`, transactionID)
+}
+
+/* GetDisputesByStatus returns the disputes with status, oldest first */
+func (repo *DisputeRepository) GetDisputesByStatus(status domain.DisputeStatus) ([]domain.Dispute, error) {
+	return repo.getDisputes(`SELECT `+disputeColumns+` FROM disputes WHERE status = ? ORDER BY id`, status)
+}
+
+func (repo *DisputeRepository) UpdateDispute(dispute *domain.Dispute) error {
+	query := `UPDATE disputes SET status = ?, provisional_credit_id = ?, reversal_id = ?, updated_at = ? WHERE id = ?`
+	_, err := repo.db.Exec(query, dispute.Status, dispute.ProvisionalCreditID, dispute.ReversalID, dispute.UpdatedAt, dispute.ID)
+	return err
+}
+
+func (repo *DisputeRepository) CreateDisputeEvent(event *domain.DisputeEvent) error {
+	query := `INSERT INTO dispute_events (dispute_id, action, status, actor_id, note, transaction_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, event.DisputeID, event.Action, event.Status, event.ActorID, event.Note, event.TransactionID, event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	return nil
+}
+
+/* GetDisputeEvents returns the audit trail of a dispute, oldest first */
+func (repo *DisputeRepository) GetDisputeEvents(disputeID int64) ([]domain.DisputeEvent, error) {
+	query := `SELECT id, dispute_id, action, status, actor_id, note, transaction_id, created_at FROM dispute_events WHERE dispute_id = ? ORDER BY id`
+	rows, err := repo.db.Query(query, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.DisputeEvent
+
+	for rows.Next() {
+		var event domain.DisputeEvent
+		var transactionID sql.NullInt64
+		if err := rows.Scan(&event.ID, &event.DisputeID, &event.Action, &event.Status, &event.ActorID, &event.Note, &transactionID, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if transactionID.Valid {
+			event.TransactionID = &transactionID.Int64
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (repo *DisputeRepository) getDispute(query string, id int64) (*domain.Dispute, error) {
+	dispute, err := scanDispute(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, err
+	}
+	return dispute, nil
+}
+
+func (repo *DisputeRepository) getDisputes(query string, args ...any) ([]domain.Dispute, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disputes []domain.Dispute
+
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, *dispute)
+	}
+
+	return disputes, rows.Err()
+}
+
+func scanDispute(scanner rowScanner) (*domain.Dispute, error) {
+	var dispute domain.Dispute
+	var provisionalCreditID, reversalID sql.NullInt64
+
+	err := scanner.Scan(&dispute.ID, &dispute.TransactionID, &dispute.ClaimantID, &dispute.Reason, &dispute.Evidence, &dispute.Status,
+		&provisionalCreditID, &reversalID, &dispute.CreatedAt, &dispute.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if provisionalCreditID.Valid {
+		dispute.ProvisionalCreditID = &provisionalCreditID.Int64
+	}
+	if reversalID.Valid {
+		dispute.ReversalID = &reversalID.Int64
+	}
+
+	return &dispute, nil
+}
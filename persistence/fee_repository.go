@@ -0,0 +1,225 @@
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var (
+	ErrFeeScheduleNotFound = errors.New("fee schedule not found")
+	ErrFeeScheduleExists   = errors.New("a fee schedule already exists for this transaction type, currency and role")
+)
+
+const feeScheduleColumns = `id, transaction_type, currency, role, type, flat_amount, percentage_bps, tiers, min_fee, max_fee, created_at, updated_at`
+
+type IFeeRepository interface {
+	CreateFeeSchedule(feeSchedule *domain.FeeSchedule) error
+	GetFeeSchedules() ([]domain.FeeSchedule, error)
+	GetFeeScheduleByID(id int64) (*domain.FeeSchedule, error)
+	FindFeeSchedule(transactionType domain.TransactionType, currency domain.Currency, role string) (*domain.FeeSchedule, error)
+	UpdateFeeSchedule(feeSchedule *domain.FeeSchedule) error
+	DeleteFeeSchedule(id int64) error
+}
+
+type FeeRepository struct {
+	db DBTX
+}
+
+func NewFeeRepository(db *sql.DB) IFeeRepository {
+	return &FeeRepository{db: db}
+}
+
+/* feeTierRow is how a tier is stored in the tiers JSON column */
+type feeTierRow struct {
+	UpTo          *string `json:"up_to"`
+	FlatAmount    string  `json:"flat_amount"`
+	PercentageBps int64   `json:"percentage_bps"`
+}
+
+func (repo *FeeRepository) CreateFeeSchedule(feeSchedule *domain.FeeSchedule) error {
+	args, err := feeScheduleArgs(feeSchedule)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO fee_schedules (transaction_type, currency, role, type, flat_amount, percentage_bps, tiers, min_fee, max_fee, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, args...)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ErrFeeScheduleExists
+		}
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	feeSchedule.ID = id
+	return nil
+}
+
+func (repo *FeeRepository) GetFeeSchedules() ([]domain.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules ORDER BY id`
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeSchedules []domain.FeeSchedule
+
+	for rows.Next() {
+		feeSchedule, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		feeSchedules = append(feeSchedules, *feeSchedule)
+	}
+
+	return feeSchedules, rows.Err()
+}
+
+func (repo *FeeRepository) GetFeeScheduleByID(id int64) (*domain.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules WHERE id = ?`
+
+	feeSchedule, err := scanFeeSchedule(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrFeeScheduleNotFound
+		}
+		return nil, err
+	}
+	return feeSchedule, nil
+}
+
+/* FindFeeSchedule prefers the schedule of the role over the default one, nil means no fee */
+func (repo *FeeRepository) FindFeeSchedule(transactionType domain.TransactionType, currency domain.Currency, role string) (*domain.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules
+		WHERE transaction_type = ? AND currency = ? AND role IN (?, '')
+		ORDER BY role = '' LIMIT 1`
+
+	feeSchedule, err := scanFeeSchedule(repo.db.QueryRow(query, transactionType, currency, role))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return feeSchedule, nil
+}
+
+func (repo *FeeRepository) UpdateFeeSchedule(feeSchedule *domain.FeeSchedule) error {
+	args, err := feeScheduleArgs(feeSchedule)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE fee_schedules SET transaction_type = ?, currency = ?, role = ?, type = ?, flat_amount = ?, percentage_bps = ?, tiers = ?,
+		min_fee = ?, max_fee = ?, created_at = ?, updated_at = ? WHERE id = ?`
+	result, err := repo.db.Exec(query, append(args, feeSchedule.ID)...)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ErrFeeScheduleExists
+		}
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrFeeScheduleNotFound
+	}
+	return nil
+}
+
+func (repo *FeeRepository) DeleteFeeSchedule(id int64) error {
+	result, err := repo.db.Exec(`DELETE FROM fee_schedules WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrFeeScheduleNotFound
+	}
+	return nil
+}
+
+func feeScheduleArgs(feeSchedule *domain.FeeSchedule) ([]any, error) {
+	var tiers sql.NullString
+	if len(feeSchedule.Tiers) > 0 {
+		tierRows := make([]feeTierRow, 0, len(feeSchedule.Tiers))
+		for _, tier := range feeSchedule.Tiers {
+			tierRow := feeTierRow{FlatAmount: tier.FlatAmount.String(), PercentageBps: tier.PercentageBps}
+			if tier.UpTo != nil {
+				upTo := tier.UpTo.String()
+				tierRow.UpTo = &upTo
+			}
+			tierRows = append(tierRows, tierRow)
+		}
+
+		encoded, err := json.Marshal(tierRows)
+		if err != nil {
+			return nil, err
+		}
+		tiers.String = string(encoded)
+		tiers.Valid = true
+	}
+
+	return []any{feeSchedule.TransactionType, feeSchedule.Currency, feeSchedule.Role, feeSchedule.Type, nullableMoney(feeSchedule.FlatAmount),
+		feeSchedule.PercentageBps, tiers, nullableMoney(feeSchedule.MinFee), nullableMoney(feeSchedule.MaxFee), feeSchedule.CreatedAt, feeSchedule.UpdatedAt}, nil
+}
+
+func scanFeeSchedule(scanner rowScanner) (*domain.FeeSchedule, error) {
+	var feeSchedule domain.FeeSchedule
+	var flatAmount, tiers, minFee, maxFee sql.NullString
+
+	err := scanner.Scan(&feeSchedule.ID, &feeSchedule.TransactionType, &feeSchedule.Currency, &feeSchedule.Role, &feeSchedule.Type, &flatAmount,
+		&feeSchedule.PercentageBps, &tiers, &minFee, &maxFee, &feeSchedule.CreatedAt, &feeSchedule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if feeSchedule.FlatAmount, err = parseNullableMoney(flatAmount, feeSchedule.Currency); err != nil {
+		return nil, err
+	}
+	if feeSchedule.MinFee, err = parseNullableMoney(minFee, feeSchedule.Currency); err != nil {
+		return nil, err
+	}
+	if feeSchedule.MaxFee, err = parseNullableMoney(maxFee, feeSchedule.Currency); err != nil {
+		return nil, err
+	}
+
+	if tiers.Valid {
+		var tierRows []feeTierRow
+		if err := json.Unmarshal([]byte(tiers.String), &tierRows); err != nil {
+			return nil, err
+		}
+
+		for _, tierRow := range tierRows {
+			tier := domain.FeeTier{PercentageBps: tierRow.PercentageBps}
+			if tier.FlatAmount, err = domain.ParseMoney(tierRow.FlatAmount, feeSchedule.Currency); err != nil {
+				return nil, err
+			}
+			if tierRow.UpTo != nil {
+				if tier.UpTo, err = parseNullableMoney(sql.NullString{String: *tierRow.UpTo, Valid: true}, feeSchedule.Currency); err != nil {
+					return nil, err
+				}
+			}
+			feeSchedule.Tiers = append(feeSchedule.Tiers, tier)
+		}
+	}
+
+	return &feeSchedule, nil
+}
@@ -0,0 +1,156 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var (
+	ErrFxRateNotFound  = errors.New("fx rate not found")
+	ErrFxQuoteNotFound = errors.New("fx quote not found")
+)
+
+const fxQuoteColumns = `id, user_id, from_currency, to_currency, sell_amount, buy_amount, mid_rate, rate, spread_bps, status,
+	expires_at, created_at, executed_at, sell_transaction_id, buy_transaction_id`
+
+type IFxRepository interface {
+	UpsertRate(rate domain.FxRate) error
+	GetRate(base domain.Currency, quote domain.Currency) (*domain.FxRate, error)
+	GetRates() ([]domain.FxRate, error)
+	CreateQuote(quote *domain.FxQuote) error
+	GetQuoteByID(id string) (*domain.FxQuote, error)
+	GetQuoteByIDForUpdate(id string) (*domain.FxQuote, error)
+	MarkQuoteExecuted(id string, sellTransactionID int64, buyTransactionID int64, executedAt time.Time) error
+}
+
+type FxRepository struct {
+	db DBTX
+}
+
+func NewFxRepository(db *sql.DB) IFxRepository {
+	return &FxRepository{db: db}
+}
+
+func (repo *FxRepository) UpsertRate(rate domain.FxRate) error {
+	query := `INSERT INTO fx_rates (base_currency, quote_currency, rate, spread_bps, updated_at) VALUES (?, ?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE rate = VALUES(rate), spread_bps = VALUES(spread_bps), updated_at = NOW()`
+	_, err := repo.db.Exec(query, rate.Base, rate.Quote, domain.FormatRate(rate.Rate), rate.SpreadBps)
+	return err
+}
+
+func (repo *FxRepository) GetRate(base domain.Currency, quote domain.Currency) (*domain.FxRate, error) {
+	query := `SELECT base_currency, quote_currency, rate, spread_bps, updated_at FROM fx_rates WHERE base_currency = ? AND quote_currency = ?`
+
+	rate, err := scanFxRate(repo.db.QueryRow(query, base, quote))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrFxRateNotFound
+		}
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (repo *FxRepository) GetRates() ([]domain.FxRate, error) {
+	query := `SELECT base_currency, quote_currency, rate, spread_bps, updated_at FROM fx_rates ORDER BY base_currency, quote_currency`
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []domain.FxRate
+
+	for rows.Next() {
+		rate, err := scanFxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (repo *FxRepository) CreateQuote(quote *domain.FxQuote) error {
+	query := `INSERT INTO fx_quotes (id, user_id, from_currency, to_currency, sell_amount, buy_amount, mid_rate, rate, spread_bps, status, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := repo.db.Exec(query, quote.ID, quote.UserID, quote.SellAmount.Currency(), quote.BuyAmount.Currency(), quote.SellAmount, quote.BuyAmount,
+		domain.FormatRate(quote.MidRate), domain.FormatRate(quote.Rate), quote.SpreadBps, quote.Status, quote.ExpiresAt, quote.CreatedAt)
+	return err
+}
+
+func (repo *FxRepository) GetQuoteByID(id string) (*domain.FxQuote, error) {
+	return repo.getQuote(`SELECT `+fxQuoteColumns+` FROM fx_quotes WHERE id = ?`, id)
+}
+
+/* GetQuoteByIDForUpdate locks the quote so it can be executed only once */
+func (repo *FxRepository) GetQuoteByIDForUpdate(id string) (*domain.FxQuote, error) {
+	return repo.getQuote(`SELECT `+fxQuoteColumns+` FROM fx_quotes WHERE id = ? FOR UPDATE`, id)
+}
+
+func (repo *FxRepository) MarkQuoteExecuted(id string, sellTransactionID int64, buyTransactionID int64, executedAt time.Time) error {
+	query := `UPDATE fx_quotes SET status = ?, sell_transaction_id = ?, buy_transaction_id = ?, executed_at = ? WHERE id = ?`
+	_, err := repo.db.Exec(query, domain.FxQuoteExecuted, sellTransactionID, buyTransactionID, executedAt, id)
+	return err
+}
+
+func (repo *FxRepository) getQuote(query string, id string) (*domain.FxQuote, error) {
+	var quote domain.FxQuote
+	var fromCurrency, toCurrency domain.Currency
+	var sellAmount, buyAmount, midRate, rate string
+	var executedAt sql.NullTime
+	var sellTransactionID, buyTransactionID sql.NullInt64
+
+	err := repo.db.QueryRow(query, id).Scan(&quote.ID, &quote.UserID, &fromCurrency, &toCurrency, &sellAmount, &buyAmount, &midRate, &rate,
+		&quote.SpreadBps, &quote.Status, &quote.ExpiresAt, &quote.CreatedAt, &executedAt, &sellTransactionID, &buyTransactionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrFxQuoteNotFound
+		}
+		return nil, err
+	}
+
+	if quote.SellAmount, err = domain.ParseMoney(sellAmount, fromCurrency); err != nil {
+		return nil, err
+	}
+	if quote.BuyAmount, err = domain.ParseMoney(buyAmount, toCurrency); err != nil {
+		return nil, err
+	}
+	if quote.MidRate, err = domain.ParseRate(midRate); err != nil {
+		return nil, err
+	}
+	if quote.Rate, err = domain.ParseRate(rate); err != nil {
+		return nil, err
+	}
+
+	if executedAt.Valid {
+		quote.ExecutedAt = &executedAt.Time
+	}
+	if sellTransactionID.Valid {
+		quote.SellTransactionID = &sellTransactionID.Int64
+	}
+	if buyTransactionID.Valid {
+		quote.BuyTransactionID = &buyTransactionID.Int64
+	}
+
+	return &quote, nil
+}
+
+func scanFxRate(scanner rowScanner) (*domain.FxRate, error) {
+	var rate domain.FxRate
+	var value string
+
+	err := scanner.Scan(&rate.Base, &rate.Quote, &value, &rate.SpreadBps, &rate.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if rate.Rate, err = domain.ParseRate(value); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
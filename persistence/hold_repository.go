@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrHoldNotFound = errors.New("hold not found")
+
+const holdColumns = `transaction_id, user_id, to_user_id, amount, captured_amount, currency, status, expires_at, created_at, updated_at`
+
+type IHoldRepository interface {
+	CreateHold(hold *domain.Hold) error
+	GetHoldByTransactionID(transactionID int64) (*domain.Hold, error)
+	GetHoldByTransactionIDForUpdate(transactionID int64) (*domain.Hold, error)
+	GetExpiredHolds(now time.Time, limit int) ([]domain.Hold, error)
+	UpdateHold(hold *domain.Hold) error
+}
+
+type HoldRepository struct {
+	db DBTX
+}
+
+func NewHoldRepository(db *sql.DB) IHoldRepository {
+	return &HoldRepository{db: db}
+}
+
+func (repo *HoldRepository) CreateHold(hold *domain.Hold) error {
+	var toUser sql.NullInt64
+	if hold.ToUser != nil {
+		toUser.Int64 = *hold.ToUser
+		toUser.Valid = true
+	}
+
+	query := `INSERT INTO holds (transaction_id, user_id, to_user_id, amount, captured_amount, currency, status, expires_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := repo.db.Exec(query, hold.TransactionID, hold.UserID, toUser, hold.Amount, hold.CapturedAmount, hold.Amount.Currency(),
+		hold.Status, hold.ExpiresAt, hold.CreatedAt, hold.UpdatedAt)
+	return err
+}
+
+func (repo *HoldRepository) GetHoldByTransactionID(transactionID int64) (*domain.Hold, error) {
+	return repo.getHold(`SELECT `+holdColumns+` FROM holds WHERE transaction_id = ?`, transactionID)
+}
+
+/* GetHoldByTransactionIDForUpdate locks the hold until the surrounding unit of work ends */
+func (repo *HoldRepository) GetHoldByTransactionIDForUpdate(transactionID int64) (*domain.Hold, error) {
+	return repo.getHold(`SELECT `+holdColumns+` FROM holds WHERE transaction_id = ? FOR UPDATE`, transactionID)
+}
+
+/* GetExpiredHolds returns authorized holds whose TTL has run out */
+func (repo *HoldRepository) GetExpiredHolds(now time.Time, limit int) ([]domain.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE status = ? AND expires_at <= ? ORDER BY expires_at LIMIT ?`
+	rows, err := repo.db.Query(query, domain.Authorized, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []domain.Hold
+
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, *hold)
+	}
+
+	return holds, rows.Err()
+}
+
+func (repo *HoldRepository) UpdateHold(hold *domain.Hold) error {
+	query := `UPDATE holds SET captured_amount = ?, status = ?, updated_at = ? WHERE transaction_id = ?`
+	_, err := repo.db.Exec(query, hold.CapturedAmount, hold.Status, hold.UpdatedAt, hold.TransactionID)
+	return err
+}
+
+func (repo *HoldRepository) getHold(query string, transactionID int64) (*domain.Hold, error) {
+	hold, err := scanHold(repo.db.QueryRow(query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrHoldNotFound
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+func scanHold(scanner rowScanner) (*domain.Hold, error) {
+	var hold domain.Hold
+	var toUser sql.NullInt64
+	var amount, capturedAmount string
+	var currency domain.Currency
+
+	err := scanner.Scan(&hold.TransactionID, &hold.UserID, &toUser, &amount, &capturedAmount, &currency, &hold.Status, &hold.ExpiresAt, &hold.CreatedAt, &hold.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if toUser.Valid {
+		hold.ToUser = &toUser.Int64
+	}
+	if hold.Amount, err = domain.ParseMoney(amount, currency); err != nil {
+		return nil, err
+	}
+	if hold.CapturedAmount, err = domain.ParseMoney(capturedAmount, currency); err != nil {
+		return nil, err
+	}
+
+	return &hold, nil
+}
@@ -0,0 +1,116 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/go-sql-driver/mysql"
+)
+
+var ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+
+type IIdempotencyRepository interface {
+	Reserve(key string, requestFingerprint string, now time.Time) error
+	GetByKey(key string) (*domain.IdempotencyRecord, error)
+	Complete(key string, statusCode int, responseBody []byte, now time.Time) error
+	Reclaim(key string, staleBefore time.Time, now time.Time) (bool, error)
+	Delete(key string) error
+	DeleteExpired(key string, completedBefore time.Time) error
+	Purge(completedBefore time.Time, inFlightBefore time.Time, limit int) (int64, error)
+}
+
+type IdempotencyRepository struct {
+	db DBTX
+}
+
+func NewIdempotencyRepository(db *sql.DB) IIdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+/* Reserve claims the key, the primary key guarantees only one request wins */
+func (repo *IdempotencyRepository) Reserve(key string, requestFingerprint string, now time.Time) error {
+	query := `INSERT INTO idempotency_keys (idempotency_key, request_fingerprint, created_at) VALUES (?, ?, ?)`
+	_, err := repo.db.Exec(query, key, requestFingerprint, now)
+	if isDuplicateEntry(err) {
+		return ErrIdempotencyKeyExists
+	}
+	return err
+}
+
+func (repo *IdempotencyRepository) GetByKey(key string) (*domain.IdempotencyRecord, error) {
+	query := `SELECT idempotency_key, request_fingerprint, status_code, response_body, created_at, completed_at FROM idempotency_keys WHERE idempotency_key = ?`
+	row := repo.db.QueryRow(query, key)
+
+	var record domain.IdempotencyRecord
+	var statusCode sql.NullInt64
+	var responseBody sql.NullString
+	var completedAt sql.NullTime
+	err := row.Scan(&record.Key, &record.RequestFingerprint, &statusCode, &responseBody, &record.CreatedAt, &completedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	record.ResponseBody = []byte(responseBody.String)
+	if completedAt.Valid {
+		record.CompletedAt = &completedAt.Time
+	}
+	return &record, nil
+}
+
+func (repo *IdempotencyRepository) Complete(key string, statusCode int, responseBody []byte, now time.Time) error {
+	query := `UPDATE idempotency_keys SET status_code = ?, response_body = ?, completed_at = ? WHERE idempotency_key = ?`
+	_, err := repo.db.Exec(query, statusCode, string(responseBody), now, key)
+	return err
+}
+
+/*
+Reclaim restarts the clock of a key whose request has been in flight since
+before staleBefore. Only one of several callers racing for it gets true.
+*/
+func (repo *IdempotencyRepository) Reclaim(key string, staleBefore time.Time, now time.Time) (bool, error) {
+	query := `UPDATE idempotency_keys SET created_at = ? WHERE idempotency_key = ? AND completed_at IS NULL AND created_at <= ?`
+	result, err := repo.db.Exec(query, now, key, staleBefore)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (repo *IdempotencyRepository) Delete(key string) error {
+	query := `DELETE FROM idempotency_keys WHERE idempotency_key = ?`
+	_, err := repo.db.Exec(query, key)
+	return err
+}
+
+/* DeleteExpired forgets the key only when its response was stored before completedBefore */
+func (repo *IdempotencyRepository) DeleteExpired(key string, completedBefore time.Time) error {
+	query := `DELETE FROM idempotency_keys WHERE idempotency_key = ? AND completed_at <= ?`
+	_, err := repo.db.Exec(query, key, completedBefore)
+	return err
+}
+
+/* Purge deletes up to limit keys completed before completedBefore or left in flight since before inFlightBefore */
+func (repo *IdempotencyRepository) Purge(completedBefore time.Time, inFlightBefore time.Time, limit int) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE completed_at <= ? OR (completed_at IS NULL AND created_at <= ?) LIMIT ?`
+	result, err := repo.db.Exec(query, completedBefore, inFlightBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
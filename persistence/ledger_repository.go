@@ -0,0 +1,211 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrLedgerAccountNotFound = errors.New("ledger account not found")
+
+type ILedgerRepository interface {
+	GetAccountByCode(code string) (*domain.LedgerAccount, error)
+	GetOrCreateUserAccount(userID int64, currency domain.Currency) (*domain.LedgerAccount, error)
+	CreateJournalEntry(entry *domain.JournalEntry) error
+	GetAccountBalance(accountID int64) (domain.Money, error)
+	GetAccountPostings(accountID int64) ([]domain.Posting, error)
+	GetUserAccounts() ([]domain.LedgerAccount, error)
+	GetAccountBalanceAt(accountID int64, at time.Time) (domain.Money, error)
+	GetAccountPostingsBetween(accountID int64, from time.Time, to time.Time) ([]domain.Posting, error)
+}
+
+type LedgerRepository struct {
+	db DBTX
+}
+
+func NewLedgerRepository(db *sql.DB) ILedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+func (repo *LedgerRepository) GetAccountByCode(code string) (*domain.LedgerAccount, error) {
+	query := `SELECT id, code, user_id, currency, type, created_at FROM ledger_accounts WHERE code = ?`
+	row := repo.db.QueryRow(query, code)
+
+	var account domain.LedgerAccount
+	var userID sql.NullInt64
+	err := row.Scan(&account.ID, &account.Code, &userID, &account.Currency, &account.Type, &account.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLedgerAccountNotFound
+		}
+		return nil, err
+	}
+
+	if userID.Valid {
+		account.UserID = &userID.Int64
+	}
+	return &account, nil
+}
+
+func (repo *LedgerRepository) GetOrCreateUserAccount(userID int64, currency domain.Currency) (*domain.LedgerAccount, error) {
+	code := domain.UserAccountCode(userID, currency)
+
+	query := `INSERT IGNORE INTO ledger_accounts (code, user_id, currency, type, created_at) VALUES (?, ?, ?, ?, NOW())`
+	if _, err := repo.db.Exec(query, code, userID, currency, domain.UserLedgerAccount); err != nil {
+		return nil, err
+	}
+
+	return repo.GetAccountByCode(code)
+}
+
+/* CreateJournalEntry refuses unbalanced entries before anything is written */
+func (repo *LedgerRepository) CreateJournalEntry(entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	var transactionID sql.NullInt64
+	if entry.TransactionID != nil {
+		transactionID.Int64 = *entry.TransactionID
+		transactionID.Valid = true
+	}
+
+	query := `INSERT INTO journal_entries (transaction_id, description, created_at) VALUES (?, ?, ?)`
+	result, err := repo.db.Exec(query, transactionID, entry.Description, entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	entryID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = entryID
+
+	postingQuery := `INSERT INTO ledger_postings (journal_entry_id, account_id, amount, created_at) VALUES (?, ?, ?, ?)`
+	for i := range entry.Postings {
+		posting := &entry.Postings[i]
+		posting.JournalEntryID = entryID
+		posting.CreatedAt = entry.CreatedAt
+
+		result, err := repo.db.Exec(postingQuery, posting.JournalEntryID, posting.AccountID, posting.Amount, posting.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		postingID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		posting.ID = postingID
+	}
+
+	return nil
+}
+
+func (repo *LedgerRepository) GetAccountBalance(accountID int64) (domain.Money, error) {
+	query := `SELECT a.currency, COALESCE(SUM(p.amount), 0) FROM ledger_accounts a
+		LEFT JOIN ledger_postings p ON p.account_id = a.id
+		WHERE a.id = ? GROUP BY a.currency`
+
+	var currency domain.Currency
+	var balance string
+	if err := repo.db.QueryRow(query, accountID).Scan(&currency, &balance); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Money{}, ErrLedgerAccountNotFound
+		}
+		return domain.Money{}, err
+	}
+	return domain.ParseMoney(balance, currency)
+}
+
+func (repo *LedgerRepository) GetAccountPostings(accountID int64) ([]domain.Posting, error) {
+	query := `SELECT p.id, p.journal_entry_id, p.account_id, p.amount, a.currency, p.created_at FROM ledger_postings p
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE p.account_id = ? ORDER BY p.id`
+	return repo.queryPostings(query, accountID)
+}
+
+func (repo *LedgerRepository) GetUserAccounts() ([]domain.LedgerAccount, error) {
+	query := `SELECT id, code, user_id, currency, type, created_at FROM ledger_accounts WHERE type = ? ORDER BY id`
+	rows, err := repo.db.Query(query, domain.UserLedgerAccount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []domain.LedgerAccount
+
+	for rows.Next() {
+		var account domain.LedgerAccount
+		var userID sql.NullInt64
+		if err := rows.Scan(&account.ID, &account.Code, &userID, &account.Currency, &account.Type, &account.CreatedAt); err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			account.UserID = &userID.Int64
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+/* GetAccountBalanceAt sums what was posted to the account before at */
+func (repo *LedgerRepository) GetAccountBalanceAt(accountID int64, at time.Time) (domain.Money, error) {
+	query := `SELECT a.currency, COALESCE(SUM(p.amount), 0) FROM ledger_accounts a
+		LEFT JOIN ledger_postings p ON p.account_id = a.id AND p.created_at < ?
+		WHERE a.id = ? GROUP BY a.currency`
+
+	var currency domain.Currency
+	var balance string
+	if err := repo.db.QueryRow(query, at, accountID).Scan(&currency, &balance); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Money{}, ErrLedgerAccountNotFound
+		}
+		return domain.Money{}, err
+	}
+	return domain.ParseMoney(balance, currency)
+}
+
+/* GetAccountPostingsBetween returns the postings in [from, to), oldest first */
+func (repo *LedgerRepository) GetAccountPostingsBetween(accountID int64, from time.Time, to time.Time) ([]domain.Posting, error) {
+	query := `SELECT p.id, p.journal_entry_id, p.account_id, p.amount, a.currency, p.created_at FROM ledger_postings p
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE p.account_id = ? AND p.created_at >= ? AND p.created_at < ? ORDER BY p.created_at, p.id`
+	return repo.queryPostings(query, accountID, from, to)
+}
+
+func (repo *LedgerRepository) queryPostings(query string, args ...any) ([]domain.Posting, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postings []domain.Posting
+
+	for rows.Next() {
+		var posting domain.Posting
+		var amount string
+		var currency domain.Currency
+		err := rows.Scan(&posting.ID, &posting.JournalEntryID, &posting.AccountID, &amount, &currency, &posting.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		posting.Amount, err = domain.ParseMoney(amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, posting)
+	}
+
+	return postings, rows.Err()
+}
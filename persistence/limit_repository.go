@@ -0,0 +1,212 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var (
+	ErrLimitProfileNotFound = errors.New("limit profile not found")
+	ErrLimitProfileExists   = errors.New("a limit profile already exists for this user or role, transaction type and currency")
+)
+
+const limitProfileColumns = `id, user_id, role, transaction_type, currency, max_single_amount, daily_amount, monthly_amount, daily_count, created_at, updated_at`
+
+type ILimitRepository interface {
+	CreateLimitProfile(limitProfile *domain.LimitProfile) error
+	GetLimitProfiles() ([]domain.LimitProfile, error)
+	GetLimitProfileByID(id int64) (*domain.LimitProfile, error)
+	FindLimitProfile(userID int64, role string, transactionType domain.TransactionType, currency domain.Currency) (*domain.LimitProfile, error)
+	UpdateLimitProfile(limitProfile *domain.LimitProfile) error
+	DeleteLimitProfile(id int64) error
+}
+
+type LimitRepository struct {
+	db DBTX
+}
+
+func NewLimitRepository(db *sql.DB) ILimitRepository {
+	return &LimitRepository{db: db}
+}
+
+func (repo *LimitRepository) CreateLimitProfile(limitProfile *domain.LimitProfile) error {
+	query := `INSERT INTO limit_profiles (user_id, role, transaction_type, currency, max_single_amount, daily_amount, monthly_amount, daily_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, limitProfileArgs(limitProfile)...)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ErrLimitProfileExists
+		}
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	limitProfile.ID = id
+	return nil
+}
+
+func (repo *LimitRepository) GetLimitProfiles() ([]domain.LimitProfile, error) {
+	query := `SELECT ` + limitProfileColumns + ` FROM limit_profiles ORDER BY id`
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limitProfiles []domain.LimitProfile
+
+	for rows.Next() {
+		limitProfile, err := scanLimitProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		limitProfiles = append(limitProfiles, *limitProfile)
+	}
+
+	return limitProfiles, rows.Err()
+}
+
+func (repo *LimitRepository) GetLimitProfileByID(id int64) (*domain.LimitProfile, error) {
+	query := `SELECT ` + limitProfileColumns + ` FROM limit_profiles WHERE id = ?`
+
+	limitProfile, err := scanLimitProfile(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLimitProfileNotFound
+		}
+		return nil, err
+	}
+	return limitProfile, nil
+}
+
+/* FindLimitProfile prefers the user's own profile over the one of their role, nil means no limits */
+func (repo *LimitRepository) FindLimitProfile(userID int64, role string, transactionType domain.TransactionType, currency domain.Currency) (*domain.LimitProfile, error) {
+	query := `SELECT ` + limitProfileColumns + ` FROM limit_profiles
+		WHERE (user_id = ? OR role = ?) AND transaction_type = ? AND currency = ?
+		ORDER BY user_id IS NULL LIMIT 1`
+
+	limitProfile, err := scanLimitProfile(repo.db.QueryRow(query, userID, role, transactionType, currency))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return limitProfile, nil
+}
+
+func (repo *LimitRepository) UpdateLimitProfile(limitProfile *domain.LimitProfile) error {
+	query := `UPDATE limit_profiles SET user_id = ?, role = ?, transaction_type = ?, currency = ?, max_single_amount = ?, daily_amount = ?,
+		monthly_amount = ?, daily_count = ?, created_at = ?, updated_at = ? WHERE id = ?`
+	result, err := repo.db.Exec(query, append(limitProfileArgs(limitProfile), limitProfile.ID)...)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ErrLimitProfileExists
+		}
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLimitProfileNotFound
+	}
+	return nil
+}
+
+func (repo *LimitRepository) DeleteLimitProfile(id int64) error {
+	result, err := repo.db.Exec(`DELETE FROM limit_profiles WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLimitProfileNotFound
+	}
+	return nil
+}
+
+func limitProfileArgs(limitProfile *domain.LimitProfile) []any {
+	var userID sql.NullInt64
+	if limitProfile.UserID != nil {
+		userID.Int64 = *limitProfile.UserID
+		userID.Valid = true
+	}
+	var role sql.NullString
+	if limitProfile.Role != "" {
+		role.String = limitProfile.Role
+		role.Valid = true
+	}
+	var dailyCount sql.NullInt64
+	if limitProfile.DailyCount != nil {
+		dailyCount.Int64 = int64(*limitProfile.DailyCount)
+		dailyCount.Valid = true
+	}
+
+	return []any{userID, role, limitProfile.TransactionType, limitProfile.Currency, nullableMoney(limitProfile.MaxSingleAmount),
+		nullableMoney(limitProfile.DailyAmount), nullableMoney(limitProfile.MonthlyAmount), dailyCount, limitProfile.CreatedAt, limitProfile.UpdatedAt}
+}
+
+func scanLimitProfile(scanner rowScanner) (*domain.LimitProfile, error) {
+	var limitProfile domain.LimitProfile
+	var userID, dailyCount sql.NullInt64
+	var role, maxSingleAmount, dailyAmount, monthlyAmount sql.NullString
+
+	err := scanner.Scan(&limitProfile.ID, &userID, &role, &limitProfile.TransactionType, &limitProfile.Currency, &maxSingleAmount,
+		&dailyAmount, &monthlyAmount, &dailyCount, &limitProfile.CreatedAt, &limitProfile.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		limitProfile.UserID = &userID.Int64
+	}
+	limitProfile.Role = role.String
+	if dailyCount.Valid {
+		count := int(dailyCount.Int64)
+		limitProfile.DailyCount = &count
+	}
+
+	if limitProfile.MaxSingleAmount, err = parseNullableMoney(maxSingleAmount, limitProfile.Currency); err != nil {
+		return nil, err
+	}
+	if limitProfile.DailyAmount, err = parseNullableMoney(dailyAmount, limitProfile.Currency); err != nil {
+		return nil, err
+	}
+	if limitProfile.MonthlyAmount, err = parseNullableMoney(monthlyAmount, limitProfile.Currency); err != nil {
+		return nil, err
+	}
+
+	return &limitProfile, nil
+}
+
+/* nullableMoney and parseNullableMoney map an optional amount to a NULL column and back */
+func nullableMoney(amount *domain.Money) any {
+	if amount == nil {
+		return nil
+	}
+	return *amount
+}
+
+func parseNullableMoney(value sql.NullString, currency domain.Currency) (*domain.Money, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	amount, err := domain.ParseMoney(value.String, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
@@ -0,0 +1,140 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrOverdraftNotFound = errors.New("overdraft facility not found")
+
+const overdraftColumns = `user_id, currency, credit_limit, daily_rate_bps, last_charged_on, created_at, updated_at`
+
+type IOverdraftRepository interface {
+	SaveFacility(facility *domain.OverdraftFacility) error
+	GetFacility(userID int64, currency domain.Currency) (*domain.OverdraftFacility, error)
+	GetFacilityForUpdate(userID int64, currency domain.Currency) (*domain.OverdraftFacility, error)
+	GetFacilities() ([]domain.OverdraftFacility, error)
+	GetFacilitiesToCharge(day time.Time, limit int) ([]domain.OverdraftFacility, error)
+	MarkCharged(userID int64, currency domain.Currency, day time.Time) error
+	DeleteFacility(userID int64, currency domain.Currency) error
+}
+
+type OverdraftRepository struct {
+	db DBTX
+}
+
+func NewOverdraftRepository(db *sql.DB) IOverdraftRepository {
+	return &OverdraftRepository{db: db}
+}
+
+/* SaveFacility creates the facility or changes the limit and rate of the existing one */
+func (repo *OverdraftRepository) SaveFacility(facility *domain.OverdraftFacility) error {
+	now := time.Now()
+	query := `INSERT INTO overdraft_facilities (user_id, currency, credit_limit, daily_rate_bps, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE credit_limit = VALUES(credit_limit), daily_rate_bps = VALUES(daily_rate_bps), updated_at = VALUES(updated_at)`
+	if _, err := repo.db.Exec(query, facility.UserID, facility.Currency, facility.CreditLimit, facility.DailyRateBps, now, now); err != nil {
+		return err
+	}
+
+	saved, err := repo.GetFacility(facility.UserID, facility.Currency)
+	if err != nil {
+		return err
+	}
+	*facility = *saved
+	return nil
+}
+
+func (repo *OverdraftRepository) GetFacility(userID int64, currency domain.Currency) (*domain.OverdraftFacility, error) {
+	return repo.getFacility(`SELECT `+overdraftColumns+` FROM overdraft_facilities WHERE user_id = ? AND currency = ?`, userID, currency)
+}
+
+/* GetFacilityForUpdate locks the facility until the surrounding unit of work ends */
+func (repo *OverdraftRepository) GetFacilityForUpdate(userID int64, currency domain.Currency) (*domain.OverdraftFacility, error) {
+	return repo.getFacility(`SELECT `+overdraftColumns+` FROM overdraft_facilities WHERE user_id = ? AND currency = ? FOR UPDATE`, userID, currency)
+}
+
+func (repo *OverdraftRepository) GetFacilities() ([]domain.OverdraftFacility, error) {
+	return repo.queryFacilities(`SELECT ` + overdraftColumns + ` FROM overdraft_facilities ORDER BY user_id, currency`)
+}
+
+/* GetFacilitiesToCharge returns the facilities with a rate whose charge of day isn't posted yet */
+func (repo *OverdraftRepository) GetFacilitiesToCharge(day time.Time, limit int) ([]domain.OverdraftFacility, error) {
+	query := `SELECT ` + overdraftColumns + ` FROM overdraft_facilities
+		WHERE daily_rate_bps > 0 AND (last_charged_on IS NULL OR last_charged_on < ?) ORDER BY user_id, currency LIMIT ?`
+	return repo.queryFacilities(query, day.Format(time.DateOnly), limit)
+}
+
+func (repo *OverdraftRepository) MarkCharged(userID int64, currency domain.Currency, day time.Time) error {
+	query := `UPDATE overdraft_facilities SET last_charged_on = ? WHERE user_id = ? AND currency = ?`
+	_, err := repo.db.Exec(query, day.Format(time.DateOnly), userID, currency)
+	return err
+}
+
+func (repo *OverdraftRepository) DeleteFacility(userID int64, currency domain.Currency) error {
+	result, err := repo.db.Exec(`DELETE FROM overdraft_facilities WHERE user_id = ? AND currency = ?`, userID, currency)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrOverdraftNotFound
+	}
+	return nil
+}
+
+func (repo *OverdraftRepository) getFacility(query string, userID int64, currency domain.Currency) (*domain.OverdraftFacility, error) {
+	facility, err := scanOverdraftFacility(repo.db.QueryRow(query, userID, currency))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOverdraftNotFound
+		}
+		return nil, err
+	}
+	return facility, nil
+}
+
+func (repo *OverdraftRepository) queryFacilities(query string, args ...any) ([]domain.OverdraftFacility, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facilities []domain.OverdraftFacility
+
+	for rows.Next() {
+		facility, err := scanOverdraftFacility(rows)
+		if err != nil {
+			return nil, err
+		}
+		facilities = append(facilities, *facility)
+	}
+
+	return facilities, rows.Err()
+}
+
+func scanOverdraftFacility(scanner rowScanner) (*domain.OverdraftFacility, error) {
+	var facility domain.OverdraftFacility
+	var creditLimit string
+	var lastChargedOn sql.NullTime
+
+	err := scanner.Scan(&facility.UserID, &facility.Currency, &creditLimit, &facility.DailyRateBps, &lastChargedOn, &facility.CreatedAt, &facility.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if facility.CreditLimit, err = domain.ParseMoney(creditLimit, facility.Currency); err != nil {
+		return nil, err
+	}
+	if lastChargedOn.Valid {
+		day := time.Date(lastChargedOn.Time.Year(), lastChargedOn.Time.Month(), lastChargedOn.Time.Day(), 0, 0, 0, 0, time.UTC)
+		facility.LastChargedOn = &day
+	}
+	return &facility, nil
+}
@@ -0,0 +1,110 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrPocketNotFound = errors.New("pocket not found")
+
+const pocketColumns = `id, user_id, currency, name, amount, status, created_at, updated_at, closed_at`
+
+type IPocketRepository interface {
+	CreatePocket(pocket *domain.Pocket) error
+	GetPocketByID(id int64) (*domain.Pocket, error)
+	GetPocketByIDForUpdate(id int64) (*domain.Pocket, error)
+	GetOpenPockets(userID int64, currency domain.Currency) ([]domain.Pocket, error)
+	UpdatePocket(pocket *domain.Pocket) error
+}
+
+type PocketRepository struct {
+	db DBTX
+}
+
+func NewPocketRepository(db *sql.DB) IPocketRepository {
+	return &PocketRepository{db: db}
+}
+
+func (repo *PocketRepository) CreatePocket(pocket *domain.Pocket) error {
+	query := `INSERT INTO pockets (user_id, currency, name, amount, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, pocket.UserID, pocket.Currency, pocket.Name, pocket.Amount, pocket.Status, pocket.CreatedAt, pocket.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	pocket.ID = id
+	return nil
+}
+
+func (repo *PocketRepository) GetPocketByID(id int64) (*domain.Pocket, error) {
+	return repo.getPocket(`SELECT `+pocketColumns+` FROM pockets WHERE id = ?`, id)
+}
+
+/* GetPocketByIDForUpdate locks the pocket until the surrounding unit of work ends */
+func (repo *PocketRepository) GetPocketByIDForUpdate(id int64) (*domain.Pocket, error) {
+	return repo.getPocket(`SELECT `+pocketColumns+` FROM pockets WHERE id = ? FOR UPDATE`, id)
+}
+
+func (repo *PocketRepository) GetOpenPockets(userID int64, currency domain.Currency) ([]domain.Pocket, error) {
+	query := `SELECT ` + pocketColumns + ` FROM pockets WHERE user_id = ? AND currency = ? AND status = ? ORDER BY id`
+	rows, err := repo.db.Query(query, userID, currency, domain.PocketOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pockets []domain.Pocket
+
+	for rows.Next() {
+		pocket, err := scanPocket(rows)
+		if err != nil {
+			return nil, err
+		}
+		pockets = append(pockets, *pocket)
+	}
+
+	return pockets, rows.Err()
+}
+
+func (repo *PocketRepository) UpdatePocket(pocket *domain.Pocket) error {
+	query := `UPDATE pockets SET name = ?, amount = ?, status = ?, updated_at = ?, closed_at = ? WHERE id = ?`
+	_, err := repo.db.Exec(query, pocket.Name, pocket.Amount, pocket.Status, pocket.UpdatedAt, pocket.ClosedAt, pocket.ID)
+	return err
+}
+
+func (repo *PocketRepository) getPocket(query string, id int64) (*domain.Pocket, error) {
+	pocket, err := scanPocket(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPocketNotFound
+		}
+		return nil, err
+	}
+	return pocket, nil
+}
+
+func scanPocket(scanner rowScanner) (*domain.Pocket, error) {
+	var pocket domain.Pocket
+	var amount string
+	var closedAt sql.NullTime
+
+	err := scanner.Scan(&pocket.ID, &pocket.UserID, &pocket.Currency, &pocket.Name, &amount, &pocket.Status, &pocket.CreatedAt, &pocket.UpdatedAt, &closedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if pocket.Amount, err = domain.ParseMoney(amount, pocket.Currency); err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		pocket.ClosedAt = &closedAt.Time
+	}
+	return &pocket, nil
+}
@@ -0,0 +1,109 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrRiskReviewNotFound = errors.New("risk review not found")
+
+const riskReviewColumns = `id, transaction_id, user_id, reasons, status, resolved_by, note, created_at, resolved_at`
+
+type IRiskRepository interface {
+	CreateRiskReview(review *domain.RiskReview) error
+	GetRiskReviewByID(id int64) (*domain.RiskReview, error)
+	GetRiskReviewsByStatus(status domain.RiskReviewStatus) ([]domain.RiskReview, error)
+	ResolveRiskReview(review *domain.RiskReview) (bool, error)
+}
+
+type RiskRepository struct {
+	db DBTX
+}
+
+func NewRiskRepository(db *sql.DB) IRiskRepository {
+	return &RiskRepository{db: db}
+}
+
+func (repo *RiskRepository) CreateRiskReview(review *domain.RiskReview) error {
+	query := `INSERT INTO risk_reviews (transaction_id, user_id, reasons, status, created_at) VALUES (?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, review.TransactionID, review.UserID, review.Reasons, review.Status, review.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	review.ID = id
+	return nil
+}
+
+func (repo *RiskRepository) GetRiskReviewByID(id int64) (*domain.RiskReview, error) {
+	query := `SELECT ` + riskReviewColumns + ` FROM risk_reviews WHERE id = ?`
+	review, err := scanRiskReview(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRiskReviewNotFound
+		}
+		return nil, err
+	}
+	return review, nil
+}
+
+/* GetRiskReviewsByStatus returns the reviews with status, oldest first */
+func (repo *RiskRepository) GetRiskReviewsByStatus(status domain.RiskReviewStatus) ([]domain.RiskReview, error) {
+	query := `SELECT ` + riskReviewColumns + ` FROM risk_reviews WHERE status = ? ORDER BY id`
+	rows, err := repo.db.Query(query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []domain.RiskReview
+
+	for rows.Next() {
+		review, err := scanRiskReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+
+	return reviews, rows.Err()
+}
+
+/* ResolveRiskReview stores the outcome only while the review is still open, so it is resolved once */
+func (repo *RiskRepository) ResolveRiskReview(review *domain.RiskReview) (bool, error) {
+	query := `UPDATE risk_reviews SET status = ?, resolved_by = ?, note = ?, resolved_at = ? WHERE id = ? AND status = ?`
+	result, err := repo.db.Exec(query, review.Status, review.ResolvedBy, review.Note, review.ResolvedAt, review.ID, domain.RiskReviewOpen)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func scanRiskReview(scanner rowScanner) (*domain.RiskReview, error) {
+	var review domain.RiskReview
+	var resolvedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+
+	err := scanner.Scan(&review.ID, &review.TransactionID, &review.UserID, &review.Reasons, &review.Status, &resolvedBy, &review.Note, &review.CreatedAt, &resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedBy.Valid {
+		review.ResolvedBy = &resolvedBy.Int64
+	}
+	if resolvedAt.Valid {
+		review.ResolvedAt = &resolvedAt.Time
+	}
+
+	return &review, nil
+}
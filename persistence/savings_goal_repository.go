@@ -0,0 +1,298 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var (
+	ErrSavingsGoalNotFound = errors.New("savings goal not found")
+	ErrSweepRuleNotFound   = errors.New("sweep rule not found")
+)
+
+const (
+	savingsGoalColumns = `id, user_id, currency, name, target_amount, target_date, saved_amount, status, created_at, updated_at, closed_at`
+	sweepRuleColumns   = `id, goal_id, user_id, currency, type, amount, percentage_bps, frequency, day, end_date, max_occurrences, next_run_at,
+	occurrences_count, active, created_at, updated_at`
+	savingsSweepColumns = `id, goal_id, rule_id, transaction_id, amount, created_at`
+)
+
+type ISavingsGoalRepository interface {
+	CreateGoal(goal *domain.SavingsGoal) error
+	GetGoalByID(id int64) (*domain.SavingsGoal, error)
+	GetGoalByIDForUpdate(id int64) (*domain.SavingsGoal, error)
+	GetGoalsByUserID(userID int64) ([]domain.SavingsGoal, error)
+	UpdateGoal(goal *domain.SavingsGoal) error
+	CreateRule(rule *domain.SweepRule) error
+	GetRuleByIDForUpdate(id int64) (*domain.SweepRule, error)
+	GetRulesByGoalID(goalID int64) ([]domain.SweepRule, error)
+	GetActiveRules(userID int64, currency domain.Currency, ruleType domain.SweepRuleType) ([]domain.SweepRule, error)
+	GetDueRules(now time.Time, limit int) ([]domain.SweepRule, error)
+	UpdateRule(rule *domain.SweepRule) error
+	CreateSweep(sweep *domain.SavingsSweep) error
+	GetSweepsByGoalID(goalID int64) ([]domain.SavingsSweep, error)
+}
+
+type SavingsGoalRepository struct {
+	db DBTX
+}
+
+func NewSavingsGoalRepository(db *sql.DB) ISavingsGoalRepository {
+	return &SavingsGoalRepository{db: db}
+}
+
+func (repo *SavingsGoalRepository) CreateGoal(goal *domain.SavingsGoal) error {
+	query := `INSERT INTO savings_goals (user_id, currency, name, target_amount, target_date, saved_amount, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, goal.UserID, goal.Currency, goal.Name, goal.TargetAmount, goal.TargetDate, goal.SavedAmount,
+		goal.Status, goal.CreatedAt, goal.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	goal.ID = id
+	return nil
+}
+
+func (repo *SavingsGoalRepository) GetGoalByID(id int64) (*domain.SavingsGoal, error) {
+	return repo.getGoal(`SELECT `+savingsGoalColumns+` FROM savings_goals WHERE id = ?`, id)
+}
+
+/* GetGoalByIDForUpdate locks the goal until the surrounding unit of work ends */
+func (repo *SavingsGoalRepository) GetGoalByIDForUpdate(id int64) (*domain.SavingsGoal, error) {
+	return repo.getGoal(`SELECT `+savingsGoalColumns+` FROM savings_goals WHERE id = ? FOR UPDATE`, id)
+}
+
+func (repo *SavingsGoalRepository) GetGoalsByUserID(userID int64) ([]domain.SavingsGoal, error) {
+	rows, err := repo.db.Query(`SELECT `+savingsGoalColumns+` FROM savings_goals WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []domain.SavingsGoal
+
+	for rows.Next() {
+		goal, err := scanSavingsGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *goal)
+	}
+
+	return goals, rows.Err()
+}
+
+func (repo *SavingsGoalRepository) UpdateGoal(goal *domain.SavingsGoal) error {
+	query := `UPDATE savings_goals SET saved_amount = ?, status = ?, updated_at = ?, closed_at = ? WHERE id = ?`
+	_, err := repo.db.Exec(query, goal.SavedAmount, goal.Status, goal.UpdatedAt, goal.ClosedAt, goal.ID)
+	return err
+}
+
+func (repo *SavingsGoalRepository) CreateRule(rule *domain.SweepRule) error {
+	query := `INSERT INTO savings_sweep_rules (goal_id, user_id, currency, type, amount, percentage_bps, frequency, day, end_date, max_occurrences,
+		next_run_at, occurrences_count, active, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	frequency, day, endDate, maxOccurrences := recurrenceColumns(rule.Recurrence)
+	result, err := repo.db.Exec(query, rule.GoalID, rule.UserID, rule.Currency, rule.Type, nullableMoney(rule.Amount), rule.PercentageBps,
+		frequency, day, endDate, maxOccurrences, rule.NextRunAt, rule.OccurrencesCount, rule.Active, rule.CreatedAt, rule.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	rule.ID = id
+	return nil
+}
+
+/* GetRuleByIDForUpdate locks the rule until the surrounding unit of work ends */
+func (repo *SavingsGoalRepository) GetRuleByIDForUpdate(id int64) (*domain.SweepRule, error) {
+	rule, err := scanSweepRule(repo.db.QueryRow(`SELECT `+sweepRuleColumns+` FROM savings_sweep_rules WHERE id = ? FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSweepRuleNotFound
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (repo *SavingsGoalRepository) GetRulesByGoalID(goalID int64) ([]domain.SweepRule, error) {
+	return repo.queryRules(`SELECT `+sweepRuleColumns+` FROM savings_sweep_rules WHERE goal_id = ? ORDER BY id`, goalID)
+}
+
+/* GetActiveRules returns the active rules of a type the user's credits or debits in currency set off */
+func (repo *SavingsGoalRepository) GetActiveRules(userID int64, currency domain.Currency, ruleType domain.SweepRuleType) ([]domain.SweepRule, error) {
+	query := `SELECT ` + sweepRuleColumns + ` FROM savings_sweep_rules WHERE user_id = ? AND currency = ? AND type = ? AND active = TRUE ORDER BY id`
+	return repo.queryRules(query, userID, currency, ruleType)
+}
+
+func (repo *SavingsGoalRepository) GetDueRules(now time.Time, limit int) ([]domain.SweepRule, error) {
+	query := `SELECT ` + sweepRuleColumns + ` FROM savings_sweep_rules WHERE active = TRUE AND next_run_at <= ? ORDER BY next_run_at, id LIMIT ?`
+	return repo.queryRules(query, now, limit)
+}
+
+func (repo *SavingsGoalRepository) UpdateRule(rule *domain.SweepRule) error {
+	query := `UPDATE savings_sweep_rules SET next_run_at = ?, occurrences_count = ?, active = ?, updated_at = ? WHERE id = ?`
+	_, err := repo.db.Exec(query, rule.NextRunAt, rule.OccurrencesCount, rule.Active, rule.UpdatedAt, rule.ID)
+	return err
+}
+
+func (repo *SavingsGoalRepository) CreateSweep(sweep *domain.SavingsSweep) error {
+	var ruleID sql.NullInt64
+	if sweep.RuleID != nil {
+		ruleID.Int64 = *sweep.RuleID
+		ruleID.Valid = true
+	}
+
+	query := `INSERT INTO savings_sweeps (goal_id, rule_id, transaction_id, amount, created_at) VALUES (?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, sweep.GoalID, ruleID, sweep.TransactionID, sweep.Amount, sweep.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	sweep.ID = id
+	return nil
+}
+
+func (repo *SavingsGoalRepository) GetSweepsByGoalID(goalID int64) ([]domain.SavingsSweep, error) {
+	query := `SELECT s.id, s.goal_id, s.rule_id, s.transaction_id, s.amount, g.currency, s.created_at
+		FROM savings_sweeps s JOIN savings_goals g ON g.id = s.goal_id WHERE s.goal_id = ? ORDER BY s.created_at, s.id`
+	rows, err := repo.db.Query(query, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sweeps []domain.SavingsSweep
+
+	for rows.Next() {
+		var sweep domain.SavingsSweep
+		var ruleID sql.NullInt64
+		var amount string
+		var currency domain.Currency
+
+		if err := rows.Scan(&sweep.ID, &sweep.GoalID, &ruleID, &sweep.TransactionID, &amount, &currency, &sweep.CreatedAt); err != nil {
+			return nil, err
+		}
+		if ruleID.Valid {
+			sweep.RuleID = &ruleID.Int64
+		}
+		if sweep.Amount, err = domain.ParseMoney(amount, currency); err != nil {
+			return nil, err
+		}
+		sweeps = append(sweeps, sweep)
+	}
+
+	return sweeps, rows.Err()
+}
+
+func (repo *SavingsGoalRepository) getGoal(query string, id int64) (*domain.SavingsGoal, error) {
+	goal, err := scanSavingsGoal(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSavingsGoalNotFound
+		}
+		return nil, err
+	}
+	return goal, nil
+}
+
+func (repo *SavingsGoalRepository) queryRules(query string, args ...any) ([]domain.SweepRule, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.SweepRule
+
+	for rows.Next() {
+		rule, err := scanSweepRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func scanSavingsGoal(scanner rowScanner) (*domain.SavingsGoal, error) {
+	var goal domain.SavingsGoal
+	var targetAmount, savedAmount string
+	var closedAt sql.NullTime
+
+	err := scanner.Scan(&goal.ID, &goal.UserID, &goal.Currency, &goal.Name, &targetAmount, &goal.TargetDate, &savedAmount, &goal.Status,
+		&goal.CreatedAt, &goal.UpdatedAt, &closedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if goal.TargetAmount, err = domain.ParseMoney(targetAmount, goal.Currency); err != nil {
+		return nil, err
+	}
+	if goal.SavedAmount, err = domain.ParseMoney(savedAmount, goal.Currency); err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		goal.ClosedAt = &closedAt.Time
+	}
+	return &goal, nil
+}
+
+func scanSweepRule(scanner rowScanner) (*domain.SweepRule, error) {
+	var rule domain.SweepRule
+	var amount, frequency sql.NullString
+	var day int
+	var endDate, nextRunAt sql.NullTime
+	var maxOccurrences sql.NullInt64
+
+	err := scanner.Scan(&rule.ID, &rule.GoalID, &rule.UserID, &rule.Currency, &rule.Type, &amount, &rule.PercentageBps, &frequency, &day,
+		&endDate, &maxOccurrences, &nextRunAt, &rule.OccurrencesCount, &rule.Active, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule.Amount, err = parseNullableMoney(amount, rule.Currency); err != nil {
+		return nil, err
+	}
+	if frequency.Valid {
+		rule.Recurrence = &domain.Recurrence{Frequency: domain.RecurrenceFrequency(frequency.String), Day: day}
+		if endDate.Valid {
+			rule.Recurrence.EndDate = &endDate.Time
+		}
+		if maxOccurrences.Valid {
+			count := int(maxOccurrences.Int64)
+			rule.Recurrence.MaxOccurrences = &count
+		}
+	}
+	if nextRunAt.Valid {
+		rule.NextRunAt = &nextRunAt.Time
+	}
+	return &rule, nil
+}
+
+/* recurrenceColumns maps an optional recurrence to its columns, NULL without one */
+func recurrenceColumns(recurrence *domain.Recurrence) (any, int, *time.Time, *int) {
+	if recurrence == nil {
+		return nil, 0, nil, nil
+	}
+	return recurrence.Frequency, recurrence.Day, recurrence.EndDate, recurrence.MaxOccurrences
+}
@@ -0,0 +1,172 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrScheduledTransferNotFound = errors.New("scheduled transfer not found")
+
+/* failureReasonMaxLength is the size of the failure_reason columns */
+const failureReasonMaxLength = 255
+
+const scheduledTransferColumns = `id, from_user_id, to_user_id, amount, currency, execute_at, status, transaction_id, failure_reason, created_at, updated_at`
+
+type IScheduledTransferRepository interface {
+	CreateScheduledTransfer(scheduledTransfer *domain.ScheduledTransfer) error
+	GetScheduledTransferByID(id int64) (*domain.ScheduledTransfer, error)
+	GetScheduledTransfersByUserID(userID int64, status domain.ScheduledTransferStatus) ([]domain.ScheduledTransfer, error)
+	GetDueScheduledTransfers(now time.Time, limit int) ([]domain.ScheduledTransfer, error)
+	GetStaleExecutingScheduledTransfers(updatedBefore time.Time, limit int) ([]domain.ScheduledTransfer, error)
+	UpdateStatus(id int64, from domain.ScheduledTransferStatus, to domain.ScheduledTransferStatus) (bool, error)
+	MarkCompleted(id int64, transactionID int64) error
+	MarkFailed(id int64, reason string) error
+	MarkAwaitingApproval(id int64, transactionID int64) error
+	FailStaleScheduledTransfer(id int64, updatedBefore time.Time, reason string) (bool, error)
+}
+
+type ScheduledTransferRepository struct {
+	db DBTX
+}
+
+func NewScheduledTransferRepository(db *sql.DB) IScheduledTransferRepository {
+	return &ScheduledTransferRepository{db: db}
+}
+
+func (repo *ScheduledTransferRepository) CreateScheduledTransfer(scheduledTransfer *domain.ScheduledTransfer) error {
+	query := `INSERT INTO scheduled_transfers (from_user_id, to_user_id, amount, currency, execute_at, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, scheduledTransfer.FromUser, scheduledTransfer.ToUser, scheduledTransfer.Amount, scheduledTransfer.Amount.Currency(),
+		scheduledTransfer.ExecuteAt, scheduledTransfer.Status, scheduledTransfer.CreatedAt, scheduledTransfer.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	scheduledTransfer.ID = id
+	return nil
+}
+
+func (repo *ScheduledTransferRepository) GetScheduledTransferByID(id int64) (*domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = ?`
+
+	scheduledTransfer, err := scanScheduledTransfer(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrScheduledTransferNotFound
+		}
+		return nil, err
+	}
+	return scheduledTransfer, nil
+}
+
+func (repo *ScheduledTransferRepository) GetScheduledTransfersByUserID(userID int64, status domain.ScheduledTransferStatus) ([]domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE from_user_id = ? AND status = ? ORDER BY execute_at, id`
+	return repo.queryScheduledTransfers(query, userID, status)
+}
+
+func (repo *ScheduledTransferRepository) GetDueScheduledTransfers(now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE status = ? AND execute_at <= ? ORDER BY execute_at, id LIMIT ?`
+	return repo.queryScheduledTransfers(query, domain.Scheduled, now, limit)
+}
+
+/* GetStaleExecutingScheduledTransfers returns the transfers claimed before updatedBefore that were never finished */
+func (repo *ScheduledTransferRepository) GetStaleExecutingScheduledTransfers(updatedBefore time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE status = ? AND updated_at < ? ORDER BY id LIMIT ?`
+	return repo.queryScheduledTransfers(query, domain.ScheduledExecuting, updatedBefore, limit)
+}
+
+/*
+UpdateStatus is a compare-and-set, it reports false when the transfer wasn't
+in the expected status anymore, e.g. cancelled while being picked up.
+*/
+func (repo *ScheduledTransferRepository) UpdateStatus(id int64, from domain.ScheduledTransferStatus, to domain.ScheduledTransferStatus) (bool, error) {
+	query := `UPDATE scheduled_transfers SET status = ?, updated_at = NOW() WHERE id = ? AND status = ?`
+	result, err := repo.db.Exec(query, to, id, from)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (repo *ScheduledTransferRepository) MarkCompleted(id int64, transactionID int64) error {
+	query := `UPDATE scheduled_transfers SET status = ?, transaction_id = ?, updated_at = NOW() WHERE id = ?`
+	_, err := repo.db.Exec(query, domain.ScheduledCompleted, transactionID, id)
+	return err
+}
+
+func (repo *ScheduledTransferRepository) MarkFailed(id int64, reason string) error {
+	query := `UPDATE scheduled_transfers SET status = ?, failure_reason = ?, updated_at = NOW() WHERE id = ?`
+	_, err := repo.db.Exec(query, domain.ScheduledFailed, truncateRunes(reason, failureReasonMaxLength), id)
+	return err
+}
+
+func (repo *ScheduledTransferRepository) MarkAwaitingApproval(id int64, transactionID int64) error {
+	query := `UPDATE scheduled_transfers SET status = ?, transaction_id = ?, updated_at = NOW() WHERE id = ?`
+	_, err := repo.db.Exec(query, domain.ScheduledAwaitingApproval, transactionID, id)
+	return err
+}
+
+/* FailStaleScheduledTransfer fails the transfer only if it is still claimed since before updatedBefore */
+func (repo *ScheduledTransferRepository) FailStaleScheduledTransfer(id int64, updatedBefore time.Time, reason string) (bool, error) {
+	query := `UPDATE scheduled_transfers SET status = ?, failure_reason = ?, updated_at = NOW() WHERE id = ? AND status = ? AND updated_at < ?`
+	result, err := repo.db.Exec(query, domain.ScheduledFailed, truncateRunes(reason, failureReasonMaxLength), id, domain.ScheduledExecuting, updatedBefore)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (repo *ScheduledTransferRepository) queryScheduledTransfers(query string, args ...any) ([]domain.ScheduledTransfer, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scheduledTransfers []domain.ScheduledTransfer
+
+	for rows.Next() {
+		scheduledTransfer, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		scheduledTransfers = append(scheduledTransfers, *scheduledTransfer)
+	}
+
+	return scheduledTransfers, rows.Err()
+}
+
+func scanScheduledTransfer(scanner rowScanner) (*domain.ScheduledTransfer, error) {
+	var scheduledTransfer domain.ScheduledTransfer
+	var amount string
+	var currency domain.Currency
+	var transactionID sql.NullInt64
+	var failureReason sql.NullString
+
+	err := scanner.Scan(&scheduledTransfer.ID, &scheduledTransfer.FromUser, &scheduledTransfer.ToUser, &amount, &currency, &scheduledTransfer.ExecuteAt,
+		&scheduledTransfer.Status, &transactionID, &failureReason, &scheduledTransfer.CreatedAt, &scheduledTransfer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduledTransfer.Amount, err = domain.ParseMoney(amount, currency); err != nil {
+		return nil, err
+	}
+	if transactionID.Valid {
+		scheduledTransfer.TransactionID = &transactionID.Int64
+	}
+	scheduledTransfer.FailureReason = failureReason.String
+
+	return &scheduledTransfer, nil
+}
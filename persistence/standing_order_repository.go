@@ -0,0 +1,198 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrStandingOrderNotFound = errors.New("standing order not found")
+
+const standingOrderColumns = `id, from_user_id, to_user_id, amount, currency, frequency, day, start_at, end_date, max_occurrences, occurrence_at, next_run_at,
+	occurrences_count, retry_count, insufficient_funds_policy, max_retries, status, created_at, updated_at`
+
+type IStandingOrderRepository interface {
+	CreateStandingOrder(standingOrder *domain.StandingOrder) error
+	GetStandingOrderByID(id int64) (*domain.StandingOrder, error)
+	GetStandingOrdersByUserID(userID int64) ([]domain.StandingOrder, error)
+	GetDueStandingOrders(now time.Time, limit int) ([]domain.StandingOrder, error)
+	UpdateStandingOrder(standingOrder *domain.StandingOrder, expectedNextRunAt time.Time) (bool, error)
+	CreateAttempt(attempt *domain.StandingOrderAttempt) error
+	GetAttempts(standingOrderID int64) ([]domain.StandingOrderAttempt, error)
+}
+
+type StandingOrderRepository struct {
+	db DBTX
+}
+
+func NewStandingOrderRepository(db *sql.DB) IStandingOrderRepository {
+	return &StandingOrderRepository{db: db}
+}
+
+func (repo *StandingOrderRepository) CreateStandingOrder(standingOrder *domain.StandingOrder) error {
+	query := `INSERT INTO standing_orders (from_user_id, to_user_id, amount, currency, frequency, day, start_at, end_date, max_occurrences, occurrence_at, next_run_at,
+		occurrences_count, retry_count, insufficient_funds_policy, max_retries, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, standingOrder.FromUser, standingOrder.ToUser, standingOrder.Amount, standingOrder.Amount.Currency(),
+		standingOrder.Recurrence.Frequency, standingOrder.Recurrence.Day, standingOrder.StartAt, standingOrder.Recurrence.EndDate,
+		standingOrder.Recurrence.MaxOccurrences, standingOrder.OccurrenceAt, standingOrder.NextRunAt, standingOrder.OccurrencesCount,
+		standingOrder.RetryCount, standingOrder.InsufficientFundsPolicy, standingOrder.MaxRetries, standingOrder.Status,
+		standingOrder.CreatedAt, standingOrder.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	standingOrder.ID = id
+	return nil
+}
+
+func (repo *StandingOrderRepository) GetStandingOrderByID(id int64) (*domain.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE id = ?`
+
+	standingOrder, err := scanStandingOrder(repo.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStandingOrderNotFound
+		}
+		return nil, err
+	}
+	return standingOrder, nil
+}
+
+func (repo *StandingOrderRepository) GetStandingOrdersByUserID(userID int64) ([]domain.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE from_user_id = ? ORDER BY id`
+	return repo.queryStandingOrders(query, userID)
+}
+
+func (repo *StandingOrderRepository) GetDueStandingOrders(now time.Time, limit int) ([]domain.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE status = ? AND next_run_at <= ? ORDER BY next_run_at, id LIMIT ?`
+	return repo.queryStandingOrders(query, domain.StandingOrderActive, now, limit)
+}
+
+/*
+UpdateStandingOrder only writes an active order whose next_run_at is still
+expectedNextRunAt, so a cancel or an edit in the meantime isn't overwritten.
+*/
+func (repo *StandingOrderRepository) UpdateStandingOrder(standingOrder *domain.StandingOrder, expectedNextRunAt time.Time) (bool, error) {
+	query := `UPDATE standing_orders SET amount = ?, currency = ?, frequency = ?, day = ?, end_date = ?, max_occurrences = ?, occurrence_at = ?, next_run_at = ?,
+		occurrences_count = ?, retry_count = ?, insufficient_funds_policy = ?, max_retries = ?, status = ?, updated_at = ?
+		WHERE id = ? AND status = ? AND next_run_at = ?`
+	result, err := repo.db.Exec(query, standingOrder.Amount, standingOrder.Amount.Currency(), standingOrder.Recurrence.Frequency, standingOrder.Recurrence.Day,
+		standingOrder.Recurrence.EndDate, standingOrder.Recurrence.MaxOccurrences, standingOrder.OccurrenceAt, standingOrder.NextRunAt,
+		standingOrder.OccurrencesCount, standingOrder.RetryCount, standingOrder.InsufficientFundsPolicy, standingOrder.MaxRetries,
+		standingOrder.Status, standingOrder.UpdatedAt, standingOrder.ID, domain.StandingOrderActive, expectedNextRunAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (repo *StandingOrderRepository) CreateAttempt(attempt *domain.StandingOrderAttempt) error {
+	var transactionID sql.NullInt64
+	if attempt.TransactionID != nil {
+		transactionID.Int64 = *attempt.TransactionID
+		transactionID.Valid = true
+	}
+
+	query := `INSERT INTO standing_order_attempts (standing_order_id, occurrence_at, attempted_at, status, transaction_id, failure_reason) VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, attempt.StandingOrderID, attempt.OccurrenceAt, attempt.AttemptedAt, attempt.Status, transactionID, truncateRunes(attempt.FailureReason, failureReasonMaxLength))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	attempt.ID = id
+	return nil
+}
+
+func (repo *StandingOrderRepository) GetAttempts(standingOrderID int64) ([]domain.StandingOrderAttempt, error) {
+	query := `SELECT id, standing_order_id, occurrence_at, attempted_at, status, transaction_id, failure_reason FROM standing_order_attempts WHERE standing_order_id = ? ORDER BY id`
+	rows, err := repo.db.Query(query, standingOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []domain.StandingOrderAttempt
+
+	for rows.Next() {
+		var attempt domain.StandingOrderAttempt
+		var transactionID sql.NullInt64
+		var failureReason sql.NullString
+
+		err := rows.Scan(&attempt.ID, &attempt.StandingOrderID, &attempt.OccurrenceAt, &attempt.AttemptedAt, &attempt.Status, &transactionID, &failureReason)
+		if err != nil {
+			return nil, err
+		}
+
+		if transactionID.Valid {
+			attempt.TransactionID = &transactionID.Int64
+		}
+		attempt.FailureReason = failureReason.String
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+func (repo *StandingOrderRepository) queryStandingOrders(query string, args ...any) ([]domain.StandingOrder, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var standingOrders []domain.StandingOrder
+
+	for rows.Next() {
+		standingOrder, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		standingOrders = append(standingOrders, *standingOrder)
+	}
+
+	return standingOrders, rows.Err()
+}
+
+func scanStandingOrder(scanner rowScanner) (*domain.StandingOrder, error) {
+	var standingOrder domain.StandingOrder
+	var amount string
+	var currency domain.Currency
+	var endDate sql.NullTime
+	var maxOccurrences sql.NullInt64
+
+	err := scanner.Scan(&standingOrder.ID, &standingOrder.FromUser, &standingOrder.ToUser, &amount, &currency, &standingOrder.Recurrence.Frequency,
+		&standingOrder.Recurrence.Day, &standingOrder.StartAt, &endDate, &maxOccurrences, &standingOrder.OccurrenceAt, &standingOrder.NextRunAt,
+		&standingOrder.OccurrencesCount, &standingOrder.RetryCount, &standingOrder.InsufficientFundsPolicy, &standingOrder.MaxRetries,
+		&standingOrder.Status, &standingOrder.CreatedAt, &standingOrder.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if standingOrder.Amount, err = domain.ParseMoney(amount, currency); err != nil {
+		return nil, err
+	}
+	if endDate.Valid {
+		standingOrder.Recurrence.EndDate = &endDate.Time
+	}
+	if maxOccurrences.Valid {
+		count := int(maxOccurrences.Int64)
+		standingOrder.Recurrence.MaxOccurrences = &count
+	}
+
+	return &standingOrder, nil
+}
@@ -2,46 +2,84 @@ package persistence
 
 import (
 	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/denizdoganinsider/kpi_project/domain"
 )
 
+/* ErrTransactionStatusConflict means the transaction wasn't in the expected status anymore */
+var ErrTransactionStatusConflict = errors.New("transaction status was changed concurrently")
+
+/* statusReasonMaxLength is the size of transaction_status_history.reason */
+const statusReasonMaxLength = 255
+
 type ITransactionRepository interface {
 	CreateTransaction(transaction *domain.Transaction) error
 	GetTransactionByID(id int64) (*domain.Transaction, error)
-	UpdateTransactionStatus(id int64, status domain.TransactionStatus) error
-	GetUserTransactions(userID int64) ([]domain.Transaction, error)
-	UpdateBalance(userID int64, amount float64) error
+	GetTransactionByIDForUpdate(id int64) (*domain.Transaction, error)
+	GetChildTransactions(parentID int64) ([]domain.Transaction, error)
+	UpdateTransactionStatus(id int64, from domain.TransactionStatus, to domain.TransactionStatus, reason string) error
+	GetTransactionStatusHistory(transactionID int64) ([]domain.TransactionStatusChange, error)
+	GetUserTransactions(userID int64, filter domain.TransactionFilter) ([]domain.Transaction, error)
+	GetUsageSince(userID int64, transactionType domain.TransactionType, currency domain.Currency, since time.Time) (domain.Money, int, error)
+	GetHistoricalUsage(userID int64, transactionType domain.TransactionType, currency domain.Currency) (domain.Money, int, error)
+	HasCompletedTransfer(fromUserID int64, toUserID int64) (bool, error)
+	StreamUserTransactions(userID int64, currency domain.Currency, from time.Time, to time.Time, fn func(domain.Transaction) error) error
 }
 
 type TransactionRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
+const transactionColumns = `id, parent_transaction_id, from_user_id, to_user_id, amount, currency, type, status, created_at`
+
 func NewTransactionRepository(db *sql.DB) ITransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
 func (repo *TransactionRepository) CreateTransaction(transaction *domain.Transaction) error {
-	var toUser sql.NullInt64
+	var parentID, toUser sql.NullInt64
+	if transaction.ParentID != nil {
+		parentID.Int64 = *transaction.ParentID
+		parentID.Valid = true
+	}
 	if transaction.ToUser != nil {
 		toUser.Int64 = *transaction.ToUser
 		toUser.Valid = true
 	}
 
-	query := `INSERT INTO transactions (from_user_id, to_user_id, amount, type, status, created_at) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := repo.db.Exec(query, transaction.FromUser, transaction.ToUser, transaction.Amount, transaction.Type, transaction.Status, transaction.CreatedAt)
-	return err
+	query := `INSERT INTO transactions (parent_transaction_id, from_user_id, to_user_id, amount, currency, type, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := repo.db.Exec(query, parentID, transaction.FromUser, toUser, transaction.Amount, transaction.Currency, transaction.Type, transaction.Status, transaction.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	transaction.ID = id
+	return repo.recordStatusChange(id, nil, transaction.Status, "created")
 }
 
 func (repo *TransactionRepository) GetTransactionByID(id int64) (*domain.Transaction, error) {
-	query := `SELECT * FROM transactions WHERE id = ?`
-	row := repo.db.QueryRow(query, id)
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = ?`
+	return repo.getTransaction(query, id)
+}
 
-	var transaction domain.Transaction
-	var toUser sql.NullInt64
-	err := row.Scan(&transaction.ID, &transaction.FromUser, &toUser, &transaction.Amount, &transaction.Type, &transaction.Status, &transaction.CreatedAt)
+/* GetTransactionByIDForUpdate locks the row until the surrounding unit of work ends */
+func (repo *TransactionRepository) GetTransactionByIDForUpdate(id int64) (*domain.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = ? FOR UPDATE`
+	return repo.getTransaction(query, id)
+}
 
+func (repo *TransactionRepository) getTransaction(query string, id int64) (*domain.Transaction, error) {
+	row := repo.db.QueryRow(query, id)
+
+	transaction, err := scanTransaction(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -49,22 +87,247 @@ func (repo *TransactionRepository) GetTransactionByID(id int64) (*domain.Transac
 		return nil, err
 	}
 
-	if toUser.Valid {
-		transaction.ToUser = &toUser.Int64
+	return transaction, nil
+}
+
+/*
+UpdateTransactionStatus moves a transaction along the state machine. It is a
+compare-and-set on from, so of two concurrent changes only the first wins, and
+the change is recorded in the status history with reason.
+*/
+func (repo *TransactionRepository) UpdateTransactionStatus(id int64, from domain.TransactionStatus, to domain.TransactionStatus, reason string) error {
+	if !from.CanTransitionTo(to) {
+		return domain.ErrInvalidStatusTransition
 	}
 
-	return &transaction, nil
+	query := `UPDATE transactions SET status = ? WHERE id = ? AND status = ?`
+	result, err := repo.db.Exec(query, to, id, from)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrTransactionStatusConflict
+	}
+
+	return repo.recordStatusChange(id, &from, to, reason)
 }
 
-func (repo *TransactionRepository) UpdateTransactionStatus(id int64, status domain.TransactionStatus) error {
-	query := `UPDATE transactions SET status = ? WHERE id = ?`
-	_, err := repo.db.Exec(query, status, id)
+func (repo *TransactionRepository) GetTransactionStatusHistory(transactionID int64) ([]domain.TransactionStatusChange, error) {
+	query := `SELECT id, transaction_id, from_status, to_status, reason, changed_at FROM transaction_status_history WHERE transaction_id = ? ORDER BY id`
+	rows, err := repo.db.Query(query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []domain.TransactionStatusChange
+
+	for rows.Next() {
+		var change domain.TransactionStatusChange
+		var from sql.NullString
+		if err := rows.Scan(&change.ID, &change.TransactionID, &from, &change.To, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		if from.Valid {
+			status := domain.TransactionStatus(from.String)
+			change.From = &status
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (repo *TransactionRepository) recordStatusChange(transactionID int64, from *domain.TransactionStatus, to domain.TransactionStatus, reason string) error {
+	query := `INSERT INTO transaction_status_history (transaction_id, from_status, to_status, reason, changed_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := repo.db.Exec(query, transactionID, from, to, truncateRunes(reason, statusReasonMaxLength), time.Now())
 	return err
 }
 
-func (repo *TransactionRepository) GetUserTransactions(userID int64) ([]domain.Transaction, error) {
-	query := `SELECT id, from_user_id, to_user_id, amount, type, status, created_at FROM transactions WHERE from_user_id = ? OR to_user_id = ?`
-	rows, err := repo.db.Query(query, userID, userID)
+/*
+GetUserTransactions returns a page of the transactions the user sent or
+received. Sent and received are read separately so each side walks its own
+(user, created_at, id) index, then the two are merged into one page.
+*/
+func (repo *TransactionRepository) GetUserTransactions(userID int64, filter domain.TransactionFilter) ([]domain.Transaction, error) {
+	order := "DESC"
+	if filter.Ascending {
+		order = "ASC"
+	}
+	orderBy := ` ORDER BY created_at ` + order + `, id ` + order + ` LIMIT ?`
+
+	conditions, args := transactionFilterConditions(filter)
+
+	sent := `SELECT ` + transactionColumns + ` FROM transactions WHERE from_user_id = ?`
+	sentArgs := []any{userID}
+	received := `SELECT ` + transactionColumns + ` FROM transactions WHERE to_user_id = ? AND from_user_id <> ?`
+	receivedArgs := []any{userID, userID}
+	if filter.Counterparty != nil {
+		sent += ` AND to_user_id = ?`
+		sentArgs = append(sentArgs, *filter.Counterparty)
+		received += ` AND from_user_id = ?`
+		receivedArgs = append(receivedArgs, *filter.Counterparty)
+	}
+
+	query := `SELECT ` + transactionColumns + ` FROM ((` + sent + conditions + orderBy + `) UNION ALL (` + received + conditions + orderBy + `)) AS history` + orderBy
+
+	var queryArgs []any
+	queryArgs = append(queryArgs, sentArgs...)
+	queryArgs = append(queryArgs, args...)
+	queryArgs = append(queryArgs, filter.Limit)
+	queryArgs = append(queryArgs, receivedArgs...)
+	queryArgs = append(queryArgs, args...)
+	queryArgs = append(queryArgs, filter.Limit, filter.Limit)
+	return repo.queryTransactions(query, queryArgs...)
+}
+
+func transactionFilterConditions(filter domain.TransactionFilter) (string, []any) {
+	var conditions strings.Builder
+	var args []any
+
+	if len(filter.Types) > 0 {
+		conditions.WriteString(` AND type IN (?` + strings.Repeat(`, ?`, len(filter.Types)-1) + `)`)
+		for _, transactionType := range filter.Types {
+			args = append(args, transactionType)
+		}
+	}
+	if len(filter.Statuses) > 0 {
+		conditions.WriteString(` AND status IN (?` + strings.Repeat(`, ?`, len(filter.Statuses)-1) + `)`)
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.Currency != "" {
+		conditions.WriteString(` AND currency = ?`)
+		args = append(args, filter.Currency)
+	}
+	if filter.MinAmount != nil {
+		conditions.WriteString(` AND ABS(amount) >= ?`)
+		args = append(args, *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		conditions.WriteString(` AND ABS(amount) <= ?`)
+		args = append(args, *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		conditions.WriteString(` AND created_at >= ?`)
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions.WriteString(` AND created_at < ?`)
+		args = append(args, *filter.To)
+	}
+	if filter.After != nil {
+		comparison := `<`
+		if filter.Ascending {
+			comparison = `>`
+		}
+		conditions.WriteString(` AND (created_at ` + comparison + ` ? OR (created_at = ? AND id ` + comparison + ` ?))`)
+		args = append(args, filter.After.CreatedAt, filter.After.CreatedAt, filter.After.ID)
+	}
+
+	return conditions.String(), args
+}
+
+func (repo *TransactionRepository) GetChildTransactions(parentID int64) ([]domain.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE parent_transaction_id = ? ORDER BY id`
+	return repo.queryTransactions(query, parentID)
+}
+
+/*
+GetUsageSince sums the completed transactions of a type the user sent since
+the given time, captures counting as the transfers or debits they pay out.
+It reads with a shared lock so a concurrent unit of work sees the
+transactions committed while it waited for the balance lock.
+*/
+func (repo *TransactionRepository) GetUsageSince(userID int64, transactionType domain.TransactionType, currency domain.Currency, since time.Time) (domain.Money, int, error) {
+	return repo.getUsage(userID, transactionType, currency, since, ` LOCK IN SHARE MODE`)
+}
+
+/*
+GetHistoricalUsage sums every completed transaction of a type the user ever
+sent, like GetUsageSince but without locking: it only feeds an estimate, and
+share-locking the whole history would block the user's next payments.
+*/
+func (repo *TransactionRepository) GetHistoricalUsage(userID int64, transactionType domain.TransactionType, currency domain.Currency) (domain.Money, int, error) {
+	return repo.getUsage(userID, transactionType, currency, time.Time{}, ``)
+}
+
+func (repo *TransactionRepository) getUsage(userID int64, transactionType domain.TransactionType, currency domain.Currency, since time.Time, lock string) (domain.Money, int, error) {
+	query := `SELECT COALESCE(SUM(ABS(amount)), 0), COUNT(*) FROM transactions
+		WHERE from_user_id = ? AND (type = ? OR (type = ? AND (to_user_id IS NOT NULL) = ?)) AND currency = ? AND status = ? AND created_at >= ?` + lock
+
+	/* only transfers and debits have captures counting towards them, an empty type matches nothing */
+	var captureType domain.TransactionType
+	if transactionType == domain.TransferTransaction || transactionType == domain.DebitTransaction {
+		captureType = domain.CaptureTransaction
+	}
+
+	var total string
+	var count int
+	if err := repo.db.QueryRow(query, userID, transactionType, captureType, transactionType == domain.TransferTransaction, currency, domain.Completed, since).Scan(&total, &count); err != nil {
+		return domain.Money{}, 0, err
+	}
+
+	amount, err := domain.ParseMoney(total, currency)
+	if err != nil {
+		return domain.Money{}, 0, err
+	}
+	return amount, count, nil
+}
+
+/* HasCompletedTransfer tells whether the sender ever paid the receiver, in any currency */
+func (repo *TransactionRepository) HasCompletedTransfer(fromUserID int64, toUserID int64) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM transactions WHERE from_user_id = ? AND to_user_id = ? AND type = ? AND status IN (?, ?))`
+
+	var exists bool
+	if err := repo.db.QueryRow(query, fromUserID, toUserID, domain.TransferTransaction, domain.Completed, domain.Reversed).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+/*
+StreamUserTransactions hands the transactions that moved money of the user in
+[from, to) to fn oldest first, one row at a time, so long periods aren't held
+in memory. An error from fn stops the stream and is returned.
+*/
+func (repo *TransactionRepository) StreamUserTransactions(userID int64, currency domain.Currency, from time.Time, to time.Time, fn func(domain.Transaction) error) error {
+	query := `SELECT ` + transactionColumns + ` FROM (
+		(SELECT ` + transactionColumns + ` FROM transactions
+			WHERE from_user_id = ? AND currency = ? AND status IN (?, ?) AND created_at >= ? AND created_at < ?)
+		UNION ALL
+		(SELECT ` + transactionColumns + ` FROM transactions
+			WHERE to_user_id = ? AND from_user_id <> ? AND currency = ? AND status IN (?, ?) AND created_at >= ? AND created_at < ?)
+	) AS statement ORDER BY created_at, id`
+
+	rows, err := repo.db.Query(query, userID, currency, domain.Completed, domain.Reversed, from, to,
+		userID, userID, currency, domain.Completed, domain.Reversed, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(*transaction); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (repo *TransactionRepository) queryTransactions(query string, args ...any) ([]domain.Transaction, error) {
+	rows, err := repo.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,25 +336,38 @@ func (repo *TransactionRepository) GetUserTransactions(userID int64) ([]domain.T
 	var transactions []domain.Transaction
 
 	for rows.Next() {
-		var transaction domain.Transaction
-		var toUser sql.NullInt64
-		err := rows.Scan(&transaction.ID, &transaction.FromUser, &toUser, &transaction.Amount, &transaction.Type, &transaction.Status, &transaction.CreatedAt)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if toUser.Valid {
-			transaction.ToUser = &toUser.Int64
-		}
-
-		transactions = append(transactions, transaction)
+		transactions = append(transactions, *transaction)
 	}
 
 	return transactions, nil
 }
 
-func (repo *TransactionRepository) UpdateBalance(userID int64, amount float64) error {
-	query := `UPDATE balances SET amount = amount + ? WHERE user_id = ?`
-	_, err := repo.db.Exec(query, amount, userID)
-	return err
+func scanTransaction(scanner rowScanner) (*domain.Transaction, error) {
+	var transaction domain.Transaction
+	var parentID, toUser sql.NullInt64
+	var amount string
+
+	err := scanner.Scan(&transaction.ID, &parentID, &transaction.FromUser, &toUser, &amount, &transaction.Currency, &transaction.Type, &transaction.Status, &transaction.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		transaction.ParentID = &parentID.Int64
+	}
+	if toUser.Valid {
+		transaction.ToUser = &toUser.Int64
+	}
+
+	transaction.Amount, err = domain.ParseMoney(amount, transaction.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
 }
@@ -0,0 +1,253 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+var ErrTransferBatchNotFound = errors.New("transfer batch not found")
+
+const transferBatchItemColumns = `id, batch_id, line_number, to_user_id, amount, currency, reference, status, transaction_id, failure_reason, updated_at`
+
+type ITransferBatchRepository interface {
+	CreateTransferBatch(transferBatch *domain.TransferBatch) error
+	GetTransferBatchByID(id int64) (*domain.TransferBatch, error)
+	GetTransferBatchItems(batchID int64) ([]domain.TransferBatchItem, error)
+	GetPendingTransferBatchItems(limit int) ([]domain.TransferBatchItem, error)
+	GetStaleProcessingItems(updatedBefore time.Time, limit int) ([]domain.TransferBatchItem, error)
+	UpdateTransferBatchStatus(id int64, from domain.TransferBatchStatus, to domain.TransferBatchStatus) (bool, error)
+	UpdateItemStatus(id int64, from domain.TransferBatchItemStatus, to domain.TransferBatchItemStatus) (bool, error)
+	MarkItemCompleted(id int64, transactionID int64) error
+	MarkItemFailed(id int64, reason string) error
+	MarkItemAwaitingApproval(id int64, transactionID int64) error
+	FailStaleItem(id int64, updatedBefore time.Time, reason string) (bool, error)
+	CancelPendingItems(batchID int64) error
+}
+
+type TransferBatchRepository struct {
+	db *sql.DB
+}
+
+func NewTransferBatchRepository(db *sql.DB) ITransferBatchRepository {
+	return &TransferBatchRepository{db: db}
+}
+
+/* CreateTransferBatch stores the batch with all its items or nothing at all */
+func (repo *TransferBatchRepository) CreateTransferBatch(transferBatch *domain.TransferBatch) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO transfer_batches (from_user_id, currency, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, transferBatch.FromUser, transferBatch.Currency, transferBatch.Status, transferBatch.CreatedAt, transferBatch.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	batchID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `INSERT INTO transfer_batch_items (batch_id, line_number, to_user_id, amount, currency, reference, status, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	for i := range transferBatch.Items {
+		item := &transferBatch.Items[i]
+		result, err := tx.Exec(itemQuery, batchID, item.Row, item.ToUser, item.Amount, item.Amount.Currency(), item.Reference, item.Status, item.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		if item.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+		item.BatchID = batchID
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	transferBatch.ID = batchID
+	return nil
+}
+
+func (repo *TransferBatchRepository) GetTransferBatchByID(id int64) (*domain.TransferBatch, error) {
+	query := `SELECT id, from_user_id, currency, status, created_at, updated_at FROM transfer_batches WHERE id = ?`
+
+	var transferBatch domain.TransferBatch
+	err := repo.db.QueryRow(query, id).Scan(&transferBatch.ID, &transferBatch.FromUser, &transferBatch.Currency, &transferBatch.Status,
+		&transferBatch.CreatedAt, &transferBatch.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTransferBatchNotFound
+		}
+		return nil, err
+	}
+
+	if transferBatch.Progress, err = repo.getProgress(id); err != nil {
+		return nil, err
+	}
+	return &transferBatch, nil
+}
+
+func (repo *TransferBatchRepository) GetTransferBatchItems(batchID int64) ([]domain.TransferBatchItem, error) {
+	query := `SELECT ` + transferBatchItemColumns + ` FROM transfer_batch_items WHERE batch_id = ? ORDER BY line_number`
+	return repo.queryItems(query, batchID)
+}
+
+/* GetPendingTransferBatchItems returns the oldest items waiting in batches that are still running */
+func (repo *TransferBatchRepository) GetPendingTransferBatchItems(limit int) ([]domain.TransferBatchItem, error) {
+	query := `SELECT i.id, i.batch_id, i.line_number, i.to_user_id, i.amount, i.currency, i.reference, i.status, i.transaction_id, i.failure_reason, i.updated_at
+		FROM transfer_batch_items i JOIN transfer_batches b ON b.id = i.batch_id
+		WHERE b.status = ? AND i.status = ? ORDER BY i.id LIMIT ?`
+	return repo.queryItems(query, domain.BatchProcessing, domain.BatchItemPending, limit)
+}
+
+/* GetStaleProcessingItems returns the items claimed before updatedBefore that were never finished */
+func (repo *TransferBatchRepository) GetStaleProcessingItems(updatedBefore time.Time, limit int) ([]domain.TransferBatchItem, error) {
+	query := `SELECT ` + transferBatchItemColumns + ` FROM transfer_batch_items WHERE status = ? AND updated_at < ? ORDER BY id LIMIT ?`
+	return repo.queryItems(query, domain.BatchItemProcessing, updatedBefore, limit)
+}
+
+/* UpdateTransferBatchStatus is a compare-and-set, like the scheduled transfer one */
+func (repo *TransferBatchRepository) UpdateTransferBatchStatus(id int64, from domain.TransferBatchStatus, to domain.TransferBatchStatus) (bool, error) {
+	query := `UPDATE transfer_batches SET status = ?, updated_at = NOW() WHERE id = ? AND status = ?`
+	result, err := repo.db.Exec(query, to, id, from)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (repo *TransferBatchRepository) UpdateItemStatus(id int64, from domain.TransferBatchItemStatus, to domain.TransferBatchItemStatus) (bool, error) {
+	query := `UPDATE transfer_batch_items SET status = ?, updated_at = NOW() WHERE id = ? AND status = ?`
+	result, err := repo.db.Exec(query, to, id, from)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (repo *TransferBatchRepository) MarkItemCompleted(id int64, transactionID int64) error {
+	query := `UPDATE transfer_batch_items SET status = ?, transaction_id = ?, updated_at = NOW() WHERE id = ?`
+	_, err := repo.db.Exec(query, domain.BatchItemCompleted, transactionID, id)
+	return err
+}
+
+func (repo *TransferBatchRepository) MarkItemAwaitingApproval(id int64, transactionID int64) error {
+	query := `UPDATE transfer_batch_items SET status = ?, transaction_id = ?, updated_at = NOW() WHERE id = ?`
+	_, err := repo.db.Exec(query, domain.BatchItemAwaitingApproval, transactionID, id)
+	return err
+}
+
+func (repo *TransferBatchRepository) MarkItemFailed(id int64, reason string) error {
+	query := `UPDATE transfer_batch_items SET status = ?, failure_reason = ?, updated_at = NOW() WHERE id = ?`
+	_, err := repo.db.Exec(query, domain.BatchItemFailed, truncateRunes(reason, failureReasonMaxLength), id)
+	return err
+}
+
+/* FailStaleItem fails the item only if it is still claimed since before updatedBefore */
+func (repo *TransferBatchRepository) FailStaleItem(id int64, updatedBefore time.Time, reason string) (bool, error) {
+	query := `UPDATE transfer_batch_items SET status = ?, failure_reason = ?, updated_at = NOW() WHERE id = ? AND status = ? AND updated_at < ?`
+	result, err := repo.db.Exec(query, domain.BatchItemFailed, truncateRunes(reason, failureReasonMaxLength), id, domain.BatchItemProcessing, updatedBefore)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (repo *TransferBatchRepository) CancelPendingItems(batchID int64) error {
+	query := `UPDATE transfer_batch_items SET status = ?, updated_at = NOW() WHERE batch_id = ? AND status = ?`
+	_, err := repo.db.Exec(query, domain.BatchItemCancelled, batchID, domain.BatchItemPending)
+	return err
+}
+
+func (repo *TransferBatchRepository) getProgress(batchID int64) (domain.TransferBatchProgress, error) {
+	query := `SELECT status, COUNT(*) FROM transfer_batch_items WHERE batch_id = ? GROUP BY status`
+	rows, err := repo.db.Query(query, batchID)
+	if err != nil {
+		return domain.TransferBatchProgress{}, err
+	}
+	defer rows.Close()
+
+	var progress domain.TransferBatchProgress
+
+	for rows.Next() {
+		var status domain.TransferBatchItemStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return domain.TransferBatchProgress{}, err
+		}
+
+		progress.Total += count
+		switch status {
+		case domain.BatchItemPending, domain.BatchItemProcessing:
+			progress.Pending += count
+		case domain.BatchItemCompleted:
+			progress.Completed += count
+		case domain.BatchItemFailed:
+			progress.Failed += count
+		case domain.BatchItemCancelled:
+			progress.Cancelled += count
+		case domain.BatchItemAwaitingApproval:
+			progress.AwaitingApproval += count
+		}
+	}
+
+	return progress, rows.Err()
+}
+
+func (repo *TransferBatchRepository) queryItems(query string, args ...any) ([]domain.TransferBatchItem, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.TransferBatchItem
+
+	for rows.Next() {
+		item, err := scanTransferBatchItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+func scanTransferBatchItem(scanner rowScanner) (*domain.TransferBatchItem, error) {
+	var item domain.TransferBatchItem
+	var amount string
+	var currency domain.Currency
+	var transactionID sql.NullInt64
+	var failureReason sql.NullString
+
+	err := scanner.Scan(&item.ID, &item.BatchID, &item.Row, &item.ToUser, &amount, &currency, &item.Reference,
+		&item.Status, &transactionID, &failureReason, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Amount, err = domain.ParseMoney(amount, currency); err != nil {
+		return nil, err
+	}
+	if transactionID.Valid {
+		item.TransactionID = &transactionID.Int64
+	}
+	item.FailureReason = failureReason.String
+
+	return &item, nil
+}
@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+/*
+DBTX is implemented by both *sql.DB and *sql.Tx, so a repository can run
+standalone or as a participant of a unit of work without knowing which.
+*/
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+/* rowScanner is implemented by both *sql.Row and *sql.Rows */
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+/* truncateRunes cuts value to at most maxLength characters, never inside a multi-byte one */
+func truncateRunes(value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+	return string(runes[:maxLength])
+}
+
+/* Repositories groups the repositories sharing the same *sql.Tx */
+type Repositories struct {
+	Transactions ITransactionRepository
+	Balances     IBalanceRepository
+	Ledger       ILedgerRepository
+	Fx           IFxRepository
+	Holds        IHoldRepository
+	Approvals    IApprovalRepository
+	RiskReviews  IRiskRepository
+	Disputes     IDisputeRepository
+	Overdrafts   IOverdraftRepository
+	Pockets      IPocketRepository
+	SavingsGoals ISavingsGoalRepository
+}
+
+type IUnitOfWork interface {
+	Execute(fn func(repositories Repositories) error) error
+}
+
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) IUnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+/*
+Execute runs fn inside a single database transaction. The transaction is
+committed when fn returns nil and rolled back on error or panic.
+*/
+func (unitOfWork *UnitOfWork) Execute(fn func(repositories Repositories) error) (err error) {
+	tx, err := unitOfWork.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting unit of work: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	repositories := Repositories{
+		Transactions: &TransactionRepository{db: tx},
+		Balances:     &BalanceRepository{db: tx},
+		Ledger:       &LedgerRepository{db: tx},
+		Fx:           &FxRepository{db: tx},
+		Holds:        &HoldRepository{db: tx},
+		Approvals:    &ApprovalRepository{db: tx},
+		RiskReviews:  &RiskRepository{db: tx},
+		Disputes:     &DisputeRepository{db: tx},
+		Overdrafts:   &OverdraftRepository{db: tx},
+		Pockets:      &PocketRepository{db: tx},
+		SavingsGoals: &SavingsGoalRepository{db: tx},
+	}
+
+	if err = fn(repositories); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing unit of work: %w", err)
+	}
+	return nil
+}
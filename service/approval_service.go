@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* expiredApprovalsBatchSize bounds how many approvals one tick expires */
+const expiredApprovalsBatchSize = 100
+
+/* approvalNoteMaxLength matches the note column of transfer_approvals */
+const approvalNoteMaxLength = 255
+
+var (
+	ErrNotApprover         = errors.New("only approvers can decide on transfer approvals")
+	ErrSelfApproval        = errors.New("the maker of a transfer cannot decide on its approval")
+	ErrApprovalNotPending  = errors.New("only pending approvals can be approved or rejected")
+	ErrApprovalExpired     = errors.New("approval has expired")
+	ErrApprovalNoteTooLong = fmt.Errorf("note cannot be longer than %d characters", approvalNoteMaxLength)
+)
+
+type IApprovalService interface {
+	Approve(transactionID int64, checkerID int64, note string) (*domain.Transaction, error)
+	Reject(transactionID int64, checkerID int64, note string) (*domain.TransferApproval, error)
+	GetApproval(transactionID int64) (*domain.TransferApproval, error)
+	GetPendingApprovals() ([]domain.TransferApproval, error)
+	ExpireApprovals(ctx context.Context, now time.Time) error
+}
+
+type ApprovalService struct {
+	approvalRepository persistence.IApprovalRepository
+	userRepository     persistence.IUserRepository
+	unitOfWork         persistence.IUnitOfWork
+	transactionService ITransactionService
+}
+
+func NewApprovalService(approvalRepository persistence.IApprovalRepository, userRepository persistence.IUserRepository, unitOfWork persistence.IUnitOfWork, transactionService ITransactionService) IApprovalService {
+	return &ApprovalService{
+		approvalRepository: approvalRepository,
+		userRepository:     userRepository,
+		unitOfWork:         unitOfWork,
+		transactionService: transactionService,
+	}
+}
+
+/*
+Approve executes the awaiting transfer and records the checker's decision in
+the same unit of work. When the transfer can't be executed, for example the
+maker's balance is short by now, nothing changes and the approval stays
+pending until it is rejected or expires.
+*/
+func (approvalService *ApprovalService) Approve(transactionID int64, checkerID int64, note string) (*domain.Transaction, error) {
+	var transaction *domain.Transaction
+
+	err := approvalService.decide(transactionID, checkerID, domain.ApprovalApproved, note, func(repositories persistence.Repositories, approval *domain.TransferApproval) error {
+		var err error
+		transaction, err = repositories.Transactions.GetTransactionByIDForUpdate(transactionID)
+		if err != nil {
+			return err
+		}
+		if transaction == nil {
+			return ErrTransactionNotFound
+		}
+		return approvalService.transactionService.Settle(repositories, transaction, fmt.Sprintf("approved by user %d", checkerID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+/* Reject closes the approval, the transfer never moves any money */
+func (approvalService *ApprovalService) Reject(transactionID int64, checkerID int64, note string) (*domain.TransferApproval, error) {
+	var rejected *domain.TransferApproval
+
+	err := approvalService.decide(transactionID, checkerID, domain.ApprovalRejected, note, func(repositories persistence.Repositories, approval *domain.TransferApproval) error {
+		rejected = approval
+		return repositories.Transactions.UpdateTransactionStatus(transactionID, domain.AwaitingApproval, domain.Rejected, fmt.Sprintf("rejected by user %d", checkerID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rejected, nil
+}
+
+func (approvalService *ApprovalService) GetApproval(transactionID int64) (*domain.TransferApproval, error) {
+	return approvalService.approvalRepository.GetApprovalByTransactionID(transactionID)
+}
+
+func (approvalService *ApprovalService) GetPendingApprovals() ([]domain.TransferApproval, error) {
+	return approvalService.approvalRepository.GetApprovalsByStatus(domain.ApprovalPending)
+}
+
+/* ExpireApprovals is the scheduler job closing approvals nobody decided on in time */
+func (approvalService *ApprovalService) ExpireApprovals(ctx context.Context, now time.Time) error {
+	expiredApprovals, err := approvalService.approvalRepository.GetExpiredApprovals(now, expiredApprovalsBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, expiredApproval := range expiredApprovals {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		err := approvalService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			approval, err := repositories.Approvals.GetApprovalByTransactionIDForUpdate(expiredApproval.TransactionID)
+			if err != nil {
+				return err
+			}
+			/* it may have been decided on since it was listed */
+			if approval.Status != domain.ApprovalPending || !approval.IsExpired(now) {
+				return nil
+			}
+
+			approval.Status = domain.ApprovalExpired
+			approval.DecidedAt = &now
+			if err := repositories.Approvals.UpdateApproval(approval); err != nil {
+				return err
+			}
+			return repositories.Transactions.UpdateTransactionStatus(approval.TransactionID, domain.AwaitingApproval, domain.Expired, "approval expired")
+		})
+		if err != nil {
+			log.Printf("Approval of transaction %d couldn't be expired: %v", expiredApproval.TransactionID, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+decide checks the checker may decide on the locked approval, runs apply for
+the transfer and records the decision, all within one unit of work. A balance
+write conflicting with a concurrent one runs the whole decision again.
+*/
+func (approvalService *ApprovalService) decide(transactionID int64, checkerID int64, status domain.ApprovalStatus, note string, apply func(repositories persistence.Repositories, approval *domain.TransferApproval) error) error {
+	if len(note) > approvalNoteMaxLength {
+		return ErrApprovalNoteTooLong
+	}
+
+	checker, err := approvalService.userRepository.GetById(checkerID)
+	if err != nil {
+		return err
+	}
+	if checker.Role != domain.ApproverRole {
+		return ErrNotApprover
+	}
+
+	return retryOnBalanceConflict(func() error {
+		return approvalService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			now := time.Now()
+
+			approval, err := repositories.Approvals.GetApprovalByTransactionIDForUpdate(transactionID)
+			if err != nil {
+				return err
+			}
+			if approval.Status != domain.ApprovalPending {
+				return ErrApprovalNotPending
+			}
+			if approval.IsExpired(now) {
+				return ErrApprovalExpired
+			}
+			if approval.MakerID == checkerID {
+				return ErrSelfApproval
+			}
+
+			if err := apply(repositories, approval); err != nil {
+				return err
+			}
+
+			approval.Decide(checkerID, status, note, now)
+			return repositories.Approvals.UpdateApproval(approval)
+		})
+	})
+}
@@ -2,74 +2,404 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"math/rand"
+	"strings"
+	"time"
 
 	"github.com/denizdoganinsider/kpi_project/domain"
 	"github.com/denizdoganinsider/kpi_project/persistence"
 )
 
+const balanceAdjustmentDescription = "balance adjustment"
+
+/*
+A write refused for an outdated balance version is retried this many times,
+waiting balanceConflictBackoff with jitter and twice as long after each try.
+*/
+const (
+	balanceConflictRetries = 5
+	balanceConflictBackoff = 5 * time.Millisecond
+)
+
+var (
+	ErrPocketClosed            = errors.New("pocket is closed")
+	ErrPocketNameTaken         = errors.New("an open pocket with this name already exists")
+	ErrInsufficientPocketFunds = errors.New("insufficient funds in pocket")
+)
+
 type IBalanceService interface {
-	GetBalanceByUserID(userID int64) (*domain.Balance, error)
-	UpdateBalance(userID int64, amount float64) error
-	CreateBalance(userID int64, amount float64) error
+	GetBalanceByUserID(userID int64, currency domain.Currency) (*domain.Balance, error)
+	GetBalanceBreakdown(userID int64, currency domain.Currency) (*domain.BalanceBreakdown, error)
+	GetWallets(userID int64) ([]domain.Balance, error)
+	UpdateBalance(userID int64, amount domain.Money) error
+	CreateBalance(userID int64, amount domain.Money) error
+	CreatePocket(userID int64, currency domain.Currency, name string) (*domain.Pocket, error)
+	GetPocket(pocketID int64) (*domain.Pocket, error)
+	GetPockets(userID int64, currency domain.Currency) ([]domain.Pocket, error)
+	RenamePocket(pocketID int64, name string) (*domain.Pocket, error)
+	ClosePocket(pocketID int64) (*domain.Pocket, error)
+	MoveToPocket(pocketID int64, amount domain.Money) (*domain.Pocket, error)
+	MoveFromPocket(pocketID int64, amount domain.Money) (*domain.Pocket, error)
 }
 
 type BalanceService struct {
 	balanceRepository persistence.IBalanceRepository
+	pocketRepository  persistence.IPocketRepository
+	unitOfWork        persistence.IUnitOfWork
 }
 
-func NewBalanceService(balanceRepository persistence.IBalanceRepository) IBalanceService {
+func NewBalanceService(balanceRepository persistence.IBalanceRepository, pocketRepository persistence.IPocketRepository, unitOfWork persistence.IUnitOfWork) IBalanceService {
 	return &BalanceService{
 		balanceRepository: balanceRepository,
+		pocketRepository:  pocketRepository,
+		unitOfWork:        unitOfWork,
 	}
 }
 
-func (balanceService *BalanceService) GetBalanceByUserID(userID int64) (*domain.Balance, error) {
-	balance, err := balanceService.balanceRepository.GetBalanceByUserID(userID)
+func (balanceService *BalanceService) GetBalanceByUserID(userID int64, currency domain.Currency) (*domain.Balance, error) {
+	balance, err := balanceService.balanceRepository.GetBalanceByUserID(userID, currency)
 	if err != nil {
 		return nil, err
 	}
 	return balance, nil
 }
 
-func (balanceService *BalanceService) UpdateBalance(userID int64, amount float64) error {
-	balance, err := balanceService.balanceRepository.GetBalanceByUserID(userID)
-	if err != nil && err.Error() != "user doesn't have balance" {
+/* GetBalanceBreakdown returns the balance with its open pockets, both read within one unit of work */
+func (balanceService *BalanceService) GetBalanceBreakdown(userID int64, currency domain.Currency) (*domain.BalanceBreakdown, error) {
+	var breakdown domain.BalanceBreakdown
+
+	err := balanceService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		balance, err := repositories.Balances.GetBalanceByUserID(userID, currency)
+		if err != nil {
+			return err
+		}
+		breakdown.Balance = *balance
+
+		breakdown.Pockets, err = repositories.Pockets.GetOpenPockets(userID, currency)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &breakdown, nil
+}
+
+func (balanceService *BalanceService) GetWallets(userID int64) ([]domain.Balance, error) {
+	return balanceService.balanceRepository.GetBalancesByUserID(userID)
+}
+
+func (balanceService *BalanceService) UpdateBalance(userID int64, amount domain.Money) error {
+	if amount.IsZero() {
+		return errors.New("amount cannot be zero")
+	}
+
+	var newAmount domain.Money
+
+	/* the balance isn't locked, a concurrent write makes the update conflict and run again */
+	err := retryOnBalanceConflict(func() error {
+		return balanceService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			var err error
+			newAmount, err = balanceService.adjust(repositories, userID, amount)
+			return err
+		})
+	})
+	if err != nil {
 		return err
 	}
 
+	log.Printf("Balance for user %d updated to %s", userID, newAmount)
+	return nil
+}
+
+/*
+adjust adds amount to the user's balance, opening it when there is none yet.
+The change is recorded as an adjustment transaction so it shows up in
+statements like every other balance change.
+*/
+func (balanceService *BalanceService) adjust(repositories persistence.Repositories, userID int64, amount domain.Money) (domain.Money, error) {
+	balance, err := repositories.Balances.GetBalanceByUserID(userID, amount.Currency())
+	if err != nil && !errors.Is(err, persistence.ErrBalanceNotFound) {
+		return domain.Money{}, err
+	}
+
 	/* if we cannot find any balance for user, system create a new balance */
 	if balance == nil {
-		err = balanceService.balanceRepository.CreateBalance(userID, amount)
-		if err != nil {
+		if err := repositories.Balances.CreateBalance(userID, amount); err != nil {
+			return domain.Money{}, err
+		}
+		return amount, recordAdjustment(repositories, userID, amount)
+	}
+
+	/* if there is a balance we update to new balance */
+	newAmount, err := balance.Amount.Add(amount)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	if err := checkSpendable(balance, newAmount); err != nil {
+		return domain.Money{}, err
+	}
+
+	if err := repositories.Balances.UpdateBalance(balance, newAmount); err != nil {
+		return domain.Money{}, err
+	}
+	return newAmount, recordAdjustment(repositories, userID, amount)
+}
+
+func (balanceService *BalanceService) CreateBalance(userID int64, amount domain.Money) error {
+	// Kullanıcı için yeni bir bakiye oluşturulur
+	err := balanceService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		if err := repositories.Balances.CreateBalance(userID, amount); err != nil {
+			return err
+		}
+		if amount.IsZero() {
+			return nil
+		}
+		return recordAdjustment(repositories, userID, amount)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("New balance created for user %d with amount %s", userID, amount)
+	return nil
+}
+
+/*
+recordAdjustment stores the adjustment transaction of a balance already
+changed by amount, posts it and runs the savings sweeps a credit sets off.
+*/
+func recordAdjustment(repositories persistence.Repositories, userID int64, amount domain.Money) error {
+	adjustment := &domain.Transaction{
+		FromUser:  userID,
+		Amount:    amount,
+		Currency:  amount.Currency(),
+		Type:      domain.AdjustmentTransaction,
+		Status:    domain.Pending,
+		CreatedAt: time.Now(),
+	}
+	if err := repositories.Transactions.CreateTransaction(adjustment); err != nil {
+		return err
+	}
+
+	if err := postBalanceChanges(repositories, &adjustment.ID, balanceAdjustmentDescription, adjustment.BalanceDeltas(), domain.ExternalAccountCode(amount.Currency())); err != nil {
+		return err
+	}
+	if err := sweepIntoSavings(repositories, adjustment, adjustment.BalanceDeltas()); err != nil {
+		return err
+	}
+	return repositories.Transactions.UpdateTransactionStatus(adjustment.ID, domain.Pending, domain.Completed, balanceAdjustmentDescription)
+}
+
+/*
+CreatePocket opens an empty pocket in the user's wallet, opening an empty
+wallet first when the user has none in the currency yet.
+*/
+func (balanceService *BalanceService) CreatePocket(userID int64, currency domain.Currency, name string) (*domain.Pocket, error) {
+	if !currency.IsSupported() {
+		return nil, fmt.Errorf("unsupported currency %q", currency)
+	}
+	name, err := domain.PocketName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pocket := &domain.Pocket{
+		UserID:    userID,
+		Currency:  currency,
+		Name:      name,
+		Amount:    domain.Zero(currency),
+		Status:    domain.PocketOpen,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = retryOnBalanceConflict(func() error {
+		return balanceService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			_, err := repositories.Balances.GetBalanceByUserIDForUpdate(userID, currency)
+			if errors.Is(err, persistence.ErrBalanceNotFound) {
+				err = repositories.Balances.CreateBalance(userID, domain.Zero(currency))
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := checkPocketName(repositories, userID, currency, 0, name); err != nil {
+				return err
+			}
+			return repositories.Pockets.CreatePocket(pocket)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Pocket %d %q created for user %d", pocket.ID, pocket.Name, userID)
+	return pocket, nil
+}
+
+func (balanceService *BalanceService) GetPocket(pocketID int64) (*domain.Pocket, error) {
+	return balanceService.pocketRepository.GetPocketByID(pocketID)
+}
+
+func (balanceService *BalanceService) GetPockets(userID int64, currency domain.Currency) ([]domain.Pocket, error) {
+	return balanceService.pocketRepository.GetOpenPockets(userID, currency)
+}
+
+func (balanceService *BalanceService) RenamePocket(pocketID int64, name string) (*domain.Pocket, error) {
+	name, err := domain.PocketName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return balanceService.updatePocket(pocketID, func(repositories persistence.Repositories, pocket *domain.Pocket, balance *domain.Balance) error {
+		if err := checkPocketName(repositories, pocket.UserID, pocket.Currency, pocket.ID, name); err != nil {
 			return err
 		}
-		log.Printf("New balance created for user %d with amount %f", userID, amount)
+		pocket.Name = name
+		return nil
+	})
+}
+
+/* ClosePocket moves what is left in the pocket back to the main balance */
+func (balanceService *BalanceService) ClosePocket(pocketID int64) (*domain.Pocket, error) {
+	return balanceService.updatePocket(pocketID, func(repositories persistence.Repositories, pocket *domain.Pocket, balance *domain.Balance) error {
+		if !pocket.Amount.IsZero() {
+			if err := movePocketFunds(repositories, pocket, balance, pocket.Amount.Neg()); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		pocket.Status = domain.PocketClosed
+		pocket.ClosedAt = &now
 		return nil
+	})
+}
+
+/* MoveToPocket sets amount of the main balance aside, money held or borrowed through an overdraft can't be */
+func (balanceService *BalanceService) MoveToPocket(pocketID int64, amount domain.Money) (*domain.Pocket, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
 	}
 
-	/* if there is a balance we update to new balance */
-	newAmount := balance.Amount + amount
-	if newAmount < 0 {
-		return errors.New("insufficient balance")
+	return balanceService.updatePocket(pocketID, func(repositories persistence.Repositories, pocket *domain.Pocket, balance *domain.Balance) error {
+		free := domain.NewMoney(balance.Main().MinorUnits()-balance.HeldAmount.MinorUnits(), balance.Currency)
+		cmp, err := free.Cmp(amount)
+		if err != nil {
+			return err
+		}
+		if cmp < 0 {
+			return ErrInsufficientBalance
+		}
+		return movePocketFunds(repositories, pocket, balance, amount)
+	})
+}
+
+func (balanceService *BalanceService) MoveFromPocket(pocketID int64, amount domain.Money) (*domain.Pocket, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+
+	return balanceService.updatePocket(pocketID, func(repositories persistence.Repositories, pocket *domain.Pocket, balance *domain.Balance) error {
+		cmp, err := pocket.Amount.Cmp(amount)
+		if err != nil {
+			return err
+		}
+		if cmp < 0 {
+			return ErrInsufficientPocketFunds
+		}
+		return movePocketFunds(repositories, pocket, balance, amount.Neg())
+	})
+}
+
+/*
+updatePocket locks the open pocket and the balance it belongs to, lets change
+modify them and writes the pocket back, all within one unit of work.
+*/
+func (balanceService *BalanceService) updatePocket(pocketID int64, change func(repositories persistence.Repositories, pocket *domain.Pocket, balance *domain.Balance) error) (*domain.Pocket, error) {
+	var pocket *domain.Pocket
+
+	err := retryOnBalanceConflict(func() error {
+		return balanceService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			var err error
+			if pocket, err = repositories.Pockets.GetPocketByIDForUpdate(pocketID); err != nil {
+				return err
+			}
+			if !pocket.IsOpen() {
+				return ErrPocketClosed
+			}
+
+			balance, err := repositories.Balances.GetBalanceByUserIDForUpdate(pocket.UserID, pocket.Currency)
+			if err != nil {
+				return err
+			}
+
+			if err := change(repositories, pocket, balance); err != nil {
+				return err
+			}
+
+			pocket.UpdatedAt = time.Now()
+			return repositories.Pockets.UpdatePocket(pocket)
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	err = balanceService.balanceRepository.UpdateBalance(userID, newAmount)
+	return pocket, nil
+}
+
+/* movePocketFunds moves delta from the main balance into the pocket, a negative delta moves it back */
+func movePocketFunds(repositories persistence.Repositories, pocket *domain.Pocket, balance *domain.Balance, delta domain.Money) error {
+	newAmount, err := pocket.Amount.Add(delta)
+	if err != nil {
+		return err
+	}
+	pocketedAmount, err := balance.PocketedAmount.Add(delta)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Balance for user %d updated to %f", userID, newAmount)
+	if err := repositories.Balances.UpdatePocketedAmount(balance, pocketedAmount); err != nil {
+		return err
+	}
+	pocket.Amount = newAmount
 	return nil
 }
 
-func (balanceService *BalanceService) CreateBalance(userID int64, amount float64) error {
-	// Kullanıcı için yeni bir bakiye oluşturulur
-	err := balanceService.balanceRepository.CreateBalance(userID, amount)
+/* checkPocketName refuses name when another open pocket of the wallet has it, the wallet has to be locked */
+func checkPocketName(repositories persistence.Repositories, userID int64, currency domain.Currency, pocketID int64, name string) error {
+	pockets, err := repositories.Pockets.GetOpenPockets(userID, currency)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("New balance created for user %d with amount %f", userID, amount)
+	for _, pocket := range pockets {
+		if pocket.ID != pocketID && strings.EqualFold(pocket.Name, name) {
+			return ErrPocketNameTaken
+		}
+	}
 	return nil
 }
+
+/*
+retryOnBalanceConflict runs fn again while it fails with
+persistence.ErrBalanceConflict, up to balanceConflictRetries times. fn has to
+redo its reads, every try starts from the current balance.
+*/
+func retryOnBalanceConflict(fn func() error) error {
+	backoff := balanceConflictBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if !errors.Is(err, persistence.ErrBalanceConflict) || attempt == balanceConflictRetries {
+			return err
+		}
+
+		time.Sleep(backoff/2 + time.Duration(rand.Int63n(int64(backoff))))
+		backoff *= 2
+	}
+}
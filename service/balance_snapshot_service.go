@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* maxBackfillDays bounds one backfill request, longer histories are backfilled in several */
+const maxBackfillDays = 366
+
+var (
+	ErrAsOfInFuture         = errors.New("as_of cannot be in the future")
+	ErrInvalidBackfillRange = fmt.Errorf("backfill needs from on or before to, both closed days and at most %d days apart", maxBackfillDays)
+)
+
+type IBalanceSnapshotService interface {
+	GetBalanceAsOf(userID int64, currency domain.Currency, asOf time.Time) (*domain.HistoricalBalance, error)
+	SnapshotBalances(ctx context.Context, now time.Time) error
+	Backfill(from time.Time, to time.Time) (int, error)
+}
+
+/*
+BalanceSnapshotService reads balance history from the ledger, whose user
+postings follow every balance change including manual adjustments.
+*/
+type BalanceSnapshotService struct {
+	snapshotRepository persistence.IBalanceSnapshotRepository
+	ledgerRepository   persistence.ILedgerRepository
+}
+
+func NewBalanceSnapshotService(snapshotRepository persistence.IBalanceSnapshotRepository, ledgerRepository persistence.ILedgerRepository) IBalanceSnapshotService {
+	return &BalanceSnapshotService{
+		snapshotRepository: snapshotRepository,
+		ledgerRepository:   ledgerRepository,
+	}
+}
+
+/*
+GetBalanceAsOf starts from the newest snapshot closed by asOf and replays
+the postings made after it, or every posting when there is no snapshot yet.
+*/
+func (balanceSnapshotService *BalanceSnapshotService) GetBalanceAsOf(userID int64, currency domain.Currency, asOf time.Time) (*domain.HistoricalBalance, error) {
+	if asOf.After(time.Now()) {
+		return nil, ErrAsOfInFuture
+	}
+
+	account, err := balanceSnapshotService.ledgerRepository.GetAccountByCode(domain.UserAccountCode(userID, currency))
+	if err != nil {
+		if errors.Is(err, persistence.ErrLedgerAccountNotFound) {
+			return nil, persistence.ErrBalanceNotFound
+		}
+		return nil, err
+	}
+
+	historicalBalance := &domain.HistoricalBalance{UserID: userID, Currency: currency, AsOf: asOf}
+
+	snapshot, err := balanceSnapshotService.snapshotRepository.GetLatestSnapshot(userID, currency, domain.LastClosedDate(asOf))
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		if historicalBalance.Amount, err = balanceSnapshotService.ledgerRepository.GetAccountBalanceAt(account.ID, asOf); err != nil {
+			return nil, err
+		}
+		return historicalBalance, nil
+	}
+
+	postings, err := balanceSnapshotService.ledgerRepository.GetAccountPostingsBetween(account.ID, snapshot.ClosesAt(), asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	historicalBalance.Amount = snapshot.Amount
+	for _, posting := range postings {
+		if historicalBalance.Amount, err = historicalBalance.Amount.Add(posting.Amount); err != nil {
+			return nil, err
+		}
+	}
+	historicalBalance.SnapshotDate = &snapshot.Date
+	return historicalBalance, nil
+}
+
+/*
+SnapshotBalances writes the closing balance of the last closed day for every
+wallet that doesn't have one yet, so running it again on the same day only
+picks up what failed or was missed before.
+*/
+func (balanceSnapshotService *BalanceSnapshotService) SnapshotBalances(ctx context.Context, now time.Time) error {
+	date := domain.LastClosedDate(now)
+
+	accounts, err := balanceSnapshotService.ledgerRepository.GetUserAccounts()
+	if err != nil {
+		return err
+	}
+
+	existing, err := balanceSnapshotService.snapshotRepository.GetSnapshotsByDate(date)
+	if err != nil {
+		return err
+	}
+	snapshotted := make(map[string]bool, len(existing))
+	for _, snapshot := range existing {
+		snapshotted[domain.UserAccountCode(snapshot.UserID, snapshot.Currency)] = true
+	}
+
+	for _, account := range accounts {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if snapshotted[account.Code] || !existedBy(account, date) {
+			continue
+		}
+
+		snapshot := &domain.BalanceSnapshot{UserID: *account.UserID, Currency: account.Currency, Date: date}
+		amount, err := balanceSnapshotService.ledgerRepository.GetAccountBalanceAt(account.ID, snapshot.ClosesAt())
+		if err == nil {
+			snapshot.Amount = amount
+			err = balanceSnapshotService.snapshotRepository.SaveSnapshot(snapshot)
+		}
+		if err != nil {
+			log.Printf("Balance snapshot of %s for %s couldn't be written: %v", account.Code, date.Format(time.DateOnly), err)
+		}
+	}
+
+	return nil
+}
+
+/*
+Backfill writes the closing balance of every wallet for each day from from
+to to, replacing snapshots already there. Each wallet is read once: its
+balance before the first day and the postings of the whole range.
+*/
+func (balanceSnapshotService *BalanceSnapshotService) Backfill(from time.Time, to time.Time) (int, error) {
+	from, to = domain.SnapshotDate(from), domain.SnapshotDate(to)
+	if from.After(to) || to.After(domain.LastClosedDate(time.Now())) || to.Sub(from) >= maxBackfillDays*24*time.Hour {
+		return 0, ErrInvalidBackfillRange
+	}
+
+	accounts, err := balanceSnapshotService.ledgerRepository.GetUserAccounts()
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for _, account := range accounts {
+		count, err := balanceSnapshotService.backfillAccount(account, from, to)
+		written += count
+		if err != nil {
+			return written, err
+		}
+	}
+
+	log.Printf("Backfilled %d balance snapshots from %s to %s", written, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	return written, nil
+}
+
+func (balanceSnapshotService *BalanceSnapshotService) backfillAccount(account domain.LedgerAccount, from time.Time, to time.Time) (int, error) {
+	balance, err := balanceSnapshotService.ledgerRepository.GetAccountBalanceAt(account.ID, from)
+	if err != nil {
+		return 0, err
+	}
+
+	postings, err := balanceSnapshotService.ledgerRepository.GetAccountPostingsBetween(account.ID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		snapshot := &domain.BalanceSnapshot{UserID: *account.UserID, Currency: account.Currency, Date: date}
+		for len(postings) > 0 && postings[0].CreatedAt.Before(snapshot.ClosesAt()) {
+			if balance, err = balance.Add(postings[0].Amount); err != nil {
+				return written, err
+			}
+			postings = postings[1:]
+		}
+
+		if !existedBy(account, date) {
+			continue
+		}
+		snapshot.Amount = balance
+		if err := balanceSnapshotService.snapshotRepository.SaveSnapshot(snapshot); err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, nil
+}
+
+/* existedBy tells whether the wallet was opened before the end of date */
+func existedBy(account domain.LedgerAccount, date time.Time) bool {
+	return account.CreatedAt.Before(date.AddDate(0, 0, 1))
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* disputeNoteMaxLength matches the note column of dispute_events */
+const disputeNoteMaxLength = 255
+
+var (
+	ErrTransactionNotDisputable    = errors.New("only completed transfers can be disputed by their sender")
+	ErrDisputeAlreadyOpen          = errors.New("transaction already has an open dispute")
+	ErrProvisionalCreditNotAllowed = errors.New("provisional credit can only be given once while the dispute is active")
+	ErrDisputeNoteTooLong          = fmt.Errorf("note cannot be longer than %d characters", disputeNoteMaxLength)
+)
+
+type IDisputeService interface {
+	Open(transactionID int64, claimantID int64, reason string, evidence string) (*domain.Dispute, error)
+	GetDispute(id int64) (*domain.Dispute, error)
+	GetDisputes(status domain.DisputeStatus) ([]domain.Dispute, error)
+	GetTransactionDisputes(transactionID int64) ([]domain.Dispute, error)
+	GetEvents(id int64) ([]domain.DisputeEvent, error)
+	StartReview(id int64, reviewerID int64, note string) (*domain.Dispute, error)
+	GrantProvisionalCredit(id int64, reviewerID int64, note string) (*domain.Dispute, error)
+	Resolve(id int64, outcome domain.DisputeStatus, reviewerID int64, note string) (*domain.Dispute, error)
+}
+
+type DisputeService struct {
+	disputeRepository persistence.IDisputeRepository
+	unitOfWork        persistence.IUnitOfWork
+}
+
+func NewDisputeService(disputeRepository persistence.IDisputeRepository, unitOfWork persistence.IUnitOfWork) IDisputeService {
+	return &DisputeService{
+		disputeRepository: disputeRepository,
+		unitOfWork:        unitOfWork,
+	}
+}
+
+/*
+Open lets the sender of a completed transfer dispute it. The transfer is
+locked so two disputes of the same transfer can't both be opened.
+*/
+func (disputeService *DisputeService) Open(transactionID int64, claimantID int64, reason string, evidence string) (*domain.Dispute, error) {
+	now := time.Now()
+	dispute := &domain.Dispute{
+		TransactionID: transactionID,
+		ClaimantID:    claimantID,
+		Reason:        reason,
+		Evidence:      evidence,
+		Status:        domain.DisputeOpen,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := dispute.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := disputeService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		transaction, err := repositories.Transactions.GetTransactionByIDForUpdate(transactionID)
+		if err != nil {
+			return err
+		}
+		if transaction == nil {
+			return ErrTransactionNotFound
+		}
+		if transaction.Type != domain.TransferTransaction || transaction.Status != domain.Completed || transaction.FromUser != claimantID {
+			return ErrTransactionNotDisputable
+		}
+
+		disputes, err := repositories.Disputes.GetDisputesByTransactionID(transactionID)
+		if err != nil {
+			return err
+		}
+		for _, existing := range disputes {
+			if existing.Status.IsActive() {
+				return ErrDisputeAlreadyOpen
+			}
+		}
+
+		if err := repositories.Disputes.CreateDispute(dispute); err != nil {
+			return err
+		}
+		return recordDisputeEvent(repositories, dispute, domain.DisputeOpened, claimantID, reason, nil, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+func (disputeService *DisputeService) GetDispute(id int64) (*domain.Dispute, error) {
+	return disputeService.disputeRepository.GetDisputeByID(id)
+}
+
+/* GetDisputes lists the disputes with status, the open ones when status is empty */
+func (disputeService *DisputeService) GetDisputes(status domain.DisputeStatus) ([]domain.Dispute, error) {
+	if status == "" {
+		status = domain.DisputeOpen
+	}
+	return disputeService.disputeRepository.GetDisputesByStatus(status)
+}
+
+func (disputeService *DisputeService) GetTransactionDisputes(transactionID int64) ([]domain.Dispute, error) {
+	return disputeService.disputeRepository.GetDisputesByTransactionID(transactionID)
+}
+
+/* GetEvents returns the audit trail of the dispute, oldest first */
+func (disputeService *DisputeService) GetEvents(id int64) ([]domain.DisputeEvent, error) {
+	if _, err := disputeService.disputeRepository.GetDisputeByID(id); err != nil {
+		return nil, err
+	}
+	return disputeService.disputeRepository.GetDisputeEvents(id)
+}
+
+func (disputeService *DisputeService) StartReview(id int64, reviewerID int64, note string) (*domain.Dispute, error) {
+	return disputeService.update(id, note, func(repositories persistence.Repositories, dispute *domain.Dispute, now time.Time) error {
+		if err := dispute.MoveTo(domain.DisputeUnderReview, now); err != nil {
+			return err
+		}
+		return recordDisputeEvent(repositories, dispute, domain.DisputeReviewStarted, reviewerID, note, nil, now)
+	})
+}
+
+/*
+GrantProvisionalCredit credits the claimant with what is left of the disputed
+transfer while the dispute runs, the credit comes from the external account
+and is taken back when the dispute is resolved.
+*/
+func (disputeService *DisputeService) GrantProvisionalCredit(id int64, reviewerID int64, note string) (*domain.Dispute, error) {
+	return disputeService.update(id, note, func(repositories persistence.Repositories, dispute *domain.Dispute, now time.Time) error {
+		if !dispute.Status.IsActive() || dispute.ProvisionalCreditID != nil {
+			return ErrProvisionalCreditNotAllowed
+		}
+
+		original, err := repositories.Transactions.GetTransactionByIDForUpdate(dispute.TransactionID)
+		if err != nil {
+			return err
+		}
+		amount, err := refundableAmount(repositories.Transactions, original)
+		if err != nil {
+			return err
+		}
+		if !amount.IsPositive() {
+			return ErrTransactionNotReversible
+		}
+
+		credit := &domain.Transaction{
+			ParentID:  &original.ID,
+			FromUser:  dispute.ClaimantID,
+			Amount:    amount,
+			Currency:  amount.Currency(),
+			Type:      domain.ProvisionalCreditTransaction,
+			Status:    domain.Pending,
+			CreatedAt: now,
+		}
+		if err := repositories.Transactions.CreateTransaction(credit); err != nil {
+			return err
+		}
+		if err := moveBalances(repositories, &credit.ID, string(credit.Type), credit.BalanceDeltas(), domain.ExternalAccountCode(credit.Currency)); err != nil {
+			return err
+		}
+		if err := repositories.Transactions.UpdateTransactionStatus(credit.ID, domain.Pending, domain.Completed, fmt.Sprintf("provisional credit of dispute %d", dispute.ID)); err != nil {
+			return err
+		}
+
+		dispute.ProvisionalCreditID = &credit.ID
+		dispute.UpdatedAt = now
+		return recordDisputeEvent(repositories, dispute, domain.DisputeProvisionalCredit, reviewerID, note, &credit.ID, now)
+	})
+}
+
+/*
+Resolve closes the dispute as won or lost. A won dispute reverses what is
+left of the disputed transfer first, then on either outcome the provisional
+credit is taken back, so the claimant ends up with the transfer back or
+with nothing extra. Everything happens in one unit of work. Neither can be
+refused for money spent in the meantime, the balance is overdrawn instead
+and the user owes the shortfall.
+*/
+func (disputeService *DisputeService) Resolve(id int64, outcome domain.DisputeStatus, reviewerID int64, note string) (*domain.Dispute, error) {
+	if outcome != domain.DisputeWon && outcome != domain.DisputeLost {
+		return nil, errors.New("dispute can only be resolved as won or lost")
+	}
+
+	return disputeService.update(id, note, func(repositories persistence.Repositories, dispute *domain.Dispute, now time.Time) error {
+		if err := dispute.MoveTo(outcome, now); err != nil {
+			return err
+		}
+
+		var reversalID *int64
+		if outcome == domain.DisputeWon {
+			original, err := repositories.Transactions.GetTransactionByIDForUpdate(dispute.TransactionID)
+			if err != nil {
+				return err
+			}
+			if original.Status != domain.Completed {
+				return ErrTransactionNotReversible
+			}
+			reversal, err := reverseTransaction(repositories, original, domain.ReversalTransaction, nil, true)
+			if err != nil {
+				return err
+			}
+			dispute.ReversalID = &reversal.ID
+			reversalID = &reversal.ID
+		}
+
+		if dispute.ProvisionalCreditID != nil {
+			credit, err := repositories.Transactions.GetTransactionByIDForUpdate(*dispute.ProvisionalCreditID)
+			if err != nil {
+				return err
+			}
+			if _, err := reverseTransaction(repositories, credit, domain.ReversalTransaction, nil, true); err != nil {
+				return err
+			}
+		}
+
+		return recordDisputeEvent(repositories, dispute, domain.DisputeResolved, reviewerID, note, reversalID, now)
+	})
+}
+
+/* update locks the dispute, lets apply change it and stores it within one unit of work */
+func (disputeService *DisputeService) update(id int64, note string, apply func(repositories persistence.Repositories, dispute *domain.Dispute, now time.Time) error) (*domain.Dispute, error) {
+	if utf8.RuneCountInString(note) > disputeNoteMaxLength {
+		return nil, ErrDisputeNoteTooLong
+	}
+
+	var dispute *domain.Dispute
+
+	err := disputeService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		var err error
+		dispute, err = repositories.Disputes.GetDisputeByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+
+		if err := apply(repositories, dispute, time.Now()); err != nil {
+			return err
+		}
+		return repositories.Disputes.UpdateDispute(dispute)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+func recordDisputeEvent(repositories persistence.Repositories, dispute *domain.Dispute, action domain.DisputeAction, actorID int64, note string, transactionID *int64, now time.Time) error {
+	return repositories.Disputes.CreateDisputeEvent(&domain.DisputeEvent{
+		DisputeID:     dispute.ID,
+		Action:        action,
+		Status:        dispute.Status,
+		ActorID:       actorID,
+		Note:          note,
+		TransactionID: transactionID,
+		CreatedAt:     now,
+	})
+}
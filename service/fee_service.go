@@ -0,0 +1,85 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type IFeeService interface {
+	CreateSchedule(feeSchedule *domain.FeeSchedule) error
+	GetSchedules() ([]domain.FeeSchedule, error)
+	GetSchedule(id int64) (*domain.FeeSchedule, error)
+	UpdateSchedule(id int64, feeSchedule *domain.FeeSchedule) error
+	DeleteSchedule(id int64) error
+	Calculate(userID int64, transactionType domain.TransactionType, amount domain.Money) (domain.Money, error)
+}
+
+type FeeService struct {
+	feeRepository  persistence.IFeeRepository
+	userRepository persistence.IUserRepository
+}
+
+func NewFeeService(feeRepository persistence.IFeeRepository, userRepository persistence.IUserRepository) IFeeService {
+	return &FeeService{
+		feeRepository:  feeRepository,
+		userRepository: userRepository,
+	}
+}
+
+func (feeService *FeeService) CreateSchedule(feeSchedule *domain.FeeSchedule) error {
+	if err := feeSchedule.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	feeSchedule.CreatedAt = now
+	feeSchedule.UpdatedAt = now
+	return feeService.feeRepository.CreateFeeSchedule(feeSchedule)
+}
+
+func (feeService *FeeService) GetSchedules() ([]domain.FeeSchedule, error) {
+	return feeService.feeRepository.GetFeeSchedules()
+}
+
+func (feeService *FeeService) GetSchedule(id int64) (*domain.FeeSchedule, error) {
+	return feeService.feeRepository.GetFeeScheduleByID(id)
+}
+
+func (feeService *FeeService) UpdateSchedule(id int64, feeSchedule *domain.FeeSchedule) error {
+	existing, err := feeService.feeRepository.GetFeeScheduleByID(id)
+	if err != nil {
+		return err
+	}
+	if err := feeSchedule.Validate(); err != nil {
+		return err
+	}
+
+	feeSchedule.ID = id
+	feeSchedule.CreatedAt = existing.CreatedAt
+	feeSchedule.UpdatedAt = time.Now()
+	return feeService.feeRepository.UpdateFeeSchedule(feeSchedule)
+}
+
+func (feeService *FeeService) DeleteSchedule(id int64) error {
+	return feeService.feeRepository.DeleteFeeSchedule(id)
+}
+
+/* Calculate returns the fee the user pays on top of amount, zero when no schedule applies */
+func (feeService *FeeService) Calculate(userID int64, transactionType domain.TransactionType, amount domain.Money) (domain.Money, error) {
+	user, err := feeService.userRepository.GetById(userID)
+	if err != nil {
+		return domain.Money{}, err
+	}
+
+	feeSchedule, err := feeService.feeRepository.FindFeeSchedule(transactionType, amount.Currency(), user.Role)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	if feeSchedule == nil {
+		return domain.Zero(amount.Currency()), nil
+	}
+
+	return feeSchedule.Calculate(amount)
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service/model"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFxQuoteExpired         = errors.New("fx quote has expired")
+	ErrFxQuoteAlreadyExecuted = errors.New("fx quote was already executed")
+)
+
+type IFxService interface {
+	LoadRatesFromFile(path string) (int, error)
+	GetRates() ([]domain.FxRate, error)
+	CreateQuote(userID int64, sellAmount domain.Money, toCurrency domain.Currency) (*domain.FxQuote, error)
+	ExecuteQuote(quoteID string, userID int64) (*model.Conversion, error)
+}
+
+type FxService struct {
+	fxRepository persistence.IFxRepository
+	unitOfWork   persistence.IUnitOfWork
+	quoteTTL     time.Duration
+}
+
+func NewFxService(fxRepository persistence.IFxRepository, unitOfWork persistence.IUnitOfWork, quoteTTL time.Duration) IFxService {
+	return &FxService{
+		fxRepository: fxRepository,
+		unitOfWork:   unitOfWork,
+		quoteTTL:     quoteTTL,
+	}
+}
+
+/*
+LoadRatesFromFile upserts every pair of a .csv (base,quote,rate,spread_bps)
+or .json file into the rate table. The file is validated as a whole first
+and its rates are written in a single unit of work, all of them or none.
+*/
+func (fxService *FxService) LoadRatesFromFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var rates []domain.FxRate
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rates, err = parseRatesCSV(file)
+	case ".json":
+		rates, err = parseRatesJSON(file)
+	default:
+		return 0, fmt.Errorf("unsupported rates file %s, expected .csv or .json", path)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading rates file %s: %w", path, err)
+	}
+
+	/* one unit of work, a failing upsert leaves every rate as it was */
+	err = fxService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		for _, rate := range rates {
+			if err := repositories.Fx.UpsertRate(rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+func (fxService *FxService) GetRates() ([]domain.FxRate, error) {
+	return fxService.fxRepository.GetRates()
+}
+
+func (fxService *FxService) CreateQuote(userID int64, sellAmount domain.Money, toCurrency domain.Currency) (*domain.FxQuote, error) {
+	if !sellAmount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if sellAmount.Currency() == toCurrency {
+		return nil, errors.New("conversion needs two different currencies")
+	}
+
+	rate, err := fxService.findRate(sellAmount.Currency(), toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	customerRate := rate.CustomerRate()
+	buyAmount, err := sellAmount.Convert(customerRate, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if !buyAmount.IsPositive() {
+		return nil, errors.New("amount is too small to convert")
+	}
+
+	now := time.Now()
+	quote := &domain.FxQuote{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		SellAmount: sellAmount,
+		BuyAmount:  buyAmount,
+		MidRate:    rate.Rate,
+		Rate:       customerRate,
+		SpreadBps:  rate.SpreadBps,
+		Status:     domain.FxQuoteOpen,
+		ExpiresAt:  now.Add(fxService.quoteTTL),
+		CreatedAt:  now,
+	}
+
+	if err := fxService.fxRepository.CreateQuote(quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+/*
+ExecuteQuote converts at the quoted rate: the sell leg leaves the user's wallet
+in one currency and the buy leg arrives in the other, each balanced against
+the FX house account of its currency, all in one unit of work.
+*/
+func (fxService *FxService) ExecuteQuote(quoteID string, userID int64) (*model.Conversion, error) {
+	var conversion model.Conversion
+
+	/* the first conversion into a new wallet races other payments to open it, the loser runs again */
+	err := retryOnBalanceConflict(func() error {
+		return fxService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			quote, err := repositories.Fx.GetQuoteByIDForUpdate(quoteID)
+			if err != nil {
+				return err
+			}
+
+			/* someone else's quote is reported exactly like a missing one */
+			if quote.UserID != userID {
+				return persistence.ErrFxQuoteNotFound
+			}
+			if quote.Status == domain.FxQuoteExecuted {
+				return ErrFxQuoteAlreadyExecuted
+			}
+
+			now := time.Now()
+			if quote.IsExpired(now) {
+				return ErrFxQuoteExpired
+			}
+
+			/* both wallets are locked up front, before either leg moves */
+			if err := lockWallets(repositories.Balances, userID, quote.SellAmount.Currency(), quote.BuyAmount.Currency()); err != nil {
+				return err
+			}
+
+			sell, err := convertLeg(repositories, userID, quote.SellAmount.Neg(), now)
+			if err != nil {
+				return err
+			}
+
+			buy, err := convertLeg(repositories, userID, quote.BuyAmount, now)
+			if err != nil {
+				return err
+			}
+
+			if err := repositories.Fx.MarkQuoteExecuted(quote.ID, sell.ID, buy.ID, now); err != nil {
+				return err
+			}
+
+			quote.Status = domain.FxQuoteExecuted
+			quote.ExecutedAt = &now
+			quote.SellTransactionID = &sell.ID
+			quote.BuyTransactionID = &buy.ID
+
+			conversion = model.Conversion{Quote: *quote, SellTransaction: *sell, BuyTransaction: *buy}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &conversion, nil
+}
+
+/* findRate falls back to the inverse pair when only that one is configured */
+func (fxService *FxService) findRate(base domain.Currency, quote domain.Currency) (*domain.FxRate, error) {
+	rate, err := fxService.fxRepository.GetRate(base, quote)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, persistence.ErrFxRateNotFound) {
+		return nil, err
+	}
+
+	inverse, err := fxService.fxRepository.GetRate(quote, base)
+	if err != nil {
+		return nil, err
+	}
+
+	rate = new(domain.FxRate)
+	*rate = inverse.Inverse()
+	return rate, nil
+}
+
+/*
+lockWallets locks the user's wallets in currency order, so a conversion
+racing the opposite one can't hold one wallet while waiting for the other.
+*/
+func lockWallets(balanceRepository persistence.IBalanceRepository, userID int64, currencies ...domain.Currency) error {
+	slices.Sort(currencies)
+	for _, currency := range currencies {
+		_, err := balanceRepository.GetBalanceByUserIDForUpdate(userID, currency)
+		if err != nil && !errors.Is(err, persistence.ErrBalanceNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func convertLeg(repositories persistence.Repositories, userID int64, amount domain.Money, now time.Time) (*domain.Transaction, error) {
+	tx := &domain.Transaction{
+		FromUser:  userID,
+		Amount:    amount,
+		Currency:  amount.Currency(),
+		Type:      domain.ConversionTransaction,
+		Status:    domain.Pending,
+		CreatedAt: now,
+	}
+
+	if err := repositories.Transactions.CreateTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	deltas := map[int64]domain.Money{userID: amount}
+	if err := moveBalances(repositories, &tx.ID, string(tx.Type), deltas, domain.FxHouseAccountCode(amount.Currency())); err != nil {
+		return nil, err
+	}
+
+	if err := repositories.Transactions.UpdateTransactionStatus(tx.ID, domain.Pending, domain.Completed, "currency converted"); err != nil {
+		return nil, err
+	}
+
+	tx.Status = domain.Completed
+	return tx, nil
+}
+
+type rateFileEntry struct {
+	Base      domain.Currency `json:"base"`
+	Quote     domain.Currency `json:"quote"`
+	Rate      string          `json:"rate"`
+	SpreadBps int64           `json:"spread_bps"`
+}
+
+func (entry rateFileEntry) toRate() (domain.FxRate, error) {
+	value, err := domain.ParseRate(entry.Rate)
+	if err != nil {
+		return domain.FxRate{}, err
+	}
+
+	rate := domain.FxRate{
+		Base:      domain.Currency(strings.ToUpper(string(entry.Base))),
+		Quote:     domain.Currency(strings.ToUpper(string(entry.Quote))),
+		Rate:      value,
+		SpreadBps: entry.SpreadBps,
+	}
+	return rate, rate.Validate()
+}
+
+func parseRatesCSV(reader io.Reader) ([]domain.FxRate, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	var rates []domain.FxRate
+	/* first record is the header */
+	for i, record := range records[1:] {
+		if len(record) != 4 {
+			return nil, fmt.Errorf("line %d: expected base,quote,rate,spread_bps", i+2)
+		}
+
+		spreadBps, err := strconv.ParseInt(strings.TrimSpace(record[3]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid spread %q", i+2, record[3])
+		}
+
+		rate, err := rateFileEntry{
+			Base:      domain.Currency(strings.TrimSpace(record[0])),
+			Quote:     domain.Currency(strings.TrimSpace(record[1])),
+			Rate:      strings.TrimSpace(record[2]),
+			SpreadBps: spreadBps,
+		}.toRate()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func parseRatesJSON(reader io.Reader) ([]domain.FxRate, error) {
+	var entries []rateFileEntry
+	if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	var rates []domain.FxRate
+	for i, entry := range entries {
+		rate, err := entry.toRate()
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
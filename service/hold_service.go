@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* expiredHoldsBatchSize bounds how many holds one tick expires */
+const expiredHoldsBatchSize = 100
+
+var (
+	ErrHoldNotAuthorized  = errors.New("only authorized holds can be captured or voided")
+	ErrHoldExpired        = errors.New("hold has expired")
+	ErrCaptureExceedsHold = errors.New("capture cannot exceed the authorized amount")
+)
+
+type IHoldService interface {
+	Authorize(userID int64, toUserID *int64, amount domain.Money) (*domain.Hold, error)
+	Capture(transactionID int64, amount *domain.Money) (*domain.Transaction, error)
+	Void(transactionID int64) (*domain.Hold, error)
+	GetHold(transactionID int64) (*domain.Hold, error)
+	ExpireHolds(ctx context.Context, now time.Time) error
+}
+
+type HoldService struct {
+	holdRepository     persistence.IHoldRepository
+	unitOfWork         persistence.IUnitOfWork
+	transactionService ITransactionService
+	ttl                time.Duration
+}
+
+func NewHoldService(holdRepository persistence.IHoldRepository, unitOfWork persistence.IUnitOfWork, transactionService ITransactionService, ttl time.Duration) IHoldService {
+	return &HoldService{
+		holdRepository:     holdRepository,
+		unitOfWork:         unitOfWork,
+		transactionService: transactionService,
+		ttl:                ttl,
+	}
+}
+
+/*
+Authorize reserves amount of the user's available balance. The ledger balance
+stays the same until the hold is captured.
+*/
+func (holdService *HoldService) Authorize(userID int64, toUserID *int64, amount domain.Money) (*domain.Hold, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if toUserID != nil && *toUserID == userID {
+		return nil, errors.New("hold cannot be for the same user")
+	}
+
+	now := time.Now()
+	hold := &domain.Hold{
+		UserID:         userID,
+		ToUser:         toUserID,
+		Amount:         amount,
+		CapturedAmount: domain.Zero(amount.Currency()),
+		Status:         domain.Authorized,
+		ExpiresAt:      now.Add(holdService.ttl),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	/* a write racing the held amount makes the authorization run again */
+	err := retryOnBalanceConflict(func() error {
+		return holdService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			balance, err := repositories.Balances.GetBalanceByUserIDForUpdate(userID, amount.Currency())
+			if errors.Is(err, persistence.ErrBalanceNotFound) {
+				return ErrInsufficientBalance
+			}
+			if err != nil {
+				return err
+			}
+
+			cmp, err := balance.Available().Cmp(amount)
+			if err != nil {
+				return err
+			}
+			if cmp < 0 {
+				return ErrInsufficientBalance
+			}
+
+			heldAmount, err := balance.HeldAmount.Add(amount)
+			if err != nil {
+				return err
+			}
+			if err := repositories.Balances.UpdateHeldAmount(balance, heldAmount); err != nil {
+				return err
+			}
+
+			authorization := &domain.Transaction{
+				FromUser:  userID,
+				ToUser:    toUserID,
+				Amount:    amount,
+				Currency:  amount.Currency(),
+				Type:      domain.AuthorizationTransaction,
+				Status:    domain.Authorized,
+				CreatedAt: now,
+			}
+			if err := repositories.Transactions.CreateTransaction(authorization); err != nil {
+				return err
+			}
+
+			hold.TransactionID = authorization.ID
+			return repositories.Holds.CreateHold(hold)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+/*
+Capture moves amount, or the whole hold when amount is nil, out of the user's
+balance and releases the rest of the hold. A hold can be captured only once.
+The capture is screened and checked against limits and charged fees like the
+transfer or debit it pays out.
+*/
+func (holdService *HoldService) Capture(transactionID int64, amount *domain.Money) (*domain.Transaction, error) {
+	var capture *domain.Transaction
+
+	/* a capture can open the receiver's wallet and race other payments doing so, the loser runs again */
+	err := retryOnBalanceConflict(func() error {
+		return holdService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			now := time.Now()
+
+			hold, err := repositories.Holds.GetHoldByTransactionIDForUpdate(transactionID)
+			if err != nil {
+				return err
+			}
+			if hold.Status != domain.Authorized {
+				return ErrHoldNotAuthorized
+			}
+			if hold.IsExpired(now) {
+				return ErrHoldExpired
+			}
+
+			capturedAmount := hold.Amount
+			if amount != nil {
+				capturedAmount = *amount
+			}
+			if !capturedAmount.IsPositive() {
+				return errors.New("amount must be greater than zero")
+			}
+			cmp, err := capturedAmount.Cmp(hold.Amount)
+			if err != nil {
+				return err
+			}
+			if cmp > 0 {
+				return ErrCaptureExceedsHold
+			}
+
+			/* lock the receiver first when applyBalanceChanges would, so a capture can't deadlock with a transfer */
+			if hold.ToUser != nil && *hold.ToUser < hold.UserID {
+				if _, err := repositories.Balances.GetBalanceByUserIDForUpdate(*hold.ToUser, hold.Amount.Currency()); err != nil && !errors.Is(err, persistence.ErrBalanceNotFound) {
+					return err
+				}
+			}
+			if err := releaseHold(repositories, hold); err != nil {
+				return err
+			}
+
+			capture = &domain.Transaction{
+				ParentID:  &hold.TransactionID,
+				FromUser:  hold.UserID,
+				ToUser:    hold.ToUser,
+				Amount:    capturedAmount,
+				Currency:  capturedAmount.Currency(),
+				Type:      domain.CaptureTransaction,
+				Status:    domain.Pending,
+				CreatedAt: now,
+			}
+			/* without a receiver the captured amount leaves the system like a debit */
+			if hold.ToUser == nil {
+				capture.Amount = capturedAmount.Neg()
+			}
+			if err := repositories.Transactions.CreateTransaction(capture); err != nil {
+				return err
+			}
+
+			if err := holdService.transactionService.Process(repositories, capture, fmt.Sprintf("capture of transaction %d", hold.TransactionID)); err != nil {
+				return err
+			}
+
+			hold.CapturedAmount = capturedAmount
+			return holdService.finish(repositories, hold, domain.Captured, now)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return capture, nil
+}
+
+/* Void releases the whole hold without moving any money */
+func (holdService *HoldService) Void(transactionID int64) (*domain.Hold, error) {
+	var hold *domain.Hold
+
+	err := holdService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		var err error
+		hold, err = repositories.Holds.GetHoldByTransactionIDForUpdate(transactionID)
+		if err != nil {
+			return err
+		}
+		if hold.Status != domain.Authorized {
+			return ErrHoldNotAuthorized
+		}
+
+		if err := releaseHold(repositories, hold); err != nil {
+			return err
+		}
+		return holdService.finish(repositories, hold, domain.Voided, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (holdService *HoldService) GetHold(transactionID int64) (*domain.Hold, error) {
+	return holdService.holdRepository.GetHoldByTransactionID(transactionID)
+}
+
+/* ExpireHolds is the scheduler job releasing holds that were neither captured nor voided in time */
+func (holdService *HoldService) ExpireHolds(ctx context.Context, now time.Time) error {
+	expiredHolds, err := holdService.holdRepository.GetExpiredHolds(now, expiredHoldsBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, expiredHold := range expiredHolds {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		err := holdService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			hold, err := repositories.Holds.GetHoldByTransactionIDForUpdate(expiredHold.TransactionID)
+			if err != nil {
+				return err
+			}
+			/* it may have been captured or voided since it was listed */
+			if hold.Status != domain.Authorized || !hold.IsExpired(now) {
+				return nil
+			}
+
+			if err := releaseHold(repositories, hold); err != nil {
+				return err
+			}
+			return holdService.finish(repositories, hold, domain.Expired, now)
+		})
+		if err != nil {
+			log.Printf("Hold of transaction %d couldn't be expired: %v", expiredHold.TransactionID, err)
+		}
+	}
+
+	return nil
+}
+
+/* finish moves both the hold and its authorization transaction to status */
+func (holdService *HoldService) finish(repositories persistence.Repositories, hold *domain.Hold, status domain.TransactionStatus, now time.Time) error {
+	hold.Status = status
+	hold.UpdatedAt = now
+	if err := repositories.Holds.UpdateHold(hold); err != nil {
+		return err
+	}
+	return repositories.Transactions.UpdateTransactionStatus(hold.TransactionID, domain.Authorized, status, fmt.Sprintf("hold %s", status))
+}
+
+/* releaseHold gives the held amount back to the user's available balance */
+func releaseHold(repositories persistence.Repositories, hold *domain.Hold) error {
+	balance, err := repositories.Balances.GetBalanceByUserIDForUpdate(hold.UserID, hold.Amount.Currency())
+	if err != nil {
+		return err
+	}
+
+	heldAmount, err := balance.HeldAmount.Sub(hold.Amount)
+	if err != nil {
+		return err
+	}
+	return repositories.Balances.UpdateHeldAmount(balance, heldAmount)
+}
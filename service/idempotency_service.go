@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/idempotency"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+const (
+	/* maxBeginAttempts bounds how often Begin tries again when the key changes under it */
+	maxBeginAttempts = 3
+	/* purgeBatchSize bounds one delete of expired keys */
+	purgeBatchSize = 1000
+)
+
+var (
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInFlight = errors.New("a request with this idempotency key is still being processed")
+)
+
+type IIdempotencyService interface {
+	Begin(key string, method string, path string, body []byte) (*domain.IdempotencyRecord, error)
+	Complete(key string, statusCode int, responseBody []byte) error
+	Release(key string) error
+	PurgeExpired(ctx context.Context, now time.Time) error
+}
+
+type IdempotencyService struct {
+	idempotencyRepository persistence.IIdempotencyRepository
+	config                idempotency.Config
+}
+
+func NewIdempotencyService(idempotencyRepository persistence.IIdempotencyRepository, config idempotency.Config) IIdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepository: idempotencyRepository,
+		config:                config,
+	}
+}
+
+/*
+Begin claims the key for a new request. It returns nil when the caller should
+process the request, or the stored record when the request is a replay. A
+key whose response outlived the retention is claimed anew, and so is one
+whose request has been in flight for longer than the in-flight timeout.
+*/
+func (idempotencyService *IdempotencyService) Begin(key string, method string, path string, body []byte) (*domain.IdempotencyRecord, error) {
+	fingerprint := requestFingerprint(method, path, body)
+
+	for attempt := 0; attempt < maxBeginAttempts; attempt++ {
+		now := time.Now()
+		err := idempotencyService.idempotencyRepository.Reserve(key, fingerprint, now)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, persistence.ErrIdempotencyKeyExists) {
+			return nil, err
+		}
+
+		record, err := idempotencyService.idempotencyRepository.GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		/* the first request was released between our insert and read */
+		if record == nil {
+			continue
+		}
+		if record.IsExpired(now, idempotencyService.config.Retention) {
+			if err := idempotencyService.idempotencyRepository.DeleteExpired(key, now.Add(-idempotencyService.config.Retention)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if record.RequestFingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if record.IsCompleted() {
+			return record, nil
+		}
+
+		if record.IsStale(now, idempotencyService.config.InFlightTimeout) {
+			reclaimed, err := idempotencyService.idempotencyRepository.Reclaim(key, now.Add(-idempotencyService.config.InFlightTimeout), now)
+			if err != nil {
+				return nil, err
+			}
+			if reclaimed {
+				log.Printf("Idempotency key %s was reclaimed after being in flight since %s", key, record.CreatedAt.Format(time.RFC3339))
+				return nil, nil
+			}
+		}
+		return nil, ErrIdempotencyKeyInFlight
+	}
+
+	return nil, ErrIdempotencyKeyInFlight
+}
+
+func (idempotencyService *IdempotencyService) Complete(key string, statusCode int, responseBody []byte) error {
+	return idempotencyService.idempotencyRepository.Complete(key, statusCode, responseBody, time.Now())
+}
+
+/* Release forgets the key so that a retry is processed again */
+func (idempotencyService *IdempotencyService) Release(key string) error {
+	return idempotencyService.idempotencyRepository.Delete(key)
+}
+
+/* PurgeExpired deletes responses past the retention and keys left in flight past the timeout */
+func (idempotencyService *IdempotencyService) PurgeExpired(ctx context.Context, now time.Time) error {
+	completedBefore := now.Add(-idempotencyService.config.Retention)
+	inFlightBefore := now.Add(-idempotencyService.config.InFlightTimeout)
+
+	var purged int64
+	for ctx.Err() == nil {
+		deleted, err := idempotencyService.idempotencyRepository.Purge(completedBefore, inFlightBefore, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		purged += deleted
+		if deleted < purgeBatchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %d expired idempotency keys", purged)
+	}
+	return nil
+}
+
+func requestFingerprint(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
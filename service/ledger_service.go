@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service/model"
+)
+
+type ILedgerService interface {
+	GetUserLedger(userID int64, currency domain.Currency) (*model.UserLedger, error)
+}
+
+type LedgerService struct {
+	ledgerRepository  persistence.ILedgerRepository
+	balanceRepository persistence.IBalanceRepository
+}
+
+func NewLedgerService(ledgerRepository persistence.ILedgerRepository, balanceRepository persistence.IBalanceRepository) ILedgerService {
+	return &LedgerService{
+		ledgerRepository:  ledgerRepository,
+		balanceRepository: balanceRepository,
+	}
+}
+
+/* GetUserLedger returns the user's postings and whether balances agree with them */
+func (ledgerService *LedgerService) GetUserLedger(userID int64, currency domain.Currency) (*model.UserLedger, error) {
+	account, err := ledgerService.ledgerRepository.GetAccountByCode(domain.UserAccountCode(userID, currency))
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerBalance, err := ledgerService.ledgerRepository.GetAccountBalance(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	postings, err := ledgerService.ledgerRepository.GetAccountPostings(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceAmount := domain.Zero(ledgerBalance.Currency())
+	balance, err := ledgerService.balanceRepository.GetBalanceByUserID(userID, currency)
+	if err != nil && !errors.Is(err, persistence.ErrBalanceNotFound) {
+		return nil, err
+	}
+	if balance != nil {
+		balanceAmount = balance.Amount
+	}
+
+	difference, err := ledgerBalance.Cmp(balanceAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserLedger{
+		Account:       *account,
+		LedgerBalance: ledgerBalance,
+		Balance:       balanceAmount,
+		InSync:        difference == 0,
+		Postings:      postings,
+	}, nil
+}
+
+/*
+postBalanceChanges books the per-user deltas as one balanced journal entry.
+Whatever the user postings don't net out comes from or goes to the
+counterparty account, e.g. the external account for credits and debits.
+*/
+func postBalanceChanges(repositories persistence.Repositories, transactionID *int64, description string, deltas map[int64]domain.Money, counterpartyCode string) error {
+	userIDs := make([]int64, 0, len(deltas))
+	for userID := range deltas {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	entry := &domain.JournalEntry{
+		TransactionID: transactionID,
+		Description:   description,
+	}
+
+	currency := deltas[userIDs[0]].Currency()
+	net := domain.Zero(currency)
+	for _, userID := range userIDs {
+		account, err := repositories.Ledger.GetOrCreateUserAccount(userID, currency)
+		if err != nil {
+			return err
+		}
+
+		entry.Postings = append(entry.Postings, domain.Posting{AccountID: account.ID, Amount: deltas[userID]})
+		if net, err = net.Add(deltas[userID]); err != nil {
+			return err
+		}
+	}
+
+	if !net.IsZero() {
+		counterparty, err := repositories.Ledger.GetAccountByCode(counterpartyCode)
+		if err != nil {
+			return err
+		}
+		entry.Postings = append(entry.Postings, domain.Posting{AccountID: counterparty.ID, Amount: net.Neg()})
+	}
+
+	return repositories.Ledger.CreateJournalEntry(entry)
+}
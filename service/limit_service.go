@@ -0,0 +1,108 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type ILimitService interface {
+	CreateProfile(limitProfile *domain.LimitProfile) error
+	GetProfiles() ([]domain.LimitProfile, error)
+	GetProfile(id int64) (*domain.LimitProfile, error)
+	UpdateProfile(id int64, limitProfile *domain.LimitProfile) error
+	DeleteProfile(id int64) error
+	Check(repositories persistence.Repositories, transaction *domain.Transaction) error
+}
+
+type LimitService struct {
+	limitRepository persistence.ILimitRepository
+	userRepository  persistence.IUserRepository
+}
+
+func NewLimitService(limitRepository persistence.ILimitRepository, userRepository persistence.IUserRepository) ILimitService {
+	return &LimitService{
+		limitRepository: limitRepository,
+		userRepository:  userRepository,
+	}
+}
+
+func (limitService *LimitService) CreateProfile(limitProfile *domain.LimitProfile) error {
+	if err := limitProfile.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	limitProfile.CreatedAt = now
+	limitProfile.UpdatedAt = now
+	return limitService.limitRepository.CreateLimitProfile(limitProfile)
+}
+
+func (limitService *LimitService) GetProfiles() ([]domain.LimitProfile, error) {
+	return limitService.limitRepository.GetLimitProfiles()
+}
+
+func (limitService *LimitService) GetProfile(id int64) (*domain.LimitProfile, error) {
+	return limitService.limitRepository.GetLimitProfileByID(id)
+}
+
+func (limitService *LimitService) UpdateProfile(id int64, limitProfile *domain.LimitProfile) error {
+	existing, err := limitService.limitRepository.GetLimitProfileByID(id)
+	if err != nil {
+		return err
+	}
+	if err := limitProfile.Validate(); err != nil {
+		return err
+	}
+
+	limitProfile.ID = id
+	limitProfile.CreatedAt = existing.CreatedAt
+	limitProfile.UpdatedAt = time.Now()
+	return limitService.limitRepository.UpdateLimitProfile(limitProfile)
+}
+
+func (limitService *LimitService) DeleteProfile(id int64) error {
+	return limitService.limitRepository.DeleteLimitProfile(id)
+}
+
+/*
+Check returns a *domain.LimitExceededError when the transaction doesn't fit
+into the limit profile of its sender. It runs inside the sender's unit of
+work, after the sender's balance is locked and before it changes, so
+concurrent transactions of the same user can't both slip under a limit.
+*/
+func (limitService *LimitService) Check(repositories persistence.Repositories, transaction *domain.Transaction) error {
+	limitType := transaction.LimitType()
+	if limitType != domain.DebitTransaction && limitType != domain.TransferTransaction && limitType != domain.SplitPaymentTransaction {
+		return nil
+	}
+
+	user, err := limitService.userRepository.GetById(transaction.FromUser)
+	if err != nil {
+		return err
+	}
+
+	limitProfile, err := limitService.limitRepository.FindLimitProfile(transaction.FromUser, user.Role, limitType, transaction.Currency)
+	if err != nil {
+		return err
+	}
+	if limitProfile == nil {
+		return nil
+	}
+
+	/* days and months are counted in UTC, like balance snapshots, whatever the server's zone */
+	now := time.Now().UTC()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var usage domain.LimitUsage
+	if usage.DailyAmount, usage.DailyCount, err = repositories.Transactions.GetUsageSince(transaction.FromUser, limitType, transaction.Currency, startOfDay); err != nil {
+		return err
+	}
+	if usage.MonthlyAmount, _, err = repositories.Transactions.GetUsageSince(transaction.FromUser, limitType, transaction.Currency, startOfMonth); err != nil {
+		return err
+	}
+
+	return limitProfile.Check(transaction.Amount.Abs(), usage)
+}
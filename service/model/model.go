@@ -1,8 +1,54 @@
 package model
 
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
 type UserCreate struct {
 	Username string
 	Email    string
 	Password string
 	Role     string
 }
+
+type UserLedger struct {
+	Account       domain.LedgerAccount
+	LedgerBalance domain.Money
+	Balance       domain.Money
+	InSync        bool
+	Postings      []domain.Posting
+}
+
+type Conversion struct {
+	Quote           domain.FxQuote
+	SellTransaction domain.Transaction
+	BuyTransaction  domain.Transaction
+}
+
+/* StandingOrderCreate leaves InsufficientFundsPolicy and MaxRetries nil to use the configured defaults */
+type StandingOrderCreate struct {
+	FromUser                int64
+	ToUser                  int64
+	Amount                  domain.Money
+	Recurrence              domain.Recurrence
+	StartAt                 time.Time
+	InsufficientFundsPolicy *domain.InsufficientFundsPolicy
+	MaxRetries              *int
+}
+
+type StandingOrderUpdate struct {
+	Amount                  domain.Money
+	Recurrence              domain.Recurrence
+	InsufficientFundsPolicy *domain.InsufficientFundsPolicy
+	MaxRetries              *int
+}
+
+/* OverdraftUsage is a facility next to the balance of the wallet it covers */
+type OverdraftUsage struct {
+	Facility  domain.OverdraftFacility
+	Balance   domain.Money
+	Used      domain.Money
+	Available domain.Money
+}
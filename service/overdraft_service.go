@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service/model"
+)
+
+/* overdraftChargesBatchSize bounds how many facilities one tick charges */
+const overdraftChargesBatchSize = 100
+
+var ErrOverdraftInUse = errors.New("overdraft facility cannot be removed while the balance is negative")
+
+type IOverdraftService interface {
+	SetFacility(facility *domain.OverdraftFacility) (*model.OverdraftUsage, error)
+	GetFacility(userID int64, currency domain.Currency) (*model.OverdraftUsage, error)
+	GetFacilities() ([]model.OverdraftUsage, error)
+	RemoveFacility(userID int64, currency domain.Currency) error
+	ChargeOverdrafts(ctx context.Context, now time.Time) error
+}
+
+type OverdraftService struct {
+	overdraftRepository persistence.IOverdraftRepository
+	balanceRepository   persistence.IBalanceRepository
+	unitOfWork          persistence.IUnitOfWork
+}
+
+func NewOverdraftService(overdraftRepository persistence.IOverdraftRepository, balanceRepository persistence.IBalanceRepository, unitOfWork persistence.IUnitOfWork) IOverdraftService {
+	return &OverdraftService{
+		overdraftRepository: overdraftRepository,
+		balanceRepository:   balanceRepository,
+		unitOfWork:          unitOfWork,
+	}
+}
+
+/*
+SetFacility grants the facility or changes the limit and rate of the one the
+user already has, opening an empty wallet when the user has none in the
+currency yet. Lowering the limit below what is used only stops further
+spending, the balance isn't touched.
+*/
+func (overdraftService *OverdraftService) SetFacility(facility *domain.OverdraftFacility) (*model.OverdraftUsage, error) {
+	if err := facility.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := retryOnBalanceConflict(func() error {
+		return overdraftService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			_, err := repositories.Balances.GetBalanceByUserIDForUpdate(facility.UserID, facility.Currency)
+			if errors.Is(err, persistence.ErrBalanceNotFound) {
+				err = repositories.Balances.CreateBalance(facility.UserID, domain.Zero(facility.Currency))
+			}
+			if err != nil {
+				return err
+			}
+
+			return repositories.Overdrafts.SaveFacility(facility)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return overdraftService.GetFacility(facility.UserID, facility.Currency)
+}
+
+func (overdraftService *OverdraftService) GetFacility(userID int64, currency domain.Currency) (*model.OverdraftUsage, error) {
+	facility, err := overdraftService.overdraftRepository.GetFacility(userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	return overdraftService.usageOf(*facility)
+}
+
+func (overdraftService *OverdraftService) GetFacilities() ([]model.OverdraftUsage, error) {
+	facilities, err := overdraftService.overdraftRepository.GetFacilities()
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]model.OverdraftUsage, 0, len(facilities))
+	for _, facility := range facilities {
+		usage, err := overdraftService.usageOf(facility)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, *usage)
+	}
+	return usages, nil
+}
+
+/* RemoveFacility only removes a facility that isn't used, the overdraft has to be paid back first */
+func (overdraftService *OverdraftService) RemoveFacility(userID int64, currency domain.Currency) error {
+	return overdraftService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		if _, err := repositories.Overdrafts.GetFacilityForUpdate(userID, currency); err != nil {
+			return err
+		}
+
+		balance, err := repositories.Balances.GetBalanceByUserIDForUpdate(userID, currency)
+		if err != nil && !errors.Is(err, persistence.ErrBalanceNotFound) {
+			return err
+		}
+		if balance != nil && balance.Main().IsNegative() {
+			return ErrOverdraftInUse
+		}
+
+		return repositories.Overdrafts.DeleteFacility(userID, currency)
+	})
+}
+
+/*
+ChargeOverdrafts posts the daily charge of every facility with a rate that
+wasn't charged today yet, on the overdrawn balance at the time it runs.
+Facilities that aren't overdrawn are marked as charged too, so every day
+is looked at once.
+*/
+func (overdraftService *OverdraftService) ChargeOverdrafts(ctx context.Context, now time.Time) error {
+	day := domain.SnapshotDate(now)
+
+	facilities, err := overdraftService.overdraftRepository.GetFacilitiesToCharge(day, overdraftChargesBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, facility := range facilities {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		err := retryOnBalanceConflict(func() error {
+			return overdraftService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+				return chargeOverdraft(repositories, facility.UserID, facility.Currency, day, now)
+			})
+		})
+		if err != nil {
+			log.Printf("Overdraft of user %d in %s couldn't be charged for %s: %v", facility.UserID, facility.Currency, day.Format(time.DateOnly), err)
+		}
+	}
+
+	return nil
+}
+
+/*
+chargeOverdraft debits the charge of day from the wallet into the fee house
+account. The charge is owed whatever the balance is, so unlike a debit it
+may take the balance past the credit limit.
+*/
+func chargeOverdraft(repositories persistence.Repositories, userID int64, currency domain.Currency, day time.Time, now time.Time) error {
+	facility, err := repositories.Overdrafts.GetFacilityForUpdate(userID, currency)
+	if errors.Is(err, persistence.ErrOverdraftNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if facility.IsChargedOn(day) {
+		return nil
+	}
+
+	balance, err := repositories.Balances.GetBalanceByUserIDForUpdate(userID, currency)
+	if err != nil && !errors.Is(err, persistence.ErrBalanceNotFound) {
+		return err
+	}
+
+	if balance != nil {
+		charge, err := facility.DailyCharge(balance.Main())
+		if err != nil {
+			return err
+		}
+		if charge.IsPositive() {
+			if err := postOverdraftCharge(repositories, balance, charge, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return repositories.Overdrafts.MarkCharged(userID, currency, day)
+}
+
+func postOverdraftCharge(repositories persistence.Repositories, balance *domain.Balance, charge domain.Money, now time.Time) error {
+	overdrawn := balance.OverdraftUsed()
+	chargeTransaction := &domain.Transaction{
+		FromUser:  balance.UserID,
+		Amount:    charge.Neg(),
+		Currency:  balance.Currency,
+		Type:      domain.OverdraftChargeTransaction,
+		Status:    domain.Pending,
+		CreatedAt: now,
+	}
+	if err := repositories.Transactions.CreateTransaction(chargeTransaction); err != nil {
+		return err
+	}
+
+	newAmount, err := balance.Amount.Add(chargeTransaction.Amount)
+	if err != nil {
+		return err
+	}
+	if err := repositories.Balances.UpdateBalance(balance, newAmount); err != nil {
+		return err
+	}
+	if err := postBalanceChanges(repositories, &chargeTransaction.ID, string(chargeTransaction.Type), chargeTransaction.BalanceDeltas(), domain.FeeHouseAccountCode(balance.Currency)); err != nil {
+		return err
+	}
+
+	return repositories.Transactions.UpdateTransactionStatus(chargeTransaction.ID, domain.Pending, domain.Completed, fmt.Sprintf("daily charge on %s overdrawn", overdrawn))
+}
+
+func (overdraftService *OverdraftService) usageOf(facility domain.OverdraftFacility) (*model.OverdraftUsage, error) {
+	balance, err := overdraftService.balanceRepository.GetBalanceByUserID(facility.UserID, facility.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.OverdraftUsage{
+		Facility:  facility,
+		Balance:   balance.Amount,
+		Used:      balance.OverdraftUsed(),
+		Available: balance.Available(),
+	}, nil
+}
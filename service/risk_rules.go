@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/*
+IRiskRule is one check of the risk engine. Evaluate runs within the unit of
+work of the screened transaction and returns why the transaction matches the
+rule, or an empty reason when it doesn't. Rules beyond the configurable ones
+are plugged in by passing them to NewRiskService.
+*/
+type IRiskRule interface {
+	Name() string
+	Action() domain.RiskAction
+	Evaluate(repositories persistence.Repositories, transaction *domain.Transaction) (string, error)
+}
+
+/* newRiskRule builds the rule of a validated config */
+func newRiskRule(config domain.RiskRuleConfig) (IRiskRule, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch config.Type {
+	case domain.VelocityRiskRule:
+		return &velocityRiskRule{configuredRiskRule{config: config}}, nil
+	case domain.NewCounterpartyRiskRule:
+		return &newCounterpartyRiskRule{configuredRiskRule{config: config}}, nil
+	default:
+		return &aboveAverageRiskRule{configuredRiskRule{config: config}}, nil
+	}
+}
+
+/* configuredRiskRule is shared by the rules built from a RiskRuleConfig */
+type configuredRiskRule struct {
+	config domain.RiskRuleConfig
+}
+
+func (rule *configuredRiskRule) Name() string {
+	return rule.config.Name
+}
+
+func (rule *configuredRiskRule) Action() domain.RiskAction {
+	return rule.config.Action
+}
+
+/* velocityRiskRule matches a sender making too many transactions of a type in a short window */
+type velocityRiskRule struct {
+	configuredRiskRule
+}
+
+func (rule *velocityRiskRule) Evaluate(repositories persistence.Repositories, transaction *domain.Transaction) (string, error) {
+	if !rule.config.AppliesTo(transaction.Type) {
+		return "", nil
+	}
+
+	_, count, err := repositories.Transactions.GetUsageSince(transaction.FromUser, transaction.Type, transaction.Currency, time.Now().Add(-rule.config.Window))
+	if err != nil {
+		return "", err
+	}
+	if count+1 <= rule.config.MaxCount {
+		return "", nil
+	}
+	return fmt.Sprintf("%d %s transactions within %s", count+1, transaction.Type, rule.config.Window), nil
+}
+
+/* newCounterpartyRiskRule matches a large transfer to a receiver the sender never paid before */
+type newCounterpartyRiskRule struct {
+	configuredRiskRule
+}
+
+func (rule *newCounterpartyRiskRule) Evaluate(repositories persistence.Repositories, transaction *domain.Transaction) (string, error) {
+	if transaction.ToUser == nil || !rule.config.AppliesTo(transaction.Type) {
+		return "", nil
+	}
+
+	minAmount, ok := rule.config.MinAmounts[transaction.Currency]
+	if !ok {
+		return "", nil
+	}
+	cmp, err := transaction.Amount.Abs().Cmp(minAmount)
+	if err != nil || cmp < 0 {
+		return "", err
+	}
+
+	paidBefore, err := repositories.Transactions.HasCompletedTransfer(transaction.FromUser, *transaction.ToUser)
+	if err != nil || paidBefore {
+		return "", err
+	}
+	return fmt.Sprintf("first transfer to user %d is %s %s", *transaction.ToUser, transaction.Amount.Abs(), transaction.Currency), nil
+}
+
+/* aboveAverageRiskRule matches an amount far above what the sender usually moves */
+type aboveAverageRiskRule struct {
+	configuredRiskRule
+}
+
+func (rule *aboveAverageRiskRule) Evaluate(repositories persistence.Repositories, transaction *domain.Transaction) (string, error) {
+	if !rule.config.AppliesTo(transaction.Type) {
+		return "", nil
+	}
+
+	total, count, err := repositories.Transactions.GetHistoricalUsage(transaction.FromUser, transaction.Type, transaction.Currency)
+	if err != nil {
+		return "", err
+	}
+	if count < rule.config.MinHistory {
+		return "", nil
+	}
+
+	average := domain.NewMoney(total.MinorUnits()/int64(count), transaction.Currency)
+	if float64(transaction.Amount.Abs().MinorUnits()) <= rule.config.Multiplier*float64(average.MinorUnits()) {
+		return "", nil
+	}
+	return fmt.Sprintf("%s %s is over %g times the average of %s", transaction.Amount.Abs(), transaction.Currency, rule.config.Multiplier, average), nil
+}
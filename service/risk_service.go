@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* riskReviewNoteMaxLength matches the note column of risk_reviews */
+const riskReviewNoteMaxLength = 255
+
+var (
+	ErrTransactionBlocked    = errors.New("transaction blocked by risk rules")
+	ErrRiskReviewResolved    = errors.New("risk review is already resolved")
+	ErrRiskReviewNoteTooLong = fmt.Errorf("note cannot be longer than %d characters", riskReviewNoteMaxLength)
+)
+
+type IRiskService interface {
+	LoadRulesFromFile(path string) (int, error)
+	Screen(repositories persistence.Repositories, transaction *domain.Transaction) error
+	GetReviews(status domain.RiskReviewStatus) ([]domain.RiskReview, error)
+	GetReview(id int64) (*domain.RiskReview, error)
+	ResolveReview(id int64, status domain.RiskReviewStatus, resolvedBy int64, note string) (*domain.RiskReview, error)
+}
+
+type RiskService struct {
+	riskRepository persistence.IRiskRepository
+	rules          []IRiskRule
+}
+
+func NewRiskService(riskRepository persistence.IRiskRepository, rules []IRiskRule) IRiskService {
+	return &RiskService{
+		riskRepository: riskRepository,
+		rules:          rules,
+	}
+}
+
+/*
+LoadRulesFromFile adds the rules of a .json file to the engine. The file is
+validated as a whole first, so a bad rule doesn't leave half of them loaded.
+*/
+func (riskService *RiskService) LoadRulesFromFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var entries []riskRuleFileEntry
+	if err := json.NewDecoder(file).Decode(&entries); err != nil {
+		return 0, fmt.Errorf("error reading risk rules file %s: %w", path, err)
+	}
+
+	var rules []IRiskRule
+	for i, entry := range entries {
+		config, err := entry.toConfig()
+		if err != nil {
+			return 0, fmt.Errorf("error reading risk rules file %s: entry %d: %w", path, i+1, err)
+		}
+		rule, err := newRiskRule(config)
+		if err != nil {
+			return 0, fmt.Errorf("error reading risk rules file %s: entry %d: %w", path, i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	riskService.rules = append(riskService.rules, rules...)
+	return len(rules), nil
+}
+
+/*
+Screen runs every rule against the transaction before its money moves, it is
+called within the transaction's unit of work once the transaction is stored.
+A blocking match fails the transaction, a flagging one lets it through and
+queues it for review. Matching allow rules are only logged, which lets a new
+rule be watched before it is enforced.
+*/
+func (riskService *RiskService) Screen(repositories persistence.Repositories, transaction *domain.Transaction) error {
+	matches := map[domain.RiskAction][]string{}
+	for _, rule := range riskService.rules {
+		reason, err := rule.Evaluate(repositories, transaction)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			matches[rule.Action()] = append(matches[rule.Action()], fmt.Sprintf("%s: %s", rule.Name(), reason))
+		}
+	}
+
+	for _, match := range matches[domain.RiskAllow] {
+		log.Printf("Transaction %d of user %d allowed by risk rule %s", transaction.ID, transaction.FromUser, match)
+	}
+	if blocked := matches[domain.RiskBlock]; len(blocked) > 0 {
+		return fmt.Errorf("%w: %s", ErrTransactionBlocked, strings.Join(blocked, "; "))
+	}
+	if flagged := matches[domain.RiskFlag]; len(flagged) > 0 {
+		return repositories.RiskReviews.CreateRiskReview(&domain.RiskReview{
+			TransactionID: transaction.ID,
+			UserID:        transaction.FromUser,
+			Reasons:       strings.Join(flagged, "; "),
+			Status:        domain.RiskReviewOpen,
+			CreatedAt:     time.Now(),
+		})
+	}
+	return nil
+}
+
+/* GetReviews lists the review queue, the open reviews when status is empty */
+func (riskService *RiskService) GetReviews(status domain.RiskReviewStatus) ([]domain.RiskReview, error) {
+	if status == "" {
+		status = domain.RiskReviewOpen
+	}
+	return riskService.riskRepository.GetRiskReviewsByStatus(status)
+}
+
+func (riskService *RiskService) GetReview(id int64) (*domain.RiskReview, error) {
+	return riskService.riskRepository.GetRiskReviewByID(id)
+}
+
+/* ResolveReview takes the review off the queue as cleared or confirmed, only once */
+func (riskService *RiskService) ResolveReview(id int64, status domain.RiskReviewStatus, resolvedBy int64, note string) (*domain.RiskReview, error) {
+	if len(note) > riskReviewNoteMaxLength {
+		return nil, ErrRiskReviewNoteTooLong
+	}
+
+	review, err := riskService.riskRepository.GetRiskReviewByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != domain.RiskReviewOpen {
+		return nil, ErrRiskReviewResolved
+	}
+
+	if err := review.Resolve(status, resolvedBy, note, time.Now()); err != nil {
+		return nil, err
+	}
+
+	resolved, err := riskService.riskRepository.ResolveRiskReview(review)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return nil, ErrRiskReviewResolved
+	}
+	return review, nil
+}
+
+/* riskRuleFileEntry is one rule of the risk rules file, amounts are per currency */
+type riskRuleFileEntry struct {
+	Name             string                     `json:"name"`
+	Type             domain.RiskRuleType        `json:"type"`
+	Action           domain.RiskAction          `json:"action"`
+	TransactionTypes []domain.TransactionType   `json:"transaction_types"`
+	MaxCount         int                        `json:"max_count"`
+	WindowMinutes    int                        `json:"window_minutes"`
+	MinAmounts       map[domain.Currency]string `json:"min_amounts"`
+	Multiplier       float64                    `json:"multiplier"`
+	MinHistory       int                        `json:"min_history"`
+}
+
+func (entry riskRuleFileEntry) toConfig() (domain.RiskRuleConfig, error) {
+	config := domain.RiskRuleConfig{
+		Name:             entry.Name,
+		Type:             entry.Type,
+		Action:           entry.Action,
+		TransactionTypes: entry.TransactionTypes,
+		MaxCount:         entry.MaxCount,
+		Window:           time.Duration(entry.WindowMinutes) * time.Minute,
+		Multiplier:       entry.Multiplier,
+		MinHistory:       entry.MinHistory,
+	}
+
+	if len(entry.MinAmounts) > 0 {
+		config.MinAmounts = map[domain.Currency]domain.Money{}
+	}
+	for currency, value := range entry.MinAmounts {
+		currency = domain.Currency(strings.ToUpper(string(currency)))
+		amount, err := domain.ParseMoney(value, currency)
+		if err != nil {
+			return domain.RiskRuleConfig{}, err
+		}
+		config.MinAmounts[currency] = amount
+	}
+
+	return config, nil
+}
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+const (
+	/* dueSweepRulesBatchSize bounds how many scheduled sweeps one tick runs */
+	dueSweepRulesBatchSize = 100
+	/* sweepRateWindowDays is how far back triggered sweeps are averaged for projections */
+	sweepRateWindowDays = 30
+)
+
+var (
+	ErrSavingsGoalClosed  = errors.New("savings goal is closed")
+	ErrSavingsGoalReached = errors.New("savings goal has already reached its target")
+)
+
+type ISavingsGoalService interface {
+	CreateGoal(goal *domain.SavingsGoal) (*domain.SavingsGoal, error)
+	GetGoal(goalID int64) (*domain.SavingsGoal, error)
+	GetGoals(userID int64) ([]domain.SavingsGoal, error)
+	CloseGoal(goalID int64) (*domain.SavingsGoal, error)
+	Contribute(goalID int64, amount domain.Money) (*domain.SavingsSweep, error)
+	AddRule(goalID int64, rule *domain.SweepRule, startAt time.Time) (*domain.SweepRule, error)
+	GetRules(goalID int64) ([]domain.SweepRule, error)
+	RemoveRule(goalID int64, ruleID int64) error
+	GetSweeps(goalID int64) ([]domain.SavingsSweep, error)
+	GetProjection(goalID int64) (*domain.SavingsProjection, error)
+	ExecuteDueSweeps(ctx context.Context, now time.Time) error
+}
+
+type SavingsGoalService struct {
+	savingsGoalRepository persistence.ISavingsGoalRepository
+	unitOfWork            persistence.IUnitOfWork
+}
+
+func NewSavingsGoalService(savingsGoalRepository persistence.ISavingsGoalRepository, unitOfWork persistence.IUnitOfWork) ISavingsGoalService {
+	return &SavingsGoalService{
+		savingsGoalRepository: savingsGoalRepository,
+		unitOfWork:            unitOfWork,
+	}
+}
+
+func (savingsGoalService *SavingsGoalService) CreateGoal(goal *domain.SavingsGoal) (*domain.SavingsGoal, error) {
+	now := time.Now()
+	if err := goal.Validate(now); err != nil {
+		return nil, err
+	}
+
+	goal.SavedAmount = domain.Zero(goal.Currency)
+	goal.Status = domain.SavingsGoalActive
+	goal.CreatedAt = now
+	goal.UpdatedAt = now
+	if err := savingsGoalService.savingsGoalRepository.CreateGoal(goal); err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+func (savingsGoalService *SavingsGoalService) GetGoal(goalID int64) (*domain.SavingsGoal, error) {
+	return savingsGoalService.savingsGoalRepository.GetGoalByID(goalID)
+}
+
+func (savingsGoalService *SavingsGoalService) GetGoals(userID int64) ([]domain.SavingsGoal, error) {
+	return savingsGoalService.savingsGoalRepository.GetGoalsByUserID(userID)
+}
+
+/*
+CloseGoal pays what the goal saved back into the wallet as a
+savings_withdrawal transaction and stops its rules. SavedAmount keeps what
+was paid back.
+*/
+func (savingsGoalService *SavingsGoalService) CloseGoal(goalID int64) (*domain.SavingsGoal, error) {
+	var goal *domain.SavingsGoal
+
+	err := retryOnBalanceConflict(func() error {
+		return savingsGoalService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			var err error
+			if goal, err = repositories.SavingsGoals.GetGoalByIDForUpdate(goalID); err != nil {
+				return err
+			}
+			if !goal.IsActive() {
+				return ErrSavingsGoalClosed
+			}
+
+			now := time.Now()
+			if goal.SavedAmount.IsPositive() {
+				if err := payBackSavings(repositories, goal, now); err != nil {
+					return err
+				}
+			}
+
+			rules, err := repositories.SavingsGoals.GetRulesByGoalID(goal.ID)
+			if err != nil {
+				return err
+			}
+			for _, rule := range rules {
+				if err := deactivateSweepRule(repositories, &rule, now); err != nil {
+					return err
+				}
+			}
+
+			goal.Status = domain.SavingsGoalClosed
+			goal.ClosedAt = &now
+			goal.UpdatedAt = now
+			return repositories.SavingsGoals.UpdateGoal(goal)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return goal, nil
+}
+
+/* Contribute sweeps amount into the goal by hand, capped at what the goal still misses */
+func (savingsGoalService *SavingsGoalService) Contribute(goalID int64, amount domain.Money) (*domain.SavingsSweep, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+
+	var savingsSweep *domain.SavingsSweep
+
+	err := retryOnBalanceConflict(func() error {
+		return savingsGoalService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			var err error
+			savingsSweep, err = sweepIntoGoal(repositories, goalID, nil, nil, amount, time.Now())
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return savingsSweep, nil
+}
+
+/*
+AddRule adds a sweep rule to an active goal. A fixed amount rule first runs
+on the first occurrence of its recurrence on or after startAt, or now when
+startAt is zero or already passed.
+*/
+func (savingsGoalService *SavingsGoalService) AddRule(goalID int64, rule *domain.SweepRule, startAt time.Time) (*domain.SweepRule, error) {
+	goal, err := savingsGoalService.savingsGoalRepository.GetGoalByID(goalID)
+	if err != nil {
+		return nil, err
+	}
+	if !goal.IsActive() {
+		return nil, ErrSavingsGoalClosed
+	}
+
+	now := time.Now()
+	rule.GoalID = goal.ID
+	rule.UserID = goal.UserID
+	rule.Currency = goal.Currency
+	rule.OccurrencesCount = 0
+	rule.Active = true
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if rule.Type == domain.FixedAmountSweep {
+		if startAt.Before(now) {
+			startAt = now
+		}
+		nextRunAt := rule.Recurrence.FirstOccurrence(startAt)
+		if rule.Recurrence.IsFinished(nextRunAt, 0) {
+			return nil, ErrRecurrenceEndsBeforeStart
+		}
+		rule.NextRunAt = &nextRunAt
+	}
+
+	if err := savingsGoalService.savingsGoalRepository.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (savingsGoalService *SavingsGoalService) GetRules(goalID int64) ([]domain.SweepRule, error) {
+	if _, err := savingsGoalService.savingsGoalRepository.GetGoalByID(goalID); err != nil {
+		return nil, err
+	}
+	return savingsGoalService.savingsGoalRepository.GetRulesByGoalID(goalID)
+}
+
+/* RemoveRule deactivates the rule, the sweeps it made keep pointing at it */
+func (savingsGoalService *SavingsGoalService) RemoveRule(goalID int64, ruleID int64) error {
+	return savingsGoalService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		rule, err := repositories.SavingsGoals.GetRuleByIDForUpdate(ruleID)
+		if err != nil {
+			return err
+		}
+		if rule.GoalID != goalID {
+			return persistence.ErrSweepRuleNotFound
+		}
+		return deactivateSweepRule(repositories, rule, time.Now())
+	})
+}
+
+func (savingsGoalService *SavingsGoalService) GetSweeps(goalID int64) ([]domain.SavingsSweep, error) {
+	if _, err := savingsGoalService.savingsGoalRepository.GetGoalByID(goalID); err != nil {
+		return nil, err
+	}
+	return savingsGoalService.savingsGoalRepository.GetSweepsByGoalID(goalID)
+}
+
+/*
+GetProjection projects the goal from its fixed amount rules and the average
+daily amount its triggered sweeps saved over the last sweepRateWindowDays,
+or since the goal was created when that is more recent.
+*/
+func (savingsGoalService *SavingsGoalService) GetProjection(goalID int64) (*domain.SavingsProjection, error) {
+	goal, err := savingsGoalService.savingsGoalRepository.GetGoalByID(goalID)
+	if err != nil {
+		return nil, err
+	}
+	if !goal.IsActive() {
+		return nil, ErrSavingsGoalClosed
+	}
+
+	rules, err := savingsGoalService.savingsGoalRepository.GetRulesByGoalID(goalID)
+	if err != nil {
+		return nil, err
+	}
+	sweeps, err := savingsGoalService.savingsGoalRepository.GetSweepsByGoalID(goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	triggered := make(map[int64]bool, len(rules))
+	for _, rule := range rules {
+		triggered[rule.ID] = rule.Type != domain.FixedAmountSweep
+	}
+
+	now := time.Now()
+	windowDays := min(sweepRateWindowDays, int64(now.Sub(goal.CreatedAt).Hours()/24)+1)
+	windowStart := now.AddDate(0, 0, -int(windowDays))
+
+	var sweptInWindow int64
+	for _, sweep := range sweeps {
+		if sweep.RuleID != nil && triggered[*sweep.RuleID] && !sweep.CreatedAt.Before(windowStart) {
+			sweptInWindow += sweep.Amount.MinorUnits()
+		}
+	}
+
+	projection := goal.Project(rules, domain.NewMoney(sweptInWindow/windowDays, goal.Currency), now)
+	return &projection, nil
+}
+
+/*
+ExecuteDueSweeps runs the fixed amount rules whose occurrence is due. An
+occurrence the main balance can't cover is skipped, and a rule whose goal
+was reached or closed is deactivated.
+*/
+func (savingsGoalService *SavingsGoalService) ExecuteDueSweeps(ctx context.Context, now time.Time) error {
+	dueRules, err := savingsGoalService.savingsGoalRepository.GetDueRules(now, dueSweepRulesBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, dueRule := range dueRules {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		err := retryOnBalanceConflict(func() error {
+			return savingsGoalService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+				return runScheduledSweep(repositories, dueRule.ID, now)
+			})
+		})
+		if err != nil {
+			log.Printf("Sweep rule %d couldn't run: %v", dueRule.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func runScheduledSweep(repositories persistence.Repositories, ruleID int64, now time.Time) error {
+	rule, err := repositories.SavingsGoals.GetRuleByIDForUpdate(ruleID)
+	if err != nil {
+		return err
+	}
+	if !rule.Active || rule.NextRunAt.After(now) {
+		return nil
+	}
+
+	_, err = sweepIntoGoal(repositories, rule.GoalID, &rule.ID, nil, *rule.Amount, now)
+	switch {
+	case err == nil:
+		rule.Advance()
+	case errors.Is(err, ErrInsufficientBalance):
+		log.Printf("Sweep rule %d skipped its occurrence at %s: %v", rule.ID, rule.NextRunAt.Format(time.RFC3339), err)
+		rule.Advance()
+	case errors.Is(err, ErrSavingsGoalReached), errors.Is(err, ErrSavingsGoalClosed):
+		rule.Active = false
+	default:
+		return err
+	}
+
+	rule.UpdatedAt = now
+	return repositories.SavingsGoals.UpdateRule(rule)
+}
+
+/*
+sweepIntoSavings runs the rules transaction sets off within its unit of
+work: every amount it adds to a user's balance, as given by deltas, feeds
+that user's percentage_of_credit rules and every amount it takes feeds
+round_up rules. A sweep the main balance can't cover is skipped, it never
+fails the transaction.
+*/
+func sweepIntoSavings(repositories persistence.Repositories, transaction *domain.Transaction, deltas map[int64]domain.Money) error {
+	for _, userID := range sortedUserIDs(deltas) {
+		ruleType := domain.PercentageOfCreditSweep
+		delta := deltas[userID]
+		switch {
+		case delta.IsNegative():
+			ruleType = domain.RoundUpSweep
+		case !delta.IsPositive():
+			continue
+		}
+
+		if err := runSweepRules(repositories, transaction, userID, ruleType, delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* runSweepRules sweeps into the goals of the user's active rules of ruleType, each taking its share of trigger */
+func runSweepRules(repositories persistence.Repositories, transaction *domain.Transaction, userID int64, ruleType domain.SweepRuleType, trigger domain.Money) error {
+	rules, err := repositories.SavingsGoals.GetActiveRules(userID, trigger.Currency(), ruleType)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		amount, err := rule.SweepAmount(trigger)
+		if err != nil {
+			return err
+		}
+		if amount.IsZero() {
+			continue
+		}
+
+		_, err = sweepIntoGoal(repositories, rule.GoalID, &rule.ID, &transaction.ID, amount, transaction.CreatedAt)
+		switch {
+		case err == nil, errors.Is(err, ErrInsufficientBalance):
+		case errors.Is(err, ErrSavingsGoalReached), errors.Is(err, ErrSavingsGoalClosed):
+			if err := deactivateSweepRule(repositories, &rule, transaction.CreatedAt); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+sweepIntoGoal moves amount of the main balance into the goal as a
+savings_sweep transaction paid into the savings account. The goal never
+takes more than it still misses, and money held or borrowed through an
+overdraft isn't swept.
+*/
+func sweepIntoGoal(repositories persistence.Repositories, goalID int64, ruleID *int64, parentID *int64, amount domain.Money, now time.Time) (*domain.SavingsSweep, error) {
+	goal, err := repositories.SavingsGoals.GetGoalByIDForUpdate(goalID)
+	if err != nil {
+		return nil, err
+	}
+	if !goal.IsActive() {
+		return nil, ErrSavingsGoalClosed
+	}
+	if goal.IsReached() {
+		return nil, ErrSavingsGoalReached
+	}
+
+	cmp, err := amount.Cmp(goal.Remaining())
+	if err != nil {
+		return nil, err
+	}
+	if cmp > 0 {
+		amount = goal.Remaining()
+	}
+
+	balance, err := repositories.Balances.GetBalanceByUserIDForUpdate(goal.UserID, goal.Currency)
+	if errors.Is(err, persistence.ErrBalanceNotFound) {
+		return nil, ErrInsufficientBalance
+	}
+	if err != nil {
+		return nil, err
+	}
+	free, err := balance.Main().Sub(balance.HeldAmount)
+	if err != nil {
+		return nil, err
+	}
+	if cmp, err = free.Cmp(amount); err != nil {
+		return nil, err
+	}
+	if cmp < 0 {
+		return nil, ErrInsufficientBalance
+	}
+
+	sweepTransaction := &domain.Transaction{
+		ParentID:  parentID,
+		FromUser:  goal.UserID,
+		Amount:    amount.Neg(),
+		Currency:  goal.Currency,
+		Type:      domain.SavingsSweepTransaction,
+		Status:    domain.Pending,
+		CreatedAt: now,
+	}
+	if err := repositories.Transactions.CreateTransaction(sweepTransaction); err != nil {
+		return nil, err
+	}
+	if err := moveBalances(repositories, &sweepTransaction.ID, string(sweepTransaction.Type), sweepTransaction.BalanceDeltas(), domain.SavingsAccountCode(goal.Currency)); err != nil {
+		return nil, err
+	}
+	if err := repositories.Transactions.UpdateTransactionStatus(sweepTransaction.ID, domain.Pending, domain.Completed, fmt.Sprintf("swept into savings goal %d", goal.ID)); err != nil {
+		return nil, err
+	}
+
+	if goal.SavedAmount, err = goal.SavedAmount.Add(amount); err != nil {
+		return nil, err
+	}
+	goal.UpdatedAt = now
+	if err := repositories.SavingsGoals.UpdateGoal(goal); err != nil {
+		return nil, err
+	}
+
+	savingsSweep := &domain.SavingsSweep{
+		GoalID:        goal.ID,
+		RuleID:        ruleID,
+		TransactionID: sweepTransaction.ID,
+		Amount:        amount,
+		CreatedAt:     now,
+	}
+	if err := repositories.SavingsGoals.CreateSweep(savingsSweep); err != nil {
+		return nil, err
+	}
+	return savingsSweep, nil
+}
+
+/* payBackSavings moves everything the goal saved from the savings account back into the wallet */
+func payBackSavings(repositories persistence.Repositories, goal *domain.SavingsGoal, now time.Time) error {
+	withdrawal := &domain.Transaction{
+		FromUser:  goal.UserID,
+		Amount:    goal.SavedAmount,
+		Currency:  goal.Currency,
+		Type:      domain.SavingsWithdrawalTransaction,
+		Status:    domain.Pending,
+		CreatedAt: now,
+	}
+	if err := repositories.Transactions.CreateTransaction(withdrawal); err != nil {
+		return err
+	}
+	if err := moveBalances(repositories, &withdrawal.ID, string(withdrawal.Type), withdrawal.BalanceDeltas(), domain.SavingsAccountCode(goal.Currency)); err != nil {
+		return err
+	}
+	return repositories.Transactions.UpdateTransactionStatus(withdrawal.ID, domain.Pending, domain.Completed, fmt.Sprintf("paid back from savings goal %d", goal.ID))
+}
+
+func deactivateSweepRule(repositories persistence.Repositories, rule *domain.SweepRule, now time.Time) error {
+	if !rule.Active {
+		return nil
+	}
+	rule.Active = false
+	rule.UpdatedAt = now
+	return repositories.SavingsGoals.UpdateRule(rule)
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/scheduledtransfer"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* dueScheduledTransfersBatchSize bounds how many transfers one tick executes */
+const dueScheduledTransfersBatchSize = 100
+
+var (
+	ErrExecuteAtNotInFuture            = errors.New("execute_at must be in the future")
+	ErrScheduledTransferNotCancellable = errors.New("only scheduled transfers can be cancelled")
+	ErrScheduledTransferInterrupted    = errors.New("transfer was interrupted before it was recorded")
+)
+
+type IScheduledTransferService interface {
+	Schedule(fromUserID int64, toUserID int64, amount domain.Money, executeAt time.Time) (*domain.ScheduledTransfer, error)
+	GetByID(id int64) (*domain.ScheduledTransfer, error)
+	GetPendingByUserID(userID int64) ([]domain.ScheduledTransfer, error)
+	Cancel(id int64) (*domain.ScheduledTransfer, error)
+	ExecuteDue(ctx context.Context, now time.Time) error
+}
+
+type ScheduledTransferService struct {
+	scheduledTransferRepository persistence.IScheduledTransferRepository
+	transactionService          ITransactionService
+	config                      scheduledtransfer.Config
+}
+
+func NewScheduledTransferService(scheduledTransferRepository persistence.IScheduledTransferRepository, transactionService ITransactionService, config scheduledtransfer.Config) IScheduledTransferService {
+	return &ScheduledTransferService{
+		scheduledTransferRepository: scheduledTransferRepository,
+		transactionService:          transactionService,
+		config:                      config,
+	}
+}
+
+func (scheduledTransferService *ScheduledTransferService) Schedule(fromUserID int64, toUserID int64, amount domain.Money, executeAt time.Time) (*domain.ScheduledTransfer, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if fromUserID == toUserID {
+		return nil, errors.New("transfer cannot be to the same user")
+	}
+
+	now := time.Now()
+	if !executeAt.After(now) {
+		return nil, ErrExecuteAtNotInFuture
+	}
+
+	scheduledTransfer := &domain.ScheduledTransfer{
+		FromUser:  fromUserID,
+		ToUser:    toUserID,
+		Amount:    amount,
+		ExecuteAt: executeAt,
+		Status:    domain.Scheduled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := scheduledTransferService.scheduledTransferRepository.CreateScheduledTransfer(scheduledTransfer); err != nil {
+		return nil, err
+	}
+	return scheduledTransfer, nil
+}
+
+func (scheduledTransferService *ScheduledTransferService) GetByID(id int64) (*domain.ScheduledTransfer, error) {
+	return scheduledTransferService.scheduledTransferRepository.GetScheduledTransferByID(id)
+}
+
+func (scheduledTransferService *ScheduledTransferService) GetPendingByUserID(userID int64) ([]domain.ScheduledTransfer, error) {
+	return scheduledTransferService.scheduledTransferRepository.GetScheduledTransfersByUserID(userID, domain.Scheduled)
+}
+
+func (scheduledTransferService *ScheduledTransferService) Cancel(id int64) (*domain.ScheduledTransfer, error) {
+	cancelled, err := scheduledTransferService.scheduledTransferRepository.UpdateStatus(id, domain.Scheduled, domain.ScheduledCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduledTransfer, err := scheduledTransferService.scheduledTransferRepository.GetScheduledTransferByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrScheduledTransferNotCancellable
+	}
+	return scheduledTransfer, nil
+}
+
+/*
+ExecuteDue is the scheduler job. Each due transfer is claimed with a
+compare-and-set first, so a transfer cancelled in the meantime is skipped and
+none is executed twice. Transfers left claimed by a run that died are never
+executed again: they are settled from the transaction their transfer
+recorded, or failed when it recorded none.
+*/
+func (scheduledTransferService *ScheduledTransferService) ExecuteDue(ctx context.Context, now time.Time) error {
+	if err := scheduledTransferService.recoverStaleTransfers(now); err != nil {
+		return err
+	}
+
+	dueTransfers, err := scheduledTransferService.scheduledTransferRepository.GetDueScheduledTransfers(now, dueScheduledTransfersBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, scheduledTransfer := range dueTransfers {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		claimed, err := scheduledTransferService.scheduledTransferRepository.UpdateStatus(scheduledTransfer.ID, domain.Scheduled, domain.ScheduledExecuting)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		scheduledTransferService.execute(scheduledTransfer)
+	}
+
+	return nil
+}
+
+func (scheduledTransferService *ScheduledTransferService) execute(scheduledTransfer domain.ScheduledTransfer) {
+	transaction, err := scheduledTransferService.transactionService.Transfer(scheduledTransfer.FromUser, scheduledTransfer.ToUser, scheduledTransfer.Amount)
+	if err != nil {
+		log.Printf("Scheduled transfer %d failed: %v", scheduledTransfer.ID, err)
+		if err := scheduledTransferService.scheduledTransferRepository.MarkFailed(scheduledTransfer.ID, err.Error()); err != nil {
+			log.Printf("Scheduled transfer %d couldn't be marked as failed: %v", scheduledTransfer.ID, err)
+		}
+		return
+	}
+
+	scheduledTransferService.record(scheduledTransfer, transaction)
+}
+
+/* record marks the scheduled transfer with the transaction its transfer recorded */
+func (scheduledTransferService *ScheduledTransferService) record(scheduledTransfer domain.ScheduledTransfer, transaction *domain.Transaction) {
+	/* a transfer over the approval threshold hasn't moved any money yet, its transaction tells how the approval ends */
+	if transaction.AwaitsApproval() || transaction.Status == domain.Rejected {
+		if err := scheduledTransferService.scheduledTransferRepository.MarkAwaitingApproval(scheduledTransfer.ID, transaction.ID); err != nil {
+			log.Printf("Scheduled transfer %d is awaiting approval of transaction %d but couldn't be marked: %v", scheduledTransfer.ID, transaction.ID, err)
+		}
+		return
+	}
+
+	if err := scheduledTransferService.scheduledTransferRepository.MarkCompleted(scheduledTransfer.ID, transaction.ID); err != nil {
+		log.Printf("Scheduled transfer %d completed with transaction %d but couldn't be marked: %v", scheduledTransfer.ID, transaction.ID, err)
+	}
+}
+
+/*
+recoverStaleTransfers settles the transfers claimed for longer than the
+execution timeout. The transfer doesn't point back at its scheduled transfer,
+so the sender's transfers of that amount to that recipient since the claim
+tell whether it went through.
+*/
+func (scheduledTransferService *ScheduledTransferService) recoverStaleTransfers(now time.Time) error {
+	updatedBefore := now.Add(-scheduledTransferService.config.ExecutionTimeout)
+	staleTransfers, err := scheduledTransferService.scheduledTransferRepository.GetStaleExecutingScheduledTransfers(updatedBefore, dueScheduledTransfersBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, staleTransfer := range staleTransfers {
+		transaction, err := scheduledTransferService.findRecordedTransfer(staleTransfer)
+		if err != nil {
+			return err
+		}
+		if transaction != nil {
+			log.Printf("Scheduled transfer %d was interrupted after recording transaction %d", staleTransfer.ID, transaction.ID)
+			scheduledTransferService.record(staleTransfer, transaction)
+			continue
+		}
+
+		failed, err := scheduledTransferService.scheduledTransferRepository.FailStaleScheduledTransfer(staleTransfer.ID, updatedBefore, ErrScheduledTransferInterrupted.Error())
+		if err != nil {
+			return err
+		}
+		if failed {
+			log.Printf("Scheduled transfer %d was interrupted before recording a transaction and is failed", staleTransfer.ID)
+		}
+	}
+
+	return nil
+}
+
+/* findRecordedTransfer returns the first transfer the claimed scheduled transfer may have recorded, nil if none */
+func (scheduledTransferService *ScheduledTransferService) findRecordedTransfer(scheduledTransfer domain.ScheduledTransfer) (*domain.Transaction, error) {
+	claimedAt := scheduledTransfer.UpdatedAt
+	page, err := scheduledTransferService.transactionService.GetTransactionHistory(scheduledTransfer.FromUser, domain.TransactionFilter{
+		Types:        []domain.TransactionType{domain.TransferTransaction},
+		Counterparty: &scheduledTransfer.ToUser,
+		MinAmount:    &scheduledTransfer.Amount,
+		MaxAmount:    &scheduledTransfer.Amount,
+		From:         &claimedAt,
+		Ascending:    true,
+		Limit:        domain.MaxTransactionPageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range page.Transactions {
+		/* a failed transfer moved nothing, the scheduled transfer can be failed as well */
+		if transaction.FromUser == scheduledTransfer.FromUser && transaction.Status != domain.Failed {
+			return &transaction, nil
+		}
+	}
+	return nil, nil
+}
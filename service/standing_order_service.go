@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/standingorder"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service/model"
+)
+
+/* dueStandingOrdersBatchSize bounds how many standing orders one tick executes */
+const dueStandingOrdersBatchSize = 100
+
+var (
+	ErrStandingOrderNotActive      = errors.New("only active standing orders can be changed")
+	ErrStandingOrderConcurrentEdit = errors.New("standing order was changed concurrently, try again")
+	ErrRecurrenceEndsBeforeStart   = errors.New("recurrence ends before its first occurrence")
+)
+
+type IStandingOrderService interface {
+	Create(standingOrderCreate model.StandingOrderCreate) (*domain.StandingOrder, error)
+	GetByID(id int64) (*domain.StandingOrder, error)
+	GetByUserID(userID int64) ([]domain.StandingOrder, error)
+	Update(id int64, standingOrderUpdate model.StandingOrderUpdate) (*domain.StandingOrder, error)
+	Cancel(id int64) (*domain.StandingOrder, error)
+	GetAttempts(id int64) ([]domain.StandingOrderAttempt, error)
+	ExecuteDue(ctx context.Context, now time.Time) error
+}
+
+type StandingOrderService struct {
+	standingOrderRepository persistence.IStandingOrderRepository
+	transactionService      ITransactionService
+	config                  standingorder.Config
+}
+
+func NewStandingOrderService(standingOrderRepository persistence.IStandingOrderRepository, transactionService ITransactionService, config standingorder.Config) IStandingOrderService {
+	return &StandingOrderService{
+		standingOrderRepository: standingOrderRepository,
+		transactionService:      transactionService,
+		config:                  config,
+	}
+}
+
+func (standingOrderService *StandingOrderService) Create(standingOrderCreate model.StandingOrderCreate) (*domain.StandingOrder, error) {
+	now := time.Now()
+	startAt := standingOrderCreate.StartAt
+	if startAt.IsZero() {
+		startAt = now
+	}
+
+	standingOrder := &domain.StandingOrder{
+		FromUser:                standingOrderCreate.FromUser,
+		ToUser:                  standingOrderCreate.ToUser,
+		Amount:                  standingOrderCreate.Amount,
+		Recurrence:              standingOrderCreate.Recurrence,
+		StartAt:                 startAt,
+		InsufficientFundsPolicy: standingOrderService.config.InsufficientFundsPolicy,
+		MaxRetries:              standingOrderService.config.MaxRetries,
+		Status:                  domain.StandingOrderActive,
+		CreatedAt:               now,
+		UpdatedAt:               now,
+	}
+	if standingOrderCreate.InsufficientFundsPolicy != nil {
+		standingOrder.InsufficientFundsPolicy = *standingOrderCreate.InsufficientFundsPolicy
+	}
+	if standingOrderCreate.MaxRetries != nil {
+		standingOrder.MaxRetries = *standingOrderCreate.MaxRetries
+	}
+
+	if err := standingOrderService.schedule(standingOrder, now); err != nil {
+		return nil, err
+	}
+
+	if err := standingOrderService.standingOrderRepository.CreateStandingOrder(standingOrder); err != nil {
+		return nil, err
+	}
+	return standingOrder, nil
+}
+
+func (standingOrderService *StandingOrderService) GetByID(id int64) (*domain.StandingOrder, error) {
+	return standingOrderService.standingOrderRepository.GetStandingOrderByID(id)
+}
+
+func (standingOrderService *StandingOrderService) GetByUserID(userID int64) ([]domain.StandingOrder, error) {
+	return standingOrderService.standingOrderRepository.GetStandingOrdersByUserID(userID)
+}
+
+/*
+Update replaces the amount, recurrence and policy of an active standing order.
+The next occurrence is recomputed from the new recurrence, occurrences that
+already ran still count towards the occurrence limit.
+*/
+func (standingOrderService *StandingOrderService) Update(id int64, standingOrderUpdate model.StandingOrderUpdate) (*domain.StandingOrder, error) {
+	standingOrder, err := standingOrderService.standingOrderRepository.GetStandingOrderByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if standingOrder.Status != domain.StandingOrderActive {
+		return nil, ErrStandingOrderNotActive
+	}
+
+	expectedNextRunAt := standingOrder.NextRunAt
+	now := time.Now()
+
+	standingOrder.Amount = standingOrderUpdate.Amount
+	standingOrder.Recurrence = standingOrderUpdate.Recurrence
+	standingOrder.RetryCount = 0
+	standingOrder.UpdatedAt = now
+	if standingOrderUpdate.InsufficientFundsPolicy != nil {
+		standingOrder.InsufficientFundsPolicy = *standingOrderUpdate.InsufficientFundsPolicy
+	}
+	if standingOrderUpdate.MaxRetries != nil {
+		standingOrder.MaxRetries = *standingOrderUpdate.MaxRetries
+	}
+
+	if err := standingOrderService.schedule(standingOrder, now); err != nil {
+		return nil, err
+	}
+
+	return standingOrder, standingOrderService.save(standingOrder, expectedNextRunAt)
+}
+
+func (standingOrderService *StandingOrderService) Cancel(id int64) (*domain.StandingOrder, error) {
+	standingOrder, err := standingOrderService.standingOrderRepository.GetStandingOrderByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if standingOrder.Status != domain.StandingOrderActive {
+		return nil, ErrStandingOrderNotActive
+	}
+
+	standingOrder.Status = domain.StandingOrderCancelled
+	standingOrder.UpdatedAt = time.Now()
+
+	return standingOrder, standingOrderService.save(standingOrder, standingOrder.NextRunAt)
+}
+
+func (standingOrderService *StandingOrderService) GetAttempts(id int64) ([]domain.StandingOrderAttempt, error) {
+	if _, err := standingOrderService.standingOrderRepository.GetStandingOrderByID(id); err != nil {
+		return nil, err
+	}
+	return standingOrderService.standingOrderRepository.GetAttempts(id)
+}
+
+/*
+ExecuteDue is the scheduler job. Every due occurrence becomes a regular
+transfer and every try is recorded as an attempt. When the payer can't cover
+it, the occurrence is retried after the configured interval or skipped,
+depending on the order's policy.
+*/
+func (standingOrderService *StandingOrderService) ExecuteDue(ctx context.Context, now time.Time) error {
+	dueStandingOrders, err := standingOrderService.standingOrderRepository.GetDueStandingOrders(now, dueStandingOrdersBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, standingOrder := range dueStandingOrders {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		standingOrderService.execute(standingOrder, now)
+	}
+
+	return nil
+}
+
+/*
+execute claims the occurrence before paying it: the order is moved past it
+first, so an edit, a cancel or another run in the meantime can't pay it a
+second time. A run that dies after the claim leaves the occurrence unpaid
+and without an attempt rather than paid twice. The order stays active until
+the outcome is recorded, an order whose last occurrence was claimed that way
+is completed on its next run.
+*/
+func (standingOrderService *StandingOrderService) execute(standingOrder domain.StandingOrder, now time.Time) {
+	if standingOrder.Recurrence.IsFinished(standingOrder.OccurrenceAt, standingOrder.OccurrencesCount) {
+		standingOrder.Status = domain.StandingOrderCompleted
+		standingOrder.UpdatedAt = now
+		if err := standingOrderService.save(&standingOrder, standingOrder.NextRunAt); err != nil {
+			log.Printf("Standing order %d couldn't be completed: %v", standingOrder.ID, err)
+		}
+		return
+	}
+
+	claimed := standingOrder
+	claimed.Advance()
+	claimed.Status = domain.StandingOrderActive
+	claimed.UpdatedAt = now
+	if err := standingOrderService.save(&claimed, standingOrder.NextRunAt); err != nil {
+		log.Printf("Standing order %d occurrence at %s couldn't be claimed: %v", standingOrder.ID, standingOrder.OccurrenceAt.Format(time.RFC3339), err)
+		return
+	}
+
+	attempt := &domain.StandingOrderAttempt{
+		StandingOrderID: standingOrder.ID,
+		OccurrenceAt:    standingOrder.OccurrenceAt,
+		AttemptedAt:     now,
+	}
+
+	outcome := claimed
+	transaction, err := standingOrderService.transactionService.Transfer(standingOrder.FromUser, standingOrder.ToUser, standingOrder.Amount)
+	switch {
+	case err == nil && transaction.AwaitsApproval():
+		attempt.Status = domain.AttemptAwaitingApproval
+		attempt.TransactionID = &transaction.ID
+	case err == nil:
+		attempt.Status = domain.AttemptSucceeded
+		attempt.TransactionID = &transaction.ID
+	case errors.Is(err, ErrInsufficientBalance) && standingOrderService.canRetry(standingOrder, now):
+		attempt.Status = domain.AttemptFailed
+		attempt.FailureReason = err.Error()
+		outcome = standingOrder
+		outcome.RetryCount++
+		outcome.NextRunAt = now.Add(standingOrderService.config.RetryInterval)
+	case errors.Is(err, ErrInsufficientBalance):
+		attempt.Status = domain.AttemptSkipped
+		attempt.FailureReason = err.Error()
+	default:
+		attempt.Status = domain.AttemptFailed
+		attempt.FailureReason = err.Error()
+	}
+
+	if err := standingOrderService.standingOrderRepository.CreateAttempt(attempt); err != nil {
+		log.Printf("Standing order %d attempt couldn't be recorded: %v", standingOrder.ID, err)
+	}
+
+	if outcome.Recurrence.IsFinished(outcome.OccurrenceAt, outcome.OccurrencesCount) {
+		outcome.Status = domain.StandingOrderCompleted
+	}
+	if outcome.Status == claimed.Status && outcome.NextRunAt.Equal(claimed.NextRunAt) {
+		return
+	}
+	outcome.UpdatedAt = now
+	if err := standingOrderService.save(&outcome, claimed.NextRunAt); err != nil {
+		log.Printf("Standing order %d couldn't be moved to its next run: %v", standingOrder.ID, err)
+	}
+}
+
+/* canRetry keeps retries from running into the order's next occurrence */
+func (standingOrderService *StandingOrderService) canRetry(standingOrder domain.StandingOrder, now time.Time) bool {
+	if standingOrder.InsufficientFundsPolicy != domain.RetryOccurrence || standingOrder.RetryCount >= standingOrder.MaxRetries {
+		return false
+	}
+	nextOccurrence := standingOrder.Recurrence.NextOccurrence(standingOrder.OccurrenceAt)
+	return now.Add(standingOrderService.config.RetryInterval).Before(nextOccurrence)
+}
+
+/* schedule validates the order and points it at its first occurrence from now on */
+func (standingOrderService *StandingOrderService) schedule(standingOrder *domain.StandingOrder, now time.Time) error {
+	if err := standingOrder.Validate(); err != nil {
+		return err
+	}
+
+	from := standingOrder.StartAt
+	if from.Before(now) {
+		from = now
+	}
+
+	standingOrder.OccurrenceAt = standingOrder.Recurrence.FirstOccurrence(from)
+	standingOrder.NextRunAt = standingOrder.OccurrenceAt
+	if standingOrder.Recurrence.IsFinished(standingOrder.OccurrenceAt, standingOrder.OccurrencesCount) {
+		return ErrRecurrenceEndsBeforeStart
+	}
+	return nil
+}
+
+func (standingOrderService *StandingOrderService) save(standingOrder *domain.StandingOrder, expectedNextRunAt time.Time) error {
+	updated, err := standingOrderService.standingOrderRepository.UpdateStandingOrder(standingOrder, expectedNextRunAt)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrStandingOrderConcurrentEdit
+	}
+	return nil
+}
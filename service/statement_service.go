@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* IStatementWriter renders a statement in some format while it is being read */
+type IStatementWriter interface {
+	WriteHeader(statement *domain.Statement) error
+	WriteLine(line domain.StatementLine) error
+	WriteSummary(summary domain.StatementSummary) error
+}
+
+type IStatementService interface {
+	Export(statement *domain.Statement, writer IStatementWriter) error
+}
+
+type StatementService struct {
+	transactionRepository persistence.ITransactionRepository
+	ledgerRepository      persistence.ILedgerRepository
+}
+
+func NewStatementService(transactionRepository persistence.ITransactionRepository, ledgerRepository persistence.ILedgerRepository) IStatementService {
+	return &StatementService{
+		transactionRepository: transactionRepository,
+		ledgerRepository:      ledgerRepository,
+	}
+}
+
+/*
+Export fills in the opening balance of statement and streams its lines to
+writer. Nothing is written when the statement is invalid, so the caller can
+still answer with an error then.
+*/
+func (statementService *StatementService) Export(statement *domain.Statement, writer IStatementWriter) error {
+	if err := statement.Validate(); err != nil {
+		return err
+	}
+
+	openingBalance, err := statementService.openingBalance(statement)
+	if err != nil {
+		return err
+	}
+	statement.OpeningBalance = openingBalance
+
+	if err := writer.WriteHeader(statement); err != nil {
+		return err
+	}
+
+	balance := openingBalance
+	var totals []domain.StatementTotal
+	err = statementService.transactionRepository.StreamUserTransactions(statement.UserID, statement.Currency, statement.From, statement.To, func(transaction domain.Transaction) error {
+		amount := transaction.BalanceChange(statement.UserID)
+
+		var err error
+		if balance, err = balance.Add(amount); err != nil {
+			return err
+		}
+		if totals, err = addToTotals(totals, transaction.Type, amount); err != nil {
+			return err
+		}
+
+		return writer.WriteLine(domain.StatementLine{Transaction: transaction, Amount: amount, Balance: balance})
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteSummary(domain.StatementSummary{Totals: totals, ClosingBalance: balance})
+}
+
+/*
+openingBalance is what the user's ledger account held at the start of the
+period. The ledger follows every balance change, including the ones made
+before they were recorded as transactions, like the balance snapshots do.
+*/
+func (statementService *StatementService) openingBalance(statement *domain.Statement) (domain.Money, error) {
+	account, err := statementService.ledgerRepository.GetAccountByCode(domain.UserAccountCode(statement.UserID, statement.Currency))
+	if errors.Is(err, persistence.ErrLedgerAccountNotFound) {
+		return domain.Zero(statement.Currency), nil
+	}
+	if err != nil {
+		return domain.Money{}, err
+	}
+
+	return statementService.ledgerRepository.GetAccountBalanceAt(account.ID, statement.From)
+}
+
+func addToTotals(totals []domain.StatementTotal, transactionType domain.TransactionType, amount domain.Money) ([]domain.StatementTotal, error) {
+	for i := range totals {
+		if totals[i].Type == transactionType {
+			total, err := totals[i].Amount.Add(amount)
+			if err != nil {
+				return nil, err
+			}
+			totals[i].Amount = total
+			totals[i].Count++
+			return totals, nil
+		}
+	}
+	return append(totals, domain.StatementTotal{Type: transactionType, Count: 1, Amount: amount}), nil
+}
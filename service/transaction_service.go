@@ -2,86 +2,98 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"sort"
 	"time"
 
+	"github.com/denizdoganinsider/kpi_project/common/approval"
 	"github.com/denizdoganinsider/kpi_project/domain"
 	"github.com/denizdoganinsider/kpi_project/persistence"
 )
 
+var (
+	ErrInsufficientBalance      = errors.New("insufficient balance")
+	ErrConversionRequired       = errors.New("transfers between different currencies require an explicit conversion")
+	ErrTransactionNotFound      = errors.New("transaction not found")
+	ErrTransactionNotReversible = errors.New("only completed credits, debits, transfers and captures can be reversed or refunded")
+	ErrRefundExceedsOriginal    = errors.New("refunds cannot exceed the original amount")
+	ErrSplitPaymentNotFound     = errors.New("split payment not found")
+)
+
 type ITransactionService interface {
-	Credit(userID int64, amount float64) (*domain.Transaction, error)
-	Debit(userID int64, amount float64) (*domain.Transaction, error)
-	Transfer(fromUserID int64, toUserID int64, amount float64) (*domain.Transaction, error)
-	GetTransactionHistory(userID int64) ([]domain.Transaction, error)
+	Credit(userID int64, amount domain.Money) (*domain.Transaction, error)
+	Debit(userID int64, amount domain.Money) (*domain.Transaction, error)
+	Transfer(fromUserID int64, toUserID int64, amount domain.Money) (*domain.Transaction, error)
+	Split(instruction domain.SplitInstruction) (*domain.SplitPayment, error)
+	GetSplitPayment(transactionID int64) (*domain.SplitPayment, error)
+	Reverse(transactionID int64) (*domain.Transaction, error)
+	Refund(transactionID int64, amount domain.Money) (*domain.Transaction, error)
+	GetTransactionHistory(userID int64, filter domain.TransactionFilter) (*domain.TransactionPage, error)
+	GetTransactionEvents(transactionID int64) ([]domain.TransactionStatusChange, error)
 	GetTransactionByID(transactionID int64) (*domain.Transaction, error)
+	Process(repositories persistence.Repositories, transaction *domain.Transaction, reason string) error
+	Settle(repositories persistence.Repositories, transaction *domain.Transaction, reason string) error
 }
 
 type TransactionService struct {
 	transactionRepository persistence.ITransactionRepository
 	balanceRepo           persistence.IBalanceRepository
+	unitOfWork            persistence.IUnitOfWork
+	limitService          ILimitService
+	feeService            IFeeService
+	riskService           IRiskService
+	approvalConfig        approval.Config
 }
 
-func NewTransactionService(transactionRepository persistence.ITransactionRepository, balanceRepo persistence.IBalanceRepository) ITransactionService {
+func NewTransactionService(transactionRepository persistence.ITransactionRepository, balanceRepo persistence.IBalanceRepository, unitOfWork persistence.IUnitOfWork, limitService ILimitService, feeService IFeeService, riskService IRiskService, approvalConfig approval.Config) ITransactionService {
 	return &TransactionService{
 		transactionRepository: transactionRepository,
 		balanceRepo:           balanceRepo,
+		unitOfWork:            unitOfWork,
+		limitService:          limitService,
+		feeService:            feeService,
+		riskService:           riskService,
+		approvalConfig:        approvalConfig,
 	}
 }
 
-func (s *TransactionService) Credit(userID int64, amount float64) (*domain.Transaction, error) {
-	if amount <= 0 {
+func (s *TransactionService) Credit(userID int64, amount domain.Money) (*domain.Transaction, error) {
+	if !amount.IsPositive() {
 		return nil, errors.New("amount must be greater than zero")
 	}
 
 	tx := &domain.Transaction{
 		FromUser:  userID,
 		Amount:    amount,
+		Currency:  amount.Currency(),
 		Type:      domain.CreditTransaction,
 		Status:    domain.Pending,
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.transactionRepository.CreateTransaction(tx); err != nil {
-		return nil, err
-	}
-
-	if err := s.balanceRepo.UpdateBalance(userID, amount); err != nil {
-		s.transactionRepository.UpdateTransactionStatus(tx.ID, domain.Failed)
-		return nil, err
-	}
-
-	s.transactionRepository.UpdateTransactionStatus(tx.ID, domain.Completed)
-	return tx, nil
+	return s.execute(tx, map[int64]domain.Money{userID: amount})
 }
 
-func (s *TransactionService) Debit(userID int64, amount float64) (*domain.Transaction, error) {
-	if amount <= 0 {
+func (s *TransactionService) Debit(userID int64, amount domain.Money) (*domain.Transaction, error) {
+	if !amount.IsPositive() {
 		return nil, errors.New("amount must be greater than zero")
 	}
 
 	tx := &domain.Transaction{
 		FromUser:  userID,
-		Amount:    -amount,
+		Amount:    amount.Neg(),
+		Currency:  amount.Currency(),
 		Type:      domain.DebitTransaction,
 		Status:    domain.Pending,
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.transactionRepository.CreateTransaction(tx); err != nil {
-		return nil, err
-	}
-
-	if err := s.balanceRepo.UpdateBalance(userID, -amount); err != nil {
-		s.transactionRepository.UpdateTransactionStatus(tx.ID, domain.Failed)
-		return nil, err
-	}
-
-	s.transactionRepository.UpdateTransactionStatus(tx.ID, domain.Completed)
-	return tx, nil
+	return s.execute(tx, map[int64]domain.Money{userID: amount.Neg()})
 }
 
-func (s *TransactionService) Transfer(fromUserID int64, toUserID int64, amount float64) (*domain.Transaction, error) {
-	if amount <= 0 {
+func (s *TransactionService) Transfer(fromUserID int64, toUserID int64, amount domain.Money) (*domain.Transaction, error) {
+	if !amount.IsPositive() {
 		return nil, errors.New("amount must be greater than zero")
 	}
 	if fromUserID == toUserID {
@@ -92,33 +104,588 @@ func (s *TransactionService) Transfer(fromUserID int64, toUserID int64, amount f
 		FromUser:  fromUserID,
 		ToUser:    &toUserID,
 		Amount:    amount,
+		Currency:  amount.Currency(),
 		Type:      domain.TransferTransaction,
 		Status:    domain.Pending,
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.transactionRepository.CreateTransaction(tx); err != nil {
+	if s.requiresApproval(amount) {
+		return s.requestApproval(tx)
+	}
+
+	return s.execute(tx, map[int64]domain.Money{
+		fromUserID: amount.Neg(),
+		toUserID:   amount,
+	})
+}
+
+func (s *TransactionService) requiresApproval(amount domain.Money) bool {
+	threshold, ok := s.approvalConfig.Thresholds[amount.Currency()]
+	if !ok {
+		return false
+	}
+	cmp, err := amount.Cmp(threshold)
+	return err == nil && cmp > 0
+}
+
+/*
+requestApproval screens tx and stores it as awaiting approval together with
+its approval request, no balance is touched until an approver settles it.
+*/
+func (s *TransactionService) requestApproval(tx *domain.Transaction) (*domain.Transaction, error) {
+	tx.Status = domain.AwaitingApproval
+
+	err := s.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		if err := repositories.Transactions.CreateTransaction(tx); err != nil {
+			return err
+		}
+
+		if err := s.riskService.Screen(repositories, tx); err != nil {
+			return err
+		}
+
+		return repositories.Approvals.CreateApproval(&domain.TransferApproval{
+			TransactionID: tx.ID,
+			MakerID:       tx.FromUser,
+			Status:        domain.ApprovalPending,
+			ExpiresAt:     tx.CreatedAt.Add(s.approvalConfig.TTL),
+			CreatedAt:     tx.CreatedAt,
+		})
+	})
+	if err != nil {
+		s.recordFailure(tx, err)
 		return nil, err
 	}
 
-	if err := s.balanceRepo.UpdateBalance(fromUserID, -amount); err != nil {
-		s.transactionRepository.UpdateTransactionStatus(tx.ID, domain.Failed)
+	return tx, nil
+}
+
+/*
+Split debits the payer once for the whole split and credits every recipient
+with a leg linked to the payment, all in one unit of work and one journal
+entry, so either every leg is paid or none is.
+*/
+func (transactionService *TransactionService) Split(instruction domain.SplitInstruction) (*domain.SplitPayment, error) {
+	total, amounts, err := instruction.Allocate()
+	if err != nil {
 		return nil, err
 	}
 
-	if err := s.balanceRepo.UpdateBalance(toUserID, amount); err != nil {
-		s.transactionRepository.UpdateTransactionStatus(tx.ID, domain.Failed)
+	now := time.Now()
+	splitPayment := &domain.SplitPayment{
+		Payment: domain.Transaction{
+			FromUser:  instruction.Payer,
+			Amount:    total.Neg(),
+			Currency:  total.Currency(),
+			Type:      domain.SplitPaymentTransaction,
+			Status:    domain.Pending,
+			CreatedAt: now,
+		},
+	}
+	payment := &splitPayment.Payment
+
+	/* a recipient's new wallet opened by a concurrent payment makes the split run again */
+	err = retryOnBalanceConflict(func() error {
+		return transactionService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			payment.Status = domain.Pending
+			if err := repositories.Transactions.CreateTransaction(payment); err != nil {
+				return err
+			}
+
+			if err := transactionService.riskService.Screen(repositories, payment); err != nil {
+				return err
+			}
+
+			deltas := map[int64]domain.Money{payment.FromUser: payment.Amount}
+			legs := make([]domain.Transaction, 0, len(amounts))
+			for i, share := range instruction.Shares {
+				leg := domain.Transaction{
+					ParentID:  &payment.ID,
+					FromUser:  share.ToUser,
+					Amount:    amounts[i],
+					Currency:  payment.Currency,
+					Type:      domain.SplitLegTransaction,
+					Status:    domain.Pending,
+					CreatedAt: now,
+				}
+				if err := repositories.Transactions.CreateTransaction(&leg); err != nil {
+					return err
+				}
+				deltas[share.ToUser] = leg.Amount
+				legs = append(legs, leg)
+			}
+
+			if err := transactionService.settle(repositories, payment, deltas, "split payment settled"); err != nil {
+				return err
+			}
+
+			for i := range legs {
+				if err := repositories.Transactions.UpdateTransactionStatus(legs[i].ID, domain.Pending, domain.Completed, fmt.Sprintf("leg of split payment %d", payment.ID)); err != nil {
+					return err
+				}
+				legs[i].Status = domain.Completed
+			}
+			splitPayment.Legs = legs
+			return nil
+		})
+	})
+
+	if err != nil {
+		transactionService.recordFailure(payment, err)
 		return nil, err
 	}
 
-	s.transactionRepository.UpdateTransactionStatus(tx.ID, domain.Completed)
-	return tx, nil
+	return splitPayment, nil
 }
 
-func (transactionService *TransactionService) GetTransactionHistory(userID int64) ([]domain.Transaction, error) {
-	return transactionService.transactionRepository.GetUserTransactions(userID)
+/* GetSplitPayment returns a split payment with its legs in the order they were paid */
+func (transactionService *TransactionService) GetSplitPayment(transactionID int64) (*domain.SplitPayment, error) {
+	payment, err := transactionService.transactionRepository.GetTransactionByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil || payment.Type != domain.SplitPaymentTransaction {
+		return nil, ErrSplitPaymentNotFound
+	}
+
+	children, err := transactionService.transactionRepository.GetChildTransactions(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	splitPayment := &domain.SplitPayment{Payment: *payment, Legs: []domain.Transaction{}}
+	for _, child := range children {
+		if child.Type == domain.SplitLegTransaction {
+			splitPayment.Legs = append(splitPayment.Legs, child)
+		}
+	}
+	return splitPayment, nil
+}
+
+/* Reverse gives back whatever of the transaction hasn't been refunded yet */
+func (transactionService *TransactionService) Reverse(transactionID int64) (*domain.Transaction, error) {
+	return transactionService.reverse(transactionID, domain.ReversalTransaction, nil)
+}
+
+func (transactionService *TransactionService) Refund(transactionID int64, amount domain.Money) (*domain.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	return transactionService.reverse(transactionID, domain.RefundTransaction, &amount)
+}
+
+/*
+reverse locks the original transaction so concurrent refunds are serialized
+and gives back amount of it, or whatever is left when amount is nil.
+*/
+func (transactionService *TransactionService) reverse(transactionID int64, transactionType domain.TransactionType, amount *domain.Money) (*domain.Transaction, error) {
+	var reversal *domain.Transaction
+
+	err := transactionService.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		original, err := repositories.Transactions.GetTransactionByIDForUpdate(transactionID)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			return ErrTransactionNotFound
+		}
+		if original.Status != domain.Completed || !original.IsReversible() {
+			return ErrTransactionNotReversible
+		}
+
+		reversal, err = reverseTransaction(repositories, original, transactionType, amount, false)
+		return err
+	})
+
+	if err != nil {
+		if reversal != nil {
+			transactionService.recordFailure(reversal, err)
+		}
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+/*
+reverseTransaction checks the amount against what is left to refund of the
+locked original and moves it back. Once nothing is left the original is
+marked as reversed. With overdraw the money is taken back even when it was
+spent already, see overdrawBalances. The reversal is returned even on error
+when it was already created, so the caller can record it as failed.
+*/
+func reverseTransaction(repositories persistence.Repositories, original *domain.Transaction, transactionType domain.TransactionType, amount *domain.Money, overdraw bool) (*domain.Transaction, error) {
+	refundable, err := refundableAmount(repositories.Transactions, original)
+	if err != nil {
+		return nil, err
+	}
+	if !refundable.IsPositive() {
+		return nil, ErrTransactionNotReversible
+	}
+
+	reversedAmount := refundable
+	if amount != nil {
+		reversedAmount = *amount
+	}
+	cmp, err := reversedAmount.Cmp(refundable)
+	if err != nil {
+		return nil, err
+	}
+	if cmp > 0 {
+		return nil, ErrRefundExceedsOriginal
+	}
+
+	reversal := original.NewReversal(transactionType, reversedAmount, time.Now())
+	if err := repositories.Transactions.CreateTransaction(reversal); err != nil {
+		return nil, err
+	}
+
+	move := moveBalances
+	if overdraw {
+		move = overdrawBalances
+	}
+	if err := move(repositories, &reversal.ID, string(reversal.Type), reversal.BalanceDeltas(), domain.ExternalAccountCode(reversal.Currency)); err != nil {
+		return reversal, err
+	}
+
+	if err := repositories.Transactions.UpdateTransactionStatus(reversal.ID, domain.Pending, domain.Completed, fmt.Sprintf("%s of transaction %d", reversal.Type, original.ID)); err != nil {
+		return reversal, err
+	}
+	reversal.Status = domain.Completed
+
+	if cmp == 0 {
+		if err := repositories.Transactions.UpdateTransactionStatus(original.ID, domain.Completed, domain.Reversed, fmt.Sprintf("%s by transaction %d", reversal.Type, reversal.ID)); err != nil {
+			return reversal, err
+		}
+		original.Status = domain.Reversed
+	}
+	return reversal, nil
+}
+
+/* refundableAmount is the original amount minus every completed reversal or refund of it */
+func refundableAmount(transactionRepository persistence.ITransactionRepository, original *domain.Transaction) (domain.Money, error) {
+	children, err := transactionRepository.GetChildTransactions(original.ID)
+	if err != nil {
+		return domain.Money{}, err
+	}
+
+	refundable := original.Amount.Abs()
+	for _, child := range children {
+		if child.Status != domain.Completed || (child.Type != domain.ReversalTransaction && child.Type != domain.RefundTransaction) {
+			continue
+		}
+		if refundable, err = refundable.Sub(child.Amount.Abs()); err != nil {
+			return domain.Money{}, err
+		}
+	}
+	return refundable, nil
+}
+
+/* GetTransactionEvents returns the status history of a transaction, oldest first */
+func (transactionService *TransactionService) GetTransactionEvents(transactionID int64) ([]domain.TransactionStatusChange, error) {
+	transaction, err := transactionService.transactionRepository.GetTransactionByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+	return transactionService.transactionRepository.GetTransactionStatusHistory(transactionID)
+}
+
+/* GetTransactionHistory reads one row past the page, so the cursor is only given when there is more */
+func (transactionService *TransactionService) GetTransactionHistory(userID int64, filter domain.TransactionFilter) (*domain.TransactionPage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	pageSize := filter.Limit
+	filter.Limit++
+	transactions, err := transactionService.transactionRepository.GetUserTransactions(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.TransactionPage{Transactions: transactions}
+	if len(transactions) > pageSize {
+		page.Transactions = transactions[:pageSize]
+		last := page.Transactions[pageSize-1]
+		page.NextCursor = &domain.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return page, nil
 }
 
 func (transactionService *TransactionService) GetTransactionByID(transactionID int64) (*domain.Transaction, error) {
-	return transactionService.transactionRepository.GetTransactionByID(transactionID)
+	transaction, err := transactionService.transactionRepository.GetTransactionByID(transactionID)
+	if err != nil || transaction == nil {
+		return transaction, err
+	}
+
+	children, err := transactionService.transactionRepository.GetChildTransactions(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if child.Type == domain.FeeTransaction && child.Status == domain.Completed {
+			fee := child.Amount.Abs()
+			transaction.Fee = &fee
+		}
+	}
+
+	return transaction, nil
+}
+
+/*
+execute writes and screens the transaction, applies the per-user balance
+deltas and posts the matching journal entry within one unit of work, so all
+of them are committed or rolled back together. When the unit of work fails
+the transaction is recorded again as failed for auditing.
+*/
+func (s *TransactionService) execute(tx *domain.Transaction, deltas map[int64]domain.Money) (*domain.Transaction, error) {
+	/* two first payments into the same new wallet race to open it, the loser runs again */
+	err := retryOnBalanceConflict(func() error {
+		return s.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+			if err := repositories.Transactions.CreateTransaction(tx); err != nil {
+				return err
+			}
+
+			/* a blocked transaction fails before any money moves */
+			if err := s.riskService.Screen(repositories, tx); err != nil {
+				return err
+			}
+
+			return s.settle(repositories, tx, deltas, "balances moved")
+		})
+	})
+
+	if err != nil {
+		s.recordFailure(tx, err)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+/*
+Process screens a transaction the caller stored as pending and settles it
+within the caller's unit of work, like a capture of an authorization hold.
+*/
+func (s *TransactionService) Process(repositories persistence.Repositories, transaction *domain.Transaction, reason string) error {
+	if err := s.riskService.Screen(repositories, transaction); err != nil {
+		return err
+	}
+	return s.settle(repositories, transaction, transaction.BalanceDeltas(), reason)
+}
+
+/*
+Settle moves the balances of a transaction that was stored without moving
+them, like an approved transfer, as part of the caller's unit of work.
+*/
+func (s *TransactionService) Settle(repositories persistence.Repositories, transaction *domain.Transaction, reason string) error {
+	return s.settle(repositories, transaction, transaction.BalanceDeltas(), reason)
+}
+
+/*
+settle locks the balances and checks limits before any of them changes, then
+applies the deltas, charges the fee and runs savings sweeps and completes tx.
+*/
+func (s *TransactionService) settle(repositories persistence.Repositories, tx *domain.Transaction, deltas map[int64]domain.Money, reason string) error {
+	balances, err := lockBalances(repositories.Balances, deltas)
+	if err != nil {
+		return err
+	}
+
+	if err := s.limitService.Check(repositories, tx); err != nil {
+		return err
+	}
+
+	if err := applyBalanceChanges(repositories.Balances, balances, deltas, false); err != nil {
+		return err
+	}
+	if err := postBalanceChanges(repositories, &tx.ID, string(tx.Type), deltas, domain.ExternalAccountCode(tx.Currency)); err != nil {
+		return err
+	}
+
+	if err := s.chargeFee(repositories, tx); err != nil {
+		return err
+	}
+
+	if err := sweepIntoSavings(repositories, tx, deltas); err != nil {
+		return err
+	}
+
+	if err := repositories.Transactions.UpdateTransactionStatus(tx.ID, tx.Status, domain.Completed, reason); err != nil {
+		return err
+	}
+
+	tx.Status = domain.Completed
+	return nil
+}
+
+/*
+chargeFee posts the fee of tx as a separate fee transaction linked to it,
+paid by the sender into the fee house account.
+*/
+func (s *TransactionService) chargeFee(repositories persistence.Repositories, tx *domain.Transaction) error {
+	feeType := tx.LimitType()
+	if feeType != domain.DebitTransaction && feeType != domain.TransferTransaction {
+		return nil
+	}
+
+	fee, err := s.feeService.Calculate(tx.FromUser, feeType, tx.Amount.Abs())
+	if err != nil {
+		return err
+	}
+	if fee.IsZero() {
+		return nil
+	}
+
+	feeTransaction := &domain.Transaction{
+		ParentID:  &tx.ID,
+		FromUser:  tx.FromUser,
+		Amount:    fee.Neg(),
+		Currency:  tx.Currency,
+		Type:      domain.FeeTransaction,
+		Status:    domain.Pending,
+		CreatedAt: tx.CreatedAt,
+	}
+	if err := repositories.Transactions.CreateTransaction(feeTransaction); err != nil {
+		return err
+	}
+
+	if err := moveBalances(repositories, &feeTransaction.ID, string(feeTransaction.Type), feeTransaction.BalanceDeltas(), domain.FeeHouseAccountCode(tx.Currency)); err != nil {
+		return err
+	}
+
+	if err := repositories.Transactions.UpdateTransactionStatus(feeTransaction.ID, domain.Pending, domain.Completed, fmt.Sprintf("fee of transaction %d", tx.ID)); err != nil {
+		return err
+	}
+
+	tx.Fee = &fee
+	return nil
+}
+
+/* recordFailure keeps a failed copy of tx, its status history tells why it failed */
+func (s *TransactionService) recordFailure(tx *domain.Transaction, cause error) {
+	failed := *tx
+	failed.ID = 0
+	failed.Status = domain.Pending
+
+	err := s.unitOfWork.Execute(func(repositories persistence.Repositories) error {
+		if err := repositories.Transactions.CreateTransaction(&failed); err != nil {
+			return err
+		}
+		return repositories.Transactions.UpdateTransactionStatus(failed.ID, domain.Pending, domain.Failed, cause.Error())
+	})
+	if err != nil {
+		log.Printf("Failed transaction for user %d couldn't be recorded: %v", tx.FromUser, err)
+	}
+}
+
+/* moveBalances keeps balances and the ledger in sync for the same deltas */
+func moveBalances(repositories persistence.Repositories, transactionID *int64, description string, deltas map[int64]domain.Money, counterpartyCode string) error {
+	balances, err := lockBalances(repositories.Balances, deltas)
+	if err != nil {
+		return err
+	}
+	if err := applyBalanceChanges(repositories.Balances, balances, deltas, false); err != nil {
+		return err
+	}
+	return postBalanceChanges(repositories, transactionID, description, deltas, counterpartyCode)
+}
+
+/*
+overdrawBalances is moveBalances for changes that can't be refused, like taking
+back money a dispute decided on that the user has spent already. A balance
+may go below what it can spend or below zero, the shortfall stays on it as
+owed by the user until it is paid in.
+*/
+func overdrawBalances(repositories persistence.Repositories, transactionID *int64, description string, deltas map[int64]domain.Money, counterpartyCode string) error {
+	balances, err := lockBalances(repositories.Balances, deltas)
+	if err != nil {
+		return err
+	}
+	if err := applyBalanceChanges(repositories.Balances, balances, deltas, true); err != nil {
+		return err
+	}
+	return postBalanceChanges(repositories, transactionID, description, deltas, counterpartyCode)
+}
+
+/*
+lockBalances locks every affected balance in ascending user id order, so two
+opposite transfers can't deadlock. A user without a balance in the delta's
+currency maps to nil.
+*/
+func lockBalances(balanceRepository persistence.IBalanceRepository, deltas map[int64]domain.Money) (map[int64]*domain.Balance, error) {
+	balances := make(map[int64]*domain.Balance, len(deltas))
+	for _, userID := range sortedUserIDs(deltas) {
+		balance, err := balanceRepository.GetBalanceByUserIDForUpdate(userID, deltas[userID].Currency())
+		if err != nil && !errors.Is(err, persistence.ErrBalanceNotFound) {
+			return nil, err
+		}
+		balances[userID] = balance
+	}
+	return balances, nil
+}
+
+/* applyBalanceChanges applies the deltas to the balances lockBalances locked, refusing to overdraw them unless told to */
+func applyBalanceChanges(balanceRepository persistence.IBalanceRepository, balances map[int64]*domain.Balance, deltas map[int64]domain.Money, overdraw bool) error {
+	for _, userID := range sortedUserIDs(deltas) {
+		delta := deltas[userID]
+		balance := balances[userID]
+
+		/* a missing balance can only be opened by an incoming amount */
+		if balance == nil {
+			if delta.IsNegative() && !overdraw {
+				return ErrInsufficientBalance
+			}
+			if err := balanceRepository.CreateBalance(userID, delta); err != nil {
+				return err
+			}
+			continue
+		}
+
+		newAmount, err := balance.Amount.Add(delta)
+		if err != nil {
+			return err
+		}
+		if !overdraw {
+			if err := checkSpendable(balance, newAmount); err != nil {
+				return err
+			}
+		}
+
+		if err := balanceRepository.UpdateBalance(balance, newAmount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedUserIDs(deltas map[int64]domain.Money) []int64 {
+	userIDs := make([]int64, 0, len(deltas))
+	for userID := range deltas {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	return userIDs
+}
+
+/*
+checkSpendable rejects a new amount that would eat into held or pocketed
+funds or go past the credit limit of the user's overdraft. A balance already
+beyond that, e.g. after the limit was lowered, can still go up.
+*/
+func checkSpendable(balance *domain.Balance, newAmount domain.Money) error {
+	floor := domain.NewMoney(balance.HeldAmount.MinorUnits()+balance.PocketedAmount.MinorUnits()-balance.CreditLimit.MinorUnits(), balance.Currency)
+	cmp, err := newAmount.Cmp(floor)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 && newAmount.MinorUnits() < balance.Amount.MinorUnits() {
+		return ErrInsufficientBalance
+	}
+	return nil
 }
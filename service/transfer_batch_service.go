@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/transferbatch"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* pendingTransferBatchItemsBatchSize bounds how many batch items one tick transfers */
+const pendingTransferBatchItemsBatchSize = 100
+
+var (
+	ErrTransferBatchNotCancellable  = errors.New("only batches in progress can be cancelled")
+	ErrTransferBatchItemInterrupted = errors.New("transfer was interrupted, check the sender's transactions before sending it again")
+)
+
+type ITransferBatchService interface {
+	Create(fromUserID int64, currency domain.Currency, items []domain.TransferBatchItem) (*domain.TransferBatch, error)
+	GetByID(id int64) (*domain.TransferBatch, error)
+	GetItems(id int64) ([]domain.TransferBatchItem, error)
+	Cancel(id int64) (*domain.TransferBatch, error)
+	ProcessPending(ctx context.Context, now time.Time) error
+}
+
+type TransferBatchService struct {
+	transferBatchRepository persistence.ITransferBatchRepository
+	userRepository          persistence.IUserRepository
+	transactionService      ITransactionService
+	config                  transferbatch.Config
+}
+
+func NewTransferBatchService(transferBatchRepository persistence.ITransferBatchRepository, userRepository persistence.IUserRepository, transactionService ITransactionService, config transferbatch.Config) ITransferBatchService {
+	return &TransferBatchService{
+		transferBatchRepository: transferBatchRepository,
+		userRepository:          userRepository,
+		transactionService:      transactionService,
+		config:                  config,
+	}
+}
+
+/*
+Create validates every item before anything is stored, a batch with a single
+bad row is rejected as a whole with all its bad rows listed.
+*/
+func (transferBatchService *TransferBatchService) Create(fromUserID int64, currency domain.Currency, items []domain.TransferBatchItem) (*domain.TransferBatch, error) {
+	now := time.Now()
+	transferBatch := &domain.TransferBatch{
+		FromUser:  fromUserID,
+		Currency:  currency,
+		Status:    domain.BatchProcessing,
+		Items:     items,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := transferBatchService.validate(transferBatch); err != nil {
+		return nil, err
+	}
+
+	for i := range transferBatch.Items {
+		transferBatch.Items[i].Status = domain.BatchItemPending
+		transferBatch.Items[i].UpdatedAt = now
+	}
+
+	if err := transferBatchService.transferBatchRepository.CreateTransferBatch(transferBatch); err != nil {
+		return nil, err
+	}
+
+	transferBatch.Progress = domain.TransferBatchProgress{Total: len(items), Pending: len(items)}
+	return transferBatch, nil
+}
+
+/* validate adds the rows paying unknown users to the ones the batch itself rejects */
+func (transferBatchService *TransferBatchService) validate(transferBatch *domain.TransferBatch) error {
+	validationError := &domain.TransferBatchValidationError{}
+	if err := transferBatch.Validate(); err != nil && !errors.As(err, &validationError) {
+		return err
+	}
+
+	invalidRows := map[int]bool{}
+	for _, row := range validationError.Rows {
+		invalidRows[row.Row] = true
+	}
+
+	checked := map[int64]bool{}
+	for _, item := range transferBatch.Items {
+		if invalidRows[item.Row] {
+			continue
+		}
+
+		exists, seen := checked[item.ToUser]
+		if !seen {
+			user, err := transferBatchService.userRepository.GetById(item.ToUser)
+			exists = err == nil && user.Id == item.ToUser
+			checked[item.ToUser] = exists
+		}
+		if !exists {
+			validationError.Add(item.Row, "to_user doesn't exist")
+		}
+	}
+
+	if len(validationError.Rows) > 0 {
+		sort.SliceStable(validationError.Rows, func(i, j int) bool {
+			return validationError.Rows[i].Row < validationError.Rows[j].Row
+		})
+		return validationError
+	}
+	return nil
+}
+
+func (transferBatchService *TransferBatchService) GetByID(id int64) (*domain.TransferBatch, error) {
+	return transferBatchService.transferBatchRepository.GetTransferBatchByID(id)
+}
+
+func (transferBatchService *TransferBatchService) GetItems(id int64) ([]domain.TransferBatchItem, error) {
+	if _, err := transferBatchService.transferBatchRepository.GetTransferBatchByID(id); err != nil {
+		return nil, err
+	}
+	return transferBatchService.transferBatchRepository.GetTransferBatchItems(id)
+}
+
+/* Cancel stops the items that haven't started yet, the ones already transferred stay */
+func (transferBatchService *TransferBatchService) Cancel(id int64) (*domain.TransferBatch, error) {
+	cancelled, err := transferBatchService.transferBatchRepository.UpdateTransferBatchStatus(id, domain.BatchProcessing, domain.BatchCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	if cancelled {
+		if err := transferBatchService.transferBatchRepository.CancelPendingItems(id); err != nil {
+			return nil, err
+		}
+	}
+
+	transferBatch, err := transferBatchService.transferBatchRepository.GetTransferBatchByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrTransferBatchNotCancellable
+	}
+	return transferBatch, nil
+}
+
+/*
+ProcessPending is the scheduler job. Items are claimed with a compare-and-set
+before their transfer runs, so an item cancelled in the meantime is skipped
+and none is transferred twice. Items left claimed by a run that died are
+failed rather than retried, their transfer may already have gone through.
+Batches with nothing left to run are completed.
+*/
+func (transferBatchService *TransferBatchService) ProcessPending(ctx context.Context, now time.Time) error {
+	batchIDs, err := transferBatchService.failStaleItems(now)
+	if err != nil {
+		return err
+	}
+
+	items, err := transferBatchService.transferBatchRepository.GetPendingTransferBatchItems(pendingTransferBatchItemsBatchSize)
+	if err != nil {
+		return err
+	}
+
+	batches := map[int64]*domain.TransferBatch{}
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+
+		transferBatch, loaded := batches[item.BatchID]
+		if !loaded {
+			if transferBatch, err = transferBatchService.transferBatchRepository.GetTransferBatchByID(item.BatchID); err != nil {
+				return err
+			}
+			batches[item.BatchID] = transferBatch
+			if !slices.Contains(batchIDs, item.BatchID) {
+				batchIDs = append(batchIDs, item.BatchID)
+			}
+		}
+
+		claimed, err := transferBatchService.transferBatchRepository.UpdateItemStatus(item.ID, domain.BatchItemPending, domain.BatchItemProcessing)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		transferBatchService.transfer(transferBatch.FromUser, item)
+	}
+
+	for _, batchID := range batchIDs {
+		if err := transferBatchService.complete(batchID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/* failStaleItems fails the items claimed for longer than the processing timeout and returns their batches */
+func (transferBatchService *TransferBatchService) failStaleItems(now time.Time) ([]int64, error) {
+	updatedBefore := now.Add(-transferBatchService.config.ProcessingTimeout)
+	staleItems, err := transferBatchService.transferBatchRepository.GetStaleProcessingItems(updatedBefore, pendingTransferBatchItemsBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var batchIDs []int64
+	for _, staleItem := range staleItems {
+		failed, err := transferBatchService.transferBatchRepository.FailStaleItem(staleItem.ID, updatedBefore, ErrTransferBatchItemInterrupted.Error())
+		if err != nil {
+			return nil, err
+		}
+		if !failed {
+			continue
+		}
+
+		log.Printf("Transfer batch item %d was interrupted while processing and is failed", staleItem.ID)
+		if !slices.Contains(batchIDs, staleItem.BatchID) {
+			batchIDs = append(batchIDs, staleItem.BatchID)
+		}
+	}
+
+	return batchIDs, nil
+}
+
+func (transferBatchService *TransferBatchService) transfer(fromUserID int64, item domain.TransferBatchItem) {
+	transaction, err := transferBatchService.transactionService.Transfer(fromUserID, item.ToUser, item.Amount)
+	if err != nil {
+		log.Printf("Transfer batch item %d failed: %v", item.ID, err)
+		if err := transferBatchService.transferBatchRepository.MarkItemFailed(item.ID, err.Error()); err != nil {
+			log.Printf("Transfer batch item %d couldn't be marked as failed: %v", item.ID, err)
+		}
+		return
+	}
+
+	if transaction.AwaitsApproval() {
+		if err := transferBatchService.transferBatchRepository.MarkItemAwaitingApproval(item.ID, transaction.ID); err != nil {
+			log.Printf("Transfer batch item %d is awaiting approval of transaction %d but couldn't be marked: %v", item.ID, transaction.ID, err)
+		}
+		return
+	}
+
+	if err := transferBatchService.transferBatchRepository.MarkItemCompleted(item.ID, transaction.ID); err != nil {
+		log.Printf("Transfer batch item %d completed with transaction %d but couldn't be marked: %v", item.ID, transaction.ID, err)
+	}
+}
+
+func (transferBatchService *TransferBatchService) complete(batchID int64) error {
+	transferBatch, err := transferBatchService.transferBatchRepository.GetTransferBatchByID(batchID)
+	if err != nil {
+		return err
+	}
+	if transferBatch.Status != domain.BatchProcessing || !transferBatch.Progress.Done() {
+		return nil
+	}
+
+	_, err = transferBatchService.transferBatchRepository.UpdateTransferBatchStatus(batchID, domain.BatchProcessing, domain.BatchCompleted)
+	return err
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FeeSchedule(t *testing.T) {
+	try := func(value string) domain.Money {
+		amount, _ := domain.ParseMoney(value, domain.TRY)
+		return amount
+	}
+	ptr := func(value string) *domain.Money {
+		amount := try(value)
+		return &amount
+	}
+
+	t.Run("WhenPercentageIsBelowTheMinimum_ShouldChargeTheMinimum", func(t *testing.T) {
+		feeSchedule := domain.FeeSchedule{TransactionType: domain.TransferTransaction, Currency: domain.TRY, Type: domain.PercentageFee, PercentageBps: 100, MinFee: ptr("2"), MaxFee: ptr("25")}
+
+		fee, err := feeSchedule.Calculate(try("50"))
+		assert.Nil(t, err)
+		assert.Equal(t, try("2"), fee)
+
+		fee, _ = feeSchedule.Calculate(try("999.99"))
+		assert.Equal(t, try("9.99"), fee)
+
+		fee, _ = feeSchedule.Calculate(try("10000"))
+		assert.Equal(t, try("25"), fee)
+	})
+
+	t.Run("WhenTiered_ShouldPriceTheAmountByItsTier", func(t *testing.T) {
+		feeSchedule := domain.FeeSchedule{TransactionType: domain.TransferTransaction, Currency: domain.TRY, Type: domain.TieredFee, Tiers: []domain.FeeTier{
+			{UpTo: ptr("100"), FlatAmount: try("1")},
+			{UpTo: ptr("1000"), FlatAmount: try("2"), PercentageBps: 50},
+			{FlatAmount: try("0"), PercentageBps: 25},
+		}}
+		assert.Nil(t, feeSchedule.Validate())
+
+		fee, _ := feeSchedule.Calculate(try("100"))
+		assert.Equal(t, try("1"), fee)
+
+		fee, _ = feeSchedule.Calculate(try("500"))
+		assert.Equal(t, try("4.5"), fee)
+
+		fee, _ = feeSchedule.Calculate(try("4000"))
+		assert.Equal(t, try("10"), fee)
+	})
+
+	t.Run("WhenTiersAreOutOfOrder_ShouldBeInvalid", func(t *testing.T) {
+		feeSchedule := domain.FeeSchedule{TransactionType: domain.TransferTransaction, Currency: domain.TRY, Type: domain.TieredFee, Tiers: []domain.FeeTier{
+			{UpTo: ptr("1000"), FlatAmount: try("1")},
+			{UpTo: ptr("100"), FlatAmount: try("2")},
+			{FlatAmount: try("3")},
+		}}
+
+		assert.NotNil(t, feeSchedule.Validate())
+	})
+}
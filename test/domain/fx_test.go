@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FxRate(t *testing.T) {
+	t.Run("WhenSpreadIsConfigured_ShouldReduceCustomerRate", func(t *testing.T) {
+		mid, _ := domain.ParseRate("37.2150")
+		rate := domain.FxRate{Base: domain.EUR, Quote: domain.TRY, Rate: mid, SpreadBps: 50}
+
+		assert.Equal(t, "37.0289250000", domain.FormatRate(rate.CustomerRate()))
+	})
+
+	t.Run("WhenConverting_ShouldTruncateToMinorUnits", func(t *testing.T) {
+		rate, _ := domain.ParseRate("37.0289250000")
+		sell, _ := domain.ParseMoney("100.01", domain.EUR)
+
+		buy, err := sell.Convert(rate, domain.TRY)
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.TRY, buy.Currency())
+		assert.Equal(t, "3703.26", buy.String())
+	})
+
+	t.Run("WhenOnlyTheInversePairExists_ShouldInvertTheRate", func(t *testing.T) {
+		mid, _ := domain.ParseRate("40")
+		rate := domain.FxRate{Base: domain.EUR, Quote: domain.TRY, Rate: mid}
+
+		assert.Equal(t, "0.0250000000", domain.FormatRate(rate.Inverse().Rate))
+	})
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LimitProfile(t *testing.T) {
+	t.Run("WhenDailyCountIsReached_ShouldReportNoRemainingCount", func(t *testing.T) {
+		dailyCount := 3
+		limitProfile := domain.LimitProfile{Role: "user", TransactionType: domain.DebitTransaction, Currency: domain.TRY, DailyCount: &dailyCount}
+		amount, _ := domain.ParseMoney("1", domain.TRY)
+
+		err := limitProfile.Check(amount, domain.LimitUsage{DailyAmount: domain.Zero(domain.TRY), MonthlyAmount: domain.Zero(domain.TRY), DailyCount: 3})
+
+		limitExceededError, ok := err.(*domain.LimitExceededError)
+		assert.True(t, ok)
+		assert.Equal(t, domain.DailyCountLimit, limitExceededError.Limit)
+		assert.Equal(t, 0, *limitExceededError.RemainingCount)
+	})
+
+	t.Run("WhenProfileHasBothUserAndRole_ShouldBeInvalid", func(t *testing.T) {
+		userID := int64(1)
+		limitProfile := domain.LimitProfile{UserID: &userID, Role: "user", TransactionType: domain.DebitTransaction, Currency: domain.TRY}
+
+		assert.NotNil(t, limitProfile.Validate())
+	})
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseMoney(t *testing.T) {
+	t.Run("WhenAmountIsValid_ShouldKeepExactMinorUnits", func(t *testing.T) {
+		amount, err := domain.ParseMoney("1234567.89", domain.EUR)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(123456789), amount.MinorUnits())
+		assert.Equal(t, "1234567.89", amount.String())
+	})
+
+	t.Run("WhenAmountIsNegative_ShouldKeepSign", func(t *testing.T) {
+		amount, err := domain.ParseMoney("-0.5", domain.TRY)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(-50), amount.MinorUnits())
+		assert.Equal(t, "-0.50", amount.String())
+	})
+
+	t.Run("WhenAmountHasTooManyDecimals_ShouldFail", func(t *testing.T) {
+		_, err := domain.ParseMoney("1.005", domain.USD)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidAmount)
+	})
+
+	t.Run("WhenAmountIsNotANumber_ShouldFail", func(t *testing.T) {
+		_, err := domain.ParseMoney("1e3", domain.USD)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidAmount)
+	})
+}
+
+func Test_MoneyArithmetic(t *testing.T) {
+	t.Run("WhenAddingTenCentsTenTimes_ShouldNotDrift", func(t *testing.T) {
+		sum := domain.Zero(domain.EUR)
+		tenCents, _ := domain.ParseMoney("0.10", domain.EUR)
+
+		for i := 0; i < 10; i++ {
+			sum, _ = sum.Add(tenCents)
+		}
+
+		assert.Equal(t, "1.00", sum.String())
+	})
+
+	t.Run("WhenCurrenciesDiffer_ShouldFail", func(t *testing.T) {
+		_, err := domain.Zero(domain.EUR).Add(domain.Zero(domain.USD))
+
+		assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+	})
+}
+
+func Test_MoneyJSON(t *testing.T) {
+	t.Run("WhenMarshalled_ShouldBeAString", func(t *testing.T) {
+		data, _ := json.Marshal(domain.NewMoney(1050, domain.TRY))
+
+		assert.Equal(t, `"10.50"`, string(data))
+	})
+
+	t.Run("WhenUnmarshalledFromStringOrNumber_ShouldBeExact", func(t *testing.T) {
+		var fromString, fromNumber domain.Money
+
+		assert.Nil(t, json.Unmarshal([]byte(`"0.30"`), &fromString))
+		assert.Nil(t, json.Unmarshal([]byte(`0.30`), &fromNumber))
+		assert.Equal(t, int64(30), fromString.MinorUnits())
+		assert.Equal(t, fromString, fromNumber)
+	})
+}
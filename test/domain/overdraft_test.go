@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OverdraftFacility(t *testing.T) {
+	t.Run("WhenBalanceIsOverdrawn_ShouldChargeTheDailyRateOfTheOverdrawnAmount", func(t *testing.T) {
+		facility := domain.OverdraftFacility{UserID: 1, Currency: domain.USD, CreditLimit: domain.NewMoney(50000, domain.USD), DailyRateBps: 10}
+
+		charge, err := facility.DailyCharge(domain.NewMoney(-12345, domain.USD))
+		assert.Nil(t, err)
+		assert.Equal(t, domain.NewMoney(12, domain.USD), charge)
+
+		charge, _ = facility.DailyCharge(domain.NewMoney(100, domain.USD))
+		assert.True(t, charge.IsZero())
+	})
+
+	t.Run("WhenLimitOrRateIsOutOfRange_ShouldNotValidate", func(t *testing.T) {
+		facility := domain.OverdraftFacility{UserID: 1, Currency: domain.USD, CreditLimit: domain.Zero(domain.USD)}
+		assert.NotNil(t, facility.Validate())
+
+		facility.CreditLimit = domain.NewMoney(100, domain.EUR)
+		assert.ErrorIs(t, facility.Validate(), domain.ErrCurrencyMismatch)
+
+		facility.CreditLimit = domain.NewMoney(100, domain.USD)
+		facility.DailyRateBps = 10000
+		assert.NotNil(t, facility.Validate())
+
+		facility.DailyRateBps = 0
+		assert.Nil(t, facility.Validate())
+	})
+
+	t.Run("WhenBalanceIsOverdrawn_ShouldAddTheCreditLimitToAvailable", func(t *testing.T) {
+		balance := domain.Balance{
+			Currency:    domain.USD,
+			Amount:      domain.NewMoney(-3000, domain.USD),
+			HeldAmount:  domain.NewMoney(1000, domain.USD),
+			CreditLimit: domain.NewMoney(10000, domain.USD),
+		}
+
+		assert.Equal(t, domain.NewMoney(6000, domain.USD), balance.Available())
+		assert.Equal(t, domain.NewMoney(3000, domain.USD), balance.OverdraftUsed())
+	})
+
+	t.Run("WhenChargedOnADay_ShouldBeChargedOnlyUpToIt", func(t *testing.T) {
+		day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+		facility := domain.OverdraftFacility{LastChargedOn: &day}
+
+		assert.True(t, facility.IsChargedOn(day))
+		assert.False(t, facility.IsChargedOn(day.AddDate(0, 0, 1)))
+	})
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RiskRuleConfig(t *testing.T) {
+	t.Run("WhenRuleMissesItsParameters_ShouldBeInvalid", func(t *testing.T) {
+		velocity := domain.RiskRuleConfig{Name: "velocity", Type: domain.VelocityRiskRule, Action: domain.RiskBlock, MaxCount: 3}
+		assert.NotNil(t, velocity.Validate())
+
+		velocity.Window = time.Minute
+		assert.Nil(t, velocity.Validate())
+
+		velocity.TransactionTypes = []domain.TransactionType{domain.FeeTransaction}
+		assert.NotNil(t, velocity.Validate())
+	})
+
+	t.Run("WhenRuleHasNoTypes_ShouldApplyToEveryScreenedType", func(t *testing.T) {
+		rule := domain.RiskRuleConfig{Name: "average", Type: domain.AboveAverageRiskRule, Action: domain.RiskFlag, Multiplier: 2, MinHistory: 1}
+
+		assert.True(t, rule.AppliesTo(domain.CreditTransaction))
+		assert.False(t, rule.AppliesTo(domain.RefundTransaction))
+	})
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RoundUp(t *testing.T) {
+	t.Run("WhenAmountHasMinorUnits_ShouldRoundUpToTheNextWholeUnit", func(t *testing.T) {
+		assert.Equal(t, domain.NewMoney(70, domain.USD), domain.RoundUp(domain.NewMoney(1230, domain.USD)))
+		assert.Equal(t, domain.NewMoney(1, domain.USD), domain.RoundUp(domain.NewMoney(99, domain.USD)))
+	})
+
+	t.Run("WhenAmountIsWhole_ShouldRoundUpNothing", func(t *testing.T) {
+		assert.True(t, domain.RoundUp(domain.NewMoney(500, domain.USD)).IsZero())
+	})
+}
+
+func Test_SavingsGoal(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("WhenGoalIsInvalid_ShouldBeRejected", func(t *testing.T) {
+		for _, goal := range []domain.SavingsGoal{
+			{Name: " ", Currency: domain.USD, TargetAmount: domain.NewMoney(1000, domain.USD), TargetDate: now.AddDate(0, 1, 0)},
+			{Name: "Bike", Currency: domain.USD, TargetAmount: domain.NewMoney(0, domain.USD), TargetDate: now.AddDate(0, 1, 0)},
+			{Name: "Bike", Currency: domain.USD, TargetAmount: domain.NewMoney(1000, domain.EUR), TargetDate: now.AddDate(0, 1, 0)},
+			{Name: "Bike", Currency: domain.USD, TargetAmount: domain.NewMoney(1000, domain.USD), TargetDate: now},
+		} {
+			assert.NotNil(t, goal.Validate(now))
+		}
+	})
+
+	t.Run("WhenFixedRuleRunsDaily_ShouldProjectTheDayTheTargetIsReached", func(t *testing.T) {
+		goal := domain.SavingsGoal{Currency: domain.USD, TargetAmount: domain.NewMoney(5000, domain.USD), SavedAmount: domain.NewMoney(1500, domain.USD), TargetDate: now.AddDate(0, 0, 3)}
+		amount := domain.NewMoney(1000, domain.USD)
+		rule := domain.SweepRule{Type: domain.FixedAmountSweep, Amount: &amount, Recurrence: &domain.Recurrence{Frequency: domain.Daily}, NextRunAt: &now, Active: true}
+
+		projection := goal.Project([]domain.SweepRule{rule}, domain.Zero(domain.USD), now)
+
+		assert.Equal(t, domain.NewMoney(3500, domain.USD), projection.Remaining)
+		assert.Equal(t, now.AddDate(0, 0, 3), *projection.ProjectedCompletion)
+		assert.True(t, projection.OnTrack)
+		assert.Equal(t, domain.NewMoney(1167, domain.USD), projection.RequiredDaily)
+		assert.Equal(t, 3000, int(goal.ProgressBps()))
+	})
+
+	t.Run("WhenNothingIsSaved_ShouldProjectNoCompletion", func(t *testing.T) {
+		goal := domain.SavingsGoal{Currency: domain.USD, TargetAmount: domain.NewMoney(5000, domain.USD), SavedAmount: domain.Zero(domain.USD), TargetDate: now.AddDate(0, 1, 0)}
+
+		projection := goal.Project(nil, domain.Zero(domain.USD), now)
+
+		assert.Nil(t, projection.ProjectedCompletion)
+		assert.False(t, projection.OnTrack)
+	})
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SplitInstruction(t *testing.T) {
+	t.Run("WhenPercentagesDontDivideEvenly_ShouldGiveTheLeftoverToTheFirstShares", func(t *testing.T) {
+		total := domain.NewMoney(1000, domain.USD)
+		instruction := domain.SplitInstruction{Payer: 1, Currency: domain.USD, Total: &total, Shares: []domain.SplitShare{
+			{ToUser: 2, PercentageBps: 3333},
+			{ToUser: 3, PercentageBps: 3333},
+			{ToUser: 4, PercentageBps: 3334},
+		}}
+
+		allocated, amounts, err := instruction.Allocate()
+
+		assert.Nil(t, err)
+		assert.Equal(t, total, allocated)
+		assert.Equal(t, []domain.Money{domain.NewMoney(334, domain.USD), domain.NewMoney(333, domain.USD), domain.NewMoney(333, domain.USD)}, amounts)
+	})
+
+	t.Run("WhenSharesAreAmounts_ShouldAddThemUpToTheTotal", func(t *testing.T) {
+		first, second := domain.NewMoney(1500, domain.USD), domain.NewMoney(2500, domain.USD)
+		instruction := domain.SplitInstruction{Payer: 1, Currency: domain.USD, Shares: []domain.SplitShare{
+			{ToUser: 2, Amount: &first},
+			{ToUser: 3, Amount: &second},
+		}}
+
+		total, _, err := instruction.Allocate()
+		assert.Nil(t, err)
+		assert.Equal(t, domain.NewMoney(4000, domain.USD), total)
+
+		wrongTotal := domain.NewMoney(5000, domain.USD)
+		instruction.Total = &wrongTotal
+		_, _, err = instruction.Allocate()
+		assert.NotNil(t, err)
+	})
+
+	t.Run("WhenSharesAreInvalid_ShouldBeRejected", func(t *testing.T) {
+		amount := domain.NewMoney(1000, domain.USD)
+		total := domain.NewMoney(2000, domain.USD)
+
+		for _, shares := range [][]domain.SplitShare{
+			{{ToUser: 2, Amount: &amount}},
+			{{ToUser: 2, Amount: &amount}, {ToUser: 3, PercentageBps: 5000}},
+			{{ToUser: 2, Amount: &amount}, {ToUser: 2, Amount: &amount}},
+			{{ToUser: 1, Amount: &amount}, {ToUser: 2, Amount: &amount}},
+			{{ToUser: 2, PercentageBps: 5000}, {ToUser: 3, PercentageBps: 4000}},
+			{{ToUser: 2, PercentageBps: 1 << 62}, {ToUser: 3, PercentageBps: 1 << 62}, {ToUser: 4, PercentageBps: 1 << 62}, {ToUser: 5, PercentageBps: 1<<62 + 10000}},
+		} {
+			instruction := domain.SplitInstruction{Payer: 1, Currency: domain.USD, Total: &total, Shares: shares}
+			_, _, err := instruction.Allocate()
+			assert.NotNil(t, err)
+		}
+	})
+}
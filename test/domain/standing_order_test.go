@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Recurrence(t *testing.T) {
+	t.Run("WhenWeekly_ShouldStartOnTheConfiguredWeekday", func(t *testing.T) {
+		recurrence := domain.Recurrence{Frequency: domain.Weekly, Day: 5}
+		wednesday := time.Date(2025, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+		first := recurrence.FirstOccurrence(wednesday)
+
+		assert.Equal(t, time.Date(2025, time.January, 3, 9, 0, 0, 0, time.UTC), first)
+		assert.Equal(t, time.Date(2025, time.January, 10, 9, 0, 0, 0, time.UTC), recurrence.NextOccurrence(first))
+	})
+
+	t.Run("WhenMonthIsShorterThanDay_ShouldRunOnItsLastDay", func(t *testing.T) {
+		recurrence := domain.Recurrence{Frequency: domain.Monthly, Day: 31}
+
+		first := recurrence.FirstOccurrence(time.Date(2025, time.January, 31, 12, 0, 0, 0, time.UTC))
+		second := recurrence.NextOccurrence(first)
+		third := recurrence.NextOccurrence(second)
+
+		assert.Equal(t, time.Date(2025, time.January, 31, 12, 0, 0, 0, time.UTC), first)
+		assert.Equal(t, time.Date(2025, time.February, 28, 12, 0, 0, 0, time.UTC), second)
+		assert.Equal(t, time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC), third)
+	})
+
+	t.Run("WhenMonthlyDayHasPassed_ShouldStartNextMonth", func(t *testing.T) {
+		recurrence := domain.Recurrence{Frequency: domain.Monthly, Day: 5}
+
+		first := recurrence.FirstOccurrence(time.Date(2025, time.December, 20, 0, 0, 0, 0, time.UTC))
+
+		assert.Equal(t, time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC), first)
+	})
+
+	t.Run("WhenOccurrenceCountIsReached_ShouldCompleteTheOrder", func(t *testing.T) {
+		occurrences := 2
+		amount, _ := domain.ParseMoney("10", domain.TRY)
+		start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+		standingOrder := domain.StandingOrder{
+			Amount:       amount,
+			Recurrence:   domain.Recurrence{Frequency: domain.Daily, MaxOccurrences: &occurrences},
+			OccurrenceAt: start,
+			NextRunAt:    start,
+			Status:       domain.StandingOrderActive,
+		}
+
+		standingOrder.Advance()
+		assert.Equal(t, domain.StandingOrderActive, standingOrder.Status)
+		assert.Equal(t, start.AddDate(0, 0, 1), standingOrder.NextRunAt)
+
+		standingOrder.Advance()
+		assert.Equal(t, domain.StandingOrderCompleted, standingOrder.Status)
+	})
+}
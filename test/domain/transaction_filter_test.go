@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TransactionFilter(t *testing.T) {
+	t.Run("WhenCursorIsEncoded_ShouldParseBackToTheSamePosition", func(t *testing.T) {
+		cursor := domain.TransactionCursor{CreatedAt: time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC), ID: 42}
+
+		parsed, err := domain.ParseTransactionCursor(cursor.Encode())
+
+		assert.Nil(t, err)
+		assert.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+		assert.Equal(t, int64(42), parsed.ID)
+	})
+
+	t.Run("WhenCursorIsTamperedWith_ShouldBeRejected", func(t *testing.T) {
+		_, err := domain.ParseTransactionCursor("not-a-cursor")
+
+		assert.Equal(t, domain.ErrInvalidCursor, err)
+	})
+
+	t.Run("WhenAmountRangeIsGiven_ShouldTakeItsCurrency", func(t *testing.T) {
+		minAmount, _ := domain.ParseMoney("10", domain.EUR)
+		filter := domain.TransactionFilter{MinAmount: &minAmount}
+
+		assert.Nil(t, filter.Validate())
+		assert.Equal(t, domain.EUR, filter.Currency)
+		assert.Equal(t, domain.DefaultTransactionPageSize, filter.Limit)
+	})
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TransactionStateMachine(t *testing.T) {
+	t.Run("WhenTransitionIsListed_ShouldBeAllowed", func(t *testing.T) {
+		assert.True(t, domain.Pending.CanTransitionTo(domain.Completed))
+		assert.True(t, domain.Pending.CanTransitionTo(domain.Failed))
+		assert.True(t, domain.Completed.CanTransitionTo(domain.Reversed))
+		assert.True(t, domain.Authorized.CanTransitionTo(domain.Expired))
+		assert.True(t, domain.AwaitingApproval.CanTransitionTo(domain.Rejected))
+	})
+
+	t.Run("WhenTransitionSkipsOrGoesBack_ShouldBeRejected", func(t *testing.T) {
+		assert.False(t, domain.Pending.CanTransitionTo(domain.Reversed))
+		assert.False(t, domain.Completed.CanTransitionTo(domain.Pending))
+		assert.False(t, domain.Failed.CanTransitionTo(domain.Completed))
+		assert.False(t, domain.Captured.CanTransitionTo(domain.Voided))
+		assert.True(t, domain.Reversed.IsFinal())
+	})
+}
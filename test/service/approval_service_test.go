@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApprovalService(initialBalances map[int64]domain.Money) (service.IApprovalService, service.ITransactionService, *FakeBalanceRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	approvalRepository := NewFakeApprovalRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), NewFakeHoldRepository(), approvalRepository, NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+	userRepository := NewFakeUserRepository([]domain.User{
+		{Id: 1, Role: domain.ApproverRole},
+		{Id: 2, Role: "user"},
+		{Id: 3, Role: domain.ApproverRole},
+	})
+	limitService := service.NewLimitService(NewFakeLimitRepository(), userRepository)
+	approvalConfig := approval.Config{
+		Thresholds: map[domain.Currency]domain.Money{domain.DefaultCurrency: money("1000")},
+		TTL:        time.Hour,
+	}
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, service.NewFeeService(NewFakeFeeRepository(), userRepository), service.NewRiskService(NewFakeRiskRepository(), nil), approvalConfig)
+	approvalService := service.NewApprovalService(approvalRepository, userRepository, unitOfWork, transactionService)
+	return approvalService, transactionService, balanceRepository
+}
+
+func Test_WhenTransferIsOverTheThreshold_ShouldWaitForAnotherApprover(t *testing.T) {
+	t.Run("WhenTransferIsOverTheThreshold_ShouldWaitForAnotherApprover", func(t *testing.T) {
+		approvalService, transactionService, balanceRepository := newApprovalService(map[int64]domain.Money{1: money("5000")})
+
+		small, _ := transactionService.Transfer(1, 2, money("1000"))
+		assert.Equal(t, domain.Completed, small.Status)
+
+		transfer, err := transactionService.Transfer(1, 2, money("2000"))
+		assert.Nil(t, err)
+		assert.Equal(t, domain.AwaitingApproval, transfer.Status)
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("4000"), balance.Amount)
+
+		_, err = approvalService.Approve(transfer.ID, 1, "")
+		assert.ErrorIs(t, err, service.ErrSelfApproval)
+		_, err = approvalService.Approve(transfer.ID, 2, "")
+		assert.ErrorIs(t, err, service.ErrNotApprover)
+
+		approved, err := approvalService.Approve(transfer.ID, 3, "checked the invoice")
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, approved.Status)
+		balance, _ = balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("2000"), balance.Amount)
+
+		decided, _ := approvalService.GetApproval(transfer.ID)
+		assert.Equal(t, domain.ApprovalApproved, decided.Status)
+		assert.Equal(t, int64(3), *decided.CheckerID)
+
+		_, err = approvalService.Reject(transfer.ID, 3, "")
+		assert.ErrorIs(t, err, service.ErrApprovalNotPending)
+	})
+}
+
+func Test_WhenApprovedTransferConflicts_ShouldRunTheApprovalAgain(t *testing.T) {
+	t.Run("WhenApprovedTransferConflicts_ShouldRunTheApprovalAgain", func(t *testing.T) {
+		approvalService, transactionService, balanceRepository := newApprovalService(map[int64]domain.Money{1: money("5000")})
+
+		transfer, _ := transactionService.Transfer(1, 2, money("2000"))
+		balanceRepository.FailNextWrites(2)
+
+		approved, err := approvalService.Approve(transfer.ID, 3, "")
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, approved.Status)
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("3000"), balance.Amount)
+	})
+}
+
+func Test_WhenApprovedTransferCannotRun_ShouldStayPending(t *testing.T) {
+	t.Run("WhenApprovedTransferCannotRun_ShouldStayPending", func(t *testing.T) {
+		approvalService, transactionService, _ := newApprovalService(map[int64]domain.Money{2: money("1500")})
+
+		transfer, _ := transactionService.Transfer(2, 1, money("1500"))
+		transactionService.Debit(2, money("600"))
+
+		_, err := approvalService.Approve(transfer.ID, 1, "")
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+
+		pending, _ := approvalService.GetApproval(transfer.ID)
+		assert.Equal(t, domain.ApprovalPending, pending.Status)
+
+		rejected, err := approvalService.Reject(transfer.ID, 1, "not enough funds")
+		assert.Nil(t, err)
+		assert.Equal(t, domain.ApprovalRejected, rejected.Status)
+		original, _ := transactionService.GetTransactionByID(transfer.ID)
+		assert.Equal(t, domain.Rejected, original.Status)
+	})
+}
+
+func Test_WhenApprovalIsNotDecidedInTime_ShouldExpire(t *testing.T) {
+	t.Run("WhenApprovalIsNotDecidedInTime_ShouldExpire", func(t *testing.T) {
+		approvalService, transactionService, _ := newApprovalService(map[int64]domain.Money{1: money("5000")})
+		transfer, _ := transactionService.Transfer(1, 2, money("2000"))
+
+		assert.Nil(t, approvalService.ExpireApprovals(context.Background(), time.Now()))
+		pending, _ := approvalService.GetPendingApprovals()
+		assert.Equal(t, 1, len(pending))
+
+		assert.Nil(t, approvalService.ExpireApprovals(context.Background(), time.Now().Add(2*time.Hour)))
+		pending, _ = approvalService.GetPendingApprovals()
+		assert.Equal(t, 0, len(pending))
+
+		original, _ := transactionService.GetTransactionByID(transfer.ID)
+		assert.Equal(t, domain.Expired, original.Status)
+		_, err := approvalService.Approve(transfer.ID, 3, "")
+		assert.ErrorIs(t, err, service.ErrApprovalNotPending)
+	})
+}
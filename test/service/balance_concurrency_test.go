@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+concurrentUnitOfWork lets many callers run at once, unlike FakeUnitOfWork it
+has nothing to restore: a conflicting balance write fails before anything
+else of the adjustment is written.
+*/
+type concurrentUnitOfWork struct {
+	balanceRepository     *FakeBalanceRepository
+	ledgerRepository      *FakeLedgerRepository
+	transactionRepository *lockedTransactionRepository
+}
+
+func newConcurrentUnitOfWork(balanceRepository *FakeBalanceRepository, ledgerRepository *FakeLedgerRepository) *concurrentUnitOfWork {
+	return &concurrentUnitOfWork{
+		balanceRepository:     balanceRepository,
+		ledgerRepository:      ledgerRepository,
+		transactionRepository: &lockedTransactionRepository{ITransactionRepository: NewFakeTransactionRepository()},
+	}
+}
+
+func (unitOfWork *concurrentUnitOfWork) Execute(fn func(repositories persistence.Repositories) error) error {
+	return fn(persistence.Repositories{
+		Transactions: unitOfWork.transactionRepository,
+		Balances:     unitOfWork.balanceRepository,
+		Ledger:       unitOfWork.ledgerRepository,
+		SavingsGoals: NewFakeSavingsGoalRepository(),
+	})
+}
+
+/* lockedTransactionRepository serializes the writes the adjustments make to the fake */
+type lockedTransactionRepository struct {
+	persistence.ITransactionRepository
+	mu sync.Mutex
+}
+
+func (transactionRepository *lockedTransactionRepository) CreateTransaction(transaction *domain.Transaction) error {
+	transactionRepository.mu.Lock()
+	defer transactionRepository.mu.Unlock()
+	return transactionRepository.ITransactionRepository.CreateTransaction(transaction)
+}
+
+func (transactionRepository *lockedTransactionRepository) UpdateTransactionStatus(id int64, from domain.TransactionStatus, to domain.TransactionStatus, reason string) error {
+	transactionRepository.mu.Lock()
+	defer transactionRepository.mu.Unlock()
+	return transactionRepository.ITransactionRepository.UpdateTransactionStatus(id, from, to, reason)
+}
+
+func Test_WhenBalanceIsUpdatedInParallel_ShouldLoseNoUpdate(t *testing.T) {
+	t.Run("WhenBalanceIsUpdatedInParallel_ShouldLoseNoUpdate", func(t *testing.T) {
+		balanceRepository := NewFakeBalanceRepository(map[int64]domain.Money{1: money("1000")})
+		ledgerRepository := NewFakeLedgerRepository()
+		balanceService := service.NewBalanceService(balanceRepository, NewFakePocketRepository(), newConcurrentUnitOfWork(balanceRepository, ledgerRepository))
+
+		const workers, updatesPerWorker = 8, 25
+		var mu sync.Mutex
+		applied := money("1000")
+		var wg sync.WaitGroup
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				amount := money("1")
+				if worker%2 == 1 {
+					amount = money("-1")
+				}
+				for i := 0; i < updatesPerWorker; i++ {
+					err := balanceService.UpdateBalance(1, amount)
+					if err != nil {
+						assert.True(t, errors.Is(err, persistence.ErrBalanceConflict))
+						continue
+					}
+					mu.Lock()
+					applied, _ = applied.Add(amount)
+					mu.Unlock()
+				}
+			}(worker)
+		}
+		wg.Wait()
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, applied, balance.Amount)
+
+		account, _ := ledgerRepository.GetAccountByCode(domain.UserAccountCode(1, domain.DefaultCurrency))
+		posted, _ := ledgerRepository.GetAccountBalance(account.ID)
+		moved, _ := applied.Sub(money("1000"))
+		assert.Equal(t, moved, posted)
+	})
+}
+
+func Test_WhenNewWalletIsOpenedInParallel_ShouldCreditItOnce(t *testing.T) {
+	t.Run("WhenNewWalletIsOpenedInParallel_ShouldCreditItOnce", func(t *testing.T) {
+		balanceRepository := NewFakeBalanceRepository(map[int64]domain.Money{})
+		balanceService := service.NewBalanceService(balanceRepository, NewFakePocketRepository(), newConcurrentUnitOfWork(balanceRepository, NewFakeLedgerRepository()))
+
+		const workers = 8
+		var wg sync.WaitGroup
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, balanceService.UpdateBalance(1, money("10")))
+			}()
+		}
+		wg.Wait()
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("80"), balance.Amount)
+	})
+}
+
+func Test_WhenBalanceKeepsConflicting_ShouldGiveUpAfterBoundedRetries(t *testing.T) {
+	t.Run("WhenBalanceKeepsConflicting_ShouldGiveUpAfterBoundedRetries", func(t *testing.T) {
+		balanceRepository := NewFakeBalanceRepository(map[int64]domain.Money{1: money("100")})
+		unitOfWork := NewFakeUnitOfWork(NewFakeTransactionRepository(), balanceRepository, NewFakeLedgerRepository(), NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+		balanceService := service.NewBalanceService(balanceRepository, NewFakePocketRepository(), unitOfWork)
+
+		balanceRepository.FailNextWrites(3)
+		assert.Nil(t, balanceService.UpdateBalance(1, money("5")))
+
+		balanceRepository.FailNextWrites(100)
+		err := balanceService.UpdateBalance(1, money("5"))
+		assert.ErrorIs(t, err, persistence.ErrBalanceConflict)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("105"), balance.Amount)
+	})
+}
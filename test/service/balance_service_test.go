@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPocketBalanceService(initialBalances map[int64]domain.Money) (service.IBalanceService, service.ITransactionService) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	pocketRepository := NewFakePocketRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), pocketRepository, NewFakeSavingsGoalRepository())
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+	feeService := service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil))
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+	return service.NewBalanceService(balanceRepository, pocketRepository, unitOfWork), transactionService
+}
+
+func Test_WhenMoneyIsInAPocket_ShouldOnlyDebitTheMainBalance(t *testing.T) {
+	t.Run("WhenMoneyIsInAPocket_ShouldOnlyDebitTheMainBalance", func(t *testing.T) {
+		balanceService, transactionService := newPocketBalanceService(map[int64]domain.Money{1: money("100")})
+
+		rent, err := balanceService.CreatePocket(1, domain.DefaultCurrency, " rent ")
+		assert.Nil(t, err)
+		assert.Equal(t, "rent", rent.Name)
+		_, err = balanceService.CreatePocket(1, domain.DefaultCurrency, "Rent")
+		assert.ErrorIs(t, err, service.ErrPocketNameTaken)
+
+		_, err = balanceService.MoveToPocket(rent.ID, money("120"))
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+		rent, err = balanceService.MoveToPocket(rent.ID, money("70"))
+		assert.Nil(t, err)
+		assert.Equal(t, money("70"), rent.Amount)
+
+		_, err = transactionService.Debit(1, money("40"))
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+
+		breakdown, _ := balanceService.GetBalanceBreakdown(1, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), breakdown.Balance.Amount)
+		assert.Equal(t, money("30"), breakdown.Balance.Main())
+		assert.Equal(t, money("30"), breakdown.Balance.Available())
+		assert.Equal(t, 1, len(breakdown.Pockets))
+
+		_, err = balanceService.MoveFromPocket(rent.ID, money("80"))
+		assert.ErrorIs(t, err, service.ErrInsufficientPocketFunds)
+		_, err = balanceService.MoveFromPocket(rent.ID, money("20"))
+		assert.Nil(t, err)
+		_, err = transactionService.Debit(1, money("40"))
+		assert.Nil(t, err)
+	})
+}
+
+func Test_WhenPocketIsClosed_ShouldMoveWhatIsLeftBackToTheMainBalance(t *testing.T) {
+	t.Run("WhenPocketIsClosed_ShouldMoveWhatIsLeftBackToTheMainBalance", func(t *testing.T) {
+		balanceService, _ := newPocketBalanceService(map[int64]domain.Money{1: money("100")})
+		vacation, _ := balanceService.CreatePocket(1, domain.DefaultCurrency, "vacation")
+		balanceService.MoveToPocket(vacation.ID, money("60"))
+
+		renamed, err := balanceService.RenamePocket(vacation.ID, "summer")
+		assert.Nil(t, err)
+		assert.Equal(t, "summer", renamed.Name)
+
+		closed, err := balanceService.ClosePocket(vacation.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.PocketClosed, closed.Status)
+		assert.True(t, closed.Amount.IsZero())
+		_, err = balanceService.MoveToPocket(vacation.ID, money("10"))
+		assert.ErrorIs(t, err, service.ErrPocketClosed)
+
+		breakdown, _ := balanceService.GetBalanceBreakdown(1, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), breakdown.Balance.Main())
+		assert.Empty(t, breakdown.Pockets)
+
+		_, err = balanceService.CreatePocket(1, domain.DefaultCurrency, "summer")
+		assert.Nil(t, err)
+	})
+}
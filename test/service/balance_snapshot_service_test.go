@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+/* postAt books amount for the user against the external account at the given time */
+func postAt(ledgerRepository *FakeLedgerRepository, userID int64, amount domain.Money, at time.Time) {
+	account, _ := ledgerRepository.GetOrCreateUserAccount(userID, amount.Currency())
+	external, _ := ledgerRepository.GetAccountByCode(domain.ExternalAccountCode(amount.Currency()))
+	ledgerRepository.CreateJournalEntry(&domain.JournalEntry{
+		Description: "test",
+		CreatedAt:   at,
+		Postings: []domain.Posting{
+			{AccountID: account.ID, Amount: amount},
+			{AccountID: external.ID, Amount: amount.Neg()},
+		},
+	})
+}
+
+func Test_WhenBalanceIsAskedAsOf_ShouldReplayFromTheNearestSnapshot(t *testing.T) {
+	t.Run("WhenBalanceIsAskedAsOf_ShouldReplayFromTheNearestSnapshot", func(t *testing.T) {
+		ledgerRepository := NewFakeLedgerRepository()
+		snapshotRepository := NewFakeBalanceSnapshotRepository()
+		balanceSnapshotService := service.NewBalanceSnapshotService(snapshotRepository, ledgerRepository)
+		today := domain.SnapshotDate(time.Now())
+		fiveDaysAgo := today.AddDate(0, 0, -5)
+
+		postAt(ledgerRepository, 1, money("100"), fiveDaysAgo.Add(10*time.Hour))
+		postAt(ledgerRepository, 1, money("-30"), fiveDaysAgo.AddDate(0, 0, 2).Add(9*time.Hour))
+		postAt(ledgerRepository, 1, money("5"), fiveDaysAgo.AddDate(0, 0, 3).Add(23*time.Hour))
+
+		asOf := fiveDaysAgo.AddDate(0, 0, 3).Add(12 * time.Hour)
+		replayed, err := balanceSnapshotService.GetBalanceAsOf(1, domain.DefaultCurrency, asOf)
+		assert.Nil(t, err)
+		assert.Equal(t, money("70"), replayed.Amount)
+		assert.Nil(t, replayed.SnapshotDate)
+
+		written, err := balanceSnapshotService.Backfill(fiveDaysAgo, today.AddDate(0, 0, -1))
+		assert.Nil(t, err)
+		assert.Equal(t, 5, written)
+
+		fromSnapshot, err := balanceSnapshotService.GetBalanceAsOf(1, domain.DefaultCurrency, asOf)
+		assert.Nil(t, err)
+		assert.Equal(t, money("70"), fromSnapshot.Amount)
+		assert.Equal(t, fiveDaysAgo.AddDate(0, 0, 2), *fromSnapshot.SnapshotDate)
+
+		endOfDay, _ := balanceSnapshotService.GetBalanceAsOf(1, domain.DefaultCurrency, fiveDaysAgo.AddDate(0, 0, 4))
+		assert.Equal(t, money("75"), endOfDay.Amount)
+
+		_, err = balanceSnapshotService.GetBalanceAsOf(1, domain.DefaultCurrency, time.Now().Add(time.Hour))
+		assert.ErrorIs(t, err, service.ErrAsOfInFuture)
+		_, err = balanceSnapshotService.GetBalanceAsOf(2, domain.DefaultCurrency, asOf)
+		assert.ErrorIs(t, err, persistence.ErrBalanceNotFound)
+		_, err = balanceSnapshotService.Backfill(today, today)
+		assert.ErrorIs(t, err, service.ErrInvalidBackfillRange)
+	})
+}
+
+func Test_WhenSnapshotJobRuns_ShouldWriteEachClosingBalanceOnce(t *testing.T) {
+	t.Run("WhenSnapshotJobRuns_ShouldWriteEachClosingBalanceOnce", func(t *testing.T) {
+		ledgerRepository := NewFakeLedgerRepository()
+		snapshotRepository := NewFakeBalanceSnapshotRepository()
+		balanceSnapshotService := service.NewBalanceSnapshotService(snapshotRepository, ledgerRepository)
+		today := domain.SnapshotDate(time.Now())
+		euros, _ := domain.ParseMoney("20", domain.EUR)
+
+		postAt(ledgerRepository, 1, money("40"), today.Add(-2*time.Hour))
+		postAt(ledgerRepository, 1, euros, today.Add(-time.Hour))
+		postAt(ledgerRepository, 2, money("10"), today.Add(-time.Hour))
+		postAt(ledgerRepository, 1, money("60"), today.Add(time.Minute))
+
+		assert.Nil(t, balanceSnapshotService.SnapshotBalances(context.Background(), today.Add(time.Hour)))
+		assert.Nil(t, balanceSnapshotService.SnapshotBalances(context.Background(), today.Add(2*time.Hour)))
+
+		snapshots, _ := snapshotRepository.GetSnapshotsByDate(today.AddDate(0, 0, -1))
+		assert.Equal(t, 3, len(snapshots))
+		latest, _ := snapshotRepository.GetLatestSnapshot(1, domain.DefaultCurrency, today)
+		assert.Equal(t, money("40"), latest.Amount)
+	})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDisputeService(initialBalances map[int64]domain.Money) (service.IDisputeService, service.ITransactionService, *FakeBalanceRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	disputeRepository := NewFakeDisputeRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), disputeRepository, NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+	feeService := service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil))
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+	return service.NewDisputeService(disputeRepository, unitOfWork), transactionService, balanceRepository
+}
+
+func Test_WhenDisputeIsWon_ShouldReverseTheTransferAndTakeBackTheProvisionalCredit(t *testing.T) {
+	t.Run("WhenDisputeIsWon_ShouldReverseTheTransferAndTakeBackTheProvisionalCredit", func(t *testing.T) {
+		disputeService, transactionService, balanceRepository := newDisputeService(map[int64]domain.Money{1: money("100")})
+		transfer, _ := transactionService.Transfer(1, 2, money("40"))
+
+		_, err := disputeService.Open(transfer.ID, 2, "not mine", "")
+		assert.ErrorIs(t, err, service.ErrTransactionNotDisputable)
+		dispute, err := disputeService.Open(transfer.ID, 1, "card was stolen", "police report 42")
+		assert.Nil(t, err)
+		_, err = disputeService.Open(transfer.ID, 1, "again", "")
+		assert.ErrorIs(t, err, service.ErrDisputeAlreadyOpen)
+
+		_, err = disputeService.GrantProvisionalCredit(dispute.ID, 9, "")
+		assert.Nil(t, err)
+		_, err = disputeService.GrantProvisionalCredit(dispute.ID, 9, "")
+		assert.ErrorIs(t, err, service.ErrProvisionalCreditNotAllowed)
+		claimant, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), claimant.Amount)
+
+		_, err = disputeService.Resolve(dispute.ID, domain.DisputeWon, 9, "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDisputeTransition)
+		disputeService.StartReview(dispute.ID, 9, "")
+		won, err := disputeService.Resolve(dispute.ID, domain.DisputeWon, 9, "confirmed unauthorized")
+		assert.Nil(t, err)
+		assert.NotNil(t, won.ReversalID)
+
+		claimant, _ = balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		receiver, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), claimant.Amount)
+		assert.Equal(t, domain.Zero(domain.DefaultCurrency), receiver.Amount)
+		original, _ := transactionService.GetTransactionByID(transfer.ID)
+		assert.Equal(t, domain.Reversed, original.Status)
+
+		events, _ := disputeService.GetEvents(dispute.ID)
+		assert.Equal(t, 4, len(events))
+		assert.Equal(t, domain.DisputeOpened, events[0].Action)
+		assert.Equal(t, domain.DisputeProvisionalCredit, events[1].Action)
+		assert.Equal(t, domain.DisputeWon, events[3].Status)
+	})
+}
+
+func Test_WhenDisputeIsLost_ShouldOnlyTakeBackTheProvisionalCredit(t *testing.T) {
+	t.Run("WhenDisputeIsLost_ShouldOnlyTakeBackTheProvisionalCredit", func(t *testing.T) {
+		disputeService, transactionService, balanceRepository := newDisputeService(map[int64]domain.Money{1: money("100")})
+		transfer, _ := transactionService.Transfer(1, 2, money("40"))
+
+		dispute, _ := disputeService.Open(transfer.ID, 1, "never received the goods", "")
+		disputeService.StartReview(dispute.ID, 9, "")
+		disputeService.GrantProvisionalCredit(dispute.ID, 9, "")
+		lost, err := disputeService.Resolve(dispute.ID, domain.DisputeLost, 9, "delivery was confirmed")
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.DisputeLost, lost.Status)
+		assert.Nil(t, lost.ReversalID)
+		claimant, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		receiver, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("60"), claimant.Amount)
+		assert.Equal(t, money("40"), receiver.Amount)
+
+		_, err = disputeService.Open(transfer.ID, 1, "new evidence", "")
+		assert.Nil(t, err)
+	})
+}
+
+func Test_WhenDisputeIsWonAfterTheReceiverSpentTheMoney_ShouldCloseItAndOverdrawTheReceiver(t *testing.T) {
+	t.Run("WhenDisputeIsWonAfterTheReceiverSpentTheMoney_ShouldCloseItAndOverdrawTheReceiver", func(t *testing.T) {
+		disputeService, transactionService, balanceRepository := newDisputeService(map[int64]domain.Money{1: money("100")})
+		transfer, _ := transactionService.Transfer(1, 2, money("40"))
+		_, err := transactionService.Debit(2, money("30"))
+		assert.Nil(t, err)
+
+		dispute, _ := disputeService.Open(transfer.ID, 1, "card was stolen", "")
+		disputeService.StartReview(dispute.ID, 9, "")
+		won, err := disputeService.Resolve(dispute.ID, domain.DisputeWon, 9, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.DisputeWon, won.Status)
+		claimant, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		receiver, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), claimant.Amount)
+		assert.Equal(t, money("-30"), receiver.Amount)
+	})
+}
+
+func Test_WhenDisputeIsLostAfterTheClaimantSpentTheCredit_ShouldCloseItAndOverdrawTheClaimant(t *testing.T) {
+	t.Run("WhenDisputeIsLostAfterTheClaimantSpentTheCredit_ShouldCloseItAndOverdrawTheClaimant", func(t *testing.T) {
+		disputeService, transactionService, balanceRepository := newDisputeService(map[int64]domain.Money{1: money("100")})
+		transfer, _ := transactionService.Transfer(1, 2, money("40"))
+
+		dispute, _ := disputeService.Open(transfer.ID, 1, "never received the goods", "")
+		disputeService.StartReview(dispute.ID, 9, "")
+		disputeService.GrantProvisionalCredit(dispute.ID, 9, "")
+		_, err := transactionService.Debit(1, money("90"))
+		assert.Nil(t, err)
+
+		lost, err := disputeService.Resolve(dispute.ID, domain.DisputeLost, 9, "delivery was confirmed")
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.DisputeLost, lost.Status)
+		claimant, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		receiver, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("-30"), claimant.Amount)
+		assert.Equal(t, money("40"), receiver.Amount)
+	})
+}
+
+func Test_WhenNoteHasMultiByteCharacters_ShouldCountCharactersNotBytes(t *testing.T) {
+	t.Run("WhenNoteHasMultiByteCharacters_ShouldCountCharactersNotBytes", func(t *testing.T) {
+		disputeService, transactionService, _ := newDisputeService(map[int64]domain.Money{1: money("100")})
+		transfer, _ := transactionService.Transfer(1, 2, money("40"))
+		dispute, _ := disputeService.Open(transfer.ID, 1, "card was stolen", "")
+
+		_, err := disputeService.StartReview(dispute.ID, 9, strings.Repeat("ş", 256))
+		assert.ErrorIs(t, err, service.ErrDisputeNoteTooLong)
+		_, err = disputeService.StartReview(dispute.ID, 9, strings.Repeat("ş", 255))
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeApprovalRepository struct {
+	approvals []domain.TransferApproval
+}
+
+func NewFakeApprovalRepository() *FakeApprovalRepository {
+	return &FakeApprovalRepository{}
+}
+
+func (fakeApprovalRepository *FakeApprovalRepository) CreateApproval(approval *domain.TransferApproval) error {
+	fakeApprovalRepository.approvals = append(fakeApprovalRepository.approvals, *approval)
+	return nil
+}
+
+func (fakeApprovalRepository *FakeApprovalRepository) GetApprovalByTransactionID(transactionID int64) (*domain.TransferApproval, error) {
+	for _, approval := range fakeApprovalRepository.approvals {
+		if approval.TransactionID == transactionID {
+			return &approval, nil
+		}
+	}
+	return nil, persistence.ErrApprovalNotFound
+}
+
+func (fakeApprovalRepository *FakeApprovalRepository) GetApprovalByTransactionIDForUpdate(transactionID int64) (*domain.TransferApproval, error) {
+	return fakeApprovalRepository.GetApprovalByTransactionID(transactionID)
+}
+
+func (fakeApprovalRepository *FakeApprovalRepository) GetApprovalsByStatus(status domain.ApprovalStatus) ([]domain.TransferApproval, error) {
+	var approvals []domain.TransferApproval
+	for _, approval := range fakeApprovalRepository.approvals {
+		if approval.Status == status {
+			approvals = append(approvals, approval)
+		}
+	}
+	return approvals, nil
+}
+
+func (fakeApprovalRepository *FakeApprovalRepository) GetExpiredApprovals(now time.Time, limit int) ([]domain.TransferApproval, error) {
+	var approvals []domain.TransferApproval
+	for _, approval := range fakeApprovalRepository.approvals {
+		if approval.Status == domain.ApprovalPending && approval.IsExpired(now) && len(approvals) < limit {
+			approvals = append(approvals, approval)
+		}
+	}
+	return approvals, nil
+}
+
+func (fakeApprovalRepository *FakeApprovalRepository) UpdateApproval(approval *domain.TransferApproval) error {
+	for i := range fakeApprovalRepository.approvals {
+		if fakeApprovalRepository.approvals[i].TransactionID == approval.TransactionID {
+			fakeApprovalRepository.approvals[i] = *approval
+		}
+	}
+	return nil
+}
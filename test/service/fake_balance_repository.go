@@ -0,0 +1,146 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type walletKey struct {
+	userID   int64
+	currency domain.Currency
+}
+
+/*
+FakeBalanceRepository can be shared by concurrent callers and refuses stale
+writes like the real one. conflicts makes the next writes fail with
+persistence.ErrBalanceConflict regardless of the version.
+*/
+type FakeBalanceRepository struct {
+	mu        sync.Mutex
+	balances  map[walletKey]domain.Balance
+	conflicts int
+}
+
+func NewFakeBalanceRepository(initialBalances map[int64]domain.Money) *FakeBalanceRepository {
+	fakeBalanceRepository := &FakeBalanceRepository{balances: map[walletKey]domain.Balance{}}
+	for userID, amount := range initialBalances {
+		fakeBalanceRepository.CreateBalance(userID, amount)
+	}
+	return fakeBalanceRepository
+}
+
+/* FailNextWrites makes the next count writes conflict */
+func (fakeBalanceRepository *FakeBalanceRepository) FailNextWrites(count int) {
+	fakeBalanceRepository.mu.Lock()
+	defer fakeBalanceRepository.mu.Unlock()
+	fakeBalanceRepository.conflicts = count
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) GetBalanceByUserID(userID int64, currency domain.Currency) (*domain.Balance, error) {
+	fakeBalanceRepository.mu.Lock()
+	defer fakeBalanceRepository.mu.Unlock()
+
+	balance, ok := fakeBalanceRepository.balances[walletKey{userID, currency}]
+	if !ok {
+		return nil, persistence.ErrBalanceNotFound
+	}
+	return &balance, nil
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) GetBalanceByUserIDForUpdate(userID int64, currency domain.Currency) (*domain.Balance, error) {
+	return fakeBalanceRepository.GetBalanceByUserID(userID, currency)
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) GetBalancesByUserID(userID int64) ([]domain.Balance, error) {
+	fakeBalanceRepository.mu.Lock()
+	defer fakeBalanceRepository.mu.Unlock()
+
+	var balances []domain.Balance
+	for key, balance := range fakeBalanceRepository.balances {
+		if key.userID == userID {
+			balances = append(balances, balance)
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+	return balances, nil
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) UpdateBalance(balance *domain.Balance, amount domain.Money) error {
+	return fakeBalanceRepository.compareAndSwap(balance, func(stored *domain.Balance) { stored.Amount = amount })
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) UpdateHeldAmount(balance *domain.Balance, heldAmount domain.Money) error {
+	return fakeBalanceRepository.compareAndSwap(balance, func(stored *domain.Balance) { stored.HeldAmount = heldAmount })
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) UpdatePocketedAmount(balance *domain.Balance, pocketedAmount domain.Money) error {
+	return fakeBalanceRepository.compareAndSwap(balance, func(stored *domain.Balance) { stored.PocketedAmount = pocketedAmount })
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) CreateBalance(userID int64, amount domain.Money) error {
+	fakeBalanceRepository.mu.Lock()
+	defer fakeBalanceRepository.mu.Unlock()
+
+	key := walletKey{userID, amount.Currency()}
+	if _, ok := fakeBalanceRepository.balances[key]; ok {
+		return persistence.ErrBalanceConflict
+	}
+	fakeBalanceRepository.balances[key] = domain.Balance{
+		UserID:         userID,
+		Currency:       amount.Currency(),
+		Amount:         amount,
+		HeldAmount:     domain.Zero(amount.Currency()),
+		PocketedAmount: domain.Zero(amount.Currency()),
+		CreditLimit:    domain.Zero(amount.Currency()),
+		LastUpdatedAt:  time.Now(),
+	}
+	return nil
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) compareAndSwap(balance *domain.Balance, apply func(stored *domain.Balance)) error {
+	fakeBalanceRepository.mu.Lock()
+	defer fakeBalanceRepository.mu.Unlock()
+
+	key := walletKey{balance.UserID, balance.Currency}
+	stored, ok := fakeBalanceRepository.balances[key]
+	if !ok || stored.Version != balance.Version || fakeBalanceRepository.conflicts > 0 {
+		if fakeBalanceRepository.conflicts > 0 {
+			fakeBalanceRepository.conflicts--
+		}
+		return persistence.ErrBalanceConflict
+	}
+
+	apply(&stored)
+	stored.Version++
+	stored.LastUpdatedAt = time.Now()
+	fakeBalanceRepository.balances[key] = stored
+	*balance = stored
+	return nil
+}
+
+/* setCreditLimit only touches an open wallet, the overdraft service opens one before saving a facility */
+func (fakeBalanceRepository *FakeBalanceRepository) setCreditLimit(userID int64, creditLimit domain.Money) {
+	fakeBalanceRepository.mu.Lock()
+	defer fakeBalanceRepository.mu.Unlock()
+
+	key := walletKey{userID, creditLimit.Currency()}
+	if balance, ok := fakeBalanceRepository.balances[key]; ok {
+		balance.CreditLimit = creditLimit
+		fakeBalanceRepository.balances[key] = balance
+	}
+}
+
+func (fakeBalanceRepository *FakeBalanceRepository) snapshot() map[walletKey]domain.Balance {
+	fakeBalanceRepository.mu.Lock()
+	defer fakeBalanceRepository.mu.Unlock()
+
+	copied := map[walletKey]domain.Balance{}
+	for key, balance := range fakeBalanceRepository.balances {
+		copied[key] = balance
+	}
+	return copied
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+)
+
+type FakeBalanceSnapshotRepository struct {
+	snapshots []domain.BalanceSnapshot
+}
+
+func NewFakeBalanceSnapshotRepository() *FakeBalanceSnapshotRepository {
+	return &FakeBalanceSnapshotRepository{}
+}
+
+func (fakeBalanceSnapshotRepository *FakeBalanceSnapshotRepository) SaveSnapshot(snapshot *domain.BalanceSnapshot) error {
+	for i, existing := range fakeBalanceSnapshotRepository.snapshots {
+		if existing.UserID == snapshot.UserID && existing.Currency == snapshot.Currency && existing.Date.Equal(snapshot.Date) {
+			fakeBalanceSnapshotRepository.snapshots[i] = *snapshot
+			return nil
+		}
+	}
+	fakeBalanceSnapshotRepository.snapshots = append(fakeBalanceSnapshotRepository.snapshots, *snapshot)
+	return nil
+}
+
+func (fakeBalanceSnapshotRepository *FakeBalanceSnapshotRepository) GetLatestSnapshot(userID int64, currency domain.Currency, onOrBefore time.Time) (*domain.BalanceSnapshot, error) {
+	var latest *domain.BalanceSnapshot
+	for _, snapshot := range fakeBalanceSnapshotRepository.snapshots {
+		if snapshot.UserID != userID || snapshot.Currency != currency || snapshot.Date.After(onOrBefore) {
+			continue
+		}
+		if latest == nil || snapshot.Date.After(latest.Date) {
+			found := snapshot
+			latest = &found
+		}
+	}
+	return latest, nil
+}
+
+func (fakeBalanceSnapshotRepository *FakeBalanceSnapshotRepository) GetSnapshotsByDate(date time.Time) ([]domain.BalanceSnapshot, error) {
+	var snapshots []domain.BalanceSnapshot
+	for _, snapshot := range fakeBalanceSnapshotRepository.snapshots {
+		if snapshot.Date.Equal(date) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeDisputeRepository struct {
+	disputes []domain.Dispute
+	events   []domain.DisputeEvent
+}
+
+func NewFakeDisputeRepository() *FakeDisputeRepository {
+	return &FakeDisputeRepository{}
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) CreateDispute(dispute *domain.Dispute) error {
+	dispute.ID = int64(len(fakeDisputeRepository.disputes) + 1)
+	fakeDisputeRepository.disputes = append(fakeDisputeRepository.disputes, *dispute)
+	return nil
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) GetDisputeByID(id int64) (*domain.Dispute, error) {
+	for _, dispute := range fakeDisputeRepository.disputes {
+		if dispute.ID == id {
+			return &dispute, nil
+		}
+	}
+	return nil, persistence.ErrDisputeNotFound
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) GetDisputeByIDForUpdate(id int64) (*domain.Dispute, error) {
+	return fakeDisputeRepository.GetDisputeByID(id)
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) GetDisputesByTransactionID(transactionID int64) ([]domain.Dispute, error) {
+	var disputes []domain.Dispute
+	for _, dispute := range fakeDisputeRepository.disputes {
+		if dispute.TransactionID == transactionID {
+			disputes = append(disputes, dispute)
+		}
+	}
+	return disputes, nil
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) GetDisputesByStatus(status domain.DisputeStatus) ([]domain.Dispute, error) {
+	var disputes []domain.Dispute
+	for _, dispute := range fakeDisputeRepository.disputes {
+		if dispute.Status == status {
+			disputes = append(disputes, dispute)
+		}
+	}
+	return disputes, nil
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) UpdateDispute(dispute *domain.Dispute) error {
+	for i := range fakeDisputeRepository.disputes {
+		if fakeDisputeRepository.disputes[i].ID == dispute.ID {
+			fakeDisputeRepository.disputes[i] = *dispute
+		}
+	}
+	return nil
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) CreateDisputeEvent(event *domain.DisputeEvent) error {
+	event.ID = int64(len(fakeDisputeRepository.events) + 1)
+	fakeDisputeRepository.events = append(fakeDisputeRepository.events, *event)
+	return nil
+}
+
+func (fakeDisputeRepository *FakeDisputeRepository) GetDisputeEvents(disputeID int64) ([]domain.DisputeEvent, error) {
+	var events []domain.DisputeEvent
+	for _, event := range fakeDisputeRepository.events {
+		if event.DisputeID == disputeID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
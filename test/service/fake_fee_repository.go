@@ -0,0 +1,69 @@
+package service
+
+import (
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeFeeRepository struct {
+	feeSchedules []domain.FeeSchedule
+}
+
+func NewFakeFeeRepository() *FakeFeeRepository {
+	return &FakeFeeRepository{}
+}
+
+func (fakeFeeRepository *FakeFeeRepository) CreateFeeSchedule(feeSchedule *domain.FeeSchedule) error {
+	feeSchedule.ID = int64(len(fakeFeeRepository.feeSchedules) + 1)
+	fakeFeeRepository.feeSchedules = append(fakeFeeRepository.feeSchedules, *feeSchedule)
+	return nil
+}
+
+func (fakeFeeRepository *FakeFeeRepository) GetFeeSchedules() ([]domain.FeeSchedule, error) {
+	return fakeFeeRepository.feeSchedules, nil
+}
+
+func (fakeFeeRepository *FakeFeeRepository) GetFeeScheduleByID(id int64) (*domain.FeeSchedule, error) {
+	for _, feeSchedule := range fakeFeeRepository.feeSchedules {
+		if feeSchedule.ID == id {
+			return &feeSchedule, nil
+		}
+	}
+	return nil, persistence.ErrFeeScheduleNotFound
+}
+
+func (fakeFeeRepository *FakeFeeRepository) FindFeeSchedule(transactionType domain.TransactionType, currency domain.Currency, role string) (*domain.FeeSchedule, error) {
+	var defaultSchedule *domain.FeeSchedule
+	for _, feeSchedule := range fakeFeeRepository.feeSchedules {
+		if feeSchedule.TransactionType != transactionType || feeSchedule.Currency != currency {
+			continue
+		}
+		if feeSchedule.Role != "" && feeSchedule.Role == role {
+			return &feeSchedule, nil
+		}
+		if feeSchedule.Role == "" {
+			defaultSchedule = &feeSchedule
+		}
+	}
+	return defaultSchedule, nil
+}
+
+func (fakeFeeRepository *FakeFeeRepository) UpdateFeeSchedule(feeSchedule *domain.FeeSchedule) error {
+	for i := range fakeFeeRepository.feeSchedules {
+		if fakeFeeRepository.feeSchedules[i].ID == feeSchedule.ID {
+			fakeFeeRepository.feeSchedules[i] = *feeSchedule
+			return nil
+		}
+	}
+	return persistence.ErrFeeScheduleNotFound
+}
+
+func (fakeFeeRepository *FakeFeeRepository) DeleteFeeSchedule(id int64) error {
+	for i := range fakeFeeRepository.feeSchedules {
+		if fakeFeeRepository.feeSchedules[i].ID == id {
+			fakeFeeRepository.feeSchedules = append(fakeFeeRepository.feeSchedules[:i], fakeFeeRepository.feeSchedules[i+1:]...)
+			return nil
+		}
+	}
+	return persistence.ErrFeeScheduleNotFound
+}
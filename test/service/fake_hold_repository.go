@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeHoldRepository struct {
+	holds []domain.Hold
+}
+
+func NewFakeHoldRepository() *FakeHoldRepository {
+	return &FakeHoldRepository{}
+}
+
+func (fakeHoldRepository *FakeHoldRepository) CreateHold(hold *domain.Hold) error {
+	fakeHoldRepository.holds = append(fakeHoldRepository.holds, *hold)
+	return nil
+}
+
+func (fakeHoldRepository *FakeHoldRepository) GetHoldByTransactionID(transactionID int64) (*domain.Hold, error) {
+	for _, hold := range fakeHoldRepository.holds {
+		if hold.TransactionID == transactionID {
+			return &hold, nil
+		}
+	}
+	return nil, persistence.ErrHoldNotFound
+}
+
+func (fakeHoldRepository *FakeHoldRepository) GetHoldByTransactionIDForUpdate(transactionID int64) (*domain.Hold, error) {
+	return fakeHoldRepository.GetHoldByTransactionID(transactionID)
+}
+
+func (fakeHoldRepository *FakeHoldRepository) GetExpiredHolds(now time.Time, limit int) ([]domain.Hold, error) {
+	var holds []domain.Hold
+	for _, hold := range fakeHoldRepository.holds {
+		if hold.Status == domain.Authorized && hold.IsExpired(now) && len(holds) < limit {
+			holds = append(holds, hold)
+		}
+	}
+	return holds, nil
+}
+
+func (fakeHoldRepository *FakeHoldRepository) UpdateHold(hold *domain.Hold) error {
+	for i := range fakeHoldRepository.holds {
+		if fakeHoldRepository.holds[i].TransactionID == hold.TransactionID {
+			fakeHoldRepository.holds[i] = *hold
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeIdempotencyRepository struct {
+	records map[string]domain.IdempotencyRecord
+}
+
+func NewFakeIdempotencyRepository() *FakeIdempotencyRepository {
+	return &FakeIdempotencyRepository{records: map[string]domain.IdempotencyRecord{}}
+}
+
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) Reserve(key string, requestFingerprint string, now time.Time) error {
+	if _, ok := fakeIdempotencyRepository.records[key]; ok {
+		return persistence.ErrIdempotencyKeyExists
+	}
+	fakeIdempotencyRepository.records[key] = domain.IdempotencyRecord{Key: key, RequestFingerprint: requestFingerprint, CreatedAt: now}
+	return nil
+}
+
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) GetByKey(key string) (*domain.IdempotencyRecord, error) {
+	record, ok := fakeIdempotencyRepository.records[key]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) Complete(key string, statusCode int, responseBody []byte, now time.Time) error {
+	record := fakeIdempotencyRepository.records[key]
+	record.StatusCode = statusCode
+	record.ResponseBody = responseBody
+	record.CompletedAt = &now
+	fakeIdempotencyRepository.records[key] = record
+	return nil
+}
+
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) Reclaim(key string, staleBefore time.Time, now time.Time) (bool, error) {
+	record, ok := fakeIdempotencyRepository.records[key]
+	if !ok || record.CompletedAt != nil || record.CreatedAt.After(staleBefore) {
+		return false, nil
+	}
+	record.CreatedAt = now
+	fakeIdempotencyRepository.records[key] = record
+	return true, nil
+}
+
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) Delete(key string) error {
+	delete(fakeIdempotencyRepository.records, key)
+	return nil
+}
+
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) DeleteExpired(key string, completedBefore time.Time) error {
+	record, ok := fakeIdempotencyRepository.records[key]
+	if ok && record.CompletedAt != nil && !record.CompletedAt.After(completedBefore) {
+		delete(fakeIdempotencyRepository.records, key)
+	}
+	return nil
+}
+
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) Purge(completedBefore time.Time, inFlightBefore time.Time, limit int) (int64, error) {
+	var purged int64
+	for key, record := range fakeIdempotencyRepository.records {
+		if purged == int64(limit) {
+			break
+		}
+		if (record.CompletedAt != nil && !record.CompletedAt.After(completedBefore)) || (record.CompletedAt == nil && !record.CreatedAt.After(inFlightBefore)) {
+			delete(fakeIdempotencyRepository.records, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+/* age moves the key's timestamps back by d, as if it was stored that long ago */
+func (fakeIdempotencyRepository *FakeIdempotencyRepository) age(key string, d time.Duration) {
+	record := fakeIdempotencyRepository.records[key]
+	record.CreatedAt = record.CreatedAt.Add(-d)
+	if record.CompletedAt != nil {
+		completedAt := record.CompletedAt.Add(-d)
+		record.CompletedAt = &completedAt
+	}
+	fakeIdempotencyRepository.records[key] = record
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeLedgerRepository struct {
+	mu       sync.Mutex
+	accounts []domain.LedgerAccount
+	entries  []domain.JournalEntry
+}
+
+func NewFakeLedgerRepository() *FakeLedgerRepository {
+	fakeLedgerRepository := &FakeLedgerRepository{}
+	for _, currency := range []domain.Currency{domain.TRY, domain.EUR, domain.USD} {
+		fakeLedgerRepository.accounts = append(fakeLedgerRepository.accounts, domain.LedgerAccount{
+			ID:       int64(len(fakeLedgerRepository.accounts) + 1),
+			Code:     domain.ExternalAccountCode(currency),
+			Currency: currency,
+			Type:     domain.SystemLedgerAccount,
+		})
+	}
+	for _, currency := range []domain.Currency{domain.TRY, domain.EUR, domain.USD} {
+		fakeLedgerRepository.accounts = append(fakeLedgerRepository.accounts, domain.LedgerAccount{
+			ID:       int64(len(fakeLedgerRepository.accounts) + 1),
+			Code:     domain.FeeHouseAccountCode(currency),
+			Currency: currency,
+			Type:     domain.SystemLedgerAccount,
+		})
+	}
+	for _, currency := range []domain.Currency{domain.TRY, domain.EUR, domain.USD} {
+		fakeLedgerRepository.accounts = append(fakeLedgerRepository.accounts, domain.LedgerAccount{
+			ID:       int64(len(fakeLedgerRepository.accounts) + 1),
+			Code:     domain.SavingsAccountCode(currency),
+			Currency: currency,
+			Type:     domain.SystemLedgerAccount,
+		})
+	}
+	return fakeLedgerRepository
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) GetAccountByCode(code string) (*domain.LedgerAccount, error) {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+	return fakeLedgerRepository.accountByCode(code)
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) accountByCode(code string) (*domain.LedgerAccount, error) {
+	for _, account := range fakeLedgerRepository.accounts {
+		if account.Code == code {
+			return &account, nil
+		}
+	}
+	return nil, persistence.ErrLedgerAccountNotFound
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) GetOrCreateUserAccount(userID int64, currency domain.Currency) (*domain.LedgerAccount, error) {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+
+	account, err := fakeLedgerRepository.accountByCode(domain.UserAccountCode(userID, currency))
+	if err == nil {
+		return account, nil
+	}
+
+	created := domain.LedgerAccount{
+		ID:       int64(len(fakeLedgerRepository.accounts) + 1),
+		Code:     domain.UserAccountCode(userID, currency),
+		UserID:   &userID,
+		Currency: currency,
+		Type:     domain.UserLedgerAccount,
+	}
+	fakeLedgerRepository.accounts = append(fakeLedgerRepository.accounts, created)
+	return &created, nil
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) CreateJournalEntry(entry *domain.JournalEntry) error {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	entry.ID = int64(len(fakeLedgerRepository.entries) + 1)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	for i := range entry.Postings {
+		entry.Postings[i].JournalEntryID = entry.ID
+		entry.Postings[i].CreatedAt = entry.CreatedAt
+	}
+	fakeLedgerRepository.entries = append(fakeLedgerRepository.entries, *entry)
+	return nil
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) GetAccountBalance(accountID int64) (domain.Money, error) {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+
+	balance := domain.Zero(fakeLedgerRepository.accounts[accountID-1].Currency)
+	for _, posting := range fakeLedgerRepository.postings(accountID) {
+		balance, _ = balance.Add(posting.Amount)
+	}
+	return balance, nil
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) GetAccountPostings(accountID int64) ([]domain.Posting, error) {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+
+	return fakeLedgerRepository.postings(accountID), nil
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) GetUserAccounts() ([]domain.LedgerAccount, error) {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+
+	var accounts []domain.LedgerAccount
+	for _, account := range fakeLedgerRepository.accounts {
+		if account.Type == domain.UserLedgerAccount {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) GetAccountBalanceAt(accountID int64, at time.Time) (domain.Money, error) {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+
+	balance := domain.Zero(fakeLedgerRepository.accounts[accountID-1].Currency)
+	for _, posting := range fakeLedgerRepository.postings(accountID) {
+		if posting.CreatedAt.Before(at) {
+			balance, _ = balance.Add(posting.Amount)
+		}
+	}
+	return balance, nil
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) GetAccountPostingsBetween(accountID int64, from time.Time, to time.Time) ([]domain.Posting, error) {
+	fakeLedgerRepository.mu.Lock()
+	defer fakeLedgerRepository.mu.Unlock()
+
+	var postings []domain.Posting
+	for _, posting := range fakeLedgerRepository.postings(accountID) {
+		if !posting.CreatedAt.Before(from) && posting.CreatedAt.Before(to) {
+			postings = append(postings, posting)
+		}
+	}
+	return postings, nil
+}
+
+func (fakeLedgerRepository *FakeLedgerRepository) postings(accountID int64) []domain.Posting {
+	var postings []domain.Posting
+	for _, entry := range fakeLedgerRepository.entries {
+		for _, posting := range entry.Postings {
+			if posting.AccountID == accountID {
+				postings = append(postings, posting)
+			}
+		}
+	}
+	return postings
+}
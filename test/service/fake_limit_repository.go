@@ -0,0 +1,69 @@
+package service
+
+import (
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeLimitRepository struct {
+	limitProfiles []domain.LimitProfile
+}
+
+func NewFakeLimitRepository() *FakeLimitRepository {
+	return &FakeLimitRepository{}
+}
+
+func (fakeLimitRepository *FakeLimitRepository) CreateLimitProfile(limitProfile *domain.LimitProfile) error {
+	limitProfile.ID = int64(len(fakeLimitRepository.limitProfiles) + 1)
+	fakeLimitRepository.limitProfiles = append(fakeLimitRepository.limitProfiles, *limitProfile)
+	return nil
+}
+
+func (fakeLimitRepository *FakeLimitRepository) GetLimitProfiles() ([]domain.LimitProfile, error) {
+	return fakeLimitRepository.limitProfiles, nil
+}
+
+func (fakeLimitRepository *FakeLimitRepository) GetLimitProfileByID(id int64) (*domain.LimitProfile, error) {
+	for _, limitProfile := range fakeLimitRepository.limitProfiles {
+		if limitProfile.ID == id {
+			return &limitProfile, nil
+		}
+	}
+	return nil, persistence.ErrLimitProfileNotFound
+}
+
+func (fakeLimitRepository *FakeLimitRepository) FindLimitProfile(userID int64, role string, transactionType domain.TransactionType, currency domain.Currency) (*domain.LimitProfile, error) {
+	var roleProfile *domain.LimitProfile
+	for _, limitProfile := range fakeLimitRepository.limitProfiles {
+		if limitProfile.TransactionType != transactionType || limitProfile.Currency != currency {
+			continue
+		}
+		if limitProfile.UserID != nil && *limitProfile.UserID == userID {
+			return &limitProfile, nil
+		}
+		if limitProfile.Role != "" && limitProfile.Role == role {
+			roleProfile = &limitProfile
+		}
+	}
+	return roleProfile, nil
+}
+
+func (fakeLimitRepository *FakeLimitRepository) UpdateLimitProfile(limitProfile *domain.LimitProfile) error {
+	for i := range fakeLimitRepository.limitProfiles {
+		if fakeLimitRepository.limitProfiles[i].ID == limitProfile.ID {
+			fakeLimitRepository.limitProfiles[i] = *limitProfile
+			return nil
+		}
+	}
+	return persistence.ErrLimitProfileNotFound
+}
+
+func (fakeLimitRepository *FakeLimitRepository) DeleteLimitProfile(id int64) error {
+	for i := range fakeLimitRepository.limitProfiles {
+		if fakeLimitRepository.limitProfiles[i].ID == id {
+			fakeLimitRepository.limitProfiles = append(fakeLimitRepository.limitProfiles[:i], fakeLimitRepository.limitProfiles[i+1:]...)
+			return nil
+		}
+	}
+	return persistence.ErrLimitProfileNotFound
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* FakeOverdraftRepository mirrors every credit limit into the fake balances, like the real balance query joins it in */
+type FakeOverdraftRepository struct {
+	facilities        map[walletKey]domain.OverdraftFacility
+	balanceRepository *FakeBalanceRepository
+}
+
+func NewFakeOverdraftRepository(balanceRepository *FakeBalanceRepository) *FakeOverdraftRepository {
+	return &FakeOverdraftRepository{
+		facilities:        map[walletKey]domain.OverdraftFacility{},
+		balanceRepository: balanceRepository,
+	}
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) SaveFacility(facility *domain.OverdraftFacility) error {
+	now := time.Now()
+	key := walletKey{facility.UserID, facility.Currency}
+	if existing, ok := fakeOverdraftRepository.facilities[key]; ok {
+		facility.CreatedAt = existing.CreatedAt
+		facility.LastChargedOn = existing.LastChargedOn
+	} else {
+		facility.CreatedAt = now
+	}
+	facility.UpdatedAt = now
+
+	fakeOverdraftRepository.facilities[key] = *facility
+	fakeOverdraftRepository.balanceRepository.setCreditLimit(facility.UserID, facility.CreditLimit)
+	return nil
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) GetFacility(userID int64, currency domain.Currency) (*domain.OverdraftFacility, error) {
+	facility, ok := fakeOverdraftRepository.facilities[walletKey{userID, currency}]
+	if !ok {
+		return nil, persistence.ErrOverdraftNotFound
+	}
+	return &facility, nil
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) GetFacilityForUpdate(userID int64, currency domain.Currency) (*domain.OverdraftFacility, error) {
+	return fakeOverdraftRepository.GetFacility(userID, currency)
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) GetFacilities() ([]domain.OverdraftFacility, error) {
+	var facilities []domain.OverdraftFacility
+	for _, facility := range fakeOverdraftRepository.facilities {
+		facilities = append(facilities, facility)
+	}
+	sort.Slice(facilities, func(i, j int) bool {
+		if facilities[i].UserID != facilities[j].UserID {
+			return facilities[i].UserID < facilities[j].UserID
+		}
+		return facilities[i].Currency < facilities[j].Currency
+	})
+	return facilities, nil
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) GetFacilitiesToCharge(day time.Time, limit int) ([]domain.OverdraftFacility, error) {
+	facilities, _ := fakeOverdraftRepository.GetFacilities()
+
+	var due []domain.OverdraftFacility
+	for _, facility := range facilities {
+		if facility.DailyRateBps > 0 && !facility.IsChargedOn(day) && len(due) < limit {
+			due = append(due, facility)
+		}
+	}
+	return due, nil
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) MarkCharged(userID int64, currency domain.Currency, day time.Time) error {
+	key := walletKey{userID, currency}
+	facility, ok := fakeOverdraftRepository.facilities[key]
+	if !ok {
+		return persistence.ErrOverdraftNotFound
+	}
+	facility.LastChargedOn = &day
+	fakeOverdraftRepository.facilities[key] = facility
+	return nil
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) DeleteFacility(userID int64, currency domain.Currency) error {
+	key := walletKey{userID, currency}
+	if _, ok := fakeOverdraftRepository.facilities[key]; !ok {
+		return persistence.ErrOverdraftNotFound
+	}
+	delete(fakeOverdraftRepository.facilities, key)
+	fakeOverdraftRepository.balanceRepository.setCreditLimit(userID, domain.Zero(currency))
+	return nil
+}
+
+func (fakeOverdraftRepository *FakeOverdraftRepository) snapshot() map[walletKey]domain.OverdraftFacility {
+	copied := map[walletKey]domain.OverdraftFacility{}
+	for key, facility := range fakeOverdraftRepository.facilities {
+		copied[key] = facility
+	}
+	return copied
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakePocketRepository struct {
+	pockets []domain.Pocket
+}
+
+func NewFakePocketRepository() *FakePocketRepository {
+	return &FakePocketRepository{}
+}
+
+func (fakePocketRepository *FakePocketRepository) CreatePocket(pocket *domain.Pocket) error {
+	pocket.ID = int64(len(fakePocketRepository.pockets) + 1)
+	fakePocketRepository.pockets = append(fakePocketRepository.pockets, *pocket)
+	return nil
+}
+
+func (fakePocketRepository *FakePocketRepository) GetPocketByID(id int64) (*domain.Pocket, error) {
+	for _, pocket := range fakePocketRepository.pockets {
+		if pocket.ID == id {
+			return &pocket, nil
+		}
+	}
+	return nil, persistence.ErrPocketNotFound
+}
+
+func (fakePocketRepository *FakePocketRepository) GetPocketByIDForUpdate(id int64) (*domain.Pocket, error) {
+	return fakePocketRepository.GetPocketByID(id)
+}
+
+func (fakePocketRepository *FakePocketRepository) GetOpenPockets(userID int64, currency domain.Currency) ([]domain.Pocket, error) {
+	var pockets []domain.Pocket
+	for _, pocket := range fakePocketRepository.pockets {
+		if pocket.UserID == userID && pocket.Currency == currency && pocket.IsOpen() {
+			pockets = append(pockets, pocket)
+		}
+	}
+	return pockets, nil
+}
+
+func (fakePocketRepository *FakePocketRepository) UpdatePocket(pocket *domain.Pocket) error {
+	for i := range fakePocketRepository.pockets {
+		if fakePocketRepository.pockets[i].ID == pocket.ID {
+			fakePocketRepository.pockets[i] = *pocket
+			return nil
+		}
+	}
+	return persistence.ErrPocketNotFound
+}
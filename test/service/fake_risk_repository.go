@@ -0,0 +1,49 @@
+package service
+
+import (
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeRiskRepository struct {
+	reviews []domain.RiskReview
+}
+
+func NewFakeRiskRepository() *FakeRiskRepository {
+	return &FakeRiskRepository{}
+}
+
+func (fakeRiskRepository *FakeRiskRepository) CreateRiskReview(review *domain.RiskReview) error {
+	review.ID = int64(len(fakeRiskRepository.reviews) + 1)
+	fakeRiskRepository.reviews = append(fakeRiskRepository.reviews, *review)
+	return nil
+}
+
+func (fakeRiskRepository *FakeRiskRepository) GetRiskReviewByID(id int64) (*domain.RiskReview, error) {
+	for _, review := range fakeRiskRepository.reviews {
+		if review.ID == id {
+			return &review, nil
+		}
+	}
+	return nil, persistence.ErrRiskReviewNotFound
+}
+
+func (fakeRiskRepository *FakeRiskRepository) GetRiskReviewsByStatus(status domain.RiskReviewStatus) ([]domain.RiskReview, error) {
+	var reviews []domain.RiskReview
+	for _, review := range fakeRiskRepository.reviews {
+		if review.Status == status {
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews, nil
+}
+
+func (fakeRiskRepository *FakeRiskRepository) ResolveRiskReview(review *domain.RiskReview) (bool, error) {
+	for i := range fakeRiskRepository.reviews {
+		if fakeRiskRepository.reviews[i].ID == review.ID && fakeRiskRepository.reviews[i].Status == domain.RiskReviewOpen {
+			fakeRiskRepository.reviews[i] = *review
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeSavingsGoalRepository struct {
+	goals  []domain.SavingsGoal
+	rules  []domain.SweepRule
+	sweeps []domain.SavingsSweep
+}
+
+func NewFakeSavingsGoalRepository() *FakeSavingsGoalRepository {
+	return &FakeSavingsGoalRepository{}
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) CreateGoal(goal *domain.SavingsGoal) error {
+	goal.ID = int64(len(fakeSavingsGoalRepository.goals) + 1)
+	fakeSavingsGoalRepository.goals = append(fakeSavingsGoalRepository.goals, *goal)
+	return nil
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetGoalByID(id int64) (*domain.SavingsGoal, error) {
+	for _, goal := range fakeSavingsGoalRepository.goals {
+		if goal.ID == id {
+			return &goal, nil
+		}
+	}
+	return nil, persistence.ErrSavingsGoalNotFound
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetGoalByIDForUpdate(id int64) (*domain.SavingsGoal, error) {
+	return fakeSavingsGoalRepository.GetGoalByID(id)
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetGoalsByUserID(userID int64) ([]domain.SavingsGoal, error) {
+	var goals []domain.SavingsGoal
+	for _, goal := range fakeSavingsGoalRepository.goals {
+		if goal.UserID == userID {
+			goals = append(goals, goal)
+		}
+	}
+	return goals, nil
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) UpdateGoal(goal *domain.SavingsGoal) error {
+	for i := range fakeSavingsGoalRepository.goals {
+		if fakeSavingsGoalRepository.goals[i].ID == goal.ID {
+			fakeSavingsGoalRepository.goals[i] = *goal
+			return nil
+		}
+	}
+	return persistence.ErrSavingsGoalNotFound
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) CreateRule(rule *domain.SweepRule) error {
+	rule.ID = int64(len(fakeSavingsGoalRepository.rules) + 1)
+	fakeSavingsGoalRepository.rules = append(fakeSavingsGoalRepository.rules, *rule)
+	return nil
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetRuleByIDForUpdate(id int64) (*domain.SweepRule, error) {
+	for _, rule := range fakeSavingsGoalRepository.rules {
+		if rule.ID == id {
+			return &rule, nil
+		}
+	}
+	return nil, persistence.ErrSweepRuleNotFound
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetRulesByGoalID(goalID int64) ([]domain.SweepRule, error) {
+	var rules []domain.SweepRule
+	for _, rule := range fakeSavingsGoalRepository.rules {
+		if rule.GoalID == goalID {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetActiveRules(userID int64, currency domain.Currency, ruleType domain.SweepRuleType) ([]domain.SweepRule, error) {
+	var rules []domain.SweepRule
+	for _, rule := range fakeSavingsGoalRepository.rules {
+		if rule.UserID == userID && rule.Currency == currency && rule.Type == ruleType && rule.Active {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetDueRules(now time.Time, limit int) ([]domain.SweepRule, error) {
+	var rules []domain.SweepRule
+	for _, rule := range fakeSavingsGoalRepository.rules {
+		if rule.Active && rule.NextRunAt != nil && !rule.NextRunAt.After(now) && len(rules) < limit {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) UpdateRule(rule *domain.SweepRule) error {
+	for i := range fakeSavingsGoalRepository.rules {
+		if fakeSavingsGoalRepository.rules[i].ID == rule.ID {
+			fakeSavingsGoalRepository.rules[i] = *rule
+			return nil
+		}
+	}
+	return persistence.ErrSweepRuleNotFound
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) CreateSweep(sweep *domain.SavingsSweep) error {
+	sweep.ID = int64(len(fakeSavingsGoalRepository.sweeps) + 1)
+	fakeSavingsGoalRepository.sweeps = append(fakeSavingsGoalRepository.sweeps, *sweep)
+	return nil
+}
+
+func (fakeSavingsGoalRepository *FakeSavingsGoalRepository) GetSweepsByGoalID(goalID int64) ([]domain.SavingsSweep, error) {
+	var sweeps []domain.SavingsSweep
+	for _, sweep := range fakeSavingsGoalRepository.sweeps {
+		if sweep.GoalID == goalID {
+			sweeps = append(sweeps, sweep)
+		}
+	}
+	return sweeps, nil
+}
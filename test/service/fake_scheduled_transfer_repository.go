@@ -0,0 +1,111 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeScheduledTransferRepository struct {
+	scheduledTransfers []domain.ScheduledTransfer
+}
+
+func NewFakeScheduledTransferRepository() *FakeScheduledTransferRepository {
+	return &FakeScheduledTransferRepository{}
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) CreateScheduledTransfer(scheduledTransfer *domain.ScheduledTransfer) error {
+	scheduledTransfer.ID = int64(len(fakeScheduledTransferRepository.scheduledTransfers) + 1)
+	fakeScheduledTransferRepository.scheduledTransfers = append(fakeScheduledTransferRepository.scheduledTransfers, *scheduledTransfer)
+	return nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) GetScheduledTransferByID(id int64) (*domain.ScheduledTransfer, error) {
+	for _, scheduledTransfer := range fakeScheduledTransferRepository.scheduledTransfers {
+		if scheduledTransfer.ID == id {
+			return &scheduledTransfer, nil
+		}
+	}
+	return nil, persistence.ErrScheduledTransferNotFound
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) GetScheduledTransfersByUserID(userID int64, status domain.ScheduledTransferStatus) ([]domain.ScheduledTransfer, error) {
+	var scheduledTransfers []domain.ScheduledTransfer
+	for _, scheduledTransfer := range fakeScheduledTransferRepository.scheduledTransfers {
+		if scheduledTransfer.FromUser == userID && scheduledTransfer.Status == status {
+			scheduledTransfers = append(scheduledTransfers, scheduledTransfer)
+		}
+	}
+	return scheduledTransfers, nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) GetDueScheduledTransfers(now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	var scheduledTransfers []domain.ScheduledTransfer
+	for _, scheduledTransfer := range fakeScheduledTransferRepository.scheduledTransfers {
+		if scheduledTransfer.Status == domain.Scheduled && !scheduledTransfer.ExecuteAt.After(now) && len(scheduledTransfers) < limit {
+			scheduledTransfers = append(scheduledTransfers, scheduledTransfer)
+		}
+	}
+	return scheduledTransfers, nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) GetStaleExecutingScheduledTransfers(updatedBefore time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	var scheduledTransfers []domain.ScheduledTransfer
+	for _, scheduledTransfer := range fakeScheduledTransferRepository.scheduledTransfers {
+		if scheduledTransfer.Status == domain.ScheduledExecuting && scheduledTransfer.UpdatedAt.Before(updatedBefore) && len(scheduledTransfers) < limit {
+			scheduledTransfers = append(scheduledTransfers, scheduledTransfer)
+		}
+	}
+	return scheduledTransfers, nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) UpdateStatus(id int64, from domain.ScheduledTransferStatus, to domain.ScheduledTransferStatus) (bool, error) {
+	scheduledTransfer := fakeScheduledTransferRepository.find(id)
+	if scheduledTransfer == nil || scheduledTransfer.Status != from {
+		return false, nil
+	}
+	scheduledTransfer.Status = to
+	scheduledTransfer.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) MarkCompleted(id int64, transactionID int64) error {
+	scheduledTransfer := fakeScheduledTransferRepository.find(id)
+	scheduledTransfer.Status = domain.ScheduledCompleted
+	scheduledTransfer.TransactionID = &transactionID
+	return nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) MarkAwaitingApproval(id int64, transactionID int64) error {
+	scheduledTransfer := fakeScheduledTransferRepository.find(id)
+	scheduledTransfer.Status = domain.ScheduledAwaitingApproval
+	scheduledTransfer.TransactionID = &transactionID
+	return nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) MarkFailed(id int64, reason string) error {
+	scheduledTransfer := fakeScheduledTransferRepository.find(id)
+	scheduledTransfer.Status = domain.ScheduledFailed
+	scheduledTransfer.FailureReason = reason
+	return nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) FailStaleScheduledTransfer(id int64, updatedBefore time.Time, reason string) (bool, error) {
+	scheduledTransfer := fakeScheduledTransferRepository.find(id)
+	if scheduledTransfer == nil || scheduledTransfer.Status != domain.ScheduledExecuting || !scheduledTransfer.UpdatedAt.Before(updatedBefore) {
+		return false, nil
+	}
+	scheduledTransfer.Status = domain.ScheduledFailed
+	scheduledTransfer.FailureReason = reason
+	return true, nil
+}
+
+func (fakeScheduledTransferRepository *FakeScheduledTransferRepository) find(id int64) *domain.ScheduledTransfer {
+	for i := range fakeScheduledTransferRepository.scheduledTransfers {
+		if fakeScheduledTransferRepository.scheduledTransfers[i].ID == id {
+			return &fakeScheduledTransferRepository.scheduledTransfers[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeStandingOrderRepository struct {
+	standingOrders []domain.StandingOrder
+	attempts       []domain.StandingOrderAttempt
+}
+
+func NewFakeStandingOrderRepository() *FakeStandingOrderRepository {
+	return &FakeStandingOrderRepository{}
+}
+
+func (fakeStandingOrderRepository *FakeStandingOrderRepository) CreateStandingOrder(standingOrder *domain.StandingOrder) error {
+	standingOrder.ID = int64(len(fakeStandingOrderRepository.standingOrders) + 1)
+	fakeStandingOrderRepository.standingOrders = append(fakeStandingOrderRepository.standingOrders, *standingOrder)
+	return nil
+}
+
+func (fakeStandingOrderRepository *FakeStandingOrderRepository) GetStandingOrderByID(id int64) (*domain.StandingOrder, error) {
+	for _, standingOrder := range fakeStandingOrderRepository.standingOrders {
+		if standingOrder.ID == id {
+			return &standingOrder, nil
+		}
+	}
+	return nil, persistence.ErrStandingOrderNotFound
+}
+
+func (fakeStandingOrderRepository *FakeStandingOrderRepository) GetStandingOrdersByUserID(userID int64) ([]domain.StandingOrder, error) {
+	var standingOrders []domain.StandingOrder
+	for _, standingOrder := range fakeStandingOrderRepository.standingOrders {
+		if standingOrder.FromUser == userID {
+			standingOrders = append(standingOrders, standingOrder)
+		}
+	}
+	return standingOrders, nil
+}
+
+func (fakeStandingOrderRepository *FakeStandingOrderRepository) GetDueStandingOrders(now time.Time, limit int) ([]domain.StandingOrder, error) {
+	var standingOrders []domain.StandingOrder
+	for _, standingOrder := range fakeStandingOrderRepository.standingOrders {
+		if standingOrder.Status == domain.StandingOrderActive && !standingOrder.NextRunAt.After(now) && len(standingOrders) < limit {
+			standingOrders = append(standingOrders, standingOrder)
+		}
+	}
+	return standingOrders, nil
+}
+
+func (fakeStandingOrderRepository *FakeStandingOrderRepository) UpdateStandingOrder(standingOrder *domain.StandingOrder, expectedNextRunAt time.Time) (bool, error) {
+	for i := range fakeStandingOrderRepository.standingOrders {
+		stored := &fakeStandingOrderRepository.standingOrders[i]
+		if stored.ID == standingOrder.ID {
+			if stored.Status != domain.StandingOrderActive || !stored.NextRunAt.Equal(expectedNextRunAt) {
+				return false, nil
+			}
+			*stored = *standingOrder
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (fakeStandingOrderRepository *FakeStandingOrderRepository) CreateAttempt(attempt *domain.StandingOrderAttempt) error {
+	attempt.ID = int64(len(fakeStandingOrderRepository.attempts) + 1)
+	fakeStandingOrderRepository.attempts = append(fakeStandingOrderRepository.attempts, *attempt)
+	return nil
+}
+
+func (fakeStandingOrderRepository *FakeStandingOrderRepository) GetAttempts(standingOrderID int64) ([]domain.StandingOrderAttempt, error) {
+	var attempts []domain.StandingOrderAttempt
+	for _, attempt := range fakeStandingOrderRepository.attempts {
+		if attempt.StandingOrderID == standingOrderID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeTransactionRepository struct {
+	transactions  []domain.Transaction
+	statusHistory []domain.TransactionStatusChange
+}
+
+func NewFakeTransactionRepository() *FakeTransactionRepository {
+	return &FakeTransactionRepository{}
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) CreateTransaction(transaction *domain.Transaction) error {
+	transaction.ID = int64(len(fakeTransactionRepository.transactions) + 1)
+	fakeTransactionRepository.transactions = append(fakeTransactionRepository.transactions, *transaction)
+	fakeTransactionRepository.recordStatusChange(transaction.ID, nil, transaction.Status, "created")
+	return nil
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) GetTransactionByID(id int64) (*domain.Transaction, error) {
+	for _, transaction := range fakeTransactionRepository.transactions {
+		if transaction.ID == id {
+			return &transaction, nil
+		}
+	}
+	return nil, nil
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) GetTransactionByIDForUpdate(id int64) (*domain.Transaction, error) {
+	return fakeTransactionRepository.GetTransactionByID(id)
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) GetChildTransactions(parentID int64) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	for _, transaction := range fakeTransactionRepository.transactions {
+		if transaction.ParentID != nil && *transaction.ParentID == parentID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) UpdateTransactionStatus(id int64, from domain.TransactionStatus, to domain.TransactionStatus, reason string) error {
+	if !from.CanTransitionTo(to) {
+		return domain.ErrInvalidStatusTransition
+	}
+
+	for i := range fakeTransactionRepository.transactions {
+		if fakeTransactionRepository.transactions[i].ID == id && fakeTransactionRepository.transactions[i].Status == from {
+			fakeTransactionRepository.transactions[i].Status = to
+			fakeTransactionRepository.recordStatusChange(id, &from, to, reason)
+			return nil
+		}
+	}
+	return persistence.ErrTransactionStatusConflict
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) GetTransactionStatusHistory(transactionID int64) ([]domain.TransactionStatusChange, error) {
+	var changes []domain.TransactionStatusChange
+	for _, change := range fakeTransactionRepository.statusHistory {
+		if change.TransactionID == transactionID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) recordStatusChange(transactionID int64, from *domain.TransactionStatus, to domain.TransactionStatus, reason string) {
+	fakeTransactionRepository.statusHistory = append(fakeTransactionRepository.statusHistory, domain.TransactionStatusChange{
+		ID:            int64(len(fakeTransactionRepository.statusHistory) + 1),
+		TransactionID: transactionID,
+		From:          from,
+		To:            to,
+		Reason:        reason,
+		ChangedAt:     time.Now(),
+	})
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) GetUserTransactions(userID int64, filter domain.TransactionFilter) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	for _, transaction := range fakeTransactionRepository.transactions {
+		if transaction.FromUser == userID || (transaction.ToUser != nil && *transaction.ToUser == userID) {
+			if matchesTransactionFilter(transaction, userID, filter) {
+				transactions = append(transactions, transaction)
+			}
+		}
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if filter.Ascending {
+			return isBefore(transactions[i], transactions[j])
+		}
+		return isBefore(transactions[j], transactions[i])
+	})
+	if filter.Limit > 0 && len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+	}
+	return transactions, nil
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) StreamUserTransactions(userID int64, currency domain.Currency, from time.Time, to time.Time, fn func(domain.Transaction) error) error {
+	transactions, _ := fakeTransactionRepository.GetUserTransactions(userID, domain.TransactionFilter{Currency: currency, From: &from, To: &to, Ascending: true})
+	for _, transaction := range transactions {
+		if !transaction.MovedBalances() {
+			continue
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchesTransactionFilter(transaction domain.Transaction, userID int64, filter domain.TransactionFilter) bool {
+	if len(filter.Types) > 0 && !slices.Contains(filter.Types, transaction.Type) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, transaction.Status) {
+		return false
+	}
+	if filter.Counterparty != nil {
+		sentToCounterparty := transaction.FromUser == userID && transaction.ToUser != nil && *transaction.ToUser == *filter.Counterparty
+		receivedFromCounterparty := transaction.FromUser == *filter.Counterparty
+		if !sentToCounterparty && !receivedFromCounterparty {
+			return false
+		}
+	}
+	if filter.Currency != "" && transaction.Currency != filter.Currency {
+		return false
+	}
+	if filter.MinAmount != nil {
+		if cmp, _ := transaction.Amount.Abs().Cmp(*filter.MinAmount); cmp < 0 {
+			return false
+		}
+	}
+	if filter.MaxAmount != nil {
+		if cmp, _ := transaction.Amount.Abs().Cmp(*filter.MaxAmount); cmp > 0 {
+			return false
+		}
+	}
+	if filter.From != nil && transaction.CreatedAt.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && !transaction.CreatedAt.Before(*filter.To) {
+		return false
+	}
+	if filter.After != nil {
+		after := domain.Transaction{ID: filter.After.ID, CreatedAt: filter.After.CreatedAt}
+		if filter.Ascending {
+			return isBefore(after, transaction)
+		}
+		return isBefore(transaction, after)
+	}
+	return true
+}
+
+func isBefore(transaction domain.Transaction, other domain.Transaction) bool {
+	if !transaction.CreatedAt.Equal(other.CreatedAt) {
+		return transaction.CreatedAt.Before(other.CreatedAt)
+	}
+	return transaction.ID < other.ID
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) GetUsageSince(userID int64, transactionType domain.TransactionType, currency domain.Currency, since time.Time) (domain.Money, int, error) {
+	total := domain.Zero(currency)
+	count := 0
+	for _, transaction := range fakeTransactionRepository.transactions {
+		if transaction.FromUser != userID || transaction.LimitType() != transactionType || transaction.Currency != currency ||
+			transaction.Status != domain.Completed || transaction.CreatedAt.Before(since) {
+			continue
+		}
+		total, _ = total.Add(transaction.Amount.Abs())
+		count++
+	}
+	return total, count, nil
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) GetHistoricalUsage(userID int64, transactionType domain.TransactionType, currency domain.Currency) (domain.Money, int, error) {
+	return fakeTransactionRepository.GetUsageSince(userID, transactionType, currency, time.Time{})
+}
+
+func (fakeTransactionRepository *FakeTransactionRepository) HasCompletedTransfer(fromUserID int64, toUserID int64) (bool, error) {
+	for _, transaction := range fakeTransactionRepository.transactions {
+		if transaction.Type == domain.TransferTransaction && transaction.FromUser == fromUserID && transaction.ToUser != nil && *transaction.ToUser == toUserID &&
+			(transaction.Status == domain.Completed || transaction.Status == domain.Reversed) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+type FakeTransferBatchRepository struct {
+	transferBatches []domain.TransferBatch
+	items           []domain.TransferBatchItem
+}
+
+func NewFakeTransferBatchRepository() *FakeTransferBatchRepository {
+	return &FakeTransferBatchRepository{}
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) CreateTransferBatch(transferBatch *domain.TransferBatch) error {
+	transferBatch.ID = int64(len(fakeTransferBatchRepository.transferBatches) + 1)
+	for i := range transferBatch.Items {
+		transferBatch.Items[i].ID = int64(len(fakeTransferBatchRepository.items) + 1)
+		transferBatch.Items[i].BatchID = transferBatch.ID
+		fakeTransferBatchRepository.items = append(fakeTransferBatchRepository.items, transferBatch.Items[i])
+	}
+
+	stored := *transferBatch
+	stored.Items = nil
+	fakeTransferBatchRepository.transferBatches = append(fakeTransferBatchRepository.transferBatches, stored)
+	return nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) GetTransferBatchByID(id int64) (*domain.TransferBatch, error) {
+	for _, transferBatch := range fakeTransferBatchRepository.transferBatches {
+		if transferBatch.ID != id {
+			continue
+		}
+
+		transferBatch.Progress = domain.TransferBatchProgress{}
+		for _, item := range fakeTransferBatchRepository.items {
+			if item.BatchID != id {
+				continue
+			}
+			transferBatch.Progress.Total++
+			switch item.Status {
+			case domain.BatchItemPending, domain.BatchItemProcessing:
+				transferBatch.Progress.Pending++
+			case domain.BatchItemCompleted:
+				transferBatch.Progress.Completed++
+			case domain.BatchItemFailed:
+				transferBatch.Progress.Failed++
+			case domain.BatchItemCancelled:
+				transferBatch.Progress.Cancelled++
+			case domain.BatchItemAwaitingApproval:
+				transferBatch.Progress.AwaitingApproval++
+			}
+		}
+		return &transferBatch, nil
+	}
+	return nil, persistence.ErrTransferBatchNotFound
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) GetTransferBatchItems(batchID int64) ([]domain.TransferBatchItem, error) {
+	var items []domain.TransferBatchItem
+	for _, item := range fakeTransferBatchRepository.items {
+		if item.BatchID == batchID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) GetPendingTransferBatchItems(limit int) ([]domain.TransferBatchItem, error) {
+	var items []domain.TransferBatchItem
+	for _, item := range fakeTransferBatchRepository.items {
+		transferBatch := fakeTransferBatchRepository.find(item.BatchID)
+		if transferBatch.Status == domain.BatchProcessing && item.Status == domain.BatchItemPending && len(items) < limit {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) GetStaleProcessingItems(updatedBefore time.Time, limit int) ([]domain.TransferBatchItem, error) {
+	var items []domain.TransferBatchItem
+	for _, item := range fakeTransferBatchRepository.items {
+		if item.Status == domain.BatchItemProcessing && item.UpdatedAt.Before(updatedBefore) && len(items) < limit {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) UpdateTransferBatchStatus(id int64, from domain.TransferBatchStatus, to domain.TransferBatchStatus) (bool, error) {
+	transferBatch := fakeTransferBatchRepository.find(id)
+	if transferBatch == nil || transferBatch.Status != from {
+		return false, nil
+	}
+	transferBatch.Status = to
+	return true, nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) UpdateItemStatus(id int64, from domain.TransferBatchItemStatus, to domain.TransferBatchItemStatus) (bool, error) {
+	item := fakeTransferBatchRepository.findItem(id)
+	if item == nil || item.Status != from {
+		return false, nil
+	}
+	item.Status = to
+	item.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) MarkItemCompleted(id int64, transactionID int64) error {
+	item := fakeTransferBatchRepository.findItem(id)
+	item.Status = domain.BatchItemCompleted
+	item.TransactionID = &transactionID
+	return nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) MarkItemAwaitingApproval(id int64, transactionID int64) error {
+	item := fakeTransferBatchRepository.findItem(id)
+	item.Status = domain.BatchItemAwaitingApproval
+	item.TransactionID = &transactionID
+	return nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) MarkItemFailed(id int64, reason string) error {
+	item := fakeTransferBatchRepository.findItem(id)
+	item.Status = domain.BatchItemFailed
+	item.FailureReason = reason
+	return nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) FailStaleItem(id int64, updatedBefore time.Time, reason string) (bool, error) {
+	item := fakeTransferBatchRepository.findItem(id)
+	if item == nil || item.Status != domain.BatchItemProcessing || !item.UpdatedAt.Before(updatedBefore) {
+		return false, nil
+	}
+	item.Status = domain.BatchItemFailed
+	item.FailureReason = reason
+	item.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) CancelPendingItems(batchID int64) error {
+	for i := range fakeTransferBatchRepository.items {
+		if fakeTransferBatchRepository.items[i].BatchID == batchID && fakeTransferBatchRepository.items[i].Status == domain.BatchItemPending {
+			fakeTransferBatchRepository.items[i].Status = domain.BatchItemCancelled
+		}
+	}
+	return nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) find(id int64) *domain.TransferBatch {
+	for i := range fakeTransferBatchRepository.transferBatches {
+		if fakeTransferBatchRepository.transferBatches[i].ID == id {
+			return &fakeTransferBatchRepository.transferBatches[i]
+		}
+	}
+	return nil
+}
+
+func (fakeTransferBatchRepository *FakeTransferBatchRepository) findItem(id int64) *domain.TransferBatchItem {
+	for i := range fakeTransferBatchRepository.items {
+		if fakeTransferBatchRepository.items[i].ID == id {
+			return &fakeTransferBatchRepository.items[i]
+		}
+	}
+	return nil
+}
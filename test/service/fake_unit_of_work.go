@@ -0,0 +1,84 @@
+package service
+
+import (
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+)
+
+/* FakeUnitOfWork restores the fake repositories' state when fn fails */
+type FakeUnitOfWork struct {
+	transactionRepository *FakeTransactionRepository
+	balanceRepository     *FakeBalanceRepository
+	ledgerRepository      *FakeLedgerRepository
+	holdRepository        *FakeHoldRepository
+	approvalRepository    *FakeApprovalRepository
+	riskRepository        *FakeRiskRepository
+	disputeRepository     *FakeDisputeRepository
+	overdraftRepository   *FakeOverdraftRepository
+	pocketRepository      *FakePocketRepository
+	savingsGoalRepository *FakeSavingsGoalRepository
+}
+
+func NewFakeUnitOfWork(transactionRepository *FakeTransactionRepository, balanceRepository *FakeBalanceRepository, ledgerRepository *FakeLedgerRepository, holdRepository *FakeHoldRepository, approvalRepository *FakeApprovalRepository, riskRepository *FakeRiskRepository, disputeRepository *FakeDisputeRepository, overdraftRepository *FakeOverdraftRepository, pocketRepository *FakePocketRepository, savingsGoalRepository *FakeSavingsGoalRepository) persistence.IUnitOfWork {
+	return &FakeUnitOfWork{
+		transactionRepository: transactionRepository,
+		balanceRepository:     balanceRepository,
+		ledgerRepository:      ledgerRepository,
+		holdRepository:        holdRepository,
+		approvalRepository:    approvalRepository,
+		riskRepository:        riskRepository,
+		disputeRepository:     disputeRepository,
+		overdraftRepository:   overdraftRepository,
+		pocketRepository:      pocketRepository,
+		savingsGoalRepository: savingsGoalRepository,
+	}
+}
+
+func (fakeUnitOfWork *FakeUnitOfWork) Execute(fn func(repositories persistence.Repositories) error) error {
+	balances := fakeUnitOfWork.balanceRepository.snapshot()
+	transactions := append([]domain.Transaction{}, fakeUnitOfWork.transactionRepository.transactions...)
+	statusHistory := append([]domain.TransactionStatusChange{}, fakeUnitOfWork.transactionRepository.statusHistory...)
+	accounts := append([]domain.LedgerAccount{}, fakeUnitOfWork.ledgerRepository.accounts...)
+	entries := append([]domain.JournalEntry{}, fakeUnitOfWork.ledgerRepository.entries...)
+	holds := append([]domain.Hold{}, fakeUnitOfWork.holdRepository.holds...)
+	approvals := append([]domain.TransferApproval{}, fakeUnitOfWork.approvalRepository.approvals...)
+	reviews := append([]domain.RiskReview{}, fakeUnitOfWork.riskRepository.reviews...)
+	disputes := append([]domain.Dispute{}, fakeUnitOfWork.disputeRepository.disputes...)
+	disputeEvents := append([]domain.DisputeEvent{}, fakeUnitOfWork.disputeRepository.events...)
+	facilities := fakeUnitOfWork.overdraftRepository.snapshot()
+	pockets := append([]domain.Pocket{}, fakeUnitOfWork.pocketRepository.pockets...)
+	savingsGoals := append([]domain.SavingsGoal{}, fakeUnitOfWork.savingsGoalRepository.goals...)
+	sweepRules := append([]domain.SweepRule{}, fakeUnitOfWork.savingsGoalRepository.rules...)
+	savingsSweeps := append([]domain.SavingsSweep{}, fakeUnitOfWork.savingsGoalRepository.sweeps...)
+
+	err := fn(persistence.Repositories{
+		Transactions: fakeUnitOfWork.transactionRepository,
+		Balances:     fakeUnitOfWork.balanceRepository,
+		Ledger:       fakeUnitOfWork.ledgerRepository,
+		Holds:        fakeUnitOfWork.holdRepository,
+		Approvals:    fakeUnitOfWork.approvalRepository,
+		RiskReviews:  fakeUnitOfWork.riskRepository,
+		Disputes:     fakeUnitOfWork.disputeRepository,
+		Overdrafts:   fakeUnitOfWork.overdraftRepository,
+		Pockets:      fakeUnitOfWork.pocketRepository,
+		SavingsGoals: fakeUnitOfWork.savingsGoalRepository,
+	})
+	if err != nil {
+		fakeUnitOfWork.balanceRepository.balances = balances
+		fakeUnitOfWork.transactionRepository.transactions = transactions
+		fakeUnitOfWork.transactionRepository.statusHistory = statusHistory
+		fakeUnitOfWork.ledgerRepository.accounts = accounts
+		fakeUnitOfWork.ledgerRepository.entries = entries
+		fakeUnitOfWork.holdRepository.holds = holds
+		fakeUnitOfWork.approvalRepository.approvals = approvals
+		fakeUnitOfWork.riskRepository.reviews = reviews
+		fakeUnitOfWork.disputeRepository.disputes = disputes
+		fakeUnitOfWork.disputeRepository.events = disputeEvents
+		fakeUnitOfWork.overdraftRepository.facilities = facilities
+		fakeUnitOfWork.pocketRepository.pockets = pockets
+		fakeUnitOfWork.savingsGoalRepository.goals = savingsGoals
+		fakeUnitOfWork.savingsGoalRepository.rules = sweepRules
+		fakeUnitOfWork.savingsGoalRepository.sweeps = savingsSweeps
+	}
+	return err
+}
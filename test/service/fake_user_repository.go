@@ -36,7 +36,12 @@ func (fakeUserRepository *FakeUserRepository) AddUser(user domain.User) error {
 }
 
 func (fakeUserRepository *FakeUserRepository) GetById(userId int64) (domain.User, error) {
-	/* Not implemented yet */
+	for _, user := range fakeUserRepository.users {
+		if user.Id == userId {
+			return user, nil
+		}
+	}
+	/* unknown users come back empty, without a role */
 	return domain.User{}, nil
 }
 
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WhenTransferHasAFee_ShouldChargeItToTheFeeHouseAccount(t *testing.T) {
+	t.Run("WhenTransferHasAFee_ShouldChargeItToTheFeeHouseAccount", func(t *testing.T) {
+		transactionRepository := NewFakeTransactionRepository()
+		balanceRepository := NewFakeBalanceRepository(map[int64]domain.Money{1: money("100")})
+		ledgerRepository := NewFakeLedgerRepository()
+		unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, ledgerRepository, NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+		limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+		feeService := service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil))
+		transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+
+		flatAmount := money("1.50")
+		err := feeService.CreateSchedule(&domain.FeeSchedule{TransactionType: domain.TransferTransaction, Currency: domain.TRY, Type: domain.FlatFee, FlatAmount: &flatAmount})
+		assert.Nil(t, err)
+
+		transaction, err := transactionService.Transfer(1, 2, money("40"))
+		assert.Nil(t, err)
+		assert.Equal(t, money("1.50"), *transaction.Fee)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("58.50"), balance.Amount)
+
+		feeHouseAccount, _ := ledgerRepository.GetAccountByCode(domain.FeeHouseAccountCode(domain.TRY))
+		feeHouseBalance, _ := ledgerRepository.GetAccountBalance(feeHouseAccount.ID)
+		assert.Equal(t, money("1.50"), feeHouseBalance.Abs())
+
+		stored, _ := transactionService.GetTransactionByID(transaction.ID)
+		assert.Equal(t, money("1.50"), *stored.Fee)
+	})
+}
+
+func Test_WhenFeeDoesNotFitTheBalance_ShouldRejectTheTransfer(t *testing.T) {
+	t.Run("WhenFeeDoesNotFitTheBalance_ShouldRejectTheTransfer", func(t *testing.T) {
+		transactionRepository := NewFakeTransactionRepository()
+		balanceRepository := NewFakeBalanceRepository(map[int64]domain.Money{1: money("40")})
+		unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+		limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+		feeService := service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil))
+		transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+
+		flatAmount := money("1")
+		feeService.CreateSchedule(&domain.FeeSchedule{TransactionType: domain.TransferTransaction, Currency: domain.TRY, Type: domain.FlatFee, FlatAmount: &flatAmount})
+
+		_, err := transactionService.Transfer(1, 2, money("40"))
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("40"), balance.Amount)
+	})
+}
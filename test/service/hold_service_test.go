@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHoldService(initialBalances map[int64]domain.Money) (service.IHoldService, service.ITransactionService, *FakeBalanceRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	holdRepository := NewFakeHoldRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), holdRepository, NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil)), service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+	holdService := service.NewHoldService(holdRepository, unitOfWork, transactionService, time.Hour)
+	return holdService, transactionService, balanceRepository
+}
+
+func Test_WhenHoldIsAuthorized_ShouldOnlyReduceAvailableBalance(t *testing.T) {
+	t.Run("WhenHoldIsAuthorized_ShouldOnlyReduceAvailableBalance", func(t *testing.T) {
+		holdService, transactionService, balanceRepository := newHoldService(map[int64]domain.Money{1: money("100")})
+
+		_, err := holdService.Authorize(1, nil, money("70"))
+		assert.Nil(t, err)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), balance.Amount)
+		assert.Equal(t, money("30"), balance.Available())
+
+		_, err = transactionService.Debit(1, money("40"))
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+	})
+}
+
+func Test_WhenHoldIsPartiallyCaptured_ShouldPayReceiverAndReleaseTheRest(t *testing.T) {
+	t.Run("WhenHoldIsPartiallyCaptured_ShouldPayReceiverAndReleaseTheRest", func(t *testing.T) {
+		holdService, _, balanceRepository := newHoldService(map[int64]domain.Money{1: money("100")})
+		merchant := int64(2)
+		hold, _ := holdService.Authorize(1, &merchant, money("70"))
+
+		amount := money("50")
+		capture, err := holdService.Capture(hold.TransactionID, &amount)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, capture.Status)
+
+		_, err = holdService.Void(hold.TransactionID)
+		assert.ErrorIs(t, err, service.ErrHoldNotAuthorized)
+
+		captured, _ := holdService.GetHold(hold.TransactionID)
+		payer, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		receiver, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, domain.Captured, captured.Status)
+		assert.Equal(t, money("50"), payer.Amount)
+		assert.Equal(t, money("50"), payer.Available())
+		assert.Equal(t, money("50"), receiver.Amount)
+	})
+}
+
+func Test_WhenHoldConflicts_ShouldAuthorizeAndCaptureItAgain(t *testing.T) {
+	t.Run("WhenHoldConflicts_ShouldAuthorizeAndCaptureItAgain", func(t *testing.T) {
+		holdService, _, balanceRepository := newHoldService(map[int64]domain.Money{1: money("100")})
+		merchant := int64(2)
+
+		balanceRepository.FailNextWrites(2)
+		hold, err := holdService.Authorize(1, &merchant, money("70"))
+		assert.Nil(t, err)
+
+		balanceRepository.FailNextWrites(2)
+		capture, err := holdService.Capture(hold.TransactionID, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, capture.Status)
+
+		payer, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		receiver, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("30"), payer.Amount)
+		assert.Equal(t, money("0"), payer.HeldAmount)
+		assert.Equal(t, money("70"), receiver.Amount)
+	})
+}
+
+func Test_WhenHoldExpires_ShouldReleaseIt(t *testing.T) {
+	t.Run("WhenHoldExpires_ShouldReleaseIt", func(t *testing.T) {
+		holdService, _, balanceRepository := newHoldService(map[int64]domain.Money{1: money("100")})
+		hold, _ := holdService.Authorize(1, nil, money("70"))
+
+		holdService.ExpireHolds(context.Background(), time.Now().Add(2*time.Hour))
+
+		_, err := holdService.Capture(hold.TransactionID, nil)
+		assert.ErrorIs(t, err, service.ErrHoldNotAuthorized)
+
+		expired, _ := holdService.GetHold(hold.TransactionID)
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, domain.Expired, expired.Status)
+		assert.Equal(t, money("100"), balance.Available())
+	})
+}
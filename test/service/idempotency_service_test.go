@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/idempotency"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var idempotencyConfig = idempotency.Config{InFlightTimeout: 10 * time.Minute, Retention: 24 * time.Hour}
+
+func Test_WhenSameRequestIsReplayed_ShouldReturnStoredResponse(t *testing.T) {
+	t.Run("WhenSameRequestIsReplayed_ShouldReturnStoredResponse", func(t *testing.T) {
+		idempotencyService := service.NewIdempotencyService(NewFakeIdempotencyRepository(), idempotencyConfig)
+		body := []byte(`{"user_id":1,"amount":10}`)
+
+		first, err := idempotencyService.Begin("key-1", http.MethodPost, "/api/v1/transactions/credit", body)
+		assert.Nil(t, err)
+		assert.Nil(t, first)
+
+		_, err = idempotencyService.Begin("key-1", http.MethodPost, "/api/v1/transactions/credit", body)
+		assert.ErrorIs(t, err, service.ErrIdempotencyKeyInFlight)
+
+		idempotencyService.Complete("key-1", http.StatusCreated, []byte(`{"ID":7}`))
+
+		replay, err := idempotencyService.Begin("key-1", http.MethodPost, "/api/v1/transactions/credit", body)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusCreated, replay.StatusCode)
+		assert.Equal(t, `{"ID":7}`, string(replay.ResponseBody))
+	})
+}
+
+func Test_WhenKeyIsReusedWithDifferentBody_ShouldFail(t *testing.T) {
+	t.Run("WhenKeyIsReusedWithDifferentBody_ShouldFail", func(t *testing.T) {
+		idempotencyService := service.NewIdempotencyService(NewFakeIdempotencyRepository(), idempotencyConfig)
+
+		idempotencyService.Begin("key-2", http.MethodPost, "/api/v1/transactions/debit", []byte(`{"user_id":1,"amount":10}`))
+		idempotencyService.Complete("key-2", http.StatusCreated, []byte(`{}`))
+
+		_, err := idempotencyService.Begin("key-2", http.MethodPost, "/api/v1/transactions/debit", []byte(`{"user_id":1,"amount":20}`))
+		assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+	})
+}
+
+func Test_WhenRequestDiedInFlight_ShouldLetTheKeyBeClaimedAgainAfterTheTimeout(t *testing.T) {
+	t.Run("WhenRequestDiedInFlight_ShouldLetTheKeyBeClaimedAgainAfterTheTimeout", func(t *testing.T) {
+		idempotencyRepository := NewFakeIdempotencyRepository()
+		idempotencyService := service.NewIdempotencyService(idempotencyRepository, idempotencyConfig)
+		body := []byte(`{"user_id":1,"amount":10}`)
+
+		idempotencyService.Begin("key-3", http.MethodPost, "/api/v1/transactions/credit", body)
+		idempotencyRepository.age("key-3", 5*time.Minute)
+		_, err := idempotencyService.Begin("key-3", http.MethodPost, "/api/v1/transactions/credit", body)
+		assert.ErrorIs(t, err, service.ErrIdempotencyKeyInFlight)
+
+		idempotencyRepository.age("key-3", 10*time.Minute)
+		record, err := idempotencyService.Begin("key-3", http.MethodPost, "/api/v1/transactions/credit", body)
+		assert.Nil(t, err)
+		assert.Nil(t, record)
+		_, err = idempotencyService.Begin("key-3", http.MethodPost, "/api/v1/transactions/credit", body)
+		assert.ErrorIs(t, err, service.ErrIdempotencyKeyInFlight)
+	})
+}
+
+func Test_WhenKeysExpire_ShouldBeClaimedAnewAndPurged(t *testing.T) {
+	t.Run("WhenKeysExpire_ShouldBeClaimedAnewAndPurged", func(t *testing.T) {
+		idempotencyRepository := NewFakeIdempotencyRepository()
+		idempotencyService := service.NewIdempotencyService(idempotencyRepository, idempotencyConfig)
+
+		idempotencyService.Begin("key-4", http.MethodPost, "/api/v1/transactions/debit", []byte(`{"amount":10}`))
+		idempotencyService.Complete("key-4", http.StatusCreated, []byte(`{}`))
+		idempotencyRepository.age("key-4", 25*time.Hour)
+		record, err := idempotencyService.Begin("key-4", http.MethodPost, "/api/v1/transactions/debit", []byte(`{"amount":20}`))
+		assert.Nil(t, err)
+		assert.Nil(t, record)
+
+		idempotencyService.Begin("key-5", http.MethodPost, "/api/v1/transactions/debit", []byte(`{}`))
+		idempotencyService.Complete("key-5", http.StatusCreated, []byte(`{}`))
+		idempotencyService.Begin("key-6", http.MethodPost, "/api/v1/transactions/debit", []byte(`{}`))
+		idempotencyRepository.age("key-6", time.Hour)
+
+		assert.Nil(t, idempotencyService.PurgeExpired(context.Background(), time.Now()))
+		assert.Equal(t, 2, len(idempotencyRepository.records))
+		assert.Nil(t, idempotencyService.PurgeExpired(context.Background(), time.Now().Add(25*time.Hour)))
+		assert.Equal(t, 0, len(idempotencyRepository.records))
+	})
+}
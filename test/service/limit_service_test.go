@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLimitedTransactionService(initialBalances map[int64]domain.Money, users []domain.User) (service.ITransactionService, service.ILimitService, service.IHoldService, *FakeBalanceRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	holdRepository := NewFakeHoldRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), holdRepository, NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(users))
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil)), service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+	return transactionService, limitService, service.NewHoldService(holdRepository, unitOfWork, transactionService, time.Hour), balanceRepository
+}
+
+func Test_WhenDailyTransferLimitIsExceeded_ShouldRejectWithRemainingHeadroom(t *testing.T) {
+	t.Run("WhenDailyTransferLimitIsExceeded_ShouldRejectWithRemainingHeadroom", func(t *testing.T) {
+		transactionService, limitService, _, balanceRepository := newLimitedTransactionService(
+			map[int64]domain.Money{1: money("1000")},
+			[]domain.User{{Id: 1, Role: "user"}},
+		)
+		dailyAmount := money("100")
+		limitService.CreateProfile(&domain.LimitProfile{Role: "user", TransactionType: domain.TransferTransaction, Currency: domain.TRY, DailyAmount: &dailyAmount})
+
+		_, err := transactionService.Transfer(1, 2, money("70"))
+		assert.Nil(t, err)
+
+		_, err = transactionService.Transfer(1, 2, money("40"))
+		limitExceededError, ok := err.(*domain.LimitExceededError)
+		assert.True(t, ok)
+		assert.Equal(t, domain.DailyAmountLimit, limitExceededError.Limit)
+		assert.Equal(t, money("30"), *limitExceededError.RemainingAmount)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("930"), balance.Amount)
+	})
+}
+
+func Test_WhenUserHasOwnLimitProfile_ShouldPreferItOverTheRoleProfile(t *testing.T) {
+	t.Run("WhenUserHasOwnLimitProfile_ShouldPreferItOverTheRoleProfile", func(t *testing.T) {
+		transactionService, limitService, _, _ := newLimitedTransactionService(
+			map[int64]domain.Money{1: money("1000")},
+			[]domain.User{{Id: 1, Role: "user"}},
+		)
+		userID := int64(1)
+		roleLimit, userLimit := money("50"), money("500")
+		limitService.CreateProfile(&domain.LimitProfile{Role: "user", TransactionType: domain.DebitTransaction, Currency: domain.TRY, MaxSingleAmount: &roleLimit})
+		limitService.CreateProfile(&domain.LimitProfile{UserID: &userID, TransactionType: domain.DebitTransaction, Currency: domain.TRY, MaxSingleAmount: &userLimit})
+
+		_, err := transactionService.Debit(1, money("200"))
+
+		assert.Nil(t, err)
+	})
+}
+
+func Test_WhenCaptureWouldExceedTheDebitLimit_ShouldRejectItAndKeepTheHold(t *testing.T) {
+	t.Run("WhenCaptureWouldExceedTheDebitLimit_ShouldRejectItAndKeepTheHold", func(t *testing.T) {
+		transactionService, limitService, holdService, balanceRepository := newLimitedTransactionService(
+			map[int64]domain.Money{1: money("1000")},
+			[]domain.User{{Id: 1, Role: "user"}},
+		)
+		dailyAmount := money("100")
+		limitService.CreateProfile(&domain.LimitProfile{Role: "user", TransactionType: domain.DebitTransaction, Currency: domain.TRY, DailyAmount: &dailyAmount})
+
+		hold, err := holdService.Authorize(1, nil, money("80"))
+		assert.Nil(t, err)
+		_, err = holdService.Capture(hold.TransactionID, nil)
+		assert.Nil(t, err)
+
+		_, err = transactionService.Debit(1, money("30"))
+		_, ok := err.(*domain.LimitExceededError)
+		assert.True(t, ok)
+
+		second, _ := holdService.Authorize(1, nil, money("30"))
+		_, err = holdService.Capture(second.TransactionID, nil)
+		_, ok = err.(*domain.LimitExceededError)
+		assert.True(t, ok)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("920"), balance.Amount)
+		assert.Equal(t, money("30"), balance.HeldAmount)
+	})
+}
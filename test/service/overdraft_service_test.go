@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOverdraftService(initialBalances map[int64]domain.Money) (service.IOverdraftService, service.ITransactionService, *FakeBalanceRepository, *FakeLedgerRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	ledgerRepository := NewFakeLedgerRepository()
+	overdraftRepository := NewFakeOverdraftRepository(balanceRepository)
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, ledgerRepository, NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), overdraftRepository, NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+	feeService := service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil))
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+	return service.NewOverdraftService(overdraftRepository, balanceRepository, unitOfWork), transactionService, balanceRepository, ledgerRepository
+}
+
+func Test_WhenUserHasOverdraft_ShouldSpendDownToTheCreditLimit(t *testing.T) {
+	t.Run("WhenUserHasOverdraft_ShouldSpendDownToTheCreditLimit", func(t *testing.T) {
+		overdraftService, transactionService, balanceRepository, _ := newOverdraftService(map[int64]domain.Money{1: money("100")})
+
+		_, err := overdraftService.SetFacility(&domain.OverdraftFacility{UserID: 1, Currency: domain.DefaultCurrency, CreditLimit: money("200")})
+		assert.Nil(t, err)
+
+		_, err = transactionService.Debit(1, money("250"))
+		assert.Nil(t, err)
+		_, err = transactionService.Debit(1, money("60"))
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+
+		usage, _ := overdraftService.GetFacility(1, domain.DefaultCurrency)
+		assert.Equal(t, money("-150"), usage.Balance)
+		assert.Equal(t, money("150"), usage.Used)
+		assert.Equal(t, money("50"), usage.Available)
+
+		err = overdraftService.RemoveFacility(1, domain.DefaultCurrency)
+		assert.ErrorIs(t, err, service.ErrOverdraftInUse)
+
+		transactionService.Credit(1, money("150"))
+		assert.Nil(t, overdraftService.RemoveFacility(1, domain.DefaultCurrency))
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("0"), balance.Available())
+	})
+}
+
+func Test_WhenOverdraftsAreCharged_ShouldChargeEachDayOnce(t *testing.T) {
+	t.Run("WhenOverdraftsAreCharged_ShouldChargeEachDayOnce", func(t *testing.T) {
+		overdraftService, transactionService, balanceRepository, ledgerRepository := newOverdraftService(nil)
+		overdraftService.SetFacility(&domain.OverdraftFacility{UserID: 1, Currency: domain.DefaultCurrency, CreditLimit: money("1000"), DailyRateBps: 10})
+		overdraftService.SetFacility(&domain.OverdraftFacility{UserID: 2, Currency: domain.DefaultCurrency, CreditLimit: money("1000"), DailyRateBps: 10})
+		transactionService.Debit(1, money("1000"))
+
+		now := time.Now()
+		assert.Nil(t, overdraftService.ChargeOverdrafts(context.Background(), now))
+		assert.Nil(t, overdraftService.ChargeOverdrafts(context.Background(), now))
+
+		overdrawn, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		untouched, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("-1001"), overdrawn.Amount)
+		assert.Equal(t, money("0"), untouched.Amount)
+
+		feeHouseAccount, _ := ledgerRepository.GetAccountByCode(domain.FeeHouseAccountCode(domain.DefaultCurrency))
+		feeHouseBalance, _ := ledgerRepository.GetAccountBalance(feeHouseAccount.ID)
+		assert.Equal(t, money("1"), feeHouseBalance)
+
+		usage, _ := overdraftService.GetFacility(2, domain.DefaultCurrency)
+		assert.NotNil(t, usage.Facility.LastChargedOn)
+	})
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+const riskRulesFile = `[
+	{"name": "velocity", "type": "velocity", "action": "block", "transaction_types": ["transfer"], "max_count": 2, "window_minutes": 5},
+	{"name": "new-counterparty", "type": "new_counterparty", "action": "flag", "min_amounts": {"TRY": "500"}},
+	{"name": "above-average", "type": "above_average", "action": "flag", "transaction_types": ["debit"], "multiplier": 3, "min_history": 2}
+]`
+
+func newRiskService(t *testing.T, initialBalances map[int64]domain.Money) (service.IRiskService, service.ITransactionService, *FakeTransactionRepository, *FakeBalanceRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	riskRepository := NewFakeRiskRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), NewFakeHoldRepository(), NewFakeApprovalRepository(), riskRepository, NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+
+	riskService := service.NewRiskService(riskRepository, nil)
+	path := filepath.Join(t.TempDir(), "risk_rules.json")
+	os.WriteFile(path, []byte(riskRulesFile), 0o600)
+	loadedRules, err := riskService.LoadRulesFromFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, loadedRules)
+
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+	feeService := service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil))
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, riskService, approval.Config{})
+	return riskService, transactionService, transactionRepository, balanceRepository
+}
+
+func Test_WhenTransfersComeTooFast_ShouldBlockBeforeMoneyMoves(t *testing.T) {
+	t.Run("WhenTransfersComeTooFast_ShouldBlockBeforeMoneyMoves", func(t *testing.T) {
+		_, transactionService, transactionRepository, balanceRepository := newRiskService(t, map[int64]domain.Money{1: money("100")})
+
+		transactionService.Transfer(1, 2, money("10"))
+		transactionService.Transfer(1, 2, money("10"))
+		_, err := transactionService.Transfer(1, 2, money("10"))
+
+		assert.ErrorIs(t, err, service.ErrTransactionBlocked)
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("80"), balance.Amount)
+
+		history, _ := transactionRepository.GetUserTransactions(1, domain.TransactionFilter{})
+		assert.Equal(t, domain.Failed, history[0].Status)
+	})
+}
+
+func Test_WhenTransactionIsFlagged_ShouldGoThroughAndWaitForReview(t *testing.T) {
+	t.Run("WhenTransactionIsFlagged_ShouldGoThroughAndWaitForReview", func(t *testing.T) {
+		riskService, transactionService, _, _ := newRiskService(t, map[int64]domain.Money{1: money("2000")})
+
+		first, err := transactionService.Transfer(1, 2, money("600"))
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, first.Status)
+		transactionService.Transfer(1, 2, money("600"))
+
+		reviews, _ := riskService.GetReviews("")
+		assert.Equal(t, 1, len(reviews))
+		assert.Equal(t, first.ID, reviews[0].TransactionID)
+		assert.Contains(t, reviews[0].Reasons, "new-counterparty")
+
+		resolved, err := riskService.ResolveReview(reviews[0].ID, domain.RiskReviewCleared, 9, "known supplier")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(9), *resolved.ResolvedBy)
+		_, err = riskService.ResolveReview(reviews[0].ID, domain.RiskReviewConfirmed, 9, "")
+		assert.ErrorIs(t, err, service.ErrRiskReviewResolved)
+
+		reviews, _ = riskService.GetReviews("")
+		assert.Equal(t, 0, len(reviews))
+	})
+}
+
+func Test_WhenDebitIsFarAboveAverage_ShouldBeFlagged(t *testing.T) {
+	t.Run("WhenDebitIsFarAboveAverage_ShouldBeFlagged", func(t *testing.T) {
+		riskService, transactionService, _, _ := newRiskService(t, map[int64]domain.Money{1: money("1000")})
+
+		transactionService.Debit(1, money("10"))
+		transactionService.Debit(1, money("20"))
+		transactionService.Debit(1, money("45"))
+		reviews, _ := riskService.GetReviews(domain.RiskReviewOpen)
+		assert.Equal(t, 0, len(reviews))
+
+		transactionService.Debit(1, money("100"))
+		reviews, _ = riskService.GetReviews(domain.RiskReviewOpen)
+		assert.Equal(t, 1, len(reviews))
+		assert.Contains(t, reviews[0].Reasons, "above-average")
+	})
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSavingsGoalService(initialBalances map[int64]domain.Money) (service.ISavingsGoalService, service.ITransactionService, *FakeBalanceRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	savingsGoalRepository := NewFakeSavingsGoalRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, NewFakeLedgerRepository(), NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), savingsGoalRepository)
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+	feeService := service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil))
+	transactionService := service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, feeService, service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{})
+	return service.NewSavingsGoalService(savingsGoalRepository, unitOfWork), transactionService, balanceRepository
+}
+
+func newSavingsGoal(t *testing.T, savingsGoalService service.ISavingsGoalService, target string) *domain.SavingsGoal {
+	goal, err := savingsGoalService.CreateGoal(&domain.SavingsGoal{UserID: 1, Name: "Holiday", Currency: domain.DefaultCurrency, TargetAmount: money(target), TargetDate: time.Now().AddDate(0, 6, 0)})
+	assert.Nil(t, err)
+	return goal
+}
+
+func Test_WhenTransactionsSetOffSweepRules_ShouldSweepIntoTheGoal(t *testing.T) {
+	t.Run("WhenTransactionsSetOffSweepRules_ShouldSweepIntoTheGoal", func(t *testing.T) {
+		savingsGoalService, transactionService, balanceRepository := newSavingsGoalService(map[int64]domain.Money{1: money("100")})
+		goal := newSavingsGoal(t, savingsGoalService, "500")
+
+		_, err := savingsGoalService.AddRule(goal.ID, &domain.SweepRule{Type: domain.RoundUpSweep}, time.Time{})
+		assert.Nil(t, err)
+		_, err = savingsGoalService.AddRule(goal.ID, &domain.SweepRule{Type: domain.PercentageOfCreditSweep, PercentageBps: 1000}, time.Time{})
+		assert.Nil(t, err)
+
+		_, err = transactionService.Debit(1, money("12.30"))
+		assert.Nil(t, err)
+		_, err = transactionService.Credit(1, money("50"))
+		assert.Nil(t, err)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("132"), balance.Amount)
+		goal, _ = savingsGoalService.GetGoal(goal.ID)
+		assert.Equal(t, money("5.70"), goal.SavedAmount)
+
+		sweeps, _ := savingsGoalService.GetSweeps(goal.ID)
+		assert.Equal(t, 2, len(sweeps))
+		assert.Equal(t, money("0.70"), sweeps[0].Amount)
+		sweepTransaction, _ := transactionService.GetTransactionByID(sweeps[1].TransactionID)
+		assert.Equal(t, domain.SavingsSweepTransaction, sweepTransaction.Type)
+		assert.Equal(t, domain.Completed, sweepTransaction.Status)
+		assert.Equal(t, money("-5"), sweepTransaction.Amount)
+	})
+}
+
+func Test_WhenFixedSweepIsDue_ShouldSweepAndSkipWhatTheBalanceCantCover(t *testing.T) {
+	t.Run("WhenFixedSweepIsDue_ShouldSweepAndSkipWhatTheBalanceCantCover", func(t *testing.T) {
+		savingsGoalService, _, balanceRepository := newSavingsGoalService(map[int64]domain.Money{1: money("30")})
+		goal := newSavingsGoal(t, savingsGoalService, "500")
+		amount := money("20")
+
+		rule, err := savingsGoalService.AddRule(goal.ID, &domain.SweepRule{Type: domain.FixedAmountSweep, Amount: &amount, Recurrence: &domain.Recurrence{Frequency: domain.Daily}}, time.Time{})
+		assert.Nil(t, err)
+
+		now := rule.NextRunAt.Add(time.Minute)
+		assert.Nil(t, savingsGoalService.ExecuteDueSweeps(context.Background(), now))
+		assert.Nil(t, savingsGoalService.ExecuteDueSweeps(context.Background(), now.AddDate(0, 0, 1)))
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("10"), balance.Amount)
+		goal, _ = savingsGoalService.GetGoal(goal.ID)
+		assert.Equal(t, money("20"), goal.SavedAmount)
+		rules, _ := savingsGoalService.GetRules(goal.ID)
+		assert.Equal(t, 2, rules[0].OccurrencesCount)
+		assert.Equal(t, rule.NextRunAt.AddDate(0, 0, 2), *rules[0].NextRunAt)
+	})
+}
+
+func Test_WhenGoalIsClosed_ShouldPayTheSavingsBack(t *testing.T) {
+	t.Run("WhenGoalIsClosed_ShouldPayTheSavingsBack", func(t *testing.T) {
+		savingsGoalService, _, balanceRepository := newSavingsGoalService(map[int64]domain.Money{1: money("100")})
+		goal := newSavingsGoal(t, savingsGoalService, "50")
+		_, err := savingsGoalService.AddRule(goal.ID, &domain.SweepRule{Type: domain.RoundUpSweep}, time.Time{})
+		assert.Nil(t, err)
+
+		sweep, err := savingsGoalService.Contribute(goal.ID, money("80"))
+		assert.Nil(t, err)
+		assert.Equal(t, money("50"), sweep.Amount)
+		_, err = savingsGoalService.Contribute(goal.ID, money("1"))
+		assert.ErrorIs(t, err, service.ErrSavingsGoalReached)
+
+		closed, err := savingsGoalService.CloseGoal(goal.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.SavingsGoalClosed, closed.Status)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), balance.Amount)
+		rules, _ := savingsGoalService.GetRules(goal.ID)
+		assert.False(t, rules[0].Active)
+		_, err = savingsGoalService.Contribute(goal.ID, money("1"))
+		assert.ErrorIs(t, err, service.ErrSavingsGoalClosed)
+	})
+}
+
+func Test_WhenTransferComesIn_ShouldSweepAShareOfItIntoTheReceiversGoal(t *testing.T) {
+	t.Run("WhenTransferComesIn_ShouldSweepAShareOfItIntoTheReceiversGoal", func(t *testing.T) {
+		savingsGoalService, transactionService, balanceRepository := newSavingsGoalService(map[int64]domain.Money{1: money("100"), 2: money("300")})
+		goal := newSavingsGoal(t, savingsGoalService, "500")
+		_, err := savingsGoalService.AddRule(goal.ID, &domain.SweepRule{Type: domain.PercentageOfCreditSweep, PercentageBps: 1000}, time.Time{})
+		assert.Nil(t, err)
+
+		transfer, err := transactionService.Transfer(2, 1, money("200"))
+		assert.Nil(t, err)
+		_, err = transactionService.Transfer(1, 2, money("50"))
+		assert.Nil(t, err)
+
+		receiver, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("230"), receiver.Amount)
+		goal, _ = savingsGoalService.GetGoal(goal.ID)
+		assert.Equal(t, money("20"), goal.SavedAmount)
+
+		sweeps, _ := savingsGoalService.GetSweeps(goal.ID)
+		assert.Equal(t, 1, len(sweeps))
+		sweepTransaction, _ := transactionService.GetTransactionByID(sweeps[0].TransactionID)
+		assert.Equal(t, transfer.ID, *sweepTransaction.ParentID)
+	})
+}
+
+func Test_WhenTransferGoesOut_ShouldRoundItUpIntoTheSendersGoal(t *testing.T) {
+	t.Run("WhenTransferGoesOut_ShouldRoundItUpIntoTheSendersGoal", func(t *testing.T) {
+		savingsGoalService, transactionService, balanceRepository := newSavingsGoalService(map[int64]domain.Money{1: money("100"), 2: money("100")})
+		goal := newSavingsGoal(t, savingsGoalService, "500")
+		_, err := savingsGoalService.AddRule(goal.ID, &domain.SweepRule{Type: domain.RoundUpSweep}, time.Time{})
+		assert.Nil(t, err)
+
+		transfer, err := transactionService.Transfer(1, 2, money("12.30"))
+		assert.Nil(t, err)
+		_, err = transactionService.Transfer(2, 1, money("7.40"))
+		assert.Nil(t, err)
+
+		sender, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("94.40"), sender.Amount)
+		goal, _ = savingsGoalService.GetGoal(goal.ID)
+		assert.Equal(t, money("0.70"), goal.SavedAmount)
+
+		sweeps, _ := savingsGoalService.GetSweeps(goal.ID)
+		assert.Equal(t, 1, len(sweeps))
+		sweepTransaction, _ := transactionService.GetTransactionByID(sweeps[0].TransactionID)
+		assert.Equal(t, transfer.ID, *sweepTransaction.ParentID)
+	})
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/scheduledtransfer"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var scheduledTransferConfig = scheduledtransfer.Config{ExecutionTimeout: 10 * time.Minute}
+
+func Test_WhenScheduledTransfersAreDue_ShouldExecuteThemOnce(t *testing.T) {
+	t.Run("WhenScheduledTransfersAreDue_ShouldExecuteThemOnce", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100")})
+		scheduledTransferRepository := NewFakeScheduledTransferRepository()
+		scheduledTransferService := service.NewScheduledTransferService(scheduledTransferRepository, transactionService, scheduledTransferConfig)
+		now := time.Now()
+
+		due, _ := scheduledTransferService.Schedule(1, 2, money("30"), now.Add(time.Minute))
+		later, _ := scheduledTransferService.Schedule(1, 2, money("30"), now.Add(time.Hour))
+		cancelled, _ := scheduledTransferService.Schedule(1, 2, money("30"), now.Add(time.Minute))
+		tooLarge, _ := scheduledTransferService.Schedule(1, 2, money("500"), now.Add(time.Minute))
+		scheduledTransferService.Cancel(cancelled.ID)
+
+		scheduledTransferService.ExecuteDue(context.Background(), now.Add(2*time.Minute))
+		scheduledTransferService.ExecuteDue(context.Background(), now.Add(2*time.Minute))
+
+		executed, _ := scheduledTransferService.GetByID(due.ID)
+		pending, _ := scheduledTransferService.GetByID(later.ID)
+		failed, _ := scheduledTransferService.GetByID(tooLarge.ID)
+		assert.Equal(t, domain.ScheduledCompleted, executed.Status)
+		assert.NotNil(t, executed.TransactionID)
+		assert.Equal(t, domain.Scheduled, pending.Status)
+		assert.Equal(t, domain.ScheduledFailed, failed.Status)
+		assert.Equal(t, service.ErrInsufficientBalance.Error(), failed.FailureReason)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("70"), balance.Amount)
+	})
+}
+
+func Test_WhenExecuteAtIsInThePast_ShouldNotSchedule(t *testing.T) {
+	t.Run("WhenExecuteAtIsInThePast_ShouldNotSchedule", func(t *testing.T) {
+		transactionService, _, _ := newTransactionService(map[int64]domain.Money{})
+		scheduledTransferService := service.NewScheduledTransferService(NewFakeScheduledTransferRepository(), transactionService, scheduledTransferConfig)
+
+		_, err := scheduledTransferService.Schedule(1, 2, money("30"), time.Now().Add(-time.Minute))
+
+		assert.ErrorIs(t, err, service.ErrExecuteAtNotInFuture)
+	})
+}
+
+func Test_WhenScheduledTransferNeedsApproval_ShouldWaitForItInsteadOfCompleting(t *testing.T) {
+	t.Run("WhenScheduledTransferNeedsApproval_ShouldWaitForItInsteadOfCompleting", func(t *testing.T) {
+		_, transactionService, balanceRepository := newApprovalService(map[int64]domain.Money{1: money("5000")})
+		scheduledTransferService := service.NewScheduledTransferService(NewFakeScheduledTransferRepository(), transactionService, scheduledTransferConfig)
+		now := time.Now()
+
+		large, _ := scheduledTransferService.Schedule(1, 2, money("2000"), now.Add(time.Minute))
+		scheduledTransferService.ExecuteDue(context.Background(), now.Add(2*time.Minute))
+
+		awaiting, _ := scheduledTransferService.GetByID(large.ID)
+		assert.Equal(t, domain.ScheduledAwaitingApproval, awaiting.Status)
+		assert.NotNil(t, awaiting.TransactionID)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("5000"), balance.Amount)
+	})
+}
+
+func Test_WhenRunDiesWhileExecuting_ShouldSettleTheTransferFromWhatItRecorded(t *testing.T) {
+	t.Run("WhenRunDiesWhileExecuting_ShouldSettleTheTransferFromWhatItRecorded", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100")})
+		interceptingService := &interceptingTransactionService{ITransactionService: transactionService}
+		scheduledTransferService := service.NewScheduledTransferService(NewFakeScheduledTransferRepository(), interceptingService, scheduledTransferConfig)
+		now := time.Now()
+
+		transferred, _ := scheduledTransferService.Schedule(1, 2, money("30"), now.Add(time.Minute))
+		interceptingService.onTransfer = func(transfer func() (*domain.Transaction, error)) (*domain.Transaction, error) {
+			transfer()
+			panic("process died")
+		}
+		assert.Panics(t, func() { scheduledTransferService.ExecuteDue(context.Background(), now.Add(2*time.Minute)) })
+
+		notTransferred, _ := scheduledTransferService.Schedule(1, 2, money("20"), now.Add(time.Minute))
+		interceptingService.onTransfer = func(transfer func() (*domain.Transaction, error)) (*domain.Transaction, error) {
+			panic("process died")
+		}
+		assert.Panics(t, func() { scheduledTransferService.ExecuteDue(context.Background(), now.Add(2*time.Minute)) })
+
+		scheduledTransferService.ExecuteDue(context.Background(), now.Add(5*time.Minute))
+		stillExecuting, _ := scheduledTransferService.GetByID(transferred.ID)
+		assert.Equal(t, domain.ScheduledExecuting, stillExecuting.Status)
+
+		scheduledTransferService.ExecuteDue(context.Background(), now.Add(time.Hour))
+
+		completed, _ := scheduledTransferService.GetByID(transferred.ID)
+		assert.Equal(t, domain.ScheduledCompleted, completed.Status)
+		assert.NotNil(t, completed.TransactionID)
+		failed, _ := scheduledTransferService.GetByID(notTransferred.ID)
+		assert.Equal(t, domain.ScheduledFailed, failed.Status)
+		assert.Equal(t, service.ErrScheduledTransferInterrupted.Error(), failed.FailureReason)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("30"), balance.Amount)
+	})
+}
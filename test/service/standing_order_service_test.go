@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/standingorder"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/denizdoganinsider/kpi_project/service/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var standingOrderConfig = standingorder.Config{
+	InsufficientFundsPolicy: domain.RetryOccurrence,
+	MaxRetries:              1,
+	RetryInterval:           time.Hour,
+}
+
+func Test_WhenStandingOrderIsDue_ShouldTransferAndMoveToNextOccurrence(t *testing.T) {
+	t.Run("WhenStandingOrderIsDue_ShouldTransferAndMoveToNextOccurrence", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100")})
+		standingOrderRepository := NewFakeStandingOrderRepository()
+		standingOrderService := service.NewStandingOrderService(standingOrderRepository, transactionService, standingOrderConfig)
+
+		standingOrder, err := standingOrderService.Create(model.StandingOrderCreate{
+			FromUser:   1,
+			ToUser:     2,
+			Amount:     money("30"),
+			Recurrence: domain.Recurrence{Frequency: domain.Daily},
+		})
+		assert.Nil(t, err)
+
+		standingOrderService.ExecuteDue(context.Background(), standingOrder.NextRunAt)
+		standingOrderService.ExecuteDue(context.Background(), standingOrder.NextRunAt)
+
+		executed, _ := standingOrderService.GetByID(standingOrder.ID)
+		attempts, _ := standingOrderService.GetAttempts(standingOrder.ID)
+		assert.Equal(t, 1, executed.OccurrencesCount)
+		assert.Equal(t, standingOrder.NextRunAt.AddDate(0, 0, 1), executed.NextRunAt)
+		assert.Len(t, attempts, 1)
+		assert.Equal(t, domain.AttemptSucceeded, attempts[0].Status)
+		assert.NotNil(t, attempts[0].TransactionID)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("30"), balance.Amount)
+	})
+}
+
+func Test_WhenFundsAreInsufficient_ShouldRetryThenSkipTheOccurrence(t *testing.T) {
+	t.Run("WhenFundsAreInsufficient_ShouldRetryThenSkipTheOccurrence", func(t *testing.T) {
+		transactionService, _, _ := newTransactionService(map[int64]domain.Money{1: money("10")})
+		standingOrderService := service.NewStandingOrderService(NewFakeStandingOrderRepository(), transactionService, standingOrderConfig)
+
+		standingOrder, _ := standingOrderService.Create(model.StandingOrderCreate{
+			FromUser:   1,
+			ToUser:     2,
+			Amount:     money("30"),
+			Recurrence: domain.Recurrence{Frequency: domain.Daily},
+		})
+		occurrence := standingOrder.OccurrenceAt
+
+		standingOrderService.ExecuteDue(context.Background(), occurrence)
+		retried, _ := standingOrderService.GetByID(standingOrder.ID)
+		assert.Equal(t, occurrence, retried.OccurrenceAt)
+		assert.Equal(t, occurrence.Add(time.Hour), retried.NextRunAt)
+
+		standingOrderService.ExecuteDue(context.Background(), retried.NextRunAt)
+		skipped, _ := standingOrderService.GetByID(standingOrder.ID)
+		assert.Equal(t, occurrence.AddDate(0, 0, 1), skipped.OccurrenceAt)
+
+		attempts, _ := standingOrderService.GetAttempts(standingOrder.ID)
+		assert.Len(t, attempts, 2)
+		assert.Equal(t, domain.AttemptFailed, attempts[0].Status)
+		assert.Equal(t, domain.AttemptSkipped, attempts[1].Status)
+	})
+}
+
+/* interceptingTransactionService runs onTransfer around the transfers of the wrapped service */
+type interceptingTransactionService struct {
+	service.ITransactionService
+	onTransfer func(transfer func() (*domain.Transaction, error)) (*domain.Transaction, error)
+}
+
+func (transactionService *interceptingTransactionService) Transfer(fromUserID int64, toUserID int64, amount domain.Money) (*domain.Transaction, error) {
+	return transactionService.onTransfer(func() (*domain.Transaction, error) {
+		return transactionService.ITransactionService.Transfer(fromUserID, toUserID, amount)
+	})
+}
+
+func Test_WhenRunDiesAfterTheTransfer_ShouldNotPayTheOccurrenceAgain(t *testing.T) {
+	t.Run("WhenRunDiesAfterTheTransfer_ShouldNotPayTheOccurrenceAgain", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100")})
+		interceptingService := &interceptingTransactionService{ITransactionService: transactionService}
+		standingOrderService := service.NewStandingOrderService(NewFakeStandingOrderRepository(), interceptingService, standingOrderConfig)
+
+		maxOccurrences := 1
+		standingOrder, _ := standingOrderService.Create(model.StandingOrderCreate{
+			FromUser:   1,
+			ToUser:     2,
+			Amount:     money("30"),
+			Recurrence: domain.Recurrence{Frequency: domain.Daily, MaxOccurrences: &maxOccurrences},
+		})
+
+		interceptingService.onTransfer = func(transfer func() (*domain.Transaction, error)) (*domain.Transaction, error) {
+			transfer()
+			panic("process died")
+		}
+		assert.Panics(t, func() { standingOrderService.ExecuteDue(context.Background(), standingOrder.NextRunAt) })
+
+		interceptingService.onTransfer = func(transfer func() (*domain.Transaction, error)) (*domain.Transaction, error) {
+			return transfer()
+		}
+		standingOrderService.ExecuteDue(context.Background(), standingOrder.NextRunAt.AddDate(0, 0, 1))
+
+		balance, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("30"), balance.Amount)
+		completed, _ := standingOrderService.GetByID(standingOrder.ID)
+		assert.Equal(t, domain.StandingOrderCompleted, completed.Status)
+	})
+}
+
+func Test_WhenOccurrenceNeedsApproval_ShouldRecordItAsAwaitingApproval(t *testing.T) {
+	t.Run("WhenOccurrenceNeedsApproval_ShouldRecordItAsAwaitingApproval", func(t *testing.T) {
+		_, transactionService, balanceRepository := newApprovalService(map[int64]domain.Money{1: money("5000")})
+		standingOrderService := service.NewStandingOrderService(NewFakeStandingOrderRepository(), transactionService, standingOrderConfig)
+
+		standingOrder, _ := standingOrderService.Create(model.StandingOrderCreate{
+			FromUser:   1,
+			ToUser:     2,
+			Amount:     money("2000"),
+			Recurrence: domain.Recurrence{Frequency: domain.Daily},
+		})
+		standingOrderService.ExecuteDue(context.Background(), standingOrder.NextRunAt)
+
+		attempts, _ := standingOrderService.GetAttempts(standingOrder.ID)
+		assert.Len(t, attempts, 1)
+		assert.Equal(t, domain.AttemptAwaitingApproval, attempts[0].Status)
+		assert.NotNil(t, attempts[0].TransactionID)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("5000"), balance.Amount)
+	})
+}
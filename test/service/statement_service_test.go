@@ -0,0 +1,95 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/statement"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WhenStatementIsExported_ShouldRunTheBalanceFromTheOpeningOne(t *testing.T) {
+	t.Run("WhenStatementIsExported_ShouldRunTheBalanceFromTheOpeningOne", func(t *testing.T) {
+		transactionService, transactionRepository, _, ledgerRepository := newTransactionServiceWithLedger(map[int64]domain.Money{})
+		statementService := service.NewStatementService(transactionRepository, ledgerRepository)
+
+		transactionService.Credit(1, money("100"))
+		from := time.Now()
+		transactionService.Transfer(1, 2, money("30"))
+		transactionService.Transfer(2, 1, money("5"))
+		transactionService.Debit(1, money("500"))
+		transactionService.Debit(1, money("10"))
+
+		var output bytes.Buffer
+		accountStatement := &domain.Statement{UserID: 1, Currency: domain.TRY, From: from, To: time.Now().Add(time.Minute)}
+		err := statementService.Export(accountStatement, statement.NewCSVWriter(&output))
+		assert.Nil(t, err)
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		assert.Equal(t, 8, len(lines))
+		assert.True(t, strings.HasPrefix(lines[1], "opening,"))
+		assert.True(t, strings.HasSuffix(lines[1], ",100.00,"))
+		assert.True(t, strings.HasSuffix(lines[2], ",transfer,completed,2,-30.00,70.00,"))
+		assert.True(t, strings.HasSuffix(lines[3], ",transfer,completed,2,5.00,75.00,"))
+		assert.True(t, strings.HasSuffix(lines[4], ",debit,completed,,-10.00,65.00,"))
+		assert.Equal(t, "total,,,transfer,,,-25.00,,2", lines[5])
+		assert.Equal(t, "total,,,debit,,,-10.00,,1", lines[6])
+		assert.True(t, strings.HasSuffix(lines[7], ",65.00,"))
+	})
+}
+
+func Test_WhenStatementIsExportedAsPDF_ShouldWriteACompleteDocument(t *testing.T) {
+	t.Run("WhenStatementIsExportedAsPDF_ShouldWriteACompleteDocument", func(t *testing.T) {
+		transactionService, transactionRepository, balanceRepository, ledgerRepository := newTransactionServiceWithLedger(map[int64]domain.Money{})
+		balanceService := service.NewBalanceService(balanceRepository, NewFakePocketRepository(), NewFakeUnitOfWork(transactionRepository, balanceRepository, ledgerRepository, NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository()))
+		statementService := service.NewStatementService(transactionRepository, ledgerRepository)
+		balanceService.CreateBalance(1, money("1000"))
+
+		from := time.Now()
+		for i := 0; i < 150; i++ {
+			transactionService.Transfer(1, 2, money("1"))
+		}
+
+		var output bytes.Buffer
+		accountStatement := &domain.Statement{UserID: 1, Currency: domain.TRY, From: from, To: time.Now().Add(time.Minute)}
+		err := statementService.Export(accountStatement, statement.NewPDFWriter(&output))
+		assert.Nil(t, err)
+
+		document := output.String()
+		assert.True(t, strings.HasPrefix(document, "%PDF-1.4"))
+		assert.True(t, strings.HasSuffix(document, "%%EOF\n"))
+		assert.Equal(t, 3, strings.Count(document, "/Type /Page "))
+		assert.Contains(t, document, "Closing balance: 850.00")
+	})
+}
+
+func Test_WhenBalanceIsAdjusted_ShouldShowTheAdjustmentInTheStatement(t *testing.T) {
+	t.Run("WhenBalanceIsAdjusted_ShouldShowTheAdjustmentInTheStatement", func(t *testing.T) {
+		transactionService, transactionRepository, balanceRepository, ledgerRepository := newTransactionServiceWithLedger(map[int64]domain.Money{})
+		balanceService := service.NewBalanceService(balanceRepository, NewFakePocketRepository(), NewFakeUnitOfWork(transactionRepository, balanceRepository, ledgerRepository, NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository()))
+		statementService := service.NewStatementService(transactionRepository, ledgerRepository)
+
+		balanceService.UpdateBalance(1, money("100"))
+		from := time.Now()
+		balanceService.UpdateBalance(1, money("-20"))
+		transactionService.Transfer(1, 2, money("30"))
+
+		var output bytes.Buffer
+		accountStatement := &domain.Statement{UserID: 1, Currency: domain.TRY, From: from, To: time.Now().Add(time.Minute)}
+		err := statementService.Export(accountStatement, statement.NewCSVWriter(&output))
+		assert.Nil(t, err)
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		assert.True(t, strings.HasSuffix(lines[1], ",100.00,"))
+		assert.True(t, strings.HasSuffix(lines[2], ",adjustment,completed,,-20.00,80.00,"))
+		assert.True(t, strings.HasSuffix(lines[3], ",transfer,completed,2,-30.00,50.00,"))
+		assert.True(t, strings.HasSuffix(lines[len(lines)-1], ",50.00,"))
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("50"), balance.Amount)
+	})
+}
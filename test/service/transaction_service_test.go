@@ -0,0 +1,301 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/denizdoganinsider/kpi_project/common/approval"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/persistence"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func money(value string) domain.Money {
+	amount, err := domain.ParseMoney(value, domain.DefaultCurrency)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+func newTransactionService(initialBalances map[int64]domain.Money) (service.ITransactionService, *FakeTransactionRepository, *FakeBalanceRepository) {
+	transactionService, transactionRepository, balanceRepository, _ := newTransactionServiceWithLedger(initialBalances)
+	return transactionService, transactionRepository, balanceRepository
+}
+
+func newTransactionServiceWithLedger(initialBalances map[int64]domain.Money) (service.ITransactionService, *FakeTransactionRepository, *FakeBalanceRepository, *FakeLedgerRepository) {
+	transactionRepository := NewFakeTransactionRepository()
+	balanceRepository := NewFakeBalanceRepository(initialBalances)
+	ledgerRepository := NewFakeLedgerRepository()
+	unitOfWork := NewFakeUnitOfWork(transactionRepository, balanceRepository, ledgerRepository, NewFakeHoldRepository(), NewFakeApprovalRepository(), NewFakeRiskRepository(), NewFakeDisputeRepository(), NewFakeOverdraftRepository(balanceRepository), NewFakePocketRepository(), NewFakeSavingsGoalRepository())
+	limitService := service.NewLimitService(NewFakeLimitRepository(), NewFakeUserRepository(nil))
+	return service.NewTransactionService(transactionRepository, balanceRepository, unitOfWork, limitService, service.NewFeeService(NewFakeFeeRepository(), NewFakeUserRepository(nil)), service.NewRiskService(NewFakeRiskRepository(), nil), approval.Config{}), transactionRepository, balanceRepository, ledgerRepository
+}
+
+func Test_WhenTransferSucceeds_ShouldMoveBalanceBetweenUsers(t *testing.T) {
+	t.Run("WhenTransferSucceeds_ShouldMoveBalanceBetweenUsers", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100"), 2: money("10")})
+
+		transaction, err := transactionService.Transfer(1, 2, money("40"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, transaction.Status)
+		from, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		to, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("60"), from.Amount)
+		assert.Equal(t, money("50"), to.Amount)
+	})
+}
+
+func Test_WhenTransferFails_ShouldRollbackAndRecordFailure(t *testing.T) {
+	t.Run("WhenTransferFails_ShouldRollbackAndRecordFailure", func(t *testing.T) {
+		transactionService, transactionRepository, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100"), 2: money("10")})
+
+		_, err := transactionService.Transfer(2, 1, money("40"))
+
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+		from, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		to, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("10"), from.Amount)
+		assert.Equal(t, money("100"), to.Amount)
+
+		history, _ := transactionRepository.GetUserTransactions(2, domain.TransactionFilter{})
+		assert.Equal(t, 1, len(history))
+		assert.Equal(t, domain.Failed, history[0].Status)
+	})
+}
+
+func Test_WhenMoneyMoves_ShouldPostBalancedJournalEntries(t *testing.T) {
+	t.Run("WhenMoneyMoves_ShouldPostBalancedJournalEntries", func(t *testing.T) {
+		transactionService, _, _, ledgerRepository := newTransactionServiceWithLedger(map[int64]domain.Money{})
+
+		transactionService.Credit(1, money("100"))
+		transactionService.Transfer(1, 2, money("30"))
+		transactionService.Debit(2, money("10"))
+
+		assert.Equal(t, 3, len(ledgerRepository.entries))
+		for _, entry := range ledgerRepository.entries {
+			assert.Nil(t, entry.Validate())
+		}
+
+		first, _ := ledgerRepository.GetAccountByCode(domain.UserAccountCode(1, domain.DefaultCurrency))
+		second, _ := ledgerRepository.GetAccountByCode(domain.UserAccountCode(2, domain.DefaultCurrency))
+		external, _ := ledgerRepository.GetAccountByCode(domain.ExternalAccountCode(domain.DefaultCurrency))
+		firstBalance, _ := ledgerRepository.GetAccountBalance(first.ID)
+		secondBalance, _ := ledgerRepository.GetAccountBalance(second.ID)
+		externalBalance, _ := ledgerRepository.GetAccountBalance(external.ID)
+		assert.Equal(t, money("70"), firstBalance)
+		assert.Equal(t, money("20"), secondBalance)
+		assert.Equal(t, money("-90"), externalBalance)
+	})
+}
+
+func Test_WhenWalletsHaveDifferentCurrencies_ShouldKeepThemApart(t *testing.T) {
+	t.Run("WhenWalletsHaveDifferentCurrencies_ShouldKeepThemApart", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{})
+		euros, _ := domain.ParseMoney("25", domain.EUR)
+
+		transactionService.Credit(1, money("100"))
+		transactionService.Credit(1, euros)
+		_, err := transactionService.Debit(1, euros)
+
+		assert.Nil(t, err)
+		wallets, _ := balanceRepository.GetBalancesByUserID(1)
+		assert.Equal(t, 2, len(wallets))
+		assert.Equal(t, domain.Zero(domain.EUR), wallets[0].Amount)
+		assert.Equal(t, money("100"), wallets[1].Amount)
+	})
+}
+
+func Test_WhenTransferIsRefundedThenReversed_ShouldGiveBackTheWholeAmountOnce(t *testing.T) {
+	t.Run("WhenTransferIsRefundedThenReversed_ShouldGiveBackTheWholeAmountOnce", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100"), 2: money("10")})
+		transfer, _ := transactionService.Transfer(1, 2, money("40"))
+
+		refund, err := transactionService.Refund(transfer.ID, money("15"))
+		assert.Nil(t, err)
+		assert.Equal(t, domain.RefundTransaction, refund.Type)
+		assert.Equal(t, transfer.ID, *refund.ParentID)
+
+		_, err = transactionService.Refund(transfer.ID, money("30"))
+		assert.ErrorIs(t, err, service.ErrRefundExceedsOriginal)
+
+		reversal, err := transactionService.Reverse(transfer.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, money("25"), reversal.Amount)
+
+		_, err = transactionService.Reverse(transfer.ID)
+		assert.ErrorIs(t, err, service.ErrTransactionNotReversible)
+
+		original, _ := transactionService.GetTransactionByID(transfer.ID)
+		from, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		to, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, domain.Reversed, original.Status)
+		assert.Equal(t, money("100"), from.Amount)
+		assert.Equal(t, money("10"), to.Amount)
+	})
+}
+
+func Test_WhenDebitIsReversed_ShouldCreditTheUserBack(t *testing.T) {
+	t.Run("WhenDebitIsReversed_ShouldCreditTheUserBack", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100")})
+		debit, _ := transactionService.Debit(1, money("30"))
+
+		reversal, err := transactionService.Reverse(debit.ID)
+
+		assert.Nil(t, err)
+		assert.Equal(t, money("30"), reversal.Amount)
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), balance.Amount)
+	})
+}
+
+func Test_WhenHistoryIsPaged_ShouldWalkEveryTransactionOnce(t *testing.T) {
+	t.Run("WhenHistoryIsPaged_ShouldWalkEveryTransactionOnce", func(t *testing.T) {
+		transactionService, _, _ := newTransactionService(map[int64]domain.Money{1: money("100"), 3: money("100")})
+
+		transactionService.Transfer(1, 2, money("10"))
+		transactionService.Transfer(1, 3, money("20"))
+		transactionService.Transfer(3, 1, money("30"))
+		transactionService.Debit(1, money("40"))
+		transactionService.Transfer(1, 2, money("50"))
+
+		var ids []int64
+		filter := domain.TransactionFilter{Limit: 2}
+		for {
+			page, err := transactionService.GetTransactionHistory(1, filter)
+			assert.Nil(t, err)
+			for _, transaction := range page.Transactions {
+				ids = append(ids, transaction.ID)
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			filter.After = page.NextCursor
+		}
+		assert.Equal(t, []int64{5, 4, 3, 2, 1}, ids)
+
+		counterparty := int64(3)
+		minAmount := money("25")
+		page, _ := transactionService.GetTransactionHistory(1, domain.TransactionFilter{
+			Types:        []domain.TransactionType{domain.TransferTransaction},
+			Counterparty: &counterparty,
+			MinAmount:    &minAmount,
+		})
+		assert.Equal(t, 1, len(page.Transactions))
+		assert.Equal(t, int64(3), page.Transactions[0].ID)
+		assert.Nil(t, page.NextCursor)
+	})
+}
+
+func Test_WhenTransactionChangesStatus_ShouldRecordEveryChange(t *testing.T) {
+	t.Run("WhenTransactionChangesStatus_ShouldRecordEveryChange", func(t *testing.T) {
+		transactionService, transactionRepository, _ := newTransactionService(map[int64]domain.Money{1: money("100")})
+
+		transfer, _ := transactionService.Transfer(1, 2, money("40"))
+		transactionService.Reverse(transfer.ID)
+
+		events, err := transactionService.GetTransactionEvents(transfer.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(events))
+		assert.Nil(t, events[0].From)
+		assert.Equal(t, domain.Pending, events[0].To)
+		assert.Equal(t, domain.Completed, events[1].To)
+		assert.Equal(t, domain.Reversed, events[2].To)
+		assert.Equal(t, "reversal by transaction 2", events[2].Reason)
+
+		err = transactionRepository.UpdateTransactionStatus(transfer.ID, domain.Completed, domain.Reversed, "again")
+		assert.ErrorIs(t, err, persistence.ErrTransactionStatusConflict)
+		err = transactionRepository.UpdateTransactionStatus(transfer.ID, domain.Reversed, domain.Completed, "back")
+		assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition)
+	})
+}
+
+func Test_WhenTransactionFails_ShouldRecordWhyInItsHistory(t *testing.T) {
+	t.Run("WhenTransactionFails_ShouldRecordWhyInItsHistory", func(t *testing.T) {
+		transactionService, transactionRepository, _ := newTransactionService(map[int64]domain.Money{1: money("10")})
+
+		_, err := transactionService.Transfer(1, 2, money("40"))
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+
+		history, _ := transactionRepository.GetUserTransactions(1, domain.TransactionFilter{})
+		events, _ := transactionService.GetTransactionEvents(history[0].ID)
+		assert.Equal(t, 2, len(events))
+		assert.Equal(t, domain.Failed, events[1].To)
+		assert.Equal(t, service.ErrInsufficientBalance.Error(), events[1].Reason)
+	})
+}
+
+func Test_WhenPaymentIsSplit_ShouldDebitThePayerOnceAndCreditEveryRecipient(t *testing.T) {
+	t.Run("WhenPaymentIsSplit_ShouldDebitThePayerOnceAndCreditEveryRecipient", func(t *testing.T) {
+		transactionService, _, balanceRepository, ledgerRepository := newTransactionServiceWithLedger(map[int64]domain.Money{1: money("100")})
+		total := money("100")
+
+		splitPayment, err := transactionService.Split(domain.SplitInstruction{Payer: 1, Currency: domain.DefaultCurrency, Total: &total, Shares: []domain.SplitShare{
+			{ToUser: 2, PercentageBps: 5000},
+			{ToUser: 3, PercentageBps: 3000},
+			{ToUser: 4, PercentageBps: 2000},
+		}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, splitPayment.Payment.Status)
+		assert.Equal(t, 3, len(splitPayment.Legs))
+		assert.Equal(t, 1, len(ledgerRepository.entries))
+		assert.Nil(t, ledgerRepository.entries[0].Validate())
+
+		payer, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		second, _ := balanceRepository.GetBalanceByUserID(3, domain.DefaultCurrency)
+		assert.Equal(t, money("0"), payer.Amount)
+		assert.Equal(t, money("30"), second.Amount)
+
+		grouped, err := transactionService.GetSplitPayment(splitPayment.Payment.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(grouped.Legs))
+		for _, leg := range grouped.Legs {
+			assert.Equal(t, splitPayment.Payment.ID, *leg.ParentID)
+			assert.Equal(t, domain.Completed, leg.Status)
+		}
+
+		_, err = transactionService.GetSplitPayment(grouped.Legs[0].ID)
+		assert.ErrorIs(t, err, service.ErrSplitPaymentNotFound)
+	})
+}
+
+func Test_WhenSplitPaymentConflicts_ShouldRunItAgain(t *testing.T) {
+	t.Run("WhenSplitPaymentConflicts_ShouldRunItAgain", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100"), 2: money("0")})
+		first, second := money("30"), money("20")
+
+		balanceRepository.FailNextWrites(2)
+		splitPayment, err := transactionService.Split(domain.SplitInstruction{Payer: 1, Currency: domain.DefaultCurrency, Shares: []domain.SplitShare{
+			{ToUser: 2, Amount: &first},
+			{ToUser: 3, Amount: &second},
+		}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Completed, splitPayment.Payment.Status)
+		payer, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("50"), payer.Amount)
+	})
+}
+
+func Test_WhenSplitPaymentFails_ShouldPayNoRecipient(t *testing.T) {
+	t.Run("WhenSplitPaymentFails_ShouldPayNoRecipient", func(t *testing.T) {
+		transactionService, transactionRepository, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("50")})
+		first, second := money("30"), money("30")
+
+		_, err := transactionService.Split(domain.SplitInstruction{Payer: 1, Currency: domain.DefaultCurrency, Shares: []domain.SplitShare{
+			{ToUser: 2, Amount: &first},
+			{ToUser: 3, Amount: &second},
+		}})
+
+		assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+		payer, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("50"), payer.Amount)
+		_, err = balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.NotNil(t, err)
+
+		history, _ := transactionRepository.GetUserTransactions(2, domain.TransactionFilter{})
+		assert.Equal(t, 0, len(history))
+	})
+}
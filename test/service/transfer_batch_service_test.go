@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denizdoganinsider/kpi_project/common/transferbatch"
+	"github.com/denizdoganinsider/kpi_project/domain"
+	"github.com/denizdoganinsider/kpi_project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var transferBatchConfig = transferbatch.Config{ProcessingTimeout: 10 * time.Minute}
+
+func newTransferBatchService(initialBalances map[int64]domain.Money) (service.ITransferBatchService, *FakeBalanceRepository) {
+	transactionService, _, balanceRepository := newTransactionService(initialBalances)
+	users := NewFakeUserRepository([]domain.User{{Id: 1}, {Id: 2}, {Id: 3}})
+	return service.NewTransferBatchService(NewFakeTransferBatchRepository(), users, transactionService, transferBatchConfig), balanceRepository
+}
+
+func Test_WhenBatchHasInvalidRows_ShouldRejectEveryOneOfThem(t *testing.T) {
+	t.Run("WhenBatchHasInvalidRows_ShouldRejectEveryOneOfThem", func(t *testing.T) {
+		transferBatchService, _ := newTransferBatchService(map[int64]domain.Money{1: money("100")})
+
+		_, err := transferBatchService.Create(1, domain.TRY, []domain.TransferBatchItem{
+			{Row: 1, ToUser: 2, Amount: money("10")},
+			{Row: 2, ToUser: 1, Amount: money("10")},
+			{Row: 3, ToUser: 3, Amount: money("0")},
+			{Row: 4, ToUser: 9, Amount: money("10")},
+		})
+
+		validationError, ok := err.(*domain.TransferBatchValidationError)
+		assert.True(t, ok)
+		assert.Len(t, validationError.Rows, 3)
+		assert.Equal(t, 2, validationError.Rows[0].Row)
+		assert.Equal(t, 3, validationError.Rows[1].Row)
+		assert.Equal(t, 4, validationError.Rows[2].Row)
+	})
+}
+
+func Test_WhenBatchIsProcessed_ShouldTransferEachItemOnce(t *testing.T) {
+	t.Run("WhenBatchIsProcessed_ShouldTransferEachItemOnce", func(t *testing.T) {
+		transferBatchService, balanceRepository := newTransferBatchService(map[int64]domain.Money{1: money("100")})
+
+		transferBatch, err := transferBatchService.Create(1, domain.TRY, []domain.TransferBatchItem{
+			{Row: 1, ToUser: 2, Amount: money("30"), Reference: "salary"},
+			{Row: 2, ToUser: 3, Amount: money("500")},
+			{Row: 3, ToUser: 3, Amount: money("20")},
+		})
+		assert.Nil(t, err)
+
+		transferBatchService.ProcessPending(context.Background(), time.Now())
+		transferBatchService.ProcessPending(context.Background(), time.Now())
+
+		processed, _ := transferBatchService.GetByID(transferBatch.ID)
+		assert.Equal(t, domain.BatchCompleted, processed.Status)
+		assert.Equal(t, domain.TransferBatchProgress{Total: 3, Completed: 2, Failed: 1}, processed.Progress)
+
+		items, _ := transferBatchService.GetItems(transferBatch.ID)
+		assert.Equal(t, domain.BatchItemFailed, items[1].Status)
+		assert.Equal(t, service.ErrInsufficientBalance.Error(), items[1].FailureReason)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("50"), balance.Amount)
+	})
+}
+
+func Test_WhenBatchIsCancelled_ShouldSkipItsPendingItems(t *testing.T) {
+	t.Run("WhenBatchIsCancelled_ShouldSkipItsPendingItems", func(t *testing.T) {
+		transferBatchService, balanceRepository := newTransferBatchService(map[int64]domain.Money{1: money("100")})
+
+		transferBatch, _ := transferBatchService.Create(1, domain.TRY, []domain.TransferBatchItem{
+			{Row: 1, ToUser: 2, Amount: money("30")},
+		})
+
+		cancelled, err := transferBatchService.Cancel(transferBatch.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.TransferBatchProgress{Total: 1, Cancelled: 1}, cancelled.Progress)
+
+		transferBatchService.ProcessPending(context.Background(), time.Now())
+
+		_, err = transferBatchService.Cancel(transferBatch.ID)
+		assert.ErrorIs(t, err, service.ErrTransferBatchNotCancellable)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("100"), balance.Amount)
+	})
+}
+
+func Test_WhenRunDiesWithAnItemClaimed_ShouldFailItAfterTheTimeoutAndFinishTheBatch(t *testing.T) {
+	t.Run("WhenRunDiesWithAnItemClaimed_ShouldFailItAfterTheTimeoutAndFinishTheBatch", func(t *testing.T) {
+		transactionService, _, balanceRepository := newTransactionService(map[int64]domain.Money{1: money("100")})
+		interceptingService := &interceptingTransactionService{ITransactionService: transactionService}
+		users := NewFakeUserRepository([]domain.User{{Id: 1}, {Id: 2}})
+		transferBatchService := service.NewTransferBatchService(NewFakeTransferBatchRepository(), users, interceptingService, transferBatchConfig)
+
+		transferBatch, _ := transferBatchService.Create(1, domain.TRY, []domain.TransferBatchItem{
+			{Row: 1, ToUser: 2, Amount: money("30")},
+		})
+
+		interceptingService.onTransfer = func(transfer func() (*domain.Transaction, error)) (*domain.Transaction, error) {
+			transfer()
+			panic("process died")
+		}
+		assert.Panics(t, func() { transferBatchService.ProcessPending(context.Background(), time.Now()) })
+
+		interceptingService.onTransfer = func(transfer func() (*domain.Transaction, error)) (*domain.Transaction, error) {
+			return transfer()
+		}
+		transferBatchService.ProcessPending(context.Background(), time.Now())
+		running, _ := transferBatchService.GetByID(transferBatch.ID)
+		assert.Equal(t, domain.BatchProcessing, running.Status)
+
+		transferBatchService.ProcessPending(context.Background(), time.Now().Add(transferBatchConfig.ProcessingTimeout+time.Minute))
+
+		processed, _ := transferBatchService.GetByID(transferBatch.ID)
+		assert.Equal(t, domain.BatchCompleted, processed.Status)
+		assert.Equal(t, domain.TransferBatchProgress{Total: 1, Failed: 1}, processed.Progress)
+
+		items, _ := transferBatchService.GetItems(transferBatch.ID)
+		assert.Equal(t, service.ErrTransferBatchItemInterrupted.Error(), items[0].FailureReason)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(2, domain.DefaultCurrency)
+		assert.Equal(t, money("30"), balance.Amount)
+	})
+}
+
+func Test_WhenBatchItemNeedsApproval_ShouldFinishTheBatchWithItAwaitingApproval(t *testing.T) {
+	t.Run("WhenBatchItemNeedsApproval_ShouldFinishTheBatchWithItAwaitingApproval", func(t *testing.T) {
+		_, transactionService, balanceRepository := newApprovalService(map[int64]domain.Money{1: money("5000")})
+		users := NewFakeUserRepository([]domain.User{{Id: 1}, {Id: 2}, {Id: 3}})
+		transferBatchService := service.NewTransferBatchService(NewFakeTransferBatchRepository(), users, transactionService, transferBatchConfig)
+
+		transferBatch, _ := transferBatchService.Create(1, domain.TRY, []domain.TransferBatchItem{
+			{Row: 1, ToUser: 2, Amount: money("2000")},
+			{Row: 2, ToUser: 3, Amount: money("100")},
+		})
+		transferBatchService.ProcessPending(context.Background(), time.Now())
+
+		processed, _ := transferBatchService.GetByID(transferBatch.ID)
+		assert.Equal(t, domain.BatchCompleted, processed.Status)
+		assert.Equal(t, domain.TransferBatchProgress{Total: 2, Completed: 1, AwaitingApproval: 1}, processed.Progress)
+
+		items, _ := transferBatchService.GetItems(transferBatch.ID)
+		assert.Equal(t, domain.BatchItemAwaitingApproval, items[0].Status)
+		assert.NotNil(t, items[0].TransactionID)
+
+		balance, _ := balanceRepository.GetBalanceByUserID(1, domain.DefaultCurrency)
+		assert.Equal(t, money("4900"), balance.Amount)
+	})
+}